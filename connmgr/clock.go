@@ -0,0 +1,33 @@
+package connmgr
+
+import (
+	"errors"
+
+	"github.com/libp2p/go-libp2p-core/network"
+)
+
+// ErrClockNotSupported is returned by SetClock when the connection manager
+// doesn't implement ClockSetter.
+var ErrClockNotSupported = errors.New("connection manager doesn't support custom clocks")
+
+// ClockSetter is implemented by connection managers whose time-based
+// behaviour (decaying tags, grace periods, standby rotations, restoring
+// decaying tags from a Snapshot) can be driven by a network.Clock, so
+// simulations and tests can advance virtual time instead of sleeping.
+type ClockSetter interface {
+	// SetClock sets the clock of the connection manager,
+	// network.RealClock by default. It must be called before the
+	// connection manager is used.
+	SetClock(network.Clock)
+}
+
+// SetClock sets the clock of the connection manager. It returns
+// ErrClockNotSupported if cm doesn't implement ClockSetter.
+func SetClock(cm ConnManager, c network.Clock) error {
+	cs, ok := cm.(ClockSetter)
+	if !ok {
+		return ErrClockNotSupported
+	}
+	cs.SetClock(c)
+	return nil
+}
@@ -0,0 +1,103 @@
+package connmgr
+
+import (
+	"sort"
+
+	"github.com/libp2p/go-libp2p-core/network"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// pCircuit is the multiaddr code of the /p2p-circuit protocol. It's
+// registered by the circuit relay transport, not by go-multiaddr itself.
+const pCircuit = 0x0122
+
+// ConnPreference reports whether connection a should be kept in favor of
+// connection b when both lead to the same peer.
+type ConnPreference func(a, b network.Conn) bool
+
+// PreferDirect prefers direct connections over relayed ones.
+func PreferDirect(a, b network.Conn) bool {
+	return !hasProtocol(a.RemoteMultiaddr(), pCircuit) && hasProtocol(b.RemoteMultiaddr(), pCircuit)
+}
+
+// PreferQUIC prefers QUIC connections over connections using any other
+// transport.
+func PreferQUIC(a, b network.Conn) bool {
+	return hasProtocol(a.RemoteMultiaddr(), ma.P_QUIC) && !hasProtocol(b.RemoteMultiaddr(), ma.P_QUIC)
+}
+
+// ChainPreferences combines preferences: the first preference that
+// distinguishes between two connections decides.
+func ChainPreferences(prefs ...ConnPreference) ConnPreference {
+	return func(a, b network.Conn) bool {
+		for _, pref := range prefs {
+			if pref(a, b) {
+				return true
+			}
+			if pref(b, a) {
+				return false
+			}
+		}
+		return false
+	}
+}
+
+// DefaultConnPreference keeps direct connections over relayed ones, then
+// QUIC connections over other transports.
+var DefaultConnPreference = ChainPreferences(PreferDirect, PreferQUIC)
+
+// PeerConnLimits configures how many connections may be kept open to a single
+// peer, and which ones to keep.
+type PeerConnLimits struct {
+	// MaxConnsPerPeer is the number of connections to keep per peer. Zero
+	// means no limit.
+	MaxConnsPerPeer int
+
+	// Preference orders the connections to a peer. Defaults to
+	// DefaultConnPreference.
+	Preference ConnPreference
+}
+
+// PeerConnLimiter is implemented by connection managers that can enforce a
+// per-peer connection limit, closing redundant connections as they're opened.
+//
+// When connections are closed to enforce the limit, the connection manager
+// should emit an event.EvtPeerConnsCoalesced.
+type PeerConnLimiter interface {
+	SetPeerConnLimits(PeerConnLimits)
+}
+
+// SelectRedundantConns orders the connections to a peer by the limits'
+// preference and splits them into the ones to keep and the redundant ones to
+// close. Connections the preference considers equal keep their relative
+// order, so callers may pass them oldest first to favor established
+// connections.
+func SelectRedundantConns(conns []network.Conn, limits PeerConnLimits) (keep, prune []network.Conn) {
+	if limits.MaxConnsPerPeer <= 0 || len(conns) <= limits.MaxConnsPerPeer {
+		return conns, nil
+	}
+	pref := limits.Preference
+	if pref == nil {
+		pref = DefaultConnPreference
+	}
+
+	sorted := make([]network.Conn, len(conns))
+	copy(sorted, conns)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return pref(sorted[i], sorted[j])
+	})
+	return sorted[:limits.MaxConnsPerPeer], sorted[limits.MaxConnsPerPeer:]
+}
+
+func hasProtocol(addr ma.Multiaddr, code int) bool {
+	if addr == nil {
+		return false
+	}
+	for _, p := range addr.Protocols() {
+		if p.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package connmgr
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/network"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+type addrConn struct {
+	network.Conn
+	addr ma.Multiaddr
+}
+
+func (c *addrConn) RemoteMultiaddr() ma.Multiaddr { return c.addr }
+
+func TestSelectRedundantConns(t *testing.T) {
+	tcp := &addrConn{addr: ma.StringCast("/ip4/1.2.3.4/tcp/1")}
+	quic := &addrConn{addr: ma.StringCast("/ip4/1.2.3.4/udp/1/quic")}
+	tcp2 := &addrConn{addr: ma.StringCast("/ip4/1.2.3.4/tcp/2")}
+	conns := []network.Conn{tcp, quic, tcp2}
+
+	keep, prune := SelectRedundantConns(conns, PeerConnLimits{})
+	if len(keep) != 3 || len(prune) != 0 {
+		t.Fatal("expected no pruning without a limit")
+	}
+
+	keep, prune = SelectRedundantConns(conns, PeerConnLimits{MaxConnsPerPeer: 2})
+	if len(keep) != 2 || keep[0] != quic || keep[1] != tcp {
+		t.Fatalf("expected QUIC then the oldest TCP conn to be kept, got %v", keep)
+	}
+	if len(prune) != 1 || prune[0] != tcp2 {
+		t.Fatalf("expected the newest TCP conn to be pruned, got %v", prune)
+	}
+}
@@ -0,0 +1,241 @@
+package connmgr
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// DiversityPolicy keeps a node's connections spread across subnets and
+// autonomous systems, so an attacker controlling a few networks can't eclipse
+// it. Addresses are mapped to groups (e.g. "subnet:1.2.0.0/16",
+// "asn:64496"), and the number of connected peers per group is limited.
+type DiversityPolicy interface {
+	// Groups returns the groups the remote address belongs to.
+	Groups(addr ma.Multiaddr) []string
+
+	// MaxPeers returns the maximum number of connected peers in the group,
+	// or 0 if it's unlimited.
+	MaxPeers(group string) int
+}
+
+// DiversityStats is a snapshot of the spread of a node's connected peers.
+type DiversityStats struct {
+	// Peers is the number of peers tracked.
+	Peers int
+	// Groups maps each group to its number of connected peers.
+	Groups map[string]int
+}
+
+// Share returns the fraction of the peers in the group.
+func (s DiversityStats) Share(group string) float64 {
+	if s.Peers == 0 {
+		return 0
+	}
+	return float64(s.Groups[group]) / float64(s.Peers)
+}
+
+// DiversityAware is implemented by connection managers enforcing a
+// DiversityPolicy: peers in groups over their limit are trimmed first, and
+// new connections to them may be refused.
+type DiversityAware interface {
+	SetDiversityPolicy(DiversityPolicy)
+
+	// DiversityStats returns the current spread of the connected peers,
+	// for monitoring.
+	DiversityStats() DiversityStats
+}
+
+// SubnetDiversityPolicy groups addresses by IP subnet and, if ASN is set, by
+// autonomous system.
+type SubnetDiversityPolicy struct {
+	// IPv4Prefix and IPv6Prefix are the subnet prefix lengths, e.g. 16
+	// and 32.
+	IPv4Prefix, IPv6Prefix int
+	// MaxPerSubnet is the maximum number of peers per subnet, 0 for no
+	// limit.
+	MaxPerSubnet int
+
+	// ASN returns the autonomous system number of the IP, if known.
+	ASN func(net.IP) (uint32, bool)
+	// MaxPerASN is the maximum number of peers per autonomous system, 0 for
+	// no limit.
+	MaxPerASN int
+}
+
+var _ DiversityPolicy = (*SubnetDiversityPolicy)(nil)
+
+// Groups implements DiversityPolicy. Addresses without an IP belong to no
+// group.
+func (p *SubnetDiversityPolicy) Groups(addr ma.Multiaddr) []string {
+	ip := addrIP(addr)
+	if ip == nil {
+		return nil
+	}
+	var groups []string
+	prefix, bits := p.IPv6Prefix, 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, prefix, bits = ip4, p.IPv4Prefix, 32
+	}
+	if prefix > 0 {
+		subnet := net.IPNet{IP: ip.Mask(net.CIDRMask(prefix, bits)), Mask: net.CIDRMask(prefix, bits)}
+		groups = append(groups, "subnet:"+subnet.String())
+	}
+	if p.ASN != nil {
+		if asn, ok := p.ASN(ip); ok {
+			groups = append(groups, fmt.Sprintf("asn:%d", asn))
+		}
+	}
+	return groups
+}
+
+// MaxPeers implements DiversityPolicy.
+func (p *SubnetDiversityPolicy) MaxPeers(group string) int {
+	switch {
+	case strings.HasPrefix(group, "subnet:"):
+		return p.MaxPerSubnet
+	case strings.HasPrefix(group, "asn:"):
+		return p.MaxPerASN
+	default:
+		return 0
+	}
+}
+
+func addrIP(addr ma.Multiaddr) net.IP {
+	if addr == nil {
+		return nil
+	}
+	for _, code := range []int{ma.P_IP4, ma.P_IP6} {
+		if v, err := addr.ValueForProtocol(code); err == nil {
+			return net.ParseIP(v)
+		}
+	}
+	return nil
+}
+
+// DiversityTracker counts the connected peers per group of a policy, for
+// connection managers implementing DiversityAware.
+//
+// A DiversityTracker is safe for concurrent use.
+type DiversityTracker struct {
+	policy DiversityPolicy
+
+	lk     sync.Mutex
+	peers  map[peer.ID][]string
+	groups map[string]int
+}
+
+// NewDiversityTracker creates a tracker for the policy.
+func NewDiversityTracker(policy DiversityPolicy) *DiversityTracker {
+	return &DiversityTracker{
+		policy: policy,
+		peers:  make(map[peer.ID][]string),
+		groups: make(map[string]int),
+	}
+}
+
+// Allow returns true if connecting to the peer at addr keeps every group
+// within its limit. Peers already tracked are always allowed.
+//
+// The answer may be stale by the time the peer is added: use TryAdd to check
+// and track the peer atomically.
+func (t *DiversityTracker) Allow(p peer.ID, addr ma.Multiaddr) bool {
+	groups := t.policy.Groups(addr)
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	return t.allow(p, groups)
+}
+
+func (t *DiversityTracker) allow(p peer.ID, groups []string) bool {
+	if _, ok := t.peers[p]; ok {
+		return true
+	}
+	for _, g := range groups {
+		if max := t.policy.MaxPeers(g); max > 0 && t.groups[g] >= max {
+			return false
+		}
+	}
+	return true
+}
+
+// TryAdd tracks the peer, connected at addr, if that keeps every group within
+// its limit, and returns whether it's tracked. Unlike Allow followed by Add,
+// concurrent connections can't both pass the check and exceed the limit.
+func (t *DiversityTracker) TryAdd(p peer.ID, addr ma.Multiaddr) bool {
+	groups := t.policy.Groups(addr)
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	if !t.allow(p, groups) {
+		return false
+	}
+	t.add(p, groups)
+	return true
+}
+
+// Add tracks the peer, connected at addr. Peers are counted once, with the
+// address of their first connection.
+func (t *DiversityTracker) Add(p peer.ID, addr ma.Multiaddr) {
+	groups := t.policy.Groups(addr)
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	t.add(p, groups)
+}
+
+func (t *DiversityTracker) add(p peer.ID, groups []string) {
+	if _, ok := t.peers[p]; ok {
+		return
+	}
+	t.peers[p] = groups
+	for _, g := range groups {
+		t.groups[g]++
+	}
+}
+
+// Remove stops tracking the peer, e.g. once it's disconnected.
+func (t *DiversityTracker) Remove(p peer.ID) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	groups, ok := t.peers[p]
+	if !ok {
+		return
+	}
+	delete(t.peers, p)
+	for _, g := range groups {
+		if t.groups[g]--; t.groups[g] == 0 {
+			delete(t.groups, g)
+		}
+	}
+}
+
+// OverLimit returns the tracked peers belonging to a group above its limit,
+// which connection managers should trim first.
+func (t *DiversityTracker) OverLimit() []peer.ID {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	var out []peer.ID
+	for p, groups := range t.peers {
+		for _, g := range groups {
+			if max := t.policy.MaxPeers(g); max > 0 && t.groups[g] > max {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Stats returns a snapshot of the tracked peers' spread.
+func (t *DiversityTracker) Stats() DiversityStats {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	groups := make(map[string]int, len(t.groups))
+	for g, n := range t.groups {
+		groups[g] = n
+	}
+	return DiversityStats{Peers: len(t.peers), Groups: groups}
+}
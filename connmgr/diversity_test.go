@@ -0,0 +1,68 @@
+package connmgr
+
+import (
+	"net"
+	"testing"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestDiversityTracker(t *testing.T) {
+	policy := &SubnetDiversityPolicy{
+		IPv4Prefix:   16,
+		IPv6Prefix:   32,
+		MaxPerSubnet: 2,
+		ASN: func(ip net.IP) (uint32, bool) {
+			return 64496, ip[0] == 1
+		},
+		MaxPerASN: 3,
+	}
+	groups := policy.Groups(ma.StringCast("/ip4/1.2.3.4/tcp/4001"))
+	if len(groups) != 2 || groups[0] != "subnet:1.2.0.0/16" || groups[1] != "asn:64496" {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	if groups := policy.Groups(ma.StringCast("/ip6/2001:db8:1::1/tcp/4001")); len(groups) != 1 || groups[0] != "subnet:2001:db8::/32" {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+
+	tr := NewDiversityTracker(policy)
+	tr.Add("a", ma.StringCast("/ip4/1.2.0.1/tcp/4001"))
+	tr.Add("b", ma.StringCast("/ip4/1.2.0.2/tcp/4001"))
+	if tr.Allow("c", ma.StringCast("/ip4/1.2.0.3/tcp/4001")) {
+		t.Fatal("expected the subnet limit to be enforced")
+	}
+	if !tr.Allow("a", ma.StringCast("/ip4/1.2.0.1/tcp/4002")) {
+		t.Fatal("expected a tracked peer to be allowed")
+	}
+	tr.Add("c", ma.StringCast("/ip4/1.3.0.1/tcp/4001"))
+	if tr.Allow("d", ma.StringCast("/ip4/1.4.0.1/tcp/4001")) {
+		t.Fatal("expected the ASN limit to be enforced")
+	}
+	if !tr.Allow("d", ma.StringCast("/ip4/5.4.0.1/tcp/4001")) {
+		t.Fatal("expected another subnet and ASN to be allowed")
+	}
+
+	if tr.TryAdd("f", ma.StringCast("/ip4/1.2.0.6/tcp/4001")) {
+		t.Fatal("expected TryAdd to enforce the limits")
+	}
+	if !tr.TryAdd("a", ma.StringCast("/ip4/1.2.0.1/tcp/4002")) {
+		t.Fatal("expected TryAdd to accept a tracked peer")
+	}
+	tr.Add("e", ma.StringCast("/ip4/1.2.0.5/tcp/4001"))
+	if over := tr.OverLimit(); len(over) != 4 {
+		t.Fatalf("expected all peers of the over-limit ASN, got %v", over)
+	}
+	stats := tr.Stats()
+	if stats.Peers != 4 || stats.Groups["subnet:1.2.0.0/16"] != 3 || stats.Share("asn:64496") != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	tr.Remove("e")
+	tr.Remove("a")
+	if over := tr.OverLimit(); len(over) != 0 {
+		t.Fatalf("expected no peer over the limit, got %v", over)
+	}
+	if stats := tr.Stats(); stats.Peers != 2 || stats.Groups["subnet:1.2.0.0/16"] != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
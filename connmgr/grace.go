@@ -0,0 +1,88 @@
+package connmgr
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// DefaultGracePeriod is the grace period of new connections to peers with no
+// tag declaring its own.
+var DefaultGracePeriod = 20 * time.Second
+
+// GracePeriodConfigurer is implemented by connection managers that support
+// per-tag grace periods: new connections to a peer bearing a tag with a
+// declared grace period are not trimmed during that period, instead of the
+// connection manager's global one.
+//
+// Services declare a grace period for their tag once, and tag the peers they
+// connect to, e.g. DHT bootstrap connections could be protected for 2 minutes
+// and pubsub connections for 30 seconds.
+type GracePeriodConfigurer interface {
+	// SetGracePeriod declares the grace period of connections to peers
+	// tagged with tag. A zero duration removes the declaration.
+	SetGracePeriod(tag string, d time.Duration)
+
+	// GracePeriod returns the grace period of new connections to the peer.
+	GracePeriod(peer.ID) time.Duration
+}
+
+// SetGracePeriod declares the grace period of the tag with the connection
+// manager, and returns false if it doesn't implement GracePeriodConfigurer.
+func SetGracePeriod(cm ConnManager, tag string, d time.Duration) bool {
+	gc, ok := cm.(GracePeriodConfigurer)
+	if ok {
+		gc.SetGracePeriod(tag, d)
+	}
+	return ok
+}
+
+// GracePeriods maps tags to grace periods. It's safe for concurrent use, and
+// meant to be embedded in connection managers implementing
+// GracePeriodConfigurer. The zero value is ready to use.
+type GracePeriods struct {
+	mu      sync.RWMutex
+	periods map[string]time.Duration
+}
+
+// Set declares the grace period of the tag. A zero duration removes the
+// declaration.
+func (g *GracePeriods) Set(tag string, d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if d <= 0 {
+		delete(g.periods, tag)
+		return
+	}
+	if g.periods == nil {
+		g.periods = make(map[string]time.Duration)
+	}
+	g.periods[tag] = d
+}
+
+// Get returns the grace period declared for the tag, if any.
+func (g *GracePeriods) Get(tag string) (time.Duration, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	d, ok := g.periods[tag]
+	return d, ok
+}
+
+// For returns the grace period of a peer with the given tags (see
+// TagInfo.Tags): the longest period declared for one of them, or def if none
+// of them has one.
+func (g *GracePeriods) For(tags map[string]int, def time.Duration) time.Duration {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	longest, found := time.Duration(0), false
+	for tag := range tags {
+		if d, ok := g.periods[tag]; ok && d > longest {
+			longest, found = d, true
+		}
+	}
+	if !found {
+		return def
+	}
+	return longest
+}
@@ -0,0 +1,38 @@
+package connmgr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGracePeriods(t *testing.T) {
+	var g GracePeriods
+	if d := g.For(map[string]int{"dht": 1}, time.Second); d != time.Second {
+		t.Fatalf("expected the default, got %s", d)
+	}
+
+	g.Set("dht-bootstrap", 2*time.Minute)
+	g.Set("pubsub", 30*time.Second)
+	if d := g.For(map[string]int{"pubsub": 1, "other": 5}, time.Second); d != 30*time.Second {
+		t.Fatalf("expected the pubsub grace period, got %s", d)
+	}
+	if d := g.For(map[string]int{"pubsub": 1, "dht-bootstrap": 1}, time.Second); d != 2*time.Minute {
+		t.Fatalf("expected the longest grace period, got %s", d)
+	}
+	// Declared periods apply even when shorter than the default.
+	if d := g.For(map[string]int{"pubsub": 1}, time.Hour); d != 30*time.Second {
+		t.Fatalf("expected the pubsub grace period, got %s", d)
+	}
+
+	g.Set("pubsub", 0)
+	if _, ok := g.Get("pubsub"); ok {
+		t.Fatal("expected the declaration to be removed")
+	}
+
+	if !SetGracePeriod(NullConnMgr{}, "pubsub", time.Second) {
+		t.Fatal("expected NullConnMgr to accept grace periods")
+	}
+	if d := (NullConnMgr{}).GracePeriod("peer"); d != DefaultGracePeriod {
+		t.Fatalf("expected DefaultGracePeriod, got %s", d)
+	}
+}
@@ -0,0 +1,86 @@
+package connmgr
+
+import (
+	"context"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// PeerImportance is implemented by applications to tell the connection manager
+// how important peers are to them. Connection managers poll the registered
+// sources during trims, instead of applications maintaining tags ahead of
+// time: applications provide the policy, connection managers the mechanism.
+type PeerImportance interface {
+	// PeerImportance returns the importance of the peer, added to its tag
+	// score when ranking peers for trimming. It's called during trims, for
+	// every candidate peer, and must be fast. The context is canceled if
+	// the trim is aborted.
+	PeerImportance(ctx context.Context, p peer.ID) int
+}
+
+// PeerImportanceFunc is a function implementing PeerImportance.
+type PeerImportanceFunc func(ctx context.Context, p peer.ID) int
+
+// PeerImportance calls f(ctx, p).
+func (f PeerImportanceFunc) PeerImportance(ctx context.Context, p peer.ID) int {
+	return f(ctx, p)
+}
+
+// ImportanceAware is implemented by connection managers polling
+// PeerImportance sources during trims.
+type ImportanceAware interface {
+	// RegisterImportance registers the source under the name, replacing
+	// any source registered under the same name.
+	RegisterImportance(name string, src PeerImportance)
+
+	// UnregisterImportance removes the source registered under the name.
+	UnregisterImportance(name string)
+}
+
+// RegisterImportance registers the source with the connection manager, and
+// returns false if it doesn't implement ImportanceAware.
+func RegisterImportance(cm ConnManager, name string, src PeerImportance) bool {
+	ia, ok := cm.(ImportanceAware)
+	if ok {
+		ia.RegisterImportance(name, src)
+	}
+	return ok
+}
+
+// ImportanceSources holds named PeerImportance sources. It's safe for
+// concurrent use, and meant to be embedded in connection managers
+// implementing ImportanceAware. The zero value is ready to use.
+type ImportanceSources struct {
+	mu      sync.RWMutex
+	sources map[string]PeerImportance
+}
+
+// Register registers the source under the name.
+func (s *ImportanceSources) Register(name string, src PeerImportance) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sources == nil {
+		s.sources = make(map[string]PeerImportance)
+	}
+	s.sources[name] = src
+}
+
+// Unregister removes the source registered under the name.
+func (s *ImportanceSources) Unregister(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sources, name)
+}
+
+// Importance returns the sum of the importances of the peer returned by the
+// sources.
+func (s *ImportanceSources) Importance(ctx context.Context, p peer.ID) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	total := 0
+	for _, src := range s.sources {
+		total += src.PeerImportance(ctx, p)
+	}
+	return total
+}
@@ -0,0 +1,36 @@
+package connmgr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestImportanceSources(t *testing.T) {
+	ctx := context.Background()
+	var s ImportanceSources
+	if i := s.Importance(ctx, "a"); i != 0 {
+		t.Fatalf("expected no importance, got %d", i)
+	}
+
+	s.Register("pubsub", PeerImportanceFunc(func(_ context.Context, p peer.ID) int {
+		if p == "a" {
+			return 10
+		}
+		return 0
+	}))
+	s.Register("dht", PeerImportanceFunc(func(context.Context, peer.ID) int { return 1 }))
+	if i := s.Importance(ctx, "a"); i != 11 {
+		t.Fatalf("expected the sum of the sources, got %d", i)
+	}
+
+	s.Unregister("pubsub")
+	if i := s.Importance(ctx, "a"); i != 1 {
+		t.Fatalf("expected the source to be removed, got %d", i)
+	}
+
+	if !RegisterImportance(NullConnMgr{}, "app", PeerImportanceFunc(nil)) {
+		t.Fatal("expected NullConnMgr to implement ImportanceAware")
+	}
+}
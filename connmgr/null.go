@@ -2,6 +2,7 @@ package connmgr
 
 import (
 	"context"
+	"time"
 
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -21,3 +22,37 @@ func (_ NullConnMgr) Notifee() network.Notifiee                { return network.
 func (_ NullConnMgr) Protect(peer.ID, string)                  {}
 func (_ NullConnMgr) Unprotect(peer.ID, string) bool           { return false }
 func (_ NullConnMgr) Close() error                             { return nil }
+
+var _ PressureListener = (*NullConnMgr)(nil)
+
+func (_ NullConnMgr) SignalPressure(ResourcePressure) {}
+
+var _ QuotaAware = (*NullConnMgr)(nil)
+
+func (_ NullConnMgr) SetQuota(Quota) {}
+
+var _ GracePeriodConfigurer = (*NullConnMgr)(nil)
+
+func (_ NullConnMgr) SetGracePeriod(string, time.Duration) {}
+func (_ NullConnMgr) GracePeriod(peer.ID) time.Duration    { return DefaultGracePeriod }
+
+var _ EmergencyTrimmer = (*NullConnMgr)(nil)
+
+func (_ NullConnMgr) TrimTo(context.Context, int) (int, error)          { return 0, nil }
+func (_ NullConnMgr) TrimPeers(context.Context, []peer.ID) (int, error) { return 0, nil }
+
+var _ DiversityAware = (*NullConnMgr)(nil)
+
+func (_ NullConnMgr) SetDiversityPolicy(DiversityPolicy) {}
+func (_ NullConnMgr) DiversityStats() DiversityStats     { return DiversityStats{} }
+
+var _ StandbyPoolManager = (*NullConnMgr)(nil)
+
+func (_ NullConnMgr) SetStandbyPool(StandbyPool) error           { return nil }
+func (_ NullConnMgr) RemoveStandbyPool(string)                   {}
+func (_ NullConnMgr) StandbyStatus(string) (StandbyStatus, bool) { return StandbyStatus{}, false }
+
+var _ ImportanceAware = (*NullConnMgr)(nil)
+
+func (_ NullConnMgr) RegisterImportance(string, PeerImportance) {}
+func (_ NullConnMgr) UnregisterImportance(string)               {}
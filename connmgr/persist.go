@@ -0,0 +1,104 @@
+package connmgr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// SnapshotVersion is the version of the snapshot format written by
+// WriteSnapshot.
+const SnapshotVersion = 1
+
+// ErrPersistenceNotSupported is returned by Save and Restore when the
+// connection manager doesn't implement Persister.
+var ErrPersistenceNotSupported = errors.New("connection manager doesn't support persistence")
+
+// Persister is implemented by connection managers that can save their state
+// and restore it after a restart, so that a restarting node doesn't treat its
+// most valuable peers as strangers.
+type Persister interface {
+	// Save writes a snapshot of the tags and protections of all peers to w,
+	// usually with WriteSnapshot.
+	Save(w io.Writer) error
+
+	// Restore reads a snapshot written by Save and applies it. Tags and
+	// protections already present are kept; values in the snapshot replace
+	// those with the same name.
+	Restore(r io.Reader) error
+}
+
+// Snapshot is the persisted state of a connection manager.
+type Snapshot struct {
+	Version int
+	// Taken is the time the snapshot was taken. Implementations restoring
+	// decaying tags use it to account for the time spent offline.
+	Taken time.Time
+	Peers []PeerSnapshot
+}
+
+// PeerSnapshot is the persisted state of a single peer.
+type PeerSnapshot struct {
+	ID        peer.ID
+	FirstSeen time.Time
+	// Tags maps tag names to their values.
+	Tags         map[string]int        `json:",omitempty"`
+	DecayingTags []DecayingTagSnapshot `json:",omitempty"`
+	// Protections lists the tags the peer is protected under.
+	Protections []string `json:",omitempty"`
+}
+
+// DecayingTagSnapshot is the persisted state of a decaying tag.
+type DecayingTagSnapshot struct {
+	Name  string
+	Value int
+	// Interval is the interval at which the tag decays.
+	Interval time.Duration
+	// LastDecay is the last time the tag decayed.
+	LastDecay time.Time
+}
+
+// WriteSnapshot writes the snapshot to w, with its version set to
+// SnapshotVersion. The snapshot itself isn't modified.
+func WriteSnapshot(w io.Writer, s *Snapshot) error {
+	cp := *s
+	cp.Version = SnapshotVersion
+	return json.NewEncoder(w).Encode(&cp)
+}
+
+// ReadSnapshot reads a snapshot written by WriteSnapshot, rejecting snapshots
+// of unknown versions.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	s := new(Snapshot)
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+	if s.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported connection manager snapshot version %d", s.Version)
+	}
+	return s, nil
+}
+
+// Save saves the state of the connection manager to w. It returns
+// ErrPersistenceNotSupported if cm doesn't implement Persister.
+func Save(cm ConnManager, w io.Writer) error {
+	p, ok := cm.(Persister)
+	if !ok {
+		return ErrPersistenceNotSupported
+	}
+	return p.Save(w)
+}
+
+// Restore restores the state of the connection manager from r. It returns
+// ErrPersistenceNotSupported if cm doesn't implement Persister.
+func Restore(cm ConnManager, r io.Reader) error {
+	p, ok := cm.(Persister)
+	if !ok {
+		return ErrPersistenceNotSupported
+	}
+	return p.Restore(r)
+}
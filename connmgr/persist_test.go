@@ -0,0 +1,62 @@
+package connmgr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/test"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	p, err := test.RandPeerID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	s := &Snapshot{
+		Taken: now,
+		Peers: []PeerSnapshot{{
+			ID:           p,
+			FirstSeen:    now.Add(-time.Hour),
+			Tags:         map[string]int{"dht": 5},
+			DecayingTags: []DecayingTagSnapshot{{Name: "bitswap", Value: 10, Interval: time.Minute, LastDecay: now}},
+			Protections:  []string{"relay"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSnapshot(&buf, s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Version != 0 {
+		t.Fatal("expected WriteSnapshot to leave the snapshot unmodified")
+	}
+	s2, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2.Version != SnapshotVersion || !s2.Taken.Equal(now) || len(s2.Peers) != 1 {
+		t.Fatalf("unexpected snapshot: %+v", s2)
+	}
+	ps := s2.Peers[0]
+	if ps.ID != p || ps.Tags["dht"] != 5 || ps.Protections[0] != "relay" {
+		t.Fatalf("unexpected peer snapshot: %+v", ps)
+	}
+	if dt := ps.DecayingTags[0]; dt.Name != "bitswap" || dt.Value != 10 || dt.Interval != time.Minute {
+		t.Fatalf("unexpected decaying tag: %+v", dt)
+	}
+}
+
+func TestSnapshotVersion(t *testing.T) {
+	if _, err := ReadSnapshot(strings.NewReader(`{"Version":42}`)); err == nil {
+		t.Fatal("expected unknown version to be rejected")
+	}
+}
+
+func TestSaveNotSupported(t *testing.T) {
+	if err := Save(NullConnMgr{}, new(bytes.Buffer)); err != ErrPersistenceNotSupported {
+		t.Fatalf("expected ErrPersistenceNotSupported, got %v", err)
+	}
+}
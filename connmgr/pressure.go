@@ -0,0 +1,55 @@
+package connmgr
+
+// PressureLevel indicates how close the resource manager's system scope is to
+// its limits.
+type PressureLevel int
+
+const (
+	// PressureNone means resource usage is comfortably below the limits.
+	PressureNone PressureLevel = iota
+	// PressureHigh means resource usage is approaching the limits. The
+	// connection manager should trim down to its low watermark without
+	// waiting for the next periodic pass.
+	PressureHigh
+	// PressureCritical means the limits have been reached or exceeded. The
+	// connection manager should trim immediately and may go below its low
+	// watermark, sparing only protected peers.
+	PressureCritical
+)
+
+func (l PressureLevel) String() string {
+	switch l {
+	case PressureNone:
+		return "none"
+	case PressureHigh:
+		return "high"
+	case PressureCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// ResourcePressure describes the state of the resource manager's system scope
+// at the time a pressure signal is raised.
+type ResourcePressure struct {
+	// Level is the overall pressure level.
+	Level PressureLevel
+
+	// Resource names the resource that triggered the signal (e.g. "memory",
+	// "conns", "streams" or "fd").
+	Resource string
+
+	// Used and Limit are the current usage and limit of that resource.
+	Used, Limit int64
+}
+
+// PressureListener is implemented by connection managers that can react to
+// resource pressure reported by the resource manager.
+//
+// The resource manager calls SignalPressure whenever the pressure level of
+// its system scope changes. Implementations must not block; trimming should
+// be kicked off asynchronously.
+type PressureListener interface {
+	SignalPressure(ResourcePressure)
+}
@@ -0,0 +1,86 @@
+package connmgr
+
+import "time"
+
+// QuotaHighWatermark is the fraction of a transfer quota above which
+// QuotaPressure reports PressureHigh. On metered links, half of that is used
+// instead.
+var QuotaHighWatermark = 0.8
+
+// QuotaStatus describes the state of a transfer quota.
+type QuotaStatus struct {
+	// Metered is true if the link is metered (e.g. mobile or satellite),
+	// even if no explicit cap is known.
+	Metered bool
+
+	// Used is the number of bytes transferred in the current period, and
+	// Limit the cap for the period (0 if there's no cap).
+	Used, Limit int64
+
+	// PeriodEnd is the time the quota resets.
+	PeriodEnd time.Time
+
+	// Quiet is true if the node is within hours where it should be as quiet
+	// as possible (see QuietHours).
+	Quiet bool
+}
+
+// Fraction returns the fraction of the quota used, or 0 if there's no cap.
+func (s QuotaStatus) Fraction() float64 {
+	if s.Limit <= 0 {
+		return 0
+	}
+	return float64(s.Used) / float64(s.Limit)
+}
+
+// Quota is a provider of transfer quota information, e.g. daily transfer caps
+// tracked by the application or the OS's metered connection flag.
+type Quota interface {
+	QuotaStatus() QuotaStatus
+}
+
+// QuotaAware is implemented by connection managers that consult a Quota
+// provider to become less chatty (lower watermarks, fewer speculative
+// connections) when nearing the quota.
+type QuotaAware interface {
+	SetQuota(Quota)
+}
+
+// QuotaPressure maps a quota status to a pressure level: PressureCritical
+// once the cap is reached, PressureHigh above QuotaHighWatermark (half of it
+// on metered links) or during quiet hours, and PressureNone otherwise.
+func QuotaPressure(s QuotaStatus) PressureLevel {
+	f := s.Fraction()
+	watermark := QuotaHighWatermark
+	if s.Metered {
+		watermark /= 2
+	}
+	switch {
+	case s.Limit > 0 && f >= 1:
+		return PressureCritical
+	case s.Limit > 0 && f >= watermark:
+		return PressureHigh
+	case s.Quiet:
+		return PressureHigh
+	default:
+		return PressureNone
+	}
+}
+
+// QuietHours is a daily time window, in local time, during which a node
+// should keep its traffic to a minimum. Windows may wrap around midnight
+// (e.g. Start 22h, End 6h).
+type QuietHours struct {
+	// Start and End are offsets from midnight.
+	Start, End time.Duration
+}
+
+// Contains returns true if t falls within the window.
+func (q QuietHours) Contains(t time.Time) bool {
+	y, m, d := t.Date()
+	offset := t.Sub(time.Date(y, m, d, 0, 0, 0, 0, t.Location()))
+	if q.Start <= q.End {
+		return offset >= q.Start && offset < q.End
+	}
+	return offset >= q.Start || offset < q.End
+}
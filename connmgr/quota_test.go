@@ -0,0 +1,37 @@
+package connmgr
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuotaPressure(t *testing.T) {
+	for _, tc := range []struct {
+		status   QuotaStatus
+		expected PressureLevel
+	}{
+		{QuotaStatus{}, PressureNone},
+		{QuotaStatus{Used: 50, Limit: 100}, PressureNone},
+		{QuotaStatus{Used: 50, Limit: 100, Metered: true}, PressureHigh},
+		{QuotaStatus{Used: 90, Limit: 100}, PressureHigh},
+		{QuotaStatus{Used: 100, Limit: 100}, PressureCritical},
+		{QuotaStatus{Quiet: true}, PressureHigh},
+	} {
+		if l := QuotaPressure(tc.status); l != tc.expected {
+			t.Errorf("%+v: expected %s, got %s", tc.status, tc.expected, l)
+		}
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	at := func(h int) time.Time { return time.Date(2020, 1, 1, h, 0, 0, 0, time.UTC) }
+
+	night := QuietHours{Start: 22 * time.Hour, End: 6 * time.Hour}
+	if !night.Contains(at(23)) || !night.Contains(at(2)) || night.Contains(at(12)) {
+		t.Fatal("wrong window wrapping around midnight")
+	}
+	day := QuietHours{Start: 9 * time.Hour, End: 17 * time.Hour}
+	if !day.Contains(at(9)) || day.Contains(at(17)) || day.Contains(at(8)) {
+		t.Fatal("wrong daytime window")
+	}
+}
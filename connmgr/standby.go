@@ -0,0 +1,137 @@
+package connmgr
+
+import (
+	"errors"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// ErrStandbyNotSupported is returned by SetStandbyPool when the connection
+// manager doesn't implement StandbyPoolManager.
+var ErrStandbyNotSupported = errors.New("connection manager doesn't support standby pools")
+
+// DefaultStandbyRotation is the rotation interval of standby pools that don't
+// set one.
+var DefaultStandbyRotation = 10 * time.Minute
+
+// StandbyPool declares that Spares connections to peers of a labeled set
+// should be kept established, so latency-critical applications always have a
+// hot connection path. Connections in the pool are protected from trimming
+// under the tag StandbyTag(Label), and are rotated every Rotation to a peer of
+// the set not in the pool yet, so that failures of idle peers are detected.
+type StandbyPool struct {
+	Label  string
+	Peers  []peer.ID
+	Spares int
+
+	// Rotation is the interval between rotations. Zero means
+	// DefaultStandbyRotation, and a negative value disables rotation.
+	Rotation time.Duration
+}
+
+// Validate checks the pool is well-formed.
+func (p *StandbyPool) Validate() error {
+	switch {
+	case p.Label == "":
+		return errors.New("standby pool without a label")
+	case p.Spares <= 0:
+		return errors.New("standby pool without spares")
+	}
+	return nil
+}
+
+// RotationInterval returns the rotation interval of the pool, or 0 if
+// rotation is disabled.
+func (p *StandbyPool) RotationInterval() time.Duration {
+	switch {
+	case p.Rotation == 0:
+		return DefaultStandbyRotation
+	case p.Rotation < 0:
+		return 0
+	}
+	return p.Rotation
+}
+
+// StandbyTag returns the tag protecting the connections of the pool.
+func StandbyTag(label string) string {
+	return "standby:" + label
+}
+
+// StandbyStatus is the state of a standby pool.
+type StandbyStatus struct {
+	// Established lists the peers of the pool with an established
+	// connection.
+	Established []peer.ID
+	// Rotated is the time of the last rotation.
+	Rotated time.Time
+}
+
+// StandbyPoolManager is implemented by connection managers that maintain
+// warm-standby connection pools.
+type StandbyPoolManager interface {
+	// SetStandbyPool declares the pool, replacing any pool with the same
+	// label.
+	SetStandbyPool(StandbyPool) error
+
+	// RemoveStandbyPool removes the pool, unprotecting its connections.
+	RemoveStandbyPool(label string)
+
+	// StandbyStatus returns the state of the pool, and false if there's no
+	// pool with the label.
+	StandbyStatus(label string) (StandbyStatus, bool)
+}
+
+// SetStandbyPool declares the pool with the connection manager, or returns
+// ErrStandbyNotSupported if it doesn't implement StandbyPoolManager.
+func SetStandbyPool(cm ConnManager, pool StandbyPool) error {
+	sm, ok := cm.(StandbyPoolManager)
+	if !ok {
+		return ErrStandbyNotSupported
+	}
+	if err := pool.Validate(); err != nil {
+		return err
+	}
+	return sm.SetStandbyPool(pool)
+}
+
+// PlanStandby computes the changes bringing the pool's established peers,
+// current (oldest first), to the pool's declared spares: peers no longer in
+// the set and extra peers are released, and missing spares are dialed from
+// the peers of the set not established yet, in order. If rotate is set, the
+// oldest established peer is also replaced, when there's a candidate to
+// replace it with.
+func PlanStandby(pool StandbyPool, current []peer.ID, rotate bool) (dial, release []peer.ID) {
+	inSet := make(map[peer.ID]bool, len(pool.Peers))
+	for _, p := range pool.Peers {
+		inSet[p] = true
+	}
+	established := make(map[peer.ID]bool, len(current))
+	var keep []peer.ID
+	for _, p := range current {
+		established[p] = true
+		if inSet[p] && len(keep) < pool.Spares {
+			keep = append(keep, p)
+		} else {
+			release = append(release, p)
+		}
+	}
+
+	var candidates []peer.ID
+	for _, p := range pool.Peers {
+		if !established[p] {
+			candidates = append(candidates, p)
+		}
+	}
+	if rotate && len(keep) > 0 && len(candidates) > 0 {
+		release = append(release, keep[0])
+		keep = keep[1:]
+		dial = append(dial, candidates[0])
+		candidates = candidates[1:]
+	}
+	for len(keep)+len(dial) < pool.Spares && len(candidates) > 0 {
+		dial = append(dial, candidates[0])
+		candidates = candidates[1:]
+	}
+	return dial, release
+}
@@ -0,0 +1,37 @@
+package connmgr
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestPlanStandby(t *testing.T) {
+	pool := StandbyPool{Label: "relays", Peers: []peer.ID{"a", "b", "c", "d"}, Spares: 2}
+
+	for _, tc := range []struct {
+		current       []peer.ID
+		rotate        bool
+		dial, release []peer.ID
+	}{
+		{nil, false, []peer.ID{"a", "b"}, nil},
+		{[]peer.ID{"b"}, false, []peer.ID{"a"}, nil},
+		{[]peer.ID{"a", "b"}, false, nil, nil},
+		{[]peer.ID{"a", "b"}, true, []peer.ID{"c"}, []peer.ID{"a"}},
+		{[]peer.ID{"x", "a", "b", "c"}, false, nil, []peer.ID{"x", "c"}},
+	} {
+		dial, release := PlanStandby(pool, tc.current, tc.rotate)
+		if !reflect.DeepEqual(dial, tc.dial) || !reflect.DeepEqual(release, tc.release) {
+			t.Errorf("current %v, rotate %t: expected dial %v, release %v, got %v, %v",
+				tc.current, tc.rotate, tc.dial, tc.release, dial, release)
+		}
+	}
+
+	if err := SetStandbyPool(NullConnMgr{}, StandbyPool{Label: "empty"}); err == nil {
+		t.Fatal("expected a pool without spares to be rejected")
+	}
+	if err := SetStandbyPool(NullConnMgr{}, pool); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,46 @@
+package connmgr
+
+import (
+	"context"
+	"errors"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// ErrTrimNotSupported is returned by TrimTo and TrimPeers when the connection
+// manager doesn't implement EmergencyTrimmer.
+var ErrTrimNotSupported = errors.New("connection manager doesn't support emergency trims")
+
+// EmergencyTrimmer is implemented by connection managers that can be forced
+// to reduce their connections immediately, e.g. by operators or automated
+// overload handlers. Unlike TrimOpenConns, these trims ignore the watermarks
+// and the grace period of new connections; protected peers are still spared.
+type EmergencyTrimmer interface {
+	// TrimTo closes connections, starting with the lowest-value peers,
+	// until at most targetConns connections remain (or only protected
+	// peers are left). It returns the number of connections closed.
+	TrimTo(ctx context.Context, targetConns int) (int, error)
+
+	// TrimPeers closes all the connections to the given peers, unless
+	// they're protected. It returns the number of connections closed.
+	TrimPeers(ctx context.Context, peers []peer.ID) (int, error)
+}
+
+// TrimTo forces the connection manager down to targetConns connections, or
+// returns ErrTrimNotSupported if it doesn't implement EmergencyTrimmer.
+func TrimTo(ctx context.Context, cm ConnManager, targetConns int) (int, error) {
+	if et, ok := cm.(EmergencyTrimmer); ok {
+		return et.TrimTo(ctx, targetConns)
+	}
+	return 0, ErrTrimNotSupported
+}
+
+// TrimPeers forces the connection manager to close its connections to the
+// peers, or returns ErrTrimNotSupported if it doesn't implement
+// EmergencyTrimmer.
+func TrimPeers(ctx context.Context, cm ConnManager, peers []peer.ID) (int, error) {
+	if et, ok := cm.(EmergencyTrimmer); ok {
+		return et.TrimPeers(ctx, peers)
+	}
+	return 0, ErrTrimNotSupported
+}
@@ -0,0 +1,46 @@
+package connmgr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+type plainConnMgr struct {
+	ConnManager
+}
+
+type trimmingConnMgr struct {
+	NullConnMgr
+	target int
+	peers  []peer.ID
+}
+
+func (cm *trimmingConnMgr) TrimTo(_ context.Context, target int) (int, error) {
+	cm.target = target
+	return 3, nil
+}
+
+func (cm *trimmingConnMgr) TrimPeers(_ context.Context, peers []peer.ID) (int, error) {
+	cm.peers = peers
+	return len(peers), nil
+}
+
+func TestEmergencyTrim(t *testing.T) {
+	ctx := context.Background()
+	if _, err := TrimTo(ctx, plainConnMgr{}, 10); err != ErrTrimNotSupported {
+		t.Fatalf("expected ErrTrimNotSupported, got %v", err)
+	}
+	if _, err := TrimPeers(ctx, plainConnMgr{}, nil); err != ErrTrimNotSupported {
+		t.Fatalf("expected ErrTrimNotSupported, got %v", err)
+	}
+
+	cm := &trimmingConnMgr{}
+	if n, err := TrimTo(ctx, cm, 10); err != nil || n != 3 || cm.target != 10 {
+		t.Fatalf("unexpected trim: %d, %v, target %d", n, err, cm.target)
+	}
+	if n, err := TrimPeers(ctx, cm, []peer.ID{"a", "b"}); err != nil || n != 2 || len(cm.peers) != 2 {
+		t.Fatalf("unexpected trim: %d, %v", n, err)
+	}
+}
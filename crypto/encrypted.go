@@ -0,0 +1,162 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// EncryptedKeyVersion is the version of the encrypted private key format
+// produced by SerializePrivateKeyEncrypted.
+const EncryptedKeyVersion = 1
+
+const (
+	encryptedKeyKDF    = "scrypt"
+	encryptedKeyCipher = "aes-256-gcm"
+	encryptedKeySalt   = 32
+
+	// minEncryptedKeySalt and maxEncryptedKeySalt bound the salt length
+	// of loaded keys.
+	minEncryptedKeySalt = 16
+	maxEncryptedKeySalt = 64
+)
+
+// ErrBadPassphrase is returned when an encrypted private key can't be
+// decrypted, either because the passphrase is wrong or because the
+// ciphertext has been tampered with.
+var ErrBadPassphrase = errors.New("invalid passphrase or corrupted key")
+
+// ScryptParams are the scrypt parameters used to derive the encryption key
+// from a passphrase.
+type ScryptParams struct {
+	N int
+	R int
+	P int
+}
+
+// DefaultScryptParams are the scrypt parameters recommended for interactive
+// use.
+var DefaultScryptParams = ScryptParams{N: 1 << 15, R: 8, P: 1}
+
+// MaxScryptParams bound the scrypt parameters of the keys loaded by
+// DeserializePrivateKeyEncrypted, which come from the untrusted key file:
+// at the limits, deriving the key takes 1 GiB of memory.
+var MaxScryptParams = ScryptParams{N: 1 << 20, R: 8, P: 16}
+
+// ErrScryptParams is returned when loading an encrypted private key whose
+// scrypt parameters exceed MaxScryptParams.
+var ErrScryptParams = errors.New("scrypt parameters out of bounds")
+
+// check returns ErrScryptParams if the parameters exceed max.
+func (p ScryptParams) check(max ScryptParams) error {
+	if p.N <= 1 || p.N > max.N || p.R <= 0 || p.R > max.R || p.P <= 0 || p.P > max.P {
+		return fmt.Errorf("%w: N=%d, r=%d, p=%d", ErrScryptParams, p.N, p.R, p.P)
+	}
+	return nil
+}
+
+// encryptedKey is the JSON container of an encrypted private key.
+type encryptedKey struct {
+	Version    int
+	KDF        string
+	KDFParams  ScryptParams
+	Salt       []byte
+	Cipher     string
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+// SerializePrivateKeyEncrypted marshals the private key with MarshalPrivateKey
+// and encrypts it for storage at rest.
+//
+// The encryption key is derived from the passphrase with scrypt using the
+// given parameters and a random salt; the marshaled key is then sealed with
+// AES-256-GCM. The salt and nonce are read from RandReader. The result is a self-describing JSON document that can be
+// loaded with DeserializePrivateKeyEncrypted.
+func SerializePrivateKeyEncrypted(k PrivKey, passphrase []byte, params ScryptParams) ([]byte, error) {
+	plain, err := MarshalPrivateKey(k)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, encryptedKeySalt)
+	if _, err := io.ReadFull(RandReader(), salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := newKeyAEAD(passphrase, salt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(RandReader(), nonce); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&encryptedKey{
+		Version:    EncryptedKeyVersion,
+		KDF:        encryptedKeyKDF,
+		KDFParams:  params,
+		Salt:       salt,
+		Cipher:     encryptedKeyCipher,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plain, nil),
+	})
+}
+
+// DeserializePrivateKeyEncrypted decrypts and unmarshals a private key
+// serialized with SerializePrivateKeyEncrypted. Keys whose scrypt parameters
+// exceed MaxScryptParams are rejected before deriving the encryption key.
+func DeserializePrivateKeyEncrypted(data []byte, passphrase []byte) (PrivKey, error) {
+	var ek encryptedKey
+	if err := json.Unmarshal(data, &ek); err != nil {
+		return nil, err
+	}
+	if ek.Version != EncryptedKeyVersion {
+		return nil, fmt.Errorf("unsupported encrypted key version %d", ek.Version)
+	}
+	if ek.KDF != encryptedKeyKDF {
+		return nil, fmt.Errorf("unsupported key derivation function %q", ek.KDF)
+	}
+	if ek.Cipher != encryptedKeyCipher {
+		return nil, fmt.Errorf("unsupported cipher %q", ek.Cipher)
+	}
+	if err := ek.KDFParams.check(MaxScryptParams); err != nil {
+		return nil, err
+	}
+	if len(ek.Salt) < minEncryptedKeySalt || len(ek.Salt) > maxEncryptedKeySalt {
+		return nil, errors.New("invalid salt size")
+	}
+
+	aead, err := newKeyAEAD(passphrase, ek.Salt, ek.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	if len(ek.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce size")
+	}
+
+	plain, err := aead.Open(nil, ek.Nonce, ek.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrBadPassphrase
+	}
+	return UnmarshalPrivateKey(plain)
+}
+
+func newKeyAEAD(passphrase, salt []byte, params ScryptParams) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,72 @@
+package crypto_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	mrand "math/rand"
+	"testing"
+
+	. "github.com/libp2p/go-libp2p-core/crypto"
+)
+
+// Cheap parameters; DefaultScryptParams are too slow for tests.
+var testScryptParams = ScryptParams{N: 1 << 10, R: 8, P: 1}
+
+func TestEncryptedKeyRoundTrip(t *testing.T) {
+	sk, _, err := GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := SerializePrivateKeyEncrypted(sk, []byte("secret"), testScryptParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sk2, err := DeserializePrivateKeyEncrypted(data, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sk.Equals(sk2) {
+		t.Fatal("loaded key doesn't match the original")
+	}
+
+	if _, err := DeserializePrivateKeyEncrypted(data, []byte("wrong")); err != ErrBadPassphrase {
+		t.Fatalf("expected ErrBadPassphrase, got %v", err)
+	}
+
+	// The salt and nonce come from the configured entropy source.
+	prev := SetRandReader(mrand.New(mrand.NewSource(42)))
+	data1, err := SerializePrivateKeyEncrypted(sk, []byte("secret"), testScryptParams)
+	SetRandReader(mrand.New(mrand.NewSource(42)))
+	data2, err2 := SerializePrivateKeyEncrypted(sk, []byte("secret"), testScryptParams)
+	SetRandReader(prev)
+	if err != nil || err2 != nil {
+		t.Fatal(err, err2)
+	}
+	if !bytes.Equal(data1, data2) {
+		t.Fatal("expected deterministic output from a seeded source")
+	}
+}
+
+func TestEncryptedKeyBounds(t *testing.T) {
+	sk, _, err := GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := SerializePrivateKeyEncrypted(sk, []byte("secret"), testScryptParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A crafted file asking for 64 GiB of memory.
+	crafted := bytes.Replace(data, []byte(`"N":1024`), []byte(`"N":67108864`), 1)
+	if _, err := DeserializePrivateKeyEncrypted(crafted, []byte("secret")); !errors.Is(err, ErrScryptParams) {
+		t.Fatalf("expected ErrScryptParams, got %v", err)
+	}
+	crafted = bytes.Replace(data, []byte(`"P":1`), []byte(`"P":1000000`), 1)
+	if _, err := DeserializePrivateKeyEncrypted(crafted, []byte("secret")); !errors.Is(err, ErrScryptParams) {
+		t.Fatalf("expected ErrScryptParams, got %v", err)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"bytes"
 	"crypto/elliptic"
 	"crypto/hmac"
-	"crypto/rand"
 	"crypto/sha1"
 	"crypto/sha512"
 	"encoding/base64"
@@ -31,6 +30,11 @@ const (
 	Secp256k1
 	// ECDSA is an enum for the supported ECDSA key type
 	ECDSA
+	// X25519 is an enum for the supported X25519 key type. X25519 keys can't
+	// sign and are therefore not part of KeyTypes, nor of the unmarshallers:
+	// UnmarshalPublicKey and UnmarshalPrivateKey reject them, so they can't
+	// become peer identities.
+	X25519
 )
 
 var (
@@ -52,16 +56,22 @@ type PubKeyUnmarshaller func(data []byte) (PubKey, error)
 type PrivKeyUnmarshaller func(data []byte) (PrivKey, error)
 
 // PubKeyUnmarshallers is a map of unmarshallers by key type
+//
+// The size of RSA keys is checked against the RsaKeyPolicy once the
+// unmarshaller returns, so the RSA unmarshaller doesn't check it itself.
 var PubKeyUnmarshallers = map[pb.KeyType]PubKeyUnmarshaller{
-	pb.KeyType_RSA:       UnmarshalRsaPublicKey,
+	pb.KeyType_RSA:       unmarshalRsaPublicKey,
 	pb.KeyType_Ed25519:   UnmarshalEd25519PublicKey,
 	pb.KeyType_Secp256k1: UnmarshalSecp256k1PublicKey,
 	pb.KeyType_ECDSA:     UnmarshalECDSAPublicKey,
 }
 
 // PrivKeyUnmarshallers is a map of unmarshallers by key type
+//
+// As for PubKeyUnmarshallers, the size of RSA keys is checked once the
+// unmarshaller returns.
 var PrivKeyUnmarshallers = map[pb.KeyType]PrivKeyUnmarshaller{
-	pb.KeyType_RSA:       UnmarshalRsaPrivateKey,
+	pb.KeyType_RSA:       unmarshalRsaPrivateKey,
 	pb.KeyType_Ed25519:   UnmarshalEd25519PrivateKey,
 	pb.KeyType_Secp256k1: UnmarshalSecp256k1PrivateKey,
 	pb.KeyType_ECDSA:     UnmarshalECDSAPrivateKey,
@@ -108,9 +118,10 @@ type PubKey interface {
 // GenSharedKey generates the shared key from a given private key
 type GenSharedKey func([]byte) ([]byte, error)
 
-// GenerateKeyPair generates a private and public key
+// GenerateKeyPair generates a private and public key, using the entropy source
+// set with SetRandReader (crypto/rand by default).
 func GenerateKeyPair(typ, bits int) (PrivKey, PubKey, error) {
-	return GenerateKeyPairWithReader(typ, bits, rand.Reader)
+	return GenerateKeyPairWithReader(typ, bits, RandReader())
 }
 
 // GenerateKeyPairWithReader returns a keypair of the given type and bitsize
@@ -124,6 +135,8 @@ func GenerateKeyPairWithReader(typ, bits int, src io.Reader) (PrivKey, PubKey, e
 		return GenerateSecp256k1Key(src)
 	case ECDSA:
 		return GenerateECDSAKeyPair(src)
+	case X25519:
+		return GenerateX25519Key(src)
 	default:
 		return nil, nil, ErrBadKeyType
 	}
@@ -145,7 +158,7 @@ func GenerateEKeyPair(curveName string) ([]byte, GenSharedKey, error) {
 		curve = elliptic.P521()
 	}
 
-	priv, x, y, err := elliptic.GenerateKey(curve, rand.Reader)
+	priv, x, y, err := elliptic.GenerateKey(curve, RandReader())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -271,23 +284,59 @@ func KeyStretcher(cipherType string, hashType string, secret []byte) (StretchedK
 // UnmarshalPublicKey converts a protobuf serialized public key into its
 // representative object
 func UnmarshalPublicKey(data []byte) (PubKey, error) {
+	return UnmarshalPublicKeyWithRsaPolicy(data, DefaultRsaKeyPolicy())
+}
+
+// UnmarshalPublicKeyWithRsaPolicy is like UnmarshalPublicKey, but checks the
+// size of RSA keys against the given policy instead of the default one.
+func UnmarshalPublicKeyWithRsaPolicy(data []byte, policy RsaKeyPolicy) (PubKey, error) {
 	pmes := new(pb.PublicKey)
 	err := proto.Unmarshal(data, pmes)
 	if err != nil {
 		return nil, err
 	}
 
+	return publicKeyFromProto(pmes, policy)
+}
+
+// PublicKeyFromProto converts an unserialized protobuf PublicKey message
+// into its representative object.
+func PublicKeyFromProto(pmes *pb.PublicKey) (PubKey, error) {
+	return publicKeyFromProto(pmes, DefaultRsaKeyPolicy())
+}
+
+func publicKeyFromProto(pmes *pb.PublicKey, policy RsaKeyPolicy) (PubKey, error) {
 	um, ok := PubKeyUnmarshallers[pmes.GetType()]
 	if !ok {
 		return nil, ErrBadKeyType
 	}
 
-	return um(pmes.GetData())
+	pk, err := um(pmes.GetData())
+	if err != nil {
+		return nil, err
+	}
+	if pmes.GetType() == pb.KeyType_RSA {
+		if err := checkRsaPublicKey(pk, policy); err != nil {
+			return nil, err
+		}
+	}
+	return pk, nil
 }
 
 // MarshalPublicKey converts a public key object into a protobuf serialized
 // public key
 func MarshalPublicKey(k PubKey) ([]byte, error) {
+	pbmes, err := PublicKeyToProto(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(pbmes)
+}
+
+// PublicKeyToProto converts a public key object into an unserialized
+// protobuf PublicKey message.
+func PublicKeyToProto(k PubKey) (*pb.PublicKey, error) {
 	pbmes := new(pb.PublicKey)
 	pbmes.Type = k.Type()
 	data, err := k.Raw()
@@ -295,13 +344,18 @@ func MarshalPublicKey(k PubKey) ([]byte, error) {
 		return nil, err
 	}
 	pbmes.Data = data
-
-	return proto.Marshal(pbmes)
+	return pbmes, nil
 }
 
 // UnmarshalPrivateKey converts a protobuf serialized private key into its
 // representative object
 func UnmarshalPrivateKey(data []byte) (PrivKey, error) {
+	return UnmarshalPrivateKeyWithRsaPolicy(data, DefaultRsaKeyPolicy())
+}
+
+// UnmarshalPrivateKeyWithRsaPolicy is like UnmarshalPrivateKey, but checks the
+// size of RSA keys against the given policy instead of the default one.
+func UnmarshalPrivateKeyWithRsaPolicy(data []byte, policy RsaKeyPolicy) (PrivKey, error) {
 	pmes := new(pb.PrivateKey)
 	err := proto.Unmarshal(data, pmes)
 	if err != nil {
@@ -313,7 +367,16 @@ func UnmarshalPrivateKey(data []byte) (PrivKey, error) {
 		return nil, ErrBadKeyType
 	}
 
-	return um(pmes.GetData())
+	sk, err := um(pmes.GetData())
+	if err != nil {
+		return nil, err
+	}
+	if pmes.GetType() == pb.KeyType_RSA {
+		if err := checkRsaPublicKey(sk.GetPublic(), policy); err != nil {
+			return nil, err
+		}
+	}
+	return sk, nil
 }
 
 // MarshalPrivateKey converts a key object into its protobuf serialized form.
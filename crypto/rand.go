@@ -0,0 +1,107 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
+)
+
+// RepetitionCutoff is the number of identical consecutive bytes after which a
+// health-checked entropy source is considered stuck. For a healthy source,
+// the probability of such a run is negligible (2^-8 per additional byte).
+const RepetitionCutoff = 32
+
+// ErrEntropyHealthCheck is returned by a health-checked entropy source when
+// its output fails a health check.
+var ErrEntropyHealthCheck = errors.New("entropy source failed health check")
+
+var (
+	randLk     sync.RWMutex
+	randReader io.Reader = rand.Reader
+)
+
+// SetRandReader sets the entropy source used by the functions that don't take
+// one explicitly (GenerateKeyPair, GenerateEKeyPair, ...), e.g. to use a TPM
+// or DRBG, or a deterministic source in tests. Passing nil restores
+// crypto/rand. It returns the previous source.
+func SetRandReader(r io.Reader) io.Reader {
+	if r == nil {
+		r = rand.Reader
+	}
+	randLk.Lock()
+	defer randLk.Unlock()
+	prev := randReader
+	randReader = r
+	return prev
+}
+
+// RandReader returns the entropy source set with SetRandReader.
+func RandReader() io.Reader {
+	randLk.RLock()
+	defer randLk.RUnlock()
+	return randReader
+}
+
+// healthCheckedReader applies continuous health tests to an entropy source.
+type healthCheckedReader struct {
+	r io.Reader
+
+	lk       sync.Mutex
+	last     byte
+	run      int
+	started  bool
+	failed   bool
+	prevHead []byte
+}
+
+// NewHealthCheckedReader wraps an entropy source with continuous health
+// tests, making reads fail with ErrEntropyHealthCheck if the source appears
+// to be stuck. Once a test failed, all subsequent reads fail.
+//
+// Two tests are applied: a repetition count test, failing on runs of more
+// than RepetitionCutoff identical bytes, and a continuous output test,
+// failing when a read starts with the same 16 bytes as the previous one.
+func NewHealthCheckedReader(r io.Reader) io.Reader {
+	return &healthCheckedReader{r: r}
+}
+
+const healthHeadSize = 16
+
+func (h *healthCheckedReader) Read(b []byte) (int, error) {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	if h.failed {
+		return 0, ErrEntropyHealthCheck
+	}
+	n, err := h.r.Read(b)
+	if !h.check(b[:n]) {
+		h.failed = true
+		return 0, ErrEntropyHealthCheck
+	}
+	return n, err
+}
+
+func (h *healthCheckedReader) check(b []byte) bool {
+	if len(b) >= healthHeadSize {
+		if h.prevHead != nil && bytes.Equal(h.prevHead, b[:healthHeadSize]) {
+			return false
+		}
+		h.prevHead = append(h.prevHead[:0], b[:healthHeadSize]...)
+	}
+	for _, c := range b {
+		if h.started && c == h.last {
+			h.run++
+			if h.run > RepetitionCutoff {
+				return false
+			}
+			continue
+		}
+		h.started = true
+		h.last = c
+		h.run = 1
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	mrand "math/rand"
+	"testing"
+)
+
+func TestSetRandReader(t *testing.T) {
+	seeded := func() io.Reader { return mrand.New(mrand.NewSource(42)) }
+
+	prev := SetRandReader(seeded())
+	sk1, _, err := GenerateKeyPair(Ed25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetRandReader(seeded())
+	sk2, _, err := GenerateKeyPair(Ed25519, 0)
+	SetRandReader(prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sk1.Equals(sk2) {
+		t.Fatal("expected deterministic keys from a seeded source")
+	}
+	if RandReader() != rand.Reader {
+		t.Fatal("expected the default source to be restored")
+	}
+}
+
+type constReader byte
+
+func (c constReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = byte(c)
+	}
+	return len(b), nil
+}
+
+func TestHealthCheckedReader(t *testing.T) {
+	r := NewHealthCheckedReader(rand.Reader)
+	buf := make([]byte, 1024)
+	for i := 0; i < 10; i++ {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stuck := NewHealthCheckedReader(constReader(0))
+	if _, err := stuck.Read(make([]byte, RepetitionCutoff+1)); err != ErrEntropyHealthCheck {
+		t.Fatalf("expected ErrEntropyHealthCheck, got %v", err)
+	}
+	if _, err := stuck.Read(make([]byte, 1)); err != ErrEntropyHealthCheck {
+		t.Fatal("expected the failure to be sticky")
+	}
+
+	// A source repeating its output fails the continuous test.
+	block := make([]byte, 16)
+	rand.Read(block)
+	repeating := NewHealthCheckedReader(io.MultiReader(bytes.NewReader(block), bytes.NewReader(block)))
+	if _, err := repeating.Read(make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repeating.Read(make([]byte, 16)); err != ErrEntropyHealthCheck {
+		t.Fatalf("expected ErrEntropyHealthCheck, got %v", err)
+	}
+}
@@ -1,21 +1,46 @@
 package crypto
 
 import (
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 )
 
 // WeakRsaKeyEnv is an environment variable which, when set, lowers the
 // minimum required bits of RSA keys to 512. This should be used exclusively in
 // test situations.
+//
+// Deprecated: pass an RsaKeyPolicy to the *WithPolicy and *WithRsaPolicy
+// functions instead.
 const WeakRsaKeyEnv = "LIBP2P_ALLOW_WEAK_RSA_KEYS"
 
 var MinRsaKeyBits = 2048
 
+// MaxRsaKeyBits is the maximum size of RSA keys accepted by the default
+// policy. 0, the default, means no limit, as before policies existed.
+//
+// Verifying signatures made with huge keys is expensive, so accepting them
+// from the network makes for an easy denial of service: nodes are encouraged
+// to set it, or the MaxBits of their policies, to RecommendedMaxRsaKeyBits.
+var MaxRsaKeyBits = 0
+
+// RecommendedMaxRsaKeyBits is the recommended maximum size of RSA keys
+// accepted from the network.
+const RecommendedMaxRsaKeyBits = 8192
+
 // ErrRsaKeyTooSmall is returned when trying to generate or parse an RSA key
-// that's smaller than MinRsaKeyBits bits. In test
+// that's smaller than MinRsaKeyBits bits. Keys smaller than the minimum of
+// another RsaKeyPolicy fail with an error wrapping it.
 var ErrRsaKeyTooSmall error
 
+// ErrRsaKeyTooLarge is returned, wrapped in an error giving the maximum of the
+// RsaKeyPolicy in use, when trying to generate or parse an RSA key that's
+// larger than the maximum.
+var ErrRsaKeyTooLarge = errors.New("rsa key too large")
+
 func init() {
 	if _, ok := os.LookupEnv(WeakRsaKeyEnv); ok {
 		MinRsaKeyBits = 512
@@ -23,3 +48,100 @@ func init() {
 
 	ErrRsaKeyTooSmall = fmt.Errorf("rsa keys must be >= %d bits to be useful", MinRsaKeyBits)
 }
+
+// rsaKeySizeError reports the bounds of the policy a key was checked against.
+type rsaKeySizeError struct {
+	err error
+	msg string
+}
+
+func (e *rsaKeySizeError) Error() string { return e.msg }
+func (e *rsaKeySizeError) Unwrap() error { return e.err }
+
+// RsaKeyPolicy bounds the size of the RSA keys that are generated or parsed.
+//
+// The package-level functions apply DefaultRsaKeyPolicy. Code that needs to
+// accept weaker keys (e.g. tests, or interop with legacy peers) should pass
+// its own policy to the *WithPolicy and *WithRsaPolicy functions rather than
+// lowering the global limits.
+type RsaKeyPolicy struct {
+	// MinBits is the minimum key size.
+	MinBits int
+	// MaxBits is the maximum key size. 0 means no limit.
+	MaxBits int
+}
+
+// DefaultRsaKeyPolicy returns the policy applied when none is given:
+// MinRsaKeyBits to MaxRsaKeyBits bits.
+func DefaultRsaKeyPolicy() RsaKeyPolicy {
+	return RsaKeyPolicy{MinBits: MinRsaKeyBits, MaxBits: MaxRsaKeyBits}
+}
+
+// Check returns an error matching ErrRsaKeyTooSmall or ErrRsaKeyTooLarge
+// (see errors.Is) if a key of the given size is not allowed by the policy.
+func (p RsaKeyPolicy) Check(bits int) error {
+	if bits < p.MinBits {
+		if p.MinBits == MinRsaKeyBits {
+			return ErrRsaKeyTooSmall
+		}
+		return &rsaKeySizeError{ErrRsaKeyTooSmall, fmt.Sprintf("rsa keys must be >= %d bits to be useful, got %d", p.MinBits, bits)}
+	}
+	if p.MaxBits > 0 && bits > p.MaxBits {
+		return &rsaKeySizeError{ErrRsaKeyTooLarge, fmt.Sprintf("rsa keys must be <= %d bits, got %d", p.MaxBits, bits)}
+	}
+	return nil
+}
+
+// GenerateRSAKeyPairWithPolicy is like GenerateRSAKeyPair, but checks the key
+// size against the given policy.
+func GenerateRSAKeyPairWithPolicy(bits int, src io.Reader, policy RsaKeyPolicy) (PrivKey, PubKey, error) {
+	if err := policy.Check(bits); err != nil {
+		return nil, nil, err
+	}
+	return generateRSAKeyPair(bits, src)
+}
+
+// GenerateRSAKeyPair generates a new rsa private and public key
+func GenerateRSAKeyPair(bits int, src io.Reader) (PrivKey, PubKey, error) {
+	return GenerateRSAKeyPairWithPolicy(bits, src, DefaultRsaKeyPolicy())
+}
+
+// UnmarshalRsaPrivateKey returns a private key from the input x509 bytes
+func UnmarshalRsaPrivateKey(b []byte) (PrivKey, error) {
+	return UnmarshalRsaPrivateKeyWithPolicy(b, DefaultRsaKeyPolicy())
+}
+
+// UnmarshalRsaPublicKey returns a public key from the input x509 bytes
+func UnmarshalRsaPublicKey(b []byte) (PubKey, error) {
+	return UnmarshalRsaPublicKeyWithPolicy(b, DefaultRsaKeyPolicy())
+}
+
+// unmarshalRsaPrivateKey is the RSA PrivKeyUnmarshaller. It leaves checking
+// the key size to UnmarshalPrivateKeyWithRsaPolicy.
+func unmarshalRsaPrivateKey(b []byte) (PrivKey, error) {
+	return UnmarshalRsaPrivateKeyWithPolicy(b, RsaKeyPolicy{})
+}
+
+// unmarshalRsaPublicKey is the RSA PubKeyUnmarshaller. It leaves checking the
+// key size to UnmarshalPublicKeyWithRsaPolicy.
+func unmarshalRsaPublicKey(b []byte) (PubKey, error) {
+	return UnmarshalRsaPublicKeyWithPolicy(b, RsaKeyPolicy{})
+}
+
+// checkRsaPublicKey checks the size of an RSA key returned by a registered
+// unmarshaller against the policy.
+func checkRsaPublicKey(k PubKey, policy RsaKeyPolicy) error {
+	raw, err := k.Raw()
+	if err != nil {
+		return err
+	}
+	pub, err := x509.ParsePKIXPublicKey(raw)
+	if err != nil {
+		return err
+	}
+	pk, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("not actually an rsa public key")
+	}
+	return policy.Check(pk.N.BitLen())
+}
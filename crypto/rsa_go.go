@@ -25,11 +25,7 @@ type RsaPublicKey struct {
 	k rsa.PublicKey
 }
 
-// GenerateRSAKeyPair generates a new rsa private and public key
-func GenerateRSAKeyPair(bits int, src io.Reader) (PrivKey, PubKey, error) {
-	if bits < MinRsaKeyBits {
-		return nil, nil, ErrRsaKeyTooSmall
-	}
+func generateRSAKeyPair(bits int, src io.Reader) (PrivKey, PubKey, error) {
 	priv, err := rsa.GenerateKey(src, bits)
 	if err != nil {
 		return nil, nil, err
@@ -96,20 +92,22 @@ func (sk *RsaPrivateKey) Equals(k Key) bool {
 	return KeyEqual(sk, k)
 }
 
-// UnmarshalRsaPrivateKey returns a private key from the input x509 bytes
-func UnmarshalRsaPrivateKey(b []byte) (PrivKey, error) {
+// UnmarshalRsaPrivateKeyWithPolicy returns a private key from the input x509
+// bytes, checking its size against the given policy.
+func UnmarshalRsaPrivateKeyWithPolicy(b []byte, policy RsaKeyPolicy) (PrivKey, error) {
 	sk, err := x509.ParsePKCS1PrivateKey(b)
 	if err != nil {
 		return nil, err
 	}
-	if sk.N.BitLen() < MinRsaKeyBits {
-		return nil, ErrRsaKeyTooSmall
+	if err := policy.Check(sk.N.BitLen()); err != nil {
+		return nil, err
 	}
 	return &RsaPrivateKey{sk: *sk}, nil
 }
 
-// UnmarshalRsaPublicKey returns a public key from the input x509 bytes
-func UnmarshalRsaPublicKey(b []byte) (PubKey, error) {
+// UnmarshalRsaPublicKeyWithPolicy returns a public key from the input x509
+// bytes, checking its size against the given policy.
+func UnmarshalRsaPublicKeyWithPolicy(b []byte, policy RsaKeyPolicy) (PubKey, error) {
 	pub, err := x509.ParsePKIXPublicKey(b)
 	if err != nil {
 		return nil, err
@@ -118,8 +116,8 @@ func UnmarshalRsaPublicKey(b []byte) (PubKey, error) {
 	if !ok {
 		return nil, errors.New("not actually an rsa public key")
 	}
-	if pk.N.BitLen() < MinRsaKeyBits {
-		return nil, ErrRsaKeyTooSmall
+	if err := policy.Check(pk.N.BitLen()); err != nil {
+		return nil, err
 	}
 	return &RsaPublicKey{*pk}, nil
 }
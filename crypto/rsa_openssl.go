@@ -3,6 +3,8 @@
 package crypto
 
 import (
+	"crypto/rsa"
+	"crypto/x509"
 	"errors"
 	"io"
 
@@ -19,12 +21,7 @@ type RsaPublicKey struct {
 	opensslPublicKey
 }
 
-// GenerateRSAKeyPair generates a new rsa private and public key
-func GenerateRSAKeyPair(bits int, _ io.Reader) (PrivKey, PubKey, error) {
-	if bits < MinRsaKeyBits {
-		return nil, nil, ErrRsaKeyTooSmall
-	}
-
+func generateRSAKeyPair(bits int, _ io.Reader) (PrivKey, PubKey, error) {
 	key, err := openssl.GenerateRSAKey(bits)
 	if err != nil {
 		return nil, nil, err
@@ -37,8 +34,26 @@ func (sk *RsaPrivateKey) GetPublic() PubKey {
 	return &RsaPublicKey{opensslPublicKey{sk.opensslPrivateKey.key}}
 }
 
-// UnmarshalRsaPrivateKey returns a private key from the input x509 bytes
-func UnmarshalRsaPrivateKey(b []byte) (PrivKey, error) {
+// rsaKeyBits returns the size of the RSA key.
+func rsaKeyBits(key openssl.PublicKey) (int, error) {
+	der, err := key.MarshalPKIXPublicKeyDER()
+	if err != nil {
+		return 0, err
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return 0, err
+	}
+	pk, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return 0, errors.New("not actually an rsa public key")
+	}
+	return pk.N.BitLen(), nil
+}
+
+// UnmarshalRsaPrivateKeyWithPolicy returns a private key from the input x509
+// bytes, checking its size against the given policy.
+func UnmarshalRsaPrivateKeyWithPolicy(b []byte, policy RsaKeyPolicy) (PrivKey, error) {
 	key, err := unmarshalOpensslPrivateKey(b)
 	if err != nil {
 		return nil, err
@@ -46,11 +61,19 @@ func UnmarshalRsaPrivateKey(b []byte) (PrivKey, error) {
 	if key.Type() != RSA {
 		return nil, errors.New("not actually an rsa public key")
 	}
+	bits, err := rsaKeyBits(key.key)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.Check(bits); err != nil {
+		return nil, err
+	}
 	return &RsaPrivateKey{key}, nil
 }
 
-// UnmarshalRsaPublicKey returns a public key from the input x509 bytes
-func UnmarshalRsaPublicKey(b []byte) (PubKey, error) {
+// UnmarshalRsaPublicKeyWithPolicy returns a public key from the input x509
+// bytes, checking its size against the given policy.
+func UnmarshalRsaPublicKeyWithPolicy(b []byte, policy RsaKeyPolicy) (PubKey, error) {
 	key, err := unmarshalOpensslPublicKey(b)
 	if err != nil {
 		return nil, err
@@ -58,5 +81,12 @@ func UnmarshalRsaPublicKey(b []byte) (PubKey, error) {
 	if key.Type() != RSA {
 		return nil, errors.New("not actually an rsa public key")
 	}
+	bits, err := rsaKeyBits(key.key)
+	if err != nil {
+		return nil, err
+	}
+	if err := policy.Check(bits); err != nil {
+		return nil, err
+	}
 	return &RsaPublicKey{key}, nil
 }
@@ -2,7 +2,11 @@ package crypto
 
 import (
 	"crypto/rand"
+	"errors"
+	"strings"
 	"testing"
+
+	pb "github.com/libp2p/go-libp2p-core/crypto/pb"
 )
 
 func TestRSABasicSignAndVerify(t *testing.T) {
@@ -100,3 +104,82 @@ func TestRSAMarshalLoop(t *testing.T) {
 		t.Fatal("keys are not equal")
 	}
 }
+
+func TestRSAKeyPolicy(t *testing.T) {
+	priv, pub, err := GenerateRSAKeyPairWithPolicy(512, rand.Reader, RsaKeyPolicy{MinBits: 512})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := GenerateRSAKeyPairWithPolicy(512, rand.Reader, RsaKeyPolicy{MinBits: 1024}); !errors.Is(err, ErrRsaKeyTooSmall) {
+		t.Fatal("should have refused to create a key below the policy minimum")
+	}
+
+	privB, err := MarshalPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubB, err := MarshalPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	strict := RsaKeyPolicy{MinBits: 1024}
+	if _, err := UnmarshalPrivateKeyWithRsaPolicy(privB, strict); !errors.Is(err, ErrRsaKeyTooSmall) {
+		t.Fatalf("expected ErrRsaKeyTooSmall, got %v", err)
+	}
+	if _, err := UnmarshalPublicKeyWithRsaPolicy(pubB, strict); !errors.Is(err, ErrRsaKeyTooSmall) {
+		t.Fatalf("expected ErrRsaKeyTooSmall, got %v", err)
+	} else if !strings.Contains(err.Error(), "1024") {
+		t.Fatalf("expected the error to give the policy's minimum, got %q", err)
+	}
+
+	capped := RsaKeyPolicy{MinBits: 256, MaxBits: 384}
+	if _, err := UnmarshalPublicKeyWithRsaPolicy(pubB, capped); !errors.Is(err, ErrRsaKeyTooLarge) {
+		t.Fatalf("expected ErrRsaKeyTooLarge, got %v", err)
+	} else if !strings.Contains(err.Error(), "384") {
+		t.Fatalf("expected the error to give the policy's maximum, got %q", err)
+	}
+
+	lax := RsaKeyPolicy{MinBits: 512}
+	pubNew, err := UnmarshalPublicKeyWithRsaPolicy(pubB, lax)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equals(pubNew) {
+		t.Fatal("keys are not equal")
+	}
+	if _, err := UnmarshalPrivateKeyWithRsaPolicy(privB, lax); err != nil {
+		t.Fatal(err)
+	}
+
+	// Registered RSA unmarshallers are used, and the policy applied to
+	// the keys they return.
+	defer func(um PubKeyUnmarshaller) { PubKeyUnmarshallers[pb.KeyType_RSA] = um }(PubKeyUnmarshallers[pb.KeyType_RSA])
+	var called int
+	PubKeyUnmarshallers[pb.KeyType_RSA] = func(data []byte) (PubKey, error) {
+		called++
+		return UnmarshalRsaPublicKeyWithPolicy(data, RsaKeyPolicy{})
+	}
+	if _, err := UnmarshalPublicKeyWithRsaPolicy(pubB, lax); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnmarshalPublicKeyWithRsaPolicy(pubB, strict); !errors.Is(err, ErrRsaKeyTooSmall) {
+		t.Fatalf("expected ErrRsaKeyTooSmall, got %v", err)
+	}
+	if called != 2 {
+		t.Fatalf("expected the registered unmarshaller to be called twice, got %d", called)
+	}
+
+	// Other key types are unaffected by the policy.
+	_, edPub, err := GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPubB, err := MarshalPublicKey(edPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnmarshalPublicKeyWithRsaPolicy(edPubB, strict); err != nil {
+		t.Fatal(err)
+	}
+}
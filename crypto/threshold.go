@@ -0,0 +1,104 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNotEnoughShares is returned when combining fewer valid partial
+	// signatures than the threshold.
+	ErrNotEnoughShares = errors.New("not enough signature shares")
+
+	// ErrInvalidShare is returned when a partial signature doesn't verify
+	// against its share's verification key.
+	ErrInvalidShare = errors.New("invalid signature share")
+)
+
+// PartialSignature is a signature produced by a single key share.
+type PartialSignature struct {
+	// Index identifies the share, from 1 to the number of shares.
+	Index int
+	Data  []byte
+}
+
+// KeyShare is a custodian's share of a threshold private key: any Threshold
+// of the key's shares can jointly sign for the key, while fewer can't. This
+// lets a node's identity be controlled by a quorum of custodians.
+//
+// Core only defines the contracts; implementations of threshold schemes
+// (e.g. FROST for Ed25519, or threshold BLS) plug in.
+type KeyShare interface {
+	// Index returns the index of the share, from 1 to the number of
+	// shares.
+	Index() int
+
+	// PublicKey returns the public key of the group, which the combined
+	// signatures verify against, and the peer ID is derived from.
+	PublicKey() PubKey
+
+	// PartialSign signs the data with the share. Interactive schemes may
+	// require a prior round with the other custodians.
+	PartialSign(data []byte) (*PartialSignature, error)
+}
+
+// ThresholdScheme combines partial signatures into signatures of the group's
+// public key.
+type ThresholdScheme interface {
+	// Threshold returns the number of shares needed to sign, and the total
+	// number of shares.
+	Threshold() (t, n int)
+
+	// VerifyShare checks the partial signature of the data, returning
+	// ErrInvalidShare if it doesn't verify, so that misbehaving custodians
+	// can be identified.
+	VerifyShare(data []byte, share *PartialSignature) error
+
+	// Combine combines Threshold partial signatures of the data, with
+	// distinct indices, into a signature of the group's public key.
+	Combine(data []byte, shares []*PartialSignature) ([]byte, error)
+}
+
+// CombineShares verifies the partial signatures, discarding the invalid and
+// duplicate ones, and combines the first Threshold valid ones into a signature
+// of pub, which is verified before being returned. It returns
+// ErrNotEnoughShares, listing the errors of the invalid shares, if there
+// aren't enough valid shares.
+func CombineShares(scheme ThresholdScheme, pub PubKey, data []byte, shares []*PartialSignature) ([]byte, error) {
+	t, _ := scheme.Threshold()
+	var (
+		valid []*PartialSignature
+		seen  = make(map[int]bool, len(shares))
+		errs  []error
+	)
+	for _, s := range shares {
+		if len(valid) == t {
+			break
+		}
+		if s == nil || seen[s.Index] {
+			continue
+		}
+		if err := scheme.VerifyShare(data, s); err != nil {
+			errs = append(errs, fmt.Errorf("share %d: %w", s.Index, err))
+			continue
+		}
+		seen[s.Index] = true
+		valid = append(valid, s)
+	}
+	if len(valid) < t {
+		return nil, fmt.Errorf("%w: %d valid of %d needed %v", ErrNotEnoughShares, len(valid), t, errs)
+	}
+
+	sig, err := scheme.Combine(data, valid)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := pub.Verify(data, sig)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrSignatureFault
+	}
+	return sig, nil
+}
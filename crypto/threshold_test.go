@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+// trivialScheme is an insecure 2-of-3 scheme for testing: every share signs
+// with the group key, and partial signatures are the full signature.
+type trivialScheme struct {
+	sk PrivKey
+}
+
+func (trivialScheme) Threshold() (int, int) { return 2, 3 }
+
+func (s trivialScheme) VerifyShare(data []byte, share *PartialSignature) error {
+	if ok, _ := s.sk.GetPublic().Verify(data, share.Data); !ok {
+		return ErrInvalidShare
+	}
+	return nil
+}
+
+func (trivialScheme) Combine(_ []byte, shares []*PartialSignature) ([]byte, error) {
+	return shares[0].Data, nil
+}
+
+func TestCombineShares(t *testing.T) {
+	sk, pk, err := GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheme := trivialScheme{sk}
+	data := []byte("hello")
+	sig, err := sk.Sign(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	good := func(i int) *PartialSignature { return &PartialSignature{Index: i, Data: sig} }
+	bad := &PartialSignature{Index: 3, Data: []byte("garbage")}
+
+	combined, err := CombineShares(scheme, pk, data, []*PartialSignature{bad, good(1), good(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(combined, sig) {
+		t.Fatal("unexpected combined signature")
+	}
+
+	_, err = CombineShares(scheme, pk, data, []*PartialSignature{good(1), good(1), bad})
+	if !errors.Is(err, ErrNotEnoughShares) {
+		t.Fatalf("expected ErrNotEnoughShares, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeyUsage is a set of purposes a private key may sign for.
+type KeyUsage uint8
+
+const (
+	// UsageSigning allows signing arbitrary payloads, with Sign.
+	UsageSigning KeyUsage = 1 << iota
+	// UsageHandshake allows signing during secure channel handshakes, which
+	// security transports do with sec.SignHandshake.
+	UsageHandshake
+	// UsageRecordSigning allows signing records, e.g. sealing envelopes.
+	UsageRecordSigning
+
+	// UsageAny allows all purposes, like an unrestricted key.
+	UsageAny = UsageSigning | UsageHandshake | UsageRecordSigning
+)
+
+// ErrKeyUsage is returned when a key restricted with RestrictKeyUsage is used
+// to sign for a purpose it isn't allowed.
+var ErrKeyUsage = errors.New("key usage not allowed")
+
+func (u KeyUsage) String() string {
+	var names []string
+	if u&UsageSigning != 0 {
+		names = append(names, "signing")
+	}
+	if u&UsageHandshake != 0 {
+		names = append(names, "handshake")
+	}
+	if u&UsageRecordSigning != 0 {
+		names = append(names, "record-signing")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// usageKey is a private key restricted to a set of usages.
+type usageKey struct {
+	PrivKey
+	usage KeyUsage
+}
+
+// RestrictKeyUsage wraps the private key so it only signs for the allowed
+// usages: Sign requires UsageSigning, and the other usages are checked by
+// SignWithUsage, which handshakes and record sealing go through. This turns,
+// say, a handshake-only identity key accidentally reused for bulk payload
+// signing into an error.
+//
+// The restriction is a property of the wrapper: it isn't serialized by
+// MarshalPrivateKey.
+func RestrictKeyUsage(k PrivKey, allowed KeyUsage) PrivKey {
+	if uk, ok := k.(*usageKey); ok {
+		// Restrictions only narrow.
+		return &usageKey{PrivKey: uk.PrivKey, usage: uk.usage & allowed}
+	}
+	return &usageKey{PrivKey: k, usage: allowed}
+}
+
+// GetKeyUsage returns the usages the key may sign for: UsageAny unless it was
+// restricted with RestrictKeyUsage.
+func GetKeyUsage(k PrivKey) KeyUsage {
+	usage := UsageAny
+	for k != nil {
+		if uk, ok := k.(*usageKey); ok {
+			usage &= uk.usage
+		}
+		w, ok := k.(keyWrapper)
+		if !ok {
+			break
+		}
+		k = w.unwrap()
+	}
+	return usage
+}
+
+// keyWrapper is implemented by the private key wrappers of this package.
+type keyWrapper interface {
+	unwrap() PrivKey
+	signWithUsage(usage KeyUsage, data []byte) ([]byte, error)
+}
+
+// SignWithUsage signs the data with the key for the given usage, returning
+// ErrKeyUsage if the key is restricted to other usages.
+func SignWithUsage(k PrivKey, usage KeyUsage, data []byte) ([]byte, error) {
+	if w, ok := k.(keyWrapper); ok {
+		return w.signWithUsage(usage, data)
+	}
+	return k.Sign(data)
+}
+
+func (k *usageKey) unwrap() PrivKey { return k.PrivKey }
+
+func (k *usageKey) signWithUsage(usage KeyUsage, data []byte) ([]byte, error) {
+	if err := k.check(usage); err != nil {
+		return nil, err
+	}
+	return SignWithUsage(k.PrivKey, usage, data)
+}
+
+func (k *usageKey) check(usage KeyUsage) error {
+	if usage == 0 || k.usage&usage != usage {
+		return fmt.Errorf("%w: %s key used for %s", ErrKeyUsage, k.usage, usage)
+	}
+	return nil
+}
+
+// Sign signs arbitrary data, which requires UsageSigning.
+func (k *usageKey) Sign(data []byte) ([]byte, error) {
+	return SignWithUsage(k, UsageSigning, data)
+}
+
+// Equals compares the wrapped keys, ignoring usage restrictions.
+func (k *usageKey) Equals(o Key) bool {
+	return k.PrivKey.Equals(unwrapKey(o))
+}
+
+// unwrapKey returns the key wrapped by the key wrappers of this package.
+func unwrapKey(k Key) Key {
+	for {
+		w, ok := k.(keyWrapper)
+		if !ok {
+			return k
+		}
+		k = w.unwrap()
+	}
+}
@@ -0,0 +1,49 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestKeyUsage(t *testing.T) {
+	sk, pk, err := GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello")
+
+	handshake := RestrictKeyUsage(sk, UsageHandshake)
+	if _, err := handshake.Sign(data); !errors.Is(err, ErrKeyUsage) {
+		t.Fatalf("expected ErrKeyUsage, got %v", err)
+	}
+	if _, err := SignWithUsage(handshake, UsageRecordSigning, data); !errors.Is(err, ErrKeyUsage) {
+		t.Fatalf("expected ErrKeyUsage, got %v", err)
+	}
+	sig, err := SignWithUsage(handshake, UsageHandshake, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := pk.Verify(data, sig); err != nil || !ok {
+		t.Fatalf("signature doesn't verify: %v", err)
+	}
+
+	if !handshake.Equals(sk) || !sk.Equals(handshake.(*usageKey).PrivKey) {
+		t.Fatal("expected restricted key to equal the original")
+	}
+	if !handshake.GetPublic().Equals(pk) {
+		t.Fatal("expected the same public key")
+	}
+
+	// Restrictions only narrow.
+	widened := RestrictKeyUsage(handshake, UsageAny)
+	if GetKeyUsage(widened) != UsageHandshake {
+		t.Fatalf("expected usage to stay %s, got %s", UsageHandshake, GetKeyUsage(widened))
+	}
+	if GetKeyUsage(sk) != UsageAny {
+		t.Fatal("expected unrestricted key to allow any usage")
+	}
+	if _, err := SignWithUsage(sk, UsageRecordSigning, data); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,106 @@
+package crypto
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+)
+
+// DefaultVerificationCacheSize is the size of verification caches created
+// with a non-positive size.
+const DefaultVerificationCacheSize = 4096
+
+// VerificationCache remembers the outcome of recent signature verifications,
+// keyed by (key, message hash, signature), so that routers verifying the same
+// records over and over (e.g. from gossip) only pay for the first
+// verification. It holds a bounded number of entries, evicting the least
+// recently used ones.
+//
+// A VerificationCache is safe for concurrent use.
+type VerificationCache struct {
+	size int
+
+	lk      sync.Mutex
+	entries map[[sha256.Size]byte]*list.Element
+	lru     *list.List
+}
+
+type verificationEntry struct {
+	key   [sha256.Size]byte
+	valid bool
+}
+
+// NewVerificationCache creates a cache holding up to size verifications.
+func NewVerificationCache(size int) *VerificationCache {
+	if size <= 0 {
+		size = DefaultVerificationCacheSize
+	}
+	return &VerificationCache{
+		size:    size,
+		entries: make(map[[sha256.Size]byte]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func verificationKey(k PubKey, data, sig []byte) ([sha256.Size]byte, error) {
+	kb, err := k.Bytes()
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	msg := sha256.Sum256(data)
+
+	h := sha256.New()
+	var lbuf [binary.MaxVarintLen64]byte
+	for _, f := range [][]byte{kb, msg[:], sig} {
+		n := binary.PutUvarint(lbuf[:], uint64(len(f)))
+		h.Write(lbuf[:n])
+		h.Write(f)
+	}
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	return key, nil
+}
+
+// Verify returns the result of k.Verify(data, sig), from the cache if the
+// same verification was done recently. Verifications failing with an error
+// aren't cached.
+func (c *VerificationCache) Verify(k PubKey, data, sig []byte) (bool, error) {
+	key, err := verificationKey(k, data, sig)
+	if err != nil {
+		return false, err
+	}
+
+	c.lk.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		valid := e.Value.(*verificationEntry).valid
+		c.lk.Unlock()
+		return valid, nil
+	}
+	c.lk.Unlock()
+
+	valid, err := k.Verify(data, sig)
+	if err != nil {
+		return valid, err
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.entries[key] = c.lru.PushFront(&verificationEntry{key: key, valid: valid})
+		if c.lru.Len() > c.size {
+			oldest := c.lru.Back()
+			c.lru.Remove(oldest)
+			delete(c.entries, oldest.Value.(*verificationEntry).key)
+		}
+	}
+	return valid, nil
+}
+
+// Len returns the number of cached verifications.
+func (c *VerificationCache) Len() int {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	return c.lru.Len()
+}
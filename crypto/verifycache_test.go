@@ -0,0 +1,62 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+type countingPubKey struct {
+	PubKey
+	verifications int
+}
+
+func (k *countingPubKey) Verify(data, sig []byte) (bool, error) {
+	k.verifications++
+	return k.PubKey.Verify(data, sig)
+}
+
+func TestVerificationCache(t *testing.T) {
+	sk, pk, err := GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := &countingPubKey{PubKey: pk}
+	c := NewVerificationCache(2)
+
+	msgs := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+	sigs := make([][]byte, len(msgs))
+	for i, m := range msgs {
+		if sigs[i], err = sk.Sign(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if ok, err := c.Verify(k, msgs[0], sigs[0]); err != nil || !ok {
+			t.Fatalf("expected valid signature, got %v, %v", ok, err)
+		}
+	}
+	if k.verifications != 1 {
+		t.Fatalf("expected 1 verification, got %d", k.verifications)
+	}
+
+	if ok, _ := c.Verify(k, msgs[1], sigs[0]); ok {
+		t.Fatal("expected invalid signature")
+	}
+	if ok, _ := c.Verify(k, msgs[1], sigs[0]); ok {
+		t.Fatal("expected cached invalid signature")
+	}
+	if k.verifications != 2 {
+		t.Fatalf("expected 2 verifications, got %d", k.verifications)
+	}
+
+	// Evicts the least recently used entry, msgs[0].
+	c.Verify(k, msgs[2], sigs[2])
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", c.Len())
+	}
+	c.Verify(k, msgs[0], sigs[0])
+	if k.verifications != 4 {
+		t.Fatalf("expected evicted entry to be verified again, got %d verifications", k.verifications)
+	}
+}
@@ -0,0 +1,86 @@
+package crypto
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSignatureFault is returned by keys wrapped with VerifySignatures when a
+// signature they produced doesn't verify.
+var ErrSignatureFault = errors.New("produced signature doesn't verify")
+
+// verifyingKey is a private key verifying its signatures.
+type verifyingKey struct {
+	PrivKey
+}
+
+// VerifySignatures wraps the private key so every signature it produces is
+// verified with the public key before being returned (sign-then-verify).
+// This catches faulty hardware or miscompiled builds, which could otherwise
+// publish invalid signatures or, for some schemes, signatures leaking the
+// private key, at the cost of a verification per signature.
+//
+// Usage restrictions (see RestrictKeyUsage) of the wrapped key still apply.
+func VerifySignatures(k PrivKey) PrivKey {
+	if _, ok := k.(*verifyingKey); ok {
+		return k
+	}
+	return &verifyingKey{PrivKey: k}
+}
+
+// VerifiesSignatures returns true if the key was wrapped with
+// VerifySignatures.
+func VerifiesSignatures(k PrivKey) bool {
+	for k != nil {
+		if _, ok := k.(*verifyingKey); ok {
+			return true
+		}
+		w, ok := k.(keyWrapper)
+		if !ok {
+			break
+		}
+		k = w.unwrap()
+	}
+	return false
+}
+
+func (k *verifyingKey) unwrap() PrivKey { return k.PrivKey }
+
+func (k *verifyingKey) signWithUsage(usage KeyUsage, data []byte) ([]byte, error) {
+	sig, err := SignWithUsage(k.PrivKey, usage, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.verify(data, sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+func (k *verifyingKey) verify(data, sig []byte) error {
+	ok, err := k.GetPublic().Verify(data, sig)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrSignatureFault, err)
+	}
+	if !ok {
+		return ErrSignatureFault
+	}
+	return nil
+}
+
+// Sign signs the data and verifies the signature.
+func (k *verifyingKey) Sign(data []byte) ([]byte, error) {
+	sig, err := k.PrivKey.Sign(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.verify(data, sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// Equals compares the wrapped keys.
+func (k *verifyingKey) Equals(o Key) bool {
+	return k.PrivKey.Equals(unwrapKey(o))
+}
@@ -0,0 +1,63 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+// faultyKey produces corrupted signatures.
+type faultyKey struct {
+	PrivKey
+}
+
+func (k faultyKey) Sign(data []byte) ([]byte, error) {
+	sig, err := k.PrivKey.Sign(data)
+	if err != nil {
+		return nil, err
+	}
+	sig[0] ^= 1
+	return sig, nil
+}
+
+func TestVerifySignatures(t *testing.T) {
+	sk, _, err := GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello")
+
+	good := VerifySignatures(sk)
+	if !VerifiesSignatures(good) || VerifiesSignatures(sk) {
+		t.Fatal("unexpected VerifiesSignatures result")
+	}
+	if _, err := good.Sign(data); err != nil {
+		t.Fatal(err)
+	}
+	if !good.Equals(sk) {
+		t.Fatal("expected the wrapped key to equal the original")
+	}
+
+	faulty := VerifySignatures(faultyKey{sk})
+	if sig, err := faulty.Sign(data); !errors.Is(err, ErrSignatureFault) || sig != nil {
+		t.Fatalf("expected ErrSignatureFault, got %v", err)
+	}
+	if _, err := SignWithUsage(faulty, UsageRecordSigning, data); !errors.Is(err, ErrSignatureFault) {
+		t.Fatalf("expected ErrSignatureFault, got %v", err)
+	}
+
+	// Usage restrictions and verification compose.
+	restricted := VerifySignatures(RestrictKeyUsage(sk, UsageHandshake))
+	if !VerifiesSignatures(RestrictKeyUsage(restricted, UsageAny)) {
+		t.Fatal("expected the restricted key to still verify signatures")
+	}
+	if GetKeyUsage(restricted) != UsageHandshake {
+		t.Fatalf("unexpected usage %s", GetKeyUsage(restricted))
+	}
+	if _, err := restricted.Sign(data); !errors.Is(err, ErrKeyUsage) {
+		t.Fatalf("expected ErrKeyUsage, got %v", err)
+	}
+	if _, err := SignWithUsage(restricted, UsageHandshake, data); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,262 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	pb "github.com/libp2p/go-libp2p-core/crypto/pb"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/ed25519"
+)
+
+// X25519KeySize is the size of both X25519 private and public keys.
+const X25519KeySize = 32
+
+// ErrNotSigningKey is returned when trying to sign or verify with a key that
+// can only be used for key agreement, such as an X25519 key.
+var ErrNotSigningKey = errors.New("key type can't be used for signing")
+
+// X25519PrivateKey is an X25519 (curve25519 Diffie-Hellman) private key.
+//
+// X25519 keys are encryption-only: they can be used for key agreement (e.g. as
+// an HPKE receiver) but Sign always fails with ErrNotSigningKey. They can't be
+// used as peer identities: UnmarshalPrivateKey and UnmarshalPublicKey reject
+// them, and they're loaded from their raw bytes with UnmarshalX25519PrivateKey
+// and UnmarshalX25519PublicKey instead.
+type X25519PrivateKey struct {
+	k [X25519KeySize]byte
+}
+
+// X25519PublicKey is an X25519 public key. Verify always fails with
+// ErrNotSigningKey.
+type X25519PublicKey struct {
+	k [X25519KeySize]byte
+}
+
+// GenerateX25519Key generates a new X25519 private and public key pair.
+func GenerateX25519Key(src io.Reader) (PrivKey, PubKey, error) {
+	var priv X25519PrivateKey
+	if _, err := io.ReadFull(src, priv.k[:]); err != nil {
+		return nil, nil, err
+	}
+	return &priv, priv.GetPublic(), nil
+}
+
+// Type of the private key (X25519).
+func (k *X25519PrivateKey) Type() pb.KeyType {
+	return pb.KeyType_X25519
+}
+
+// Bytes marshals an X25519 private key to protobuf bytes.
+func (k *X25519PrivateKey) Bytes() ([]byte, error) {
+	return MarshalPrivateKey(k)
+}
+
+// Raw private key bytes.
+func (k *X25519PrivateKey) Raw() ([]byte, error) {
+	buf := make([]byte, X25519KeySize)
+	copy(buf, k.k[:])
+	return buf, nil
+}
+
+// Equals compares two X25519 private keys.
+func (k *X25519PrivateKey) Equals(o Key) bool {
+	xk, ok := o.(*X25519PrivateKey)
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(k.k[:], xk.k[:]) == 1
+}
+
+// GetPublic returns the X25519 public key matching the private key.
+func (k *X25519PrivateKey) GetPublic() PubKey {
+	pub := new(X25519PublicKey)
+	curve25519.ScalarBaseMult(&pub.k, &k.k)
+	return pub
+}
+
+// Sign always fails, X25519 keys can't sign.
+func (k *X25519PrivateKey) Sign([]byte) ([]byte, error) {
+	return nil, ErrNotSigningKey
+}
+
+// SharedSecret computes the X25519 shared secret between this key and the
+// given public key. It fails if the result is the all-zero value, which
+// happens when the public key is a low-order point.
+func (k *X25519PrivateKey) SharedSecret(pub *X25519PublicKey) ([]byte, error) {
+	var out [X25519KeySize]byte
+	curve25519.ScalarMult(&out, &k.k, &pub.k)
+
+	var zero [X25519KeySize]byte
+	if subtle.ConstantTimeCompare(out[:], zero[:]) == 1 {
+		return nil, errors.New("x25519: low order public key")
+	}
+	return out[:], nil
+}
+
+// Type of the public key (X25519).
+func (k *X25519PublicKey) Type() pb.KeyType {
+	return pb.KeyType_X25519
+}
+
+// Bytes returns an X25519 public key as protobuf bytes.
+func (k *X25519PublicKey) Bytes() ([]byte, error) {
+	return MarshalPublicKey(k)
+}
+
+// Raw public key bytes.
+func (k *X25519PublicKey) Raw() ([]byte, error) {
+	buf := make([]byte, X25519KeySize)
+	copy(buf, k.k[:])
+	return buf, nil
+}
+
+// Equals compares two X25519 public keys.
+func (k *X25519PublicKey) Equals(o Key) bool {
+	xk, ok := o.(*X25519PublicKey)
+	if !ok {
+		return false
+	}
+
+	return bytes.Equal(k.k[:], xk.k[:])
+}
+
+// Verify always fails, X25519 keys can't verify signatures.
+func (k *X25519PublicKey) Verify([]byte, []byte) (bool, error) {
+	return false, ErrNotSigningKey
+}
+
+// UnmarshalX25519PublicKey returns a public key from input bytes.
+func UnmarshalX25519PublicKey(data []byte) (PubKey, error) {
+	if len(data) != X25519KeySize {
+		return nil, fmt.Errorf("expected x25519 public key data size to be %d, got %d", X25519KeySize, len(data))
+	}
+	pub := new(X25519PublicKey)
+	copy(pub.k[:], data)
+	return pub, nil
+}
+
+// UnmarshalX25519PrivateKey returns a private key from input bytes.
+func UnmarshalX25519PrivateKey(data []byte) (PrivKey, error) {
+	if len(data) != X25519KeySize {
+		return nil, fmt.Errorf("expected x25519 private key data size to be %d, got %d", X25519KeySize, len(data))
+	}
+	priv := new(X25519PrivateKey)
+	copy(priv.k[:], data)
+	return priv, nil
+}
+
+var (
+	// curve25519P is the field prime 2^255 - 19.
+	curve25519P = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	// edwards25519D is the d parameter of the edwards25519 curve,
+	// -121665/121666.
+	edwards25519D = func() *big.Int {
+		d := new(big.Int).ModInverse(big.NewInt(121666), curve25519P)
+		d.Mul(d, big.NewInt(-121665))
+		return d.Mod(d, curve25519P)
+	}()
+	// x25519LowOrder are the u coordinates of the points of small order,
+	// whose shared secrets are predictable.
+	x25519LowOrder = func() []*big.Int {
+		var out []*big.Int
+		for _, s := range []string{
+			"0",
+			"1",
+			"325606250916557431795983626356110631294008115727848805560023387167927233504",
+			"39382357235489614581723060781553021112529911719440698176882885853963445705823",
+		} {
+			u, _ := new(big.Int).SetString(s, 10)
+			out = append(out, u)
+		}
+		return append(out, new(big.Int).Sub(curve25519P, big.NewInt(1)))
+	}()
+)
+
+// ErrInvalidEd25519Point is returned when converting an Ed25519 public key
+// that isn't a valid curve point, or is a point of small order.
+var ErrInvalidEd25519Point = errors.New("invalid ed25519 public key point")
+
+// X25519FromEd25519PrivateKey converts an Ed25519 private key to the X25519
+// private key sharing the same secret scalar, as described in RFC 8032 and
+// RFC 7748.
+//
+// Reusing a key for both signing and key agreement is discouraged; this is
+// meant for interoperating with systems that derive encryption keys from
+// Ed25519 identities.
+func X25519FromEd25519PrivateKey(k *Ed25519PrivateKey) *X25519PrivateKey {
+	h := sha512.Sum512(k.k[:ed25519.PrivateKeySize-ed25519.PublicKeySize])
+	priv := new(X25519PrivateKey)
+	copy(priv.k[:], h[:X25519KeySize])
+	// Clamping is also applied by curve25519, but storing the clamped scalar
+	// keeps the raw key canonical.
+	priv.k[0] &= 248
+	priv.k[31] &= 127
+	priv.k[31] |= 64
+	return priv
+}
+
+// X25519FromEd25519PublicKey converts an Ed25519 public key to the X25519
+// public key of the birationally equivalent Montgomery curve point. It
+// returns ErrInvalidEd25519Point if the key isn't on the curve or is a point
+// of small order.
+func X25519FromEd25519PublicKey(k *Ed25519PublicKey) (*X25519PublicKey, error) {
+	if len(k.k) != ed25519.PublicKeySize {
+		return nil, ErrInvalidEd25519Point
+	}
+
+	// The key is the little-endian y coordinate, with the sign of x in the
+	// top bit.
+	be := make([]byte, ed25519.PublicKeySize)
+	for i, b := range k.k {
+		be[len(be)-1-i] = b
+	}
+	be[0] &= 0x7f
+	y := new(big.Int).SetBytes(be)
+	if y.Cmp(curve25519P) >= 0 {
+		return nil, ErrInvalidEd25519Point
+	}
+
+	// The point is on the curve if x^2 = (y^2 - 1) / (d y^2 + 1) has a
+	// solution, i.e. is a square.
+	y2 := new(big.Int).Mul(y, y)
+	x2 := new(big.Int).Sub(y2, big.NewInt(1))
+	v := new(big.Int).Mul(edwards25519D, y2)
+	v.Add(v, big.NewInt(1)).Mod(v, curve25519P)
+	x2.Mul(x2, v.ModInverse(v, curve25519P)).Mod(x2, curve25519P)
+	legendre := new(big.Int).Exp(x2, new(big.Int).Rsh(curve25519P, 1), curve25519P)
+	if legendre.Sign() != 0 && legendre.Cmp(big.NewInt(1)) != 0 {
+		return nil, ErrInvalidEd25519Point
+	}
+
+	// u = (1 + y) / (1 - y)
+	one := big.NewInt(1)
+	num := new(big.Int).Add(one, y)
+	den := new(big.Int).Sub(one, y)
+	den.Mod(den, curve25519P)
+	if den.Sign() == 0 {
+		// The identity point.
+		return nil, ErrInvalidEd25519Point
+	}
+	u := num.Mul(num, den.ModInverse(den, curve25519P))
+	u.Mod(u, curve25519P)
+	for _, low := range x25519LowOrder {
+		if u.Cmp(low) == 0 {
+			return nil, ErrInvalidEd25519Point
+		}
+	}
+
+	pub := new(X25519PublicKey)
+	ub := u.Bytes()
+	for i, b := range ub {
+		pub.k[len(ub)-1-i] = b
+	}
+	return pub, nil
+}
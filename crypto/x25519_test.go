@@ -0,0 +1,143 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func TestX25519SharedSecret(t *testing.T) {
+	privA, pubA, err := GenerateX25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privB, pubB, err := GenerateX25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s1, err := privA.(*X25519PrivateKey).SharedSecret(pubB.(*X25519PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := privB.(*X25519PrivateKey).SharedSecret(pubA.(*X25519PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(s1, s2) {
+		t.Fatal("shared secrets don't match")
+	}
+
+	if _, err := privA.(*X25519PrivateKey).SharedSecret(new(X25519PublicKey)); err == nil {
+		t.Fatal("expected low order public key to be rejected")
+	}
+}
+
+func TestX25519NotSigning(t *testing.T) {
+	priv, pub, err := GenerateKeyPair(X25519, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := priv.Sign([]byte("data")); err != ErrNotSigningKey {
+		t.Fatalf("expected ErrNotSigningKey, got %v", err)
+	}
+	if ok, err := pub.Verify([]byte("data"), make([]byte, 64)); ok || err != ErrNotSigningKey {
+		t.Fatalf("expected ErrNotSigningKey, got %v", err)
+	}
+}
+
+func TestX25519MarshalLoop(t *testing.T) {
+	priv, pub, err := GenerateX25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privB, err := priv.Raw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv2, err := UnmarshalX25519PrivateKey(privB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !priv.Equals(priv2) {
+		t.Fatal("private key didn't survive the marshal loop")
+	}
+
+	pubB, err := pub.Raw()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub2, err := UnmarshalX25519PublicKey(pubB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equals(pub2) {
+		t.Fatal("public key didn't survive the marshal loop")
+	}
+}
+
+func TestX25519NotIdentity(t *testing.T) {
+	priv, pub, err := GenerateX25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privB, err := MarshalPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnmarshalPrivateKey(privB); err != ErrBadKeyType {
+		t.Fatalf("expected ErrBadKeyType, got %v", err)
+	}
+	pubB, err := MarshalPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := UnmarshalPublicKey(pubB); err != ErrBadKeyType {
+		t.Fatalf("expected ErrBadKeyType, got %v", err)
+	}
+}
+
+func TestX25519FromEd25519(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		priv, pub, err := GenerateEd25519Key(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		xpriv := X25519FromEd25519PrivateKey(priv.(*Ed25519PrivateKey))
+		xpub, err := X25519FromEd25519PublicKey(pub.(*Ed25519PublicKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !xpriv.GetPublic().Equals(xpub) {
+			t.Fatal("converted public key doesn't match the converted private key")
+		}
+	}
+}
+
+func TestX25519FromEd25519Invalid(t *testing.T) {
+	for _, hexKey := range []string{
+		// Identity.
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		// Order 2.
+		"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		// Order 4.
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		// Order 8.
+		"c7176a703d4dd84fba3c0b760d10670f2a2053fa2c39ccc64ec7fd7792ac037a",
+		"26e8958fc2b227b045c3f489f2ef98f0d5dfac05d3c63339b13802886d53fc05",
+		// Not on the curve.
+		"0200000000000000000000000000000000000000000000000000000000000000",
+	} {
+		b, _ := hex.DecodeString(hexKey)
+		pub, err := UnmarshalEd25519PublicKey(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := X25519FromEd25519PublicKey(pub.(*Ed25519PublicKey)); err != ErrInvalidEd25519Point {
+			t.Fatalf("%s: expected ErrInvalidEd25519Point, got %v", hexKey, err)
+		}
+	}
+}
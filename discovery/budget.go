@@ -0,0 +1,97 @@
+package discovery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+)
+
+// AdvertiseBudget limits the number of advertise operations per interval,
+// across all namespaces, so applications with many topics don't flood
+// rendezvous servers or the DHT. It's a sliding window: an operation is
+// allowed if fewer than limit operations were allowed during the last
+// interval.
+//
+// An AdvertiseBudget is safe for concurrent use, and can be shared by several
+// advertisers to enforce a budget for the whole application.
+type AdvertiseBudget struct {
+	limit    int
+	interval time.Duration
+	clock    network.Clock
+
+	mu sync.Mutex
+	// ops holds the times of the operations allowed during the last
+	// interval, oldest first.
+	ops []time.Time
+}
+
+// NewAdvertiseBudget creates a budget allowing limit advertise operations per
+// interval.
+func NewAdvertiseBudget(limit int, interval time.Duration) *AdvertiseBudget {
+	if limit < 1 {
+		limit = 1
+	}
+	return &AdvertiseBudget{limit: limit, interval: interval, clock: network.RealClock}
+}
+
+// SetClock sets the clock Wait uses, network.RealClock by default. It must be
+// called before the budget is used.
+func (b *AdvertiseBudget) SetClock(c network.Clock) {
+	b.clock = c
+}
+
+// Reserve takes an operation from the budget if one is available at time now,
+// and returns true. Otherwise, it returns false along with the time the next
+// operation will be available.
+func (b *AdvertiseBudget) Reserve(now time.Time) (bool, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cutoff := now.Add(-b.interval)
+	expired := 0
+	for expired < len(b.ops) && !b.ops[expired].After(cutoff) {
+		expired++
+	}
+	b.ops = b.ops[expired:]
+
+	if len(b.ops) >= b.limit {
+		return false, b.ops[0].Add(b.interval)
+	}
+	b.ops = append(b.ops, now)
+	return true, now
+}
+
+// Wait blocks until an operation is available, and takes it from the budget.
+func (b *AdvertiseBudget) Wait(ctx context.Context) error {
+	for {
+		now := b.clock.Now()
+		ok, next := b.Reserve(now)
+		if ok {
+			return nil
+		}
+		t := b.clock.NewTimer(next.Sub(now))
+		select {
+		case <-t.C():
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// BudgetedAdvertiser wraps an Advertiser so that every advertisement first
+// waits for the budget.
+type BudgetedAdvertiser struct {
+	Advertiser
+	Budget *AdvertiseBudget
+}
+
+// Advertise waits for the budget, then advertises the service.
+func (a *BudgetedAdvertiser) Advertise(ctx context.Context, ns string, opts ...Option) (time.Duration, error) {
+	if err := a.Budget.Wait(ctx); err != nil {
+		return 0, err
+	}
+	return a.Advertiser.Advertise(ctx, ns, opts...)
+}
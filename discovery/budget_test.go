@@ -0,0 +1,69 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+)
+
+type countingAdvertiser struct{ n int }
+
+func (a *countingAdvertiser) Advertise(context.Context, string, ...Option) (time.Duration, error) {
+	a.n++
+	return time.Hour, nil
+}
+
+func TestAdvertiseBudget(t *testing.T) {
+	b := NewAdvertiseBudget(2, time.Minute)
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		if ok, _ := b.Reserve(now); !ok {
+			t.Fatal("expected the budget to allow the operation")
+		}
+	}
+	ok, next := b.Reserve(now.Add(time.Second))
+	if ok || !next.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected the budget to be exhausted until %s, got %v, %s", now.Add(time.Minute), ok, next)
+	}
+	if ok, _ := b.Reserve(now.Add(time.Minute)); !ok {
+		t.Fatal("expected the budget to be replenished after the interval")
+	}
+
+	adv := &countingAdvertiser{}
+	ba := &BudgetedAdvertiser{Advertiser: adv, Budget: NewAdvertiseBudget(1, time.Hour)}
+	if _, err := ba.Advertise(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := ba.Advertise(ctx, "b"); err != context.DeadlineExceeded {
+		t.Fatalf("expected to wait for the budget, got %v", err)
+	}
+	if adv.n != 1 {
+		t.Fatalf("expected 1 advertisement, got %d", adv.n)
+	}
+
+	clock := network.NewMockClock(time.Now())
+	b = NewAdvertiseBudget(1, time.Minute)
+	b.SetClock(clock)
+	ba.Budget = b
+	if _, err := ba.Advertise(context.Background(), "c"); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := ba.Advertise(context.Background(), "d")
+		done <- err
+	}()
+	select {
+	case <-done:
+		t.Fatal("expected the advertisement to wait for the budget")
+	case <-time.After(20 * time.Millisecond):
+	}
+	clock.Advance(time.Minute)
+	if err := <-done; err != nil || adv.n != 3 {
+		t.Fatalf("expected 3 advertisements, got %d, %v", adv.n, err)
+	}
+}
@@ -0,0 +1,272 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// ErrAllBackendsBackingOff is returned by Composite.FindPeers when every
+// backend is currently backing off after a failure.
+var ErrAllBackendsBackingOff = errors.New("discovery: all backends are backing off")
+
+var (
+	// CompositeMinBackoff is the delay applied to a backend after its first
+	// consecutive failure. It doubles with every further failure.
+	CompositeMinBackoff = time.Second
+
+	// CompositeMaxBackoff is the upper bound on the delay applied to a
+	// failing backend.
+	CompositeMaxBackoff = 5 * time.Minute
+)
+
+// WeightedDiscoverer pairs a Discoverer with the relative share of results it
+// should contribute when its results are interleaved with other backends.
+type WeightedDiscoverer struct {
+	Discoverer Discoverer
+	// Weight is the relative share of this backend. Values below 1 are
+	// treated as 1.
+	Weight int
+}
+
+// Composite is a Discoverer that queries several backends (e.g. mDNS, a DHT
+// and a rendezvous point) at once and merges their results.
+//
+// Results are deduplicated by peer ID and interleaved in proportion to the
+// weight of each backend. A backend whose FindPeers call fails is skipped by
+// subsequent queries until its (exponential) backoff has elapsed.
+type Composite struct {
+	backends []*compositeBackend
+	ranker   PeerRanker
+	window   time.Duration
+	clock    network.Clock
+}
+
+var _ Discoverer = (*Composite)(nil)
+
+type compositeBackend struct {
+	d      Discoverer
+	weight int
+
+	lk          sync.Mutex
+	failures    int
+	nextAttempt time.Time
+}
+
+// NewComposite constructs a Composite discoverer over the given backends.
+func NewComposite(backends ...WeightedDiscoverer) *Composite {
+	c := &Composite{
+		backends: make([]*compositeBackend, 0, len(backends)),
+		clock:    network.RealClock,
+	}
+	for _, b := range backends {
+		w := b.Weight
+		if w < 1 {
+			w = 1
+		}
+		c.backends = append(c.backends, &compositeBackend{d: b.Discoverer, weight: w})
+	}
+	return c
+}
+
+// SetRanker sets a ranker ordering the merged results. With a ranker, results
+// are collected for up to the rank window (see SetRankWindow) and emitted in
+// ranked batches, and the
+// Limit option applies to the ranked results. It must be called before the
+// first FindPeers call.
+func (c *Composite) SetRanker(r PeerRanker) {
+	c.ranker = r
+}
+
+// SetRankWindow sets how long results are collected before being ranked,
+// trading latency for better ranking. Zero means RankWindow. It must be
+// called before the first FindPeers call.
+func (c *Composite) SetRankWindow(d time.Duration) {
+	c.window = d
+}
+
+// SetClock sets the clock backoffs and ranking windows are measured with,
+// network.RealClock by default. It must be called before the first FindPeers
+// call.
+func (c *Composite) SetClock(clock network.Clock) {
+	c.clock = clock
+}
+
+func (b *compositeBackend) ready(now time.Time) bool {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+	return !now.Before(b.nextAttempt)
+}
+
+func (b *compositeBackend) failed(now time.Time) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+	b.failures++
+	delay := CompositeMinBackoff
+	for i := 1; i < b.failures && delay < CompositeMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > CompositeMaxBackoff {
+		delay = CompositeMaxBackoff
+	}
+	b.nextAttempt = now.Add(delay)
+}
+
+func (b *compositeBackend) succeeded() {
+	b.lk.Lock()
+	b.failures = 0
+	b.nextAttempt = time.Time{}
+	b.lk.Unlock()
+}
+
+// FindPeers queries all backends that are not backing off and returns the
+// merged stream of their results. The Limit option, if set, applies to the
+// merged stream; it is also passed through to each backend.
+//
+// An error is only returned if no backend could be queried.
+func (c *Composite) FindPeers(ctx context.Context, ns string, opts ...Option) (<-chan peer.AddrInfo, error) {
+	var options Options
+	if err := options.Apply(opts...); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	var (
+		now     = c.clock.Now()
+		chans   []<-chan peer.AddrInfo
+		weights []int
+		lastErr error
+	)
+	for _, b := range c.backends {
+		if !b.ready(now) {
+			continue
+		}
+		ch, err := b.d.FindPeers(ctx, ns, opts...)
+		if err != nil {
+			b.failed(now)
+			lastErr = err
+			continue
+		}
+		b.succeeded()
+		chans = append(chans, ch)
+		weights = append(weights, b.weight)
+	}
+
+	if len(chans) == 0 {
+		cancel()
+		if lastErr == nil {
+			lastErr = ErrAllBackendsBackingOff
+		}
+		return nil, lastErr
+	}
+
+	out := make(chan peer.AddrInfo, 8)
+	if c.ranker == nil {
+		go mergeWeighted(ctx, cancel, chans, weights, options.Limit, out)
+		return out, nil
+	}
+
+	// The ranker may drop peers, so the limit can only be applied once the
+	// results are ranked. The ranking goroutine owns the cancel function since
+	// the merge finishes before the last batch is ranked and emitted.
+	merged := make(chan peer.AddrInfo, 8)
+	go mergeWeighted(ctx, func() {}, chans, weights, 0, merged)
+	window := c.window
+	if window <= 0 {
+		window = RankWindow
+	}
+	go rankResults(ctx, cancel, c.clock, window, c.ranker, ns, merged, options.Limit, out)
+	return out, nil
+}
+
+type compositeResult struct {
+	idx    int
+	pi     peer.AddrInfo
+	closed bool
+}
+
+// mergeWeighted interleaves the results of the given channels, dropping
+// duplicate peers and favoring backends in proportion to their weights. It
+// closes out and cancels the backend queries when done.
+func mergeWeighted(ctx context.Context, cancel context.CancelFunc, chans []<-chan peer.AddrInfo, weights []int, limit int, out chan<- peer.AddrInfo) {
+	defer close(out)
+	defer cancel()
+
+	in := make(chan compositeResult)
+	for i, ch := range chans {
+		go func(i int, ch <-chan peer.AddrInfo) {
+			for pi := range ch {
+				select {
+				case in <- compositeResult{idx: i, pi: pi}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case in <- compositeResult{idx: i, closed: true}:
+			case <-ctx.Done():
+			}
+		}(i, ch)
+	}
+
+	var (
+		open    = len(chans)
+		pending = 0
+		sent    = 0
+		queues  = make([][]peer.AddrInfo, len(chans))
+		served  = make([]int, len(chans))
+		seen    = make(map[peer.ID]struct{})
+	)
+
+	for open > 0 || pending > 0 {
+		// Pick the non-empty queue that has been served the least relative
+		// to its weight.
+		next := -1
+		for i, q := range queues {
+			if len(q) == 0 {
+				continue
+			}
+			if next < 0 || served[i]*weights[next] < served[next]*weights[i] {
+				next = i
+			}
+		}
+
+		var (
+			sendCh chan<- peer.AddrInfo
+			sendPi peer.AddrInfo
+		)
+		if next >= 0 {
+			sendCh = out
+			sendPi = queues[next][0]
+		}
+
+		select {
+		case r := <-in:
+			if r.closed {
+				open--
+				continue
+			}
+			if _, ok := seen[r.pi.ID]; ok {
+				continue
+			}
+			seen[r.pi.ID] = struct{}{}
+			queues[r.idx] = append(queues[r.idx], r.pi)
+			pending++
+		case sendCh <- sendPi:
+			queues[next] = queues[next][1:]
+			served[next]++
+			pending--
+			sent++
+			if limit > 0 && sent >= limit {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
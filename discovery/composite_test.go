@@ -0,0 +1,102 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+type mockDiscoverer struct {
+	peers []peer.ID
+	err   error
+	calls int
+}
+
+func (m *mockDiscoverer) FindPeers(ctx context.Context, ns string, opts ...Option) (<-chan peer.AddrInfo, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	ch := make(chan peer.AddrInfo, len(m.peers))
+	for _, p := range m.peers {
+		ch <- peer.AddrInfo{ID: p}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func collect(ch <-chan peer.AddrInfo) []peer.ID {
+	var out []peer.ID
+	for pi := range ch {
+		out = append(out, pi.ID)
+	}
+	return out
+}
+
+func TestCompositeDedup(t *testing.T) {
+	a := &mockDiscoverer{peers: []peer.ID{"a", "b", "c"}}
+	b := &mockDiscoverer{peers: []peer.ID{"c", "b", "d"}}
+	c := NewComposite(WeightedDiscoverer{Discoverer: a}, WeightedDiscoverer{Discoverer: b})
+
+	ch, err := c.FindPeers(context.Background(), "ns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := collect(ch)
+	if len(res) != 4 {
+		t.Fatalf("expected 4 unique peers, got %v", res)
+	}
+	seen := make(map[peer.ID]bool)
+	for _, p := range res {
+		if seen[p] {
+			t.Fatalf("peer %s returned twice", p)
+		}
+		seen[p] = true
+	}
+}
+
+func TestCompositeLimit(t *testing.T) {
+	a := &mockDiscoverer{peers: []peer.ID{"a", "b", "c"}}
+	b := &mockDiscoverer{peers: []peer.ID{"d", "e", "f"}}
+	c := NewComposite(WeightedDiscoverer{Discoverer: a}, WeightedDiscoverer{Discoverer: b})
+
+	ch, err := c.FindPeers(context.Background(), "ns", Limit(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := collect(ch); len(res) != 4 {
+		t.Fatalf("expected 4 peers, got %v", res)
+	}
+}
+
+func TestCompositeBackoff(t *testing.T) {
+	failing := &mockDiscoverer{err: errors.New("boom")}
+	ok := &mockDiscoverer{peers: []peer.ID{"a"}}
+	c := NewComposite(WeightedDiscoverer{Discoverer: failing}, WeightedDiscoverer{Discoverer: ok})
+
+	for i := 0; i < 3; i++ {
+		ch, err := c.FindPeers(context.Background(), "ns")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res := collect(ch); len(res) != 1 {
+			t.Fatalf("expected 1 peer, got %v", res)
+		}
+	}
+	if failing.calls != 1 {
+		t.Fatalf("expected failing backend to be queried once, got %d", failing.calls)
+	}
+	if ok.calls != 3 {
+		t.Fatalf("expected healthy backend to be queried 3 times, got %d", ok.calls)
+	}
+
+	c = NewComposite(WeightedDiscoverer{Discoverer: failing})
+	if _, err := c.FindPeers(context.Background(), "ns"); err == nil {
+		t.Fatal("expected an error when the only backend fails")
+	}
+	if _, err := c.FindPeers(context.Background(), "ns"); err != ErrAllBackendsBackingOff {
+		t.Fatalf("expected ErrAllBackendsBackingOff, got %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package discovery
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/metrics"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+)
+
+// FilterKind is the kind of a discovery Filter.
+type FilterKind string
+
+const (
+	// FilterProtocol matches peers supporting a protocol. Value is the
+	// protocol, whose last path components may be "x" wildcards, e.g.
+	// "/myproto/2.x" matches "/myproto/2.1.0".
+	FilterProtocol FilterKind = "protocol"
+	// FilterTransport matches peers with an address using a transport
+	// protocol, e.g. "quic". If Public is set, the address must be public.
+	FilterTransport FilterKind = "transport"
+	// FilterLatency matches peers whose latency is in the latency class
+	// Value, or a better one.
+	FilterLatency FilterKind = "latency"
+)
+
+// Latency classes, from best to worst.
+const (
+	LatencyClassA = "A"
+	LatencyClassB = "B"
+	LatencyClassC = "C"
+)
+
+// LatencyClassThresholds are the upper bounds of the latencies of classes A
+// and B. Peers with higher latencies are in class C.
+var LatencyClassThresholds = [2]time.Duration{50 * time.Millisecond, 200 * time.Millisecond}
+
+// LatencyClass returns the latency class of the latency.
+func LatencyClass(latency time.Duration) string {
+	switch {
+	case latency <= LatencyClassThresholds[0]:
+		return LatencyClassA
+	case latency <= LatencyClassThresholds[1]:
+		return LatencyClassB
+	default:
+		return LatencyClassC
+	}
+}
+
+// Filter is a structured constraint on the peers returned by FindPeers.
+// Backends may translate filters into their native queries; filters they
+// can't translate are evaluated client-side with FilterPeers.
+type Filter struct {
+	Kind   FilterKind
+	Value  string
+	Public bool
+}
+
+// SupportsProtocol returns a filter matching the peers supporting the
+// protocol, e.g. "/myproto/2.x".
+func SupportsProtocol(proto string) Filter {
+	return Filter{Kind: FilterProtocol, Value: proto}
+}
+
+// HasTransport returns a filter matching the peers with an address using the
+// transport protocol, e.g. "quic", optionally requiring the address to be
+// public.
+func HasTransport(transport string, public bool) Filter {
+	return Filter{Kind: FilterTransport, Value: transport, Public: public}
+}
+
+// InLatencyClass returns a filter matching the peers in the latency class or
+// a better one.
+func InLatencyClass(class string) Filter {
+	return Filter{Kind: FilterLatency, Value: class}
+}
+
+func (f Filter) String() string {
+	s := string(f.Kind) + ":" + f.Value
+	if f.Public {
+		s += ":public"
+	}
+	return s
+}
+
+// FilterEnv holds the local knowledge the filters are evaluated against.
+// Filters needing a nil source don't match.
+type FilterEnv struct {
+	Protocols peerstore.ProtoBook
+	Metrics   peerstore.Metrics
+}
+
+// Match evaluates the filter for the peer. Filters of unknown kinds don't
+// match.
+func (f Filter) Match(env FilterEnv, pi peer.AddrInfo) bool {
+	switch f.Kind {
+	case FilterProtocol:
+		if env.Protocols == nil {
+			return false
+		}
+		protos, err := env.Protocols.GetProtocols(pi.ID)
+		if err != nil {
+			return false
+		}
+		for _, p := range protos {
+			if matchProtocol(f.Value, p) {
+				return true
+			}
+		}
+		return false
+	case FilterTransport:
+		for _, a := range pi.Addrs {
+			if f.Public && metrics.ClassifyAddr(a) != metrics.ClassWAN {
+				continue
+			}
+			for _, p := range a.Protocols() {
+				if p.Name == f.Value {
+					return true
+				}
+			}
+		}
+		return false
+	case FilterLatency:
+		if env.Metrics == nil {
+			return false
+		}
+		latency := env.Metrics.LatencyEWMA(pi.ID)
+		return latency != 0 && LatencyClass(latency) <= f.Value
+	default:
+		return false
+	}
+}
+
+// matchProtocol matches the protocol against the pattern, component by
+// component, where an "x" matches any remaining version components.
+func matchProtocol(pattern, proto string) bool {
+	pp, cp := strings.Split(pattern, "/"), strings.Split(proto, "/")
+	if len(pp) != len(cp) {
+		return false
+	}
+	for i := range pp {
+		if !matchVersion(pp[i], cp[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchVersion(pattern, v string) bool {
+	pv, vv := strings.Split(pattern, "."), strings.Split(v, ".")
+	for i, c := range pv {
+		if c == "x" {
+			return true
+		}
+		if i >= len(vv) || vv[i] != c {
+			return false
+		}
+	}
+	return len(pv) == len(vv)
+}
+
+type filtersKey struct{}
+
+// Filters is an option restricting FindPeers to the peers matching all the
+// filters.
+func Filters(filters ...Filter) Option {
+	return func(opts *Options) error {
+		if opts.Other == nil {
+			opts.Other = make(map[interface{}]interface{})
+		}
+		fs, _ := opts.Other[filtersKey{}].([]Filter)
+		opts.Other[filtersKey{}] = append(fs, filters...)
+		return nil
+	}
+}
+
+// GetFilters returns the filters set by the Filters option.
+func GetFilters(opts *Options) []Filter {
+	fs, _ := opts.Other[filtersKey{}].([]Filter)
+	return fs
+}
+
+// MatchAll returns true if the peer matches all the filters.
+func MatchAll(env FilterEnv, pi peer.AddrInfo, filters []Filter) bool {
+	for _, f := range filters {
+		if !f.Match(env, pi) {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterPeers evaluates the filters client-side, forwarding the peers of in
+// matching all of them.
+func FilterPeers(ctx context.Context, in <-chan peer.AddrInfo, env FilterEnv, filters []Filter) <-chan peer.AddrInfo {
+	if len(filters) == 0 {
+		return in
+	}
+	out := make(chan peer.AddrInfo)
+	go func() {
+		defer close(out)
+		for pi := range in {
+			if !MatchAll(env, pi, filters) {
+				continue
+			}
+			select {
+			case out <- pi:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
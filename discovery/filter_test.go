@@ -0,0 +1,79 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+type mapProtoBook struct {
+	peerstore.ProtoBook
+	protos map[peer.ID][]string
+}
+
+func (b mapProtoBook) GetProtocols(p peer.ID) ([]string, error) { return b.protos[p], nil }
+
+type mapMetrics struct {
+	peerstore.Metrics
+	latency map[peer.ID]time.Duration
+}
+
+func (m mapMetrics) LatencyEWMA(p peer.ID) time.Duration { return m.latency[p] }
+
+func TestFilters(t *testing.T) {
+	env := FilterEnv{
+		Protocols: mapProtoBook{protos: map[peer.ID][]string{
+			"a": {"/myproto/2.1.0"},
+			"b": {"/myproto/1.0.0"},
+		}},
+		Metrics: mapMetrics{latency: map[peer.ID]time.Duration{
+			"a": 10 * time.Millisecond,
+			"b": 100 * time.Millisecond,
+		}},
+	}
+	a := peer.AddrInfo{ID: "a", Addrs: []ma.Multiaddr{ma.StringCast("/ip4/1.2.3.4/udp/1/quic")}}
+	b := peer.AddrInfo{ID: "b", Addrs: []ma.Multiaddr{ma.StringCast("/ip4/192.168.1.1/udp/1/quic")}}
+
+	for _, tc := range []struct {
+		filter Filter
+		a, b   bool
+	}{
+		{SupportsProtocol("/myproto/2.x"), true, false},
+		{SupportsProtocol("/myproto/x"), true, true},
+		{SupportsProtocol("/myproto/2.1"), false, false},
+		{HasTransport("quic", false), true, true},
+		{HasTransport("quic", true), true, false},
+		{HasTransport("tcp", false), false, false},
+		{InLatencyClass(LatencyClassA), true, false},
+		{InLatencyClass(LatencyClassB), true, true},
+		{Filter{Kind: "unknown"}, false, false},
+	} {
+		if got := tc.filter.Match(env, a); got != tc.a {
+			t.Errorf("%s: expected %t for a, got %t", tc.filter, tc.a, got)
+		}
+		if got := tc.filter.Match(env, b); got != tc.b {
+			t.Errorf("%s: expected %t for b, got %t", tc.filter, tc.b, got)
+		}
+	}
+
+	var opts Options
+	if err := opts.Apply(Filters(SupportsProtocol("/myproto/x")), Filters(HasTransport("quic", true))); err != nil {
+		t.Fatal(err)
+	}
+	in := make(chan peer.AddrInfo, 2)
+	in <- a
+	in <- b
+	close(in)
+	var got []peer.ID
+	for pi := range FilterPeers(context.Background(), in, env, GetFilters(&opts)) {
+		got = append(got, pi.ID)
+	}
+	if len(got) != 1 || got[0] != "a" {
+		t.Fatalf("unexpected peers: %v", got)
+	}
+}
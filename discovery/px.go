@@ -0,0 +1,150 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/record"
+)
+
+// PeerExchangeDomain is the signature domain of peer exchange records.
+const PeerExchangeDomain = "libp2p-peer-exchange-record"
+
+// PeerExchangeCodec is the payload type of peer exchange records.
+var PeerExchangeCodec = []byte("/libp2p/peer-exchange-record")
+
+// MaxPeerExchangeTTL caps the TTL of peer exchange entries, so a signer can't
+// make its peers linger in address books for arbitrarily long.
+var MaxPeerExchangeTTL = time.Hour
+
+// MaxPeerExchangeClockSkew is how far in the future of the local clock the
+// issuance time of a peer exchange record may be.
+var MaxPeerExchangeClockSkew = time.Minute
+
+// ErrPeerExchangeFromFuture is returned when consuming a peer exchange record
+// issued further in the future than MaxPeerExchangeClockSkew.
+var ErrPeerExchangeFromFuture = errors.New("peer exchange record issued in the future")
+
+func init() {
+	record.RegisterType(&PeerExchangeRecord{})
+}
+
+// PeerExchangeEntry is a peer handed out in a peer exchange, along with how
+// long its addresses should be considered valid.
+type PeerExchangeEntry struct {
+	Info peer.AddrInfo
+	// TTL is counted from the record's issuance, and capped to
+	// MaxPeerExchangeTTL.
+	TTL time.Duration
+}
+
+// PeerExchangeRecord is a list of peers handed out by a peer, e.g. during
+// gossipsub peer exchange (PX) or by a bootstrap service. It's exchanged
+// sealed in a record.Envelope signed by the peer handing it out, so every
+// protocol verifies the same format instead of a bespoke encoding.
+type PeerExchangeRecord struct {
+	// Namespace is the namespace (e.g. topic) the peers were selected for.
+	// It may be empty.
+	Namespace string `json:",omitempty"`
+	// Seq increases with every record issued by a peer.
+	Seq uint64
+	// Issued is the issuance time, as set by the signer. It's checked
+	// against the local clock, see MaxPeerExchangeClockSkew.
+	Issued time.Time
+	Peers  []PeerExchangeEntry
+}
+
+var (
+	_ record.Record    = (*PeerExchangeRecord)(nil)
+	_ record.Sequenced = (*PeerExchangeRecord)(nil)
+)
+
+// Domain implements record.Record.
+func (r *PeerExchangeRecord) Domain() string { return PeerExchangeDomain }
+
+// Codec implements record.Record.
+func (r *PeerExchangeRecord) Codec() []byte { return PeerExchangeCodec }
+
+// Sequence implements record.Sequenced.
+func (r *PeerExchangeRecord) Sequence() uint64 { return r.Seq }
+
+// MarshalRecord implements record.Record.
+func (r *PeerExchangeRecord) MarshalRecord() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// UnmarshalRecord implements record.Record.
+func (r *PeerExchangeRecord) UnmarshalRecord(data []byte) error {
+	return json.Unmarshal(data, r)
+}
+
+// Live returns the peers whose TTL, capped to MaxPeerExchangeTTL, hadn't
+// elapsed at time now. Records issued further in the future than
+// MaxPeerExchangeClockSkew have no live peers.
+func (r *PeerExchangeRecord) Live(now time.Time) []peer.AddrInfo {
+	if r.Issued.After(now.Add(MaxPeerExchangeClockSkew)) {
+		return nil
+	}
+	var out []peer.AddrInfo
+	for _, e := range r.Peers {
+		ttl := e.TTL
+		if ttl > MaxPeerExchangeTTL {
+			ttl = MaxPeerExchangeTTL
+		}
+		if now.Before(r.Issued.Add(ttl)) {
+			out = append(out, e.Info)
+		}
+	}
+	return out
+}
+
+// ConsumePeerExchange verifies a serialized envelope holding a peer exchange
+// record, and returns the record along with the peer that signed it. Records
+// issued further in the future than MaxPeerExchangeClockSkew fail with
+// ErrPeerExchangeFromFuture.
+func ConsumePeerExchange(data []byte) (*PeerExchangeRecord, peer.ID, error) {
+	return ConsumePeerExchangeAt(data, time.Now())
+}
+
+// ConsumePeerExchangeAt is like ConsumePeerExchange, checking the issuance
+// time against now.
+func ConsumePeerExchangeAt(data []byte, now time.Time) (*PeerExchangeRecord, peer.ID, error) {
+	return ConsumeCheckedPeerExchangeAt(data, now, nil)
+}
+
+// ConsumeCheckedPeerExchangeAt is ConsumePeerExchangeAt, also rejecting the
+// records revoked according to the checker. A nil checker disables revocation
+// checks.
+func ConsumeCheckedPeerExchangeAt(data []byte, now time.Time, c record.RevocationChecker) (*PeerExchangeRecord, peer.ID, error) {
+	rec := new(PeerExchangeRecord)
+	e, err := record.ConsumeCheckedTypedEnvelope(data, rec, c)
+	if err != nil {
+		return nil, "", err
+	}
+	if rec.Issued.After(now.Add(MaxPeerExchangeClockSkew)) {
+		return nil, "", ErrPeerExchangeFromFuture
+	}
+	signer, err := peer.IDFromPublicKey(e.PublicKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return rec, signer, nil
+}
+
+// PeerExchangeSource selects the peers a node hands out in peer exchanges.
+type PeerExchangeSource interface {
+	// PeerExchange returns up to limit peers for the namespace. The record
+	// is sealed by the caller with the node's key.
+	PeerExchange(ctx context.Context, ns string, limit int) (*PeerExchangeRecord, error)
+}
+
+// PeerExchanger requests peers from remote peers.
+type PeerExchanger interface {
+	// ExchangePeers requests up to limit peers for the namespace from p.
+	// Implementations must verify the record (e.g. with
+	// ConsumePeerExchange) and check it was signed by p.
+	ExchangePeers(ctx context.Context, p peer.ID, ns string, limit int) (*PeerExchangeRecord, error)
+}
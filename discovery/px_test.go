@@ -0,0 +1,114 @@
+package discovery
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/record"
+)
+
+func TestPeerExchangeRecord(t *testing.T) {
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newID := func() peer.ID {
+		_, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := peer.IDFromPublicKey(pk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	short, long := newID(), newID()
+	issued := time.Now().Round(0)
+	rec := &PeerExchangeRecord{
+		Namespace: "topic",
+		Seq:       1,
+		Issued:    issued,
+		Peers: []PeerExchangeEntry{
+			{Info: peer.AddrInfo{ID: short}, TTL: time.Minute},
+			{Info: peer.AddrInfo{ID: long}, TTL: time.Hour},
+		},
+	}
+	e, err := record.Seal(rec, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := e.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, signer, err := ConsumePeerExchange(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signer.MatchesPrivateKey(sk) {
+		t.Fatalf("unexpected signer %s", signer)
+	}
+	if got.Namespace != "topic" || got.Seq != 1 || !got.Issued.Equal(issued) || len(got.Peers) != 2 {
+		t.Fatalf("unexpected record %+v", got)
+	}
+	live := got.Live(issued.Add(10 * time.Minute))
+	if len(live) != 1 || live[0].ID != long {
+		t.Fatalf("unexpected live peers %v", live)
+	}
+
+	if _, _, err := ConsumePeerExchangeAt(data, issued.Add(-time.Hour)); err != ErrPeerExchangeFromFuture {
+		t.Fatalf("expected ErrPeerExchangeFromFuture, got %v", err)
+	}
+
+	h, err := record.EnvelopeHash(e, PeerExchangeDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev, err := record.Seal(&record.RevocationRecord{EnvelopeHash: h}, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revData, err := rev.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := record.NewRevocationList()
+	if err := list.Add(revData); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ConsumeCheckedPeerExchangeAt(data, issued, list); !errors.Is(err, record.ErrRevoked) {
+		t.Fatalf("expected ErrRevoked, got %v", err)
+	}
+
+	data[len(data)-1] ^= 1
+	if _, _, err := ConsumePeerExchange(data); err == nil {
+		t.Fatal("expected a tampered record to be rejected")
+	}
+}
+
+func TestPeerExchangeRecordBounds(t *testing.T) {
+	now := time.Now()
+	rec := &PeerExchangeRecord{
+		Issued: now,
+		Peers:  []PeerExchangeEntry{{Info: peer.AddrInfo{ID: "a"}, TTL: 1000 * time.Hour}},
+	}
+	if live := rec.Live(now.Add(MaxPeerExchangeTTL)); len(live) != 0 {
+		t.Fatal("expected the TTL to be capped")
+	}
+	if live := rec.Live(now.Add(MaxPeerExchangeTTL / 2)); len(live) != 1 {
+		t.Fatal("expected the peer to be live within the capped TTL")
+	}
+
+	// A signer can't extend the lifetime of its peers by postdating the
+	// record.
+	rec.Issued = now.Add(24 * time.Hour)
+	if live := rec.Live(now); len(live) != 0 {
+		t.Fatal("expected a postdated record to have no live peers")
+	}
+}
@@ -0,0 +1,128 @@
+package discovery
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+)
+
+// RankWindow is how long a Composite with a PeerRanker collects results
+// before ranking them, unless set with SetRankWindow. Results arriving later
+// are ranked in further batches.
+var RankWindow = 5 * time.Second
+
+// PeerRanker orders discovered peers using application-supplied signals
+// (latency probes, IP geolocation, ASN diversity, ...), so applications can
+// prefer nearby or topologically diverse peers.
+type PeerRanker interface {
+	// Rank returns the peers ordered from most to least preferred. It may
+	// drop peers it considers unsuitable, and must not modify the given
+	// slice.
+	Rank(ctx context.Context, ns string, peers []peer.AddrInfo) []peer.AddrInfo
+}
+
+// PeerRankerFunc is a function implementing PeerRanker.
+type PeerRankerFunc func(ctx context.Context, ns string, peers []peer.AddrInfo) []peer.AddrInfo
+
+// Rank calls f(ctx, ns, peers).
+func (f PeerRankerFunc) Rank(ctx context.Context, ns string, peers []peer.AddrInfo) []peer.AddrInfo {
+	return f(ctx, ns, peers)
+}
+
+// RankByLatency returns a PeerRanker preferring peers with the lowest latency
+// recorded in m. Peers with unknown latency come last, in their original
+// order.
+func RankByLatency(m peerstore.Metrics) PeerRanker {
+	return PeerRankerFunc(func(_ context.Context, _ string, in []peer.AddrInfo) []peer.AddrInfo {
+		peers := make([]peer.AddrInfo, len(in))
+		copy(peers, in)
+		sort.SliceStable(peers, func(i, j int) bool {
+			li, lj := m.LatencyEWMA(peers[i].ID), m.LatencyEWMA(peers[j].ID)
+			if li == 0 || lj == 0 {
+				return li != 0
+			}
+			return li < lj
+		})
+		return peers
+	})
+}
+
+// RankDiverse returns a PeerRanker spreading peers across the groups returned
+// by group (e.g. their ASN or region): the first peer of each group comes
+// first, then the second of each, and so on. Groups are visited in the order
+// they first appear.
+func RankDiverse(group func(peer.AddrInfo) string) PeerRanker {
+	return PeerRankerFunc(func(_ context.Context, _ string, peers []peer.AddrInfo) []peer.AddrInfo {
+		var (
+			order  []string
+			groups = make(map[string][]peer.AddrInfo)
+		)
+		for _, pi := range peers {
+			g := group(pi)
+			if _, ok := groups[g]; !ok {
+				order = append(order, g)
+			}
+			groups[g] = append(groups[g], pi)
+		}
+		out := make([]peer.AddrInfo, 0, len(peers))
+		for len(out) < len(peers) {
+			for _, g := range order {
+				if q := groups[g]; len(q) > 0 {
+					out = append(out, q[0])
+					groups[g] = q[1:]
+				}
+			}
+		}
+		return out
+	})
+}
+
+// rankResults collects results from in, in windows of the given duration,
+// and emits them to out in the order returned by the ranker, stopping after
+// limit peers if limit is positive.
+func rankResults(ctx context.Context, cancel context.CancelFunc, clock network.Clock, window time.Duration, r PeerRanker, ns string, in <-chan peer.AddrInfo, limit int, out chan<- peer.AddrInfo) {
+	defer close(out)
+	defer cancel()
+
+	sent := 0
+	for done := false; !done; {
+		var batch []peer.AddrInfo
+		timer := clock.NewTimer(window)
+	collect:
+		for {
+			select {
+			case pi, ok := <-in:
+				if !ok {
+					done = true
+					break collect
+				}
+				batch = append(batch, pi)
+			case <-timer.C():
+				break collect
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+		timer.Stop()
+
+		if len(batch) == 0 {
+			continue
+		}
+		for _, pi := range r.Rank(ctx, ns, batch) {
+			select {
+			case out <- pi:
+			case <-ctx.Done():
+				return
+			}
+			sent++
+			if limit > 0 && sent >= limit {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+type latencies map[peer.ID]time.Duration
+
+func (l latencies) RecordLatency(peer.ID, time.Duration) {}
+func (l latencies) LatencyEWMA(p peer.ID) time.Duration  { return l[p] }
+
+func TestCompositeRanker(t *testing.T) {
+	a := &mockDiscoverer{peers: []peer.ID{"a", "b", "c"}}
+	b := &mockDiscoverer{peers: []peer.ID{"d", "e"}}
+	c := NewComposite(WeightedDiscoverer{Discoverer: a}, WeightedDiscoverer{Discoverer: b})
+	c.SetRanker(RankByLatency(latencies{"e": time.Millisecond, "c": 2 * time.Millisecond, "a": 3 * time.Millisecond}))
+
+	ch, err := c.FindPeers(context.Background(), "ns", Limit(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := collect(ch)
+	if len(res) != 3 || res[0] != "e" || res[1] != "c" || res[2] != "a" {
+		t.Fatalf("unexpected ranked peers: %v", res)
+	}
+}
+
+func TestRankByLatencyCopies(t *testing.T) {
+	peers := []peer.AddrInfo{{ID: "a"}, {ID: "b"}}
+	ranked := RankByLatency(latencies{"b": time.Millisecond}).Rank(context.Background(), "ns", peers)
+	if ranked[0].ID != "b" || peers[0].ID != "a" {
+		t.Fatal("expected the ranker to sort a copy of the peers")
+	}
+}
+
+// blockingDiscoverer sends its peers, then keeps its channel open until the
+// context is canceled.
+type blockingDiscoverer struct {
+	peers []peer.ID
+}
+
+func (d *blockingDiscoverer) FindPeers(ctx context.Context, ns string, opts ...Option) (<-chan peer.AddrInfo, error) {
+	ch := make(chan peer.AddrInfo, len(d.peers))
+	for _, p := range d.peers {
+		ch <- peer.AddrInfo{ID: p}
+	}
+	go func() {
+		<-ctx.Done()
+		close(ch)
+	}()
+	return ch, nil
+}
+
+func TestCompositeRankWindow(t *testing.T) {
+	// The backend never closes its channel, so results are only emitted
+	// once the window elapses.
+	d := &blockingDiscoverer{peers: []peer.ID{"a"}}
+	c := NewComposite(WeightedDiscoverer{Discoverer: d})
+	c.SetRanker(RankByLatency(latencies{}))
+	c.SetRankWindow(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := c.FindPeers(ctx, "ns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case pi := <-ch:
+		if pi.ID != "a" {
+			t.Fatalf("unexpected peer %s", pi.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the configured window to apply")
+	}
+}
+
+func TestRankDiverse(t *testing.T) {
+	asn := map[peer.ID]string{"a": "1", "b": "1", "c": "1", "d": "2", "e": "3"}
+	var peers []peer.AddrInfo
+	for _, p := range []peer.ID{"a", "b", "c", "d", "e"} {
+		peers = append(peers, peer.AddrInfo{ID: p})
+	}
+
+	ranked := RankDiverse(func(pi peer.AddrInfo) string { return asn[pi.ID] }).Rank(context.Background(), "ns", peers)
+	var res []peer.ID
+	for _, pi := range ranked {
+		res = append(res, pi.ID)
+	}
+	expected := []peer.ID{"a", "d", "e", "b", "c"}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, res)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/record"
+)
+
+// AdvertisementReceiptDomain is the signature domain of advertisement
+// receipts.
+const AdvertisementReceiptDomain = "libp2p-advertisement-receipt"
+
+// AdvertisementReceiptCodec is the payload type of advertisement receipts.
+var AdvertisementReceiptCodec = []byte("/libp2p/advertisement-receipt")
+
+// ErrReceiptKeyMismatch is returned when the key used to seal or verify a
+// receipt doesn't match the receipt's rendezvous point.
+var ErrReceiptKeyMismatch = errors.New("key doesn't match the receipt's rendezvous point")
+
+func init() {
+	record.RegisterType(&AdvertisementReceipt{})
+}
+
+// AdvertisementReceipt is a record, signed by a rendezvous point, proving that
+// it accepted a registration for a namespace at a given time and for a given
+// TTL. Applications can keep receipts to audit whether their advertisements
+// actually propagated.
+//
+// Receipts are exchanged sealed in a record.Envelope signed by the rendezvous
+// point, see SealReceipt and ConsumeReceipt.
+type AdvertisementReceipt struct {
+	Namespace string
+	// Advertiser is the peer whose registration was accepted.
+	Advertiser peer.ID
+	// RendezvousPoint is the peer that accepted the registration and signed
+	// the receipt.
+	RendezvousPoint peer.ID
+	Accepted        time.Time
+	TTL             time.Duration
+}
+
+var _ record.Record = (*AdvertisementReceipt)(nil)
+
+// Domain implements record.Record.
+func (r *AdvertisementReceipt) Domain() string { return AdvertisementReceiptDomain }
+
+// Codec implements record.Record.
+func (r *AdvertisementReceipt) Codec() []byte { return AdvertisementReceiptCodec }
+
+// MarshalRecord implements record.Record.
+func (r *AdvertisementReceipt) MarshalRecord() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// UnmarshalRecord implements record.Record.
+func (r *AdvertisementReceipt) UnmarshalRecord(data []byte) error {
+	return json.Unmarshal(data, r)
+}
+
+// Expires returns the time at which the registration expires.
+func (r *AdvertisementReceipt) Expires() time.Time {
+	return r.Accepted.Add(r.TTL)
+}
+
+// Expired returns true if the registration expired before now.
+func (r *AdvertisementReceipt) Expired(now time.Time) bool {
+	return now.After(r.Expires())
+}
+
+// SealReceipt seals the receipt in an envelope signed with the rendezvous
+// point's key.
+func SealReceipt(r *AdvertisementReceipt, key crypto.PrivKey) (*record.Envelope, error) {
+	if !r.RendezvousPoint.MatchesPrivateKey(key) {
+		return nil, ErrReceiptKeyMismatch
+	}
+	return record.Seal(r, key)
+}
+
+// ConsumeReceipt verifies a serialized envelope holding an advertisement
+// receipt, and checks that it was signed by the receipt's rendezvous point.
+func ConsumeReceipt(data []byte) (*record.Envelope, *AdvertisementReceipt, error) {
+	return ConsumeCheckedReceipt(data, nil)
+}
+
+// ConsumeCheckedReceipt is ConsumeReceipt, also rejecting the receipts revoked
+// according to the checker. A nil checker disables revocation checks.
+func ConsumeCheckedReceipt(data []byte, c record.RevocationChecker) (*record.Envelope, *AdvertisementReceipt, error) {
+	r := new(AdvertisementReceipt)
+	e, err := record.ConsumeCheckedTypedEnvelope(data, r, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !r.RendezvousPoint.MatchesPublicKey(e.PublicKey) {
+		return nil, nil, ErrReceiptKeyMismatch
+	}
+	return e, r, nil
+}
+
+// ReceiptAdvertiser is implemented by advertisers that can return receipts
+// from the rendezvous points that accepted their registration.
+type ReceiptAdvertiser interface {
+	Advertiser
+
+	// AdvertiseWithReceipts advertises a service, like Advertise, and returns
+	// the envelopes sealing the receipts of the rendezvous points that
+	// accepted the registration. Implementations must verify the receipts,
+	// e.g. with ConsumeReceipt.
+	AdvertiseWithReceipts(ctx context.Context, ns string, opts ...Option) (time.Duration, []*record.Envelope, error)
+}
+
+// AdvertiseWithReceipts advertises a service and returns the envelopes sealing
+// the receipts of the rendezvous points that accepted the registration. If the
+// advertiser doesn't implement ReceiptAdvertiser, no receipts are returned.
+func AdvertiseWithReceipts(ctx context.Context, a Advertiser, ns string, opts ...Option) (time.Duration, []*record.Envelope, error) {
+	if ra, ok := a.(ReceiptAdvertiser); ok {
+		return ra.AdvertiseWithReceipts(ctx, ns, opts...)
+	}
+	ttl, err := a.Advertise(ctx, ns, opts...)
+	return ttl, nil, err
+}
@@ -0,0 +1,73 @@
+package discovery
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/record"
+)
+
+func TestAdvertisementReceipt(t *testing.T) {
+	sk, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp, err := peer.IDFromPublicKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSk, otherPk, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	advertiser, err := peer.IDFromPublicKey(otherPk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &AdvertisementReceipt{
+		Namespace:       "ns",
+		Advertiser:      advertiser,
+		RendezvousPoint: rp,
+		Accepted:        time.Now(),
+		TTL:             time.Hour,
+	}
+	env, err := SealReceipt(r, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := env.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, got, err := ConsumeReceipt(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Namespace != "ns" || got.Advertiser != advertiser || got.RendezvousPoint != rp || got.TTL != time.Hour || !got.Accepted.Equal(r.Accepted) {
+		t.Fatalf("unexpected receipt %+v", got)
+	}
+	if got.Expired(time.Now()) {
+		t.Fatal("receipt shouldn't have expired yet")
+	}
+
+	if _, err := SealReceipt(r, otherSk); err != ErrReceiptKeyMismatch {
+		t.Fatalf("expected ErrReceiptKeyMismatch, got %v", err)
+	}
+
+	// A receipt sealed by another key than the rendezvous point's.
+	env, err = record.Seal(r, otherSk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = env.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ConsumeReceipt(data); err != ErrReceiptKeyMismatch {
+		t.Fatalf("expected ErrReceiptKeyMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package event
+
+import (
+	"time"
+
+	peer "github.com/libp2p/go-libp2p-core/peer"
+	protocol "github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// BandwidthStats is a point-in-time snapshot of bandwidth usage. It mirrors
+// metrics.Stats.
+type BandwidthStats struct {
+	TotalIn  int64
+	TotalOut int64
+	RateIn   float64
+	RateOut  float64
+}
+
+// EvtBandwidthSample is emitted periodically with a snapshot of the local
+// node's bandwidth usage.
+type EvtBandwidthSample struct {
+	// Time is when the sample was taken.
+	Time time.Time
+	// Totals is the bandwidth used across all peers and protocols.
+	Totals BandwidthStats
+	// ByProtocol breaks the usage down by protocol. It's nil unless the
+	// emitter was configured to include it.
+	ByProtocol map[protocol.ID]BandwidthStats
+	// ByPeer breaks the usage down by peer. It's nil unless the emitter was
+	// configured to include it.
+	ByPeer map[peer.ID]BandwidthStats
+}
@@ -0,0 +1,30 @@
+package event
+
+import (
+	"time"
+
+	peer "github.com/libp2p/go-libp2p-core/peer"
+)
+
+// EvtBootstrapHealthChanged should be emitted by a bootstrap manager when the
+// number of connected bootstrap peers crosses its minimum target.
+type EvtBootstrapHealthChanged struct {
+	// Connected is the number of bootstrap peers currently connected.
+	Connected int
+	// Target is the minimum number of connected bootstrap peers.
+	Target int
+	// Healthy is true if Connected reaches Target.
+	Healthy bool
+}
+
+// EvtBootstrapPeerFailed should be emitted by a bootstrap manager when
+// connecting to a bootstrap peer fails.
+type EvtBootstrapPeerFailed struct {
+	Peer peer.ID
+	// Attempts is the number of consecutive failed attempts.
+	Attempts int
+	Err      error
+	// NextAttempt is when the peer will be retried. It's the zero time if
+	// the manager gave up on the peer.
+	NextAttempt time.Time
+}
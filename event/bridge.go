@@ -0,0 +1,55 @@
+package event
+
+// EvtBridged is emitted on the destination bus of a Bridge for each event
+// forwarded from the source bus. Forwarded events are wrapped rather than
+// re-emitted as is, so that the destination host's own subsystems don't
+// mistake them for local events.
+type EvtBridged struct {
+	// Source identifies the bus the event was emitted on, e.g. the ID of
+	// the host owning it.
+	Source string
+	// Event is the forwarded event.
+	Event interface{}
+}
+
+// Bridge forwards selected event types from one bus to another, for processes
+// embedding several hosts (simulations, gateways) that need a unified view of
+// their events. Bridging several buses into the same destination bus is
+// supported; subscribers tell the sources apart with EvtBridged.Source.
+type Bridge struct {
+	sub  Subscription
+	em   Emitter
+	done chan struct{}
+}
+
+// NewBridge forwards the events of the given types (pointers, as passed to
+// Bus.Subscribe) emitted on src to dst, as EvtBridged events tagged with
+// source, until the bridge is closed.
+func NewBridge(src, dst Bus, source string, eventTypes ...interface{}) (*Bridge, error) {
+	em, err := dst.Emitter(new(EvtBridged))
+	if err != nil {
+		return nil, err
+	}
+	sub, err := src.Subscribe(eventTypes)
+	if err != nil {
+		em.Close()
+		return nil, err
+	}
+
+	b := &Bridge{sub: sub, em: em, done: make(chan struct{})}
+	go func() {
+		defer close(b.done)
+		defer em.Close()
+		for e := range sub.Out() {
+			em.Emit(EvtBridged{Source: source, Event: e})
+		}
+	}()
+	return b, nil
+}
+
+// Close stops forwarding events.
+func (b *Bridge) Close() error {
+	err := b.sub.Close()
+	<-b.done
+	return err
+}
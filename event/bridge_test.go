@@ -0,0 +1,67 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+func TestBridge(t *testing.T) {
+	src, dst := newTestBus(), newTestBus()
+	sub, err := dst.Subscribe(new(EvtBridged))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	b, err := NewBridge(src, dst, "host-a", new(EvtLocalProtocolsUpdated), new(EvtTransportError))
+	if err != nil {
+		t.Fatal(err)
+	}
+	protoEm, err := src.Emitter(new(EvtLocalProtocolsUpdated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errEm, err := src.Emitter(new(EvtTransportError))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	protoEm.Emit(EvtLocalProtocolsUpdated{Added: []protocol.ID{"/foo/1.0.0"}})
+	errEm.Emit(EvtTransportError{Transport: "tcp", Op: TransportOpDial})
+
+	next := func() EvtBridged {
+		t.Helper()
+		select {
+		case e := <-sub.Out():
+			return e.(EvtBridged)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a bridged event")
+		}
+		return EvtBridged{}
+	}
+	if evt := next(); evt.Source != "host-a" {
+		t.Fatalf("unexpected source %q", evt.Source)
+	} else if e, ok := evt.Event.(EvtLocalProtocolsUpdated); !ok || len(e.Added) != 1 || e.Added[0] != "/foo/1.0.0" {
+		t.Fatalf("unexpected bridged event %v", evt.Event)
+	}
+	if evt := next(); evt.Source != "host-a" {
+		t.Fatalf("unexpected source %q", evt.Source)
+	} else if e, ok := evt.Event.(EvtTransportError); !ok || e.Transport != "tcp" || e.Op != TransportOpDial {
+		t.Fatalf("unexpected bridged event %v", evt.Event)
+	}
+
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := dst.emittersClosed(); n != 1 {
+		t.Fatalf("expected the destination emitter to be closed, got %d closes", n)
+	}
+	protoEm.Emit(EvtLocalProtocolsUpdated{})
+	select {
+	case e := <-sub.Out():
+		t.Fatalf("didn't expect an event after closing the bridge, got %v", e)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
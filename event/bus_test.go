@@ -0,0 +1,93 @@
+package event
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// testBus is a minimal in-memory Bus. Emitters may only be created for
+// struct event types, and panic when emitting another type, like a real bus.
+type testBus struct {
+	mu             sync.Mutex
+	subs           []*testSub
+	closedEmitters int
+}
+
+type testSub struct {
+	bus   *testBus
+	types map[reflect.Type]bool
+	out   chan interface{}
+}
+
+type testEmitter struct {
+	bus *testBus
+	typ reflect.Type
+}
+
+func newTestBus() *testBus { return new(testBus) }
+
+func (b *testBus) Subscribe(eventType interface{}, _ ...SubscriptionOpt) (Subscription, error) {
+	types, ok := eventType.([]interface{})
+	if !ok {
+		types = []interface{}{eventType}
+	}
+	s := &testSub{bus: b, types: make(map[reflect.Type]bool), out: make(chan interface{}, 16)}
+	for _, t := range types {
+		s.types[reflect.TypeOf(t).Elem()] = true
+	}
+	b.mu.Lock()
+	b.subs = append(b.subs, s)
+	b.mu.Unlock()
+	return s, nil
+}
+
+func (b *testBus) Emitter(eventType interface{}, _ ...EmitterOpt) (Emitter, error) {
+	typ := reflect.TypeOf(eventType).Elem()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s isn't a struct event type", typ)
+	}
+	return &testEmitter{bus: b, typ: typ}, nil
+}
+
+// emittersClosed returns the number of emitters closed so far.
+func (b *testBus) emittersClosed() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closedEmitters
+}
+
+func (s *testSub) Out() <-chan interface{} { return s.out }
+
+func (s *testSub) Close() error {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	for i, o := range s.bus.subs {
+		if o == s {
+			s.bus.subs = append(s.bus.subs[:i], s.bus.subs[i+1:]...)
+			close(s.out)
+			break
+		}
+	}
+	return nil
+}
+
+func (e *testEmitter) Emit(evt interface{}) {
+	if reflect.TypeOf(evt) != e.typ {
+		panic(fmt.Sprintf("emit called with %T on an emitter of %s", evt, e.typ))
+	}
+	e.bus.mu.Lock()
+	defer e.bus.mu.Unlock()
+	for _, s := range e.bus.subs {
+		if s.types[e.typ] {
+			s.out <- evt
+		}
+	}
+}
+
+func (e *testEmitter) Close() error {
+	e.bus.mu.Lock()
+	e.bus.closedEmitters++
+	e.bus.mu.Unlock()
+	return nil
+}
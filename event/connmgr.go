@@ -0,0 +1,19 @@
+package event
+
+import (
+	peer "github.com/libp2p/go-libp2p-core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// EvtPeerConnsCoalesced should be emitted by the connection manager after it
+// closed redundant connections to a peer to enforce a per-peer connection
+// limit.
+type EvtPeerConnsCoalesced struct {
+	// Peer is the peer whose connections were coalesced.
+	Peer peer.ID
+	// Kept lists the remote addresses of the connections that were kept.
+	Kept []ma.Multiaddr
+	// Closed lists the remote addresses of the connections that were closed.
+	Closed []ma.Multiaddr
+}
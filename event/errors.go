@@ -0,0 +1,54 @@
+package event
+
+import (
+	peer "github.com/libp2p/go-libp2p-core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// TransportOp is the transport operation that failed in an EvtTransportError.
+type TransportOp string
+
+const (
+	TransportOpDial    TransportOp = "dial"
+	TransportOpListen  TransportOp = "listen"
+	TransportOpAccept  TransportOp = "accept"
+	TransportOpUpgrade TransportOp = "upgrade"
+)
+
+// EvtTransportError should be emitted when a transport fails in a way that may
+// require attention from a supervising process, as opposed to the routine
+// failures of individual dials.
+type EvtTransportError struct {
+	// Transport is the name of the transport's protocol, e.g. "tcp" or "quic".
+	Transport string
+	// Op is the operation that failed.
+	Op TransportOp
+	// Addr is the local (listen) or remote (dial) address involved.
+	Addr ma.Multiaddr
+	// Peer is the remote peer, if known.
+	Peer peer.ID
+	// Err is the error returned by the transport.
+	Err error
+}
+
+// EvtListenerClosedUnexpectedly should be emitted when a listener stops
+// accepting connections without having been closed by the host, e.g. because
+// its network interface went away. A supervising process may want to restart
+// it.
+type EvtListenerClosedUnexpectedly struct {
+	// Addr is the address the listener was listening on.
+	Addr ma.Multiaddr
+	// Err is the error that made the listener stop.
+	Err error
+}
+
+// EvtResourceManagerError should be emitted when the resource manager fails
+// internally, as opposed to denying a reservation because of a limit.
+type EvtResourceManagerError struct {
+	// Scope is the name of the scope involved, e.g. "system", "service:kad-dht"
+	// or "peer:<id>".
+	Scope string
+	// Err is the error encountered.
+	Err error
+}
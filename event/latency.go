@@ -0,0 +1,143 @@
+package event
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrInstrumentationNotSupported is returned by the WithInstrumentation option
+// when the bus implementation doesn't support subscriber instrumentation.
+var ErrInstrumentationNotSupported = errors.New("event bus doesn't support subscriber instrumentation")
+
+// DefaultLatencyBuckets are the upper bounds of the buckets of the latency
+// histograms of instrumented subscriptions.
+var DefaultLatencyBuckets = []time.Duration{
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+}
+
+// LatencyHistogram is a histogram of latencies. Counts[i] counts the samples
+// lower than or equal to Bounds[i] (and greater than the previous bound); the
+// last count is for the samples greater than all the bounds.
+type LatencyHistogram struct {
+	Bounds []time.Duration
+	Counts []uint64
+
+	Count uint64
+	Sum   time.Duration
+	Max   time.Duration
+}
+
+// NewLatencyHistogram creates an empty histogram with the given sorted bucket
+// bounds, or DefaultLatencyBuckets if none are given.
+func NewLatencyHistogram(bounds ...time.Duration) *LatencyHistogram {
+	if len(bounds) == 0 {
+		bounds = DefaultLatencyBuckets
+	}
+	return &LatencyHistogram{Bounds: bounds, Counts: make([]uint64, len(bounds)+1)}
+}
+
+// Observe records a sample.
+func (h *LatencyHistogram) Observe(d time.Duration) {
+	i := sort.Search(len(h.Bounds), func(i int) bool { return d <= h.Bounds[i] })
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+	if d > h.Max {
+		h.Max = d
+	}
+}
+
+// Mean returns the mean of the samples, or 0 if there are none.
+func (h *LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile returns the upper bound of the bucket holding the q-quantile of
+// the samples, or Max for samples beyond the last bound.
+func (h *LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(h.Count))
+	if rank >= h.Count {
+		rank = h.Count - 1
+	}
+	var seen uint64
+	for i, c := range h.Counts {
+		seen += c
+		if seen > rank {
+			if i < len(h.Bounds) {
+				return h.Bounds[i]
+			}
+			break
+		}
+	}
+	return h.Max
+}
+
+// SubscriptionStats describes how promptly a subscription consumes its events,
+// to pinpoint which subscriber is the bottleneck when events arrive late.
+type SubscriptionStats struct {
+	// Name is the name given with WithInstrumentation.
+	Name string
+	// Types are the subscribed event types.
+	Types []string
+
+	// Queued is the number of events waiting in the subscription's queue.
+	Queued int
+
+	// QueueWait is the histogram of the time events spent in the
+	// subscription's queue before being read from Out.
+	QueueWait *LatencyHistogram
+	// Delivery is the histogram of the time between the Emit call and the
+	// event being read from Out, including the time the emitter was
+	// blocked by full queues.
+	Delivery *LatencyHistogram
+}
+
+// InstrumentedSubscription is a subscription made WithInstrumentation.
+type InstrumentedSubscription interface {
+	Subscription
+
+	// Stats returns a snapshot of the subscription's statistics.
+	Stats() SubscriptionStats
+}
+
+// SubscriptionStatser is implemented by buses that can list the statistics of
+// all their instrumented subscriptions.
+type SubscriptionStatser interface {
+	SubscriptionStats() []SubscriptionStats
+}
+
+// InstrumentationSetter is implemented by the subscription settings of bus
+// implementations that support subscriber instrumentation.
+type InstrumentationSetter interface {
+	SetInstrumentation(name string)
+}
+
+// WithInstrumentation is a subscription option recording the subscription's
+// delivery latency and queue wait, under the given name. The subscription
+// returned by Subscribe then implements InstrumentedSubscription.
+//
+// The option fails with ErrInstrumentationNotSupported on buses that don't
+// support instrumentation.
+func WithInstrumentation(name string) SubscriptionOpt {
+	return func(settings interface{}) error {
+		is, ok := settings.(InstrumentationSetter)
+		if !ok {
+			return ErrInstrumentationNotSupported
+		}
+		is.SetInstrumentation(name)
+		return nil
+	}
+}
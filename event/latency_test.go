@@ -0,0 +1,73 @@
+package event
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	h := NewLatencyHistogram(time.Millisecond, 10*time.Millisecond, 100*time.Millisecond)
+	if h.Mean() != 0 || h.Quantile(0.5) != 0 {
+		t.Fatal("expected an empty histogram to report zero")
+	}
+
+	for _, d := range []time.Duration{
+		500 * time.Microsecond,
+		time.Millisecond, // on a bound, counted in its bucket
+		5 * time.Millisecond,
+		50 * time.Millisecond,
+		time.Second, // beyond the last bound
+	} {
+		h.Observe(d)
+	}
+	if h.Count != 5 || h.Max != time.Second {
+		t.Fatalf("unexpected count %d and max %s", h.Count, h.Max)
+	}
+	expected := []uint64{2, 1, 1, 1}
+	for i, c := range expected {
+		if h.Counts[i] != c {
+			t.Fatalf("expected counts %v, got %v", expected, h.Counts)
+		}
+	}
+	if mean := h.Mean(); mean != (1056500*time.Microsecond)/5 {
+		t.Fatalf("unexpected mean %s", mean)
+	}
+
+	for q, want := range map[float64]time.Duration{
+		0:    time.Millisecond,
+		0.39: time.Millisecond,
+		0.4:  10 * time.Millisecond,
+		0.7:  100 * time.Millisecond,
+		0.99: time.Second,
+		1:    time.Second,
+	} {
+		if got := h.Quantile(q); got != want {
+			t.Errorf("quantile %v: expected %s, got %s", q, want, got)
+		}
+	}
+}
+
+func TestNewLatencyHistogramDefaults(t *testing.T) {
+	h := NewLatencyHistogram()
+	if len(h.Bounds) != len(DefaultLatencyBuckets) || len(h.Counts) != len(DefaultLatencyBuckets)+1 {
+		t.Fatal("expected the default buckets")
+	}
+}
+
+// instrumentationSettings records the instrumentation name.
+type instrumentationSettings struct {
+	name string
+}
+
+func (s *instrumentationSettings) SetInstrumentation(name string) { s.name = name }
+
+func TestWithInstrumentation(t *testing.T) {
+	var opt SubscriptionOpt = WithInstrumentation("dht")
+	var settings instrumentationSettings
+	if err := opt(&settings); err != nil || settings.name != "dht" {
+		t.Fatalf("expected the name to be set, got %q (%v)", settings.name, err)
+	}
+	if err := opt(struct{}{}); err != ErrInstrumentationNotSupported {
+		t.Fatalf("expected ErrInstrumentationNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,15 @@
+package event
+
+import (
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// EvtLocalListenAddrsUpdated should be emitted when the host starts or stops
+// listening on addresses at runtime, e.g. through host.Listen and
+// host.StopListening.
+type EvtLocalListenAddrsUpdated struct {
+	// Added enumerates the addresses the host started listening on.
+	Added []ma.Multiaddr
+	// Removed enumerates the addresses the host stopped listening on.
+	Removed []ma.Multiaddr
+}
@@ -0,0 +1,24 @@
+package event
+
+import (
+	"net"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// EvtLocalInterfacesChanged should be emitted by a network.NetworkMonitor
+// when the addresses of the local network interfaces or the default route
+// change. Hosts and transports consume it to re-bind listeners and
+// re-evaluate reachability.
+type EvtLocalInterfacesChanged struct {
+	// Added enumerates the interface addresses that appeared.
+	Added []ma.Multiaddr
+	// Removed enumerates the interface addresses that went away.
+	Removed []ma.Multiaddr
+
+	// DefaultRouteChanged is true if the default route changed.
+	DefaultRouteChanged bool
+	// Gateway is the gateway of the current default route, or nil if there
+	// is none (e.g. the machine went offline).
+	Gateway net.IP
+}
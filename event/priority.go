@@ -0,0 +1,53 @@
+package event
+
+import "errors"
+
+// ErrPriorityNotSupported is returned by the WithPriority option when the bus
+// implementation doesn't support priority lanes.
+var ErrPriorityNotSupported = errors.New("event bus doesn't support priorities")
+
+// Priority is the delivery priority of an emitter or subscription.
+//
+// Buses supporting priorities keep a separate queue (lane) per priority
+// level. When queues are congested, events in higher priority lanes are
+// delivered ahead of events in lower priority lanes; ordering is only
+// guaranteed within a lane.
+type Priority int
+
+const (
+	// PriorityLow is meant for chatty, informational events such as
+	// EvtBandwidthSample.
+	PriorityLow Priority = -1
+	// PriorityNormal is the default priority.
+	PriorityNormal Priority = 0
+	// PriorityHigh is meant for events that affect the behavior of other
+	// subsystems, such as protocol updates.
+	PriorityHigh Priority = 1
+	// PriorityCritical is meant for events that must be acted upon without
+	// delay, such as shutdown or loss of reachability.
+	PriorityCritical Priority = 2
+)
+
+// PrioritySetter is implemented by the emitter and subscription settings of
+// bus implementations that support priority lanes.
+type PrioritySetter interface {
+	SetPriority(Priority)
+}
+
+// WithPriority is an emitter and subscription option setting the delivery
+// priority. On an emitter, it sets the lane the emitted events are queued in;
+// on a subscription, it sets the lane the subscription is served from,
+// regardless of the emitter's priority.
+//
+// The option fails with ErrPriorityNotSupported on buses that don't support
+// priorities.
+func WithPriority(p Priority) func(interface{}) error {
+	return func(settings interface{}) error {
+		ps, ok := settings.(PrioritySetter)
+		if !ok {
+			return ErrPriorityNotSupported
+		}
+		ps.SetPriority(p)
+		return nil
+	}
+}
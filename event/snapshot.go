@@ -0,0 +1,62 @@
+package event
+
+import "errors"
+
+// ErrSnapshotNotSupported is returned by the WithSnapshot and
+// WithStateProvider options when the bus implementation doesn't support
+// state snapshots.
+var ErrSnapshotNotSupported = errors.New("event bus doesn't support state snapshots")
+
+// StateProvider returns events reflecting the current state a state-like event
+// type describes, e.g. an EvtLocalListenAddrsUpdated listing all the current
+// listen addresses as Added, or one EvtPeerProtocolsUpdated per connected
+// peer.
+type StateProvider func() []interface{}
+
+// SnapshotSetter is implemented by the subscription settings of bus
+// implementations that support state snapshots.
+type SnapshotSetter interface {
+	SetSnapshot(bool)
+}
+
+// StateProviderSetter is implemented by the emitter settings of bus
+// implementations that support state snapshots.
+type StateProviderSetter interface {
+	SetStateProvider(StateProvider)
+}
+
+// WithSnapshot is a subscription option making the subscription first
+// receive synthetic events reflecting the current state, for the subscribed
+// event types whose emitter has a StateProvider, then the live events. This
+// spares components starting up from querying the current state separately,
+// and racing with the events.
+//
+// The option fails with ErrSnapshotNotSupported on buses that don't support
+// snapshots.
+func WithSnapshot() SubscriptionOpt {
+	return func(settings interface{}) error {
+		ss, ok := settings.(SnapshotSetter)
+		if !ok {
+			return ErrSnapshotNotSupported
+		}
+		ss.SetSnapshot(true)
+		return nil
+	}
+}
+
+// WithStateProvider is an emitter option marking its event type as
+// state-like: subscriptions made WithSnapshot receive the events returned by
+// the provider before any event emitted after they subscribed.
+//
+// The option fails with ErrSnapshotNotSupported on buses that don't support
+// snapshots.
+func WithStateProvider(p StateProvider) EmitterOpt {
+	return func(settings interface{}) error {
+		ps, ok := settings.(StateProviderSetter)
+		if !ok {
+			return ErrSnapshotNotSupported
+		}
+		ps.SetStateProvider(p)
+		return nil
+	}
+}
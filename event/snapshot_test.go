@@ -0,0 +1,38 @@
+package event
+
+import "testing"
+
+// snapshotSettings records the snapshot settings.
+type snapshotSettings struct {
+	snapshot bool
+	provider StateProvider
+}
+
+func (s *snapshotSettings) SetSnapshot(b bool)               { s.snapshot = b }
+func (s *snapshotSettings) SetStateProvider(p StateProvider) { s.provider = p }
+
+func TestSnapshotOptions(t *testing.T) {
+	var settings snapshotSettings
+
+	var subOpt SubscriptionOpt = WithSnapshot()
+	if err := subOpt(&settings); err != nil || !settings.snapshot {
+		t.Fatalf("expected the snapshot to be enabled (%v)", err)
+	}
+
+	var emitOpt EmitterOpt = WithStateProvider(func() []interface{} {
+		return []interface{}{EvtLocalProtocolsUpdated{}}
+	})
+	if err := emitOpt(&settings); err != nil || settings.provider == nil {
+		t.Fatalf("expected the provider to be set (%v)", err)
+	}
+	if evts := settings.provider(); len(evts) != 1 {
+		t.Fatalf("unexpected state %v", evts)
+	}
+
+	if err := subOpt(struct{}{}); err != ErrSnapshotNotSupported {
+		t.Fatalf("expected ErrSnapshotNotSupported, got %v", err)
+	}
+	if err := emitOpt(struct{}{}); err != ErrSnapshotNotSupported {
+		t.Fatalf("expected ErrSnapshotNotSupported, got %v", err)
+	}
+}
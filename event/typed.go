@@ -0,0 +1,35 @@
+//go:build go1.18
+// +build go1.18
+
+package event
+
+// TypedEmitter is an Emitter whose event type is checked at compile time:
+// emitting an event of the wrong type doesn't compile, instead of panicking.
+//
+// Example:
+//
+//	em, err := event.NewTypedEmitter[EventT](bus)
+//	defer em.Close() // MUST call this after being done with the emitter
+//	em.Emit(EventT{})
+type TypedEmitter[T any] struct {
+	em Emitter
+}
+
+// NewTypedEmitter creates an emitter for events of type T on the bus.
+func NewTypedEmitter[T any](bus Bus, opts ...EmitterOpt) (*TypedEmitter[T], error) {
+	em, err := bus.Emitter(new(T), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedEmitter[T]{em: em}, nil
+}
+
+// Emit emits the event onto the bus.
+func (e *TypedEmitter[T]) Emit(evt T) {
+	e.em.Emit(evt)
+}
+
+// Close closes the underlying emitter.
+func (e *TypedEmitter[T]) Close() error {
+	return e.em.Close()
+}
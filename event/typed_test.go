@@ -0,0 +1,41 @@
+//go:build go1.18
+// +build go1.18
+
+package event
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+func TestTypedEmitter(t *testing.T) {
+	bus := newTestBus()
+	sub, err := bus.Subscribe(new(EvtLocalProtocolsUpdated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	em, err := NewTypedEmitter[EvtLocalProtocolsUpdated](bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	em.Emit(EvtLocalProtocolsUpdated{Added: []protocol.ID{"/foo/1.0.0"}})
+	evt, ok := (<-sub.Out()).(EvtLocalProtocolsUpdated)
+	if !ok || len(evt.Added) != 1 || evt.Added[0] != "/foo/1.0.0" {
+		t.Fatalf("unexpected event %v", evt)
+	}
+
+	if err := em.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := bus.emittersClosed(); n != 1 {
+		t.Fatalf("expected the underlying emitter to be closed, got %d closes", n)
+	}
+
+	// Errors creating the underlying emitter are returned.
+	if _, err := NewTypedEmitter[int](bus); err == nil {
+		t.Fatal("expected the bus to reject a non-struct event type")
+	}
+}
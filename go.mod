@@ -1,5 +1,7 @@
 module github.com/libp2p/go-libp2p-core
 
+go 1.13
+
 require (
 	github.com/btcsuite/btcd v0.0.0-20190523000118-16327141da8c
 	github.com/coreos/go-semver v0.3.0
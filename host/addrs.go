@@ -0,0 +1,83 @@
+package host
+
+import (
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// AddrSource describes how the host learned one of its addresses.
+type AddrSource int
+
+const (
+	// AddrSourceUnknown is used when the host doesn't track address sources.
+	AddrSourceUnknown AddrSource = iota
+	// AddrSourceListen is an address the host listens on.
+	AddrSourceListen
+	// AddrSourceObserved is an address other peers reported observing us on.
+	AddrSourceObserved
+	// AddrSourceNATMapped is an address obtained by mapping a port on the
+	// NAT device (UPnP, NAT-PMP).
+	AddrSourceNATMapped
+	// AddrSourceStatic is an address configured by the user.
+	AddrSourceStatic
+)
+
+func (s AddrSource) String() string {
+	switch s {
+	case AddrSourceListen:
+		return "listen"
+	case AddrSourceObserved:
+		return "observed"
+	case AddrSourceNATMapped:
+		return "nat-mapped"
+	case AddrSourceStatic:
+		return "static"
+	default:
+		return "unknown"
+	}
+}
+
+// AddrWithConfidence is an advertised address along with how much the host
+// trusts it to be reachable by other peers.
+type AddrWithConfidence struct {
+	Addr ma.Multiaddr
+	// Confidence ranges from 0 (pure guess) to 1 (confirmed reachable).
+	Confidence float64
+	Source     AddrSource
+}
+
+// AddrConfidenceHost is implemented by hosts that track where their addresses
+// come from and how reliable they are.
+type AddrConfidenceHost interface {
+	Host
+
+	// AddrsWithConfidence returns the same addresses as Addrs, annotated
+	// with their confidence and source.
+	AddrsWithConfidence() []AddrWithConfidence
+}
+
+// AddrsWithConfidence returns the host's advertised addresses annotated with
+// their confidence. If the host doesn't implement AddrConfidenceHost, every
+// address is returned with an unknown source and a confidence of 1.
+func AddrsWithConfidence(h Host) []AddrWithConfidence {
+	if ch, ok := h.(AddrConfidenceHost); ok {
+		return ch.AddrsWithConfidence()
+	}
+	addrs := h.Addrs()
+	out := make([]AddrWithConfidence, 0, len(addrs))
+	for _, a := range addrs {
+		out = append(out, AddrWithConfidence{Addr: a, Confidence: 1})
+	}
+	return out
+}
+
+// FilterAddrsByConfidence returns the addresses whose confidence is at least
+// min.
+func FilterAddrsByConfidence(addrs []AddrWithConfidence, min float64) []ma.Multiaddr {
+	var out []ma.Multiaddr
+	for _, a := range addrs {
+		if a.Confidence >= min {
+			out = append(out, a.Addr)
+		}
+	}
+	return out
+}
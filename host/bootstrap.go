@@ -0,0 +1,355 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+var (
+	// DefaultBootstrapCheckInterval is how often a bootstrap manager
+	// created with a zero CheckInterval checks its connections.
+	DefaultBootstrapCheckInterval = 10 * time.Second
+
+	// DefaultBootstrapDialTimeout is the timeout of the connection attempts
+	// of a bootstrap manager created with a zero DialTimeout.
+	DefaultBootstrapDialTimeout = 15 * time.Second
+
+	// DefaultReconnectBase is the base delay of ExponentialReconnect
+	// policies with a zero Base.
+	DefaultReconnectBase = time.Second
+)
+
+// ErrBootstrapManagerClosed is returned when starting a closed
+// BootstrapManager.
+var ErrBootstrapManagerClosed = errors.New("bootstrap manager closed")
+
+// ReconnectPolicy decides when to retry a bootstrap peer after failed
+// connection attempts.
+type ReconnectPolicy interface {
+	// Delay returns how long to wait after the given number of consecutive
+	// failed attempts, or false to give up on the peer.
+	Delay(attempts int) (time.Duration, bool)
+}
+
+// ExponentialReconnect is a ReconnectPolicy doubling the delay after each
+// failure, from Base (DefaultReconnectBase if zero) up to Max (without bound
+// if zero). It gives up after MaxAttempts failures, unless MaxAttempts is 0.
+type ExponentialReconnect struct {
+	Base, Max   time.Duration
+	MaxAttempts int
+}
+
+// maxReconnectDelay bounds the delays of ExponentialReconnect policies
+// without Max, so doubling doesn't overflow.
+const maxReconnectDelay = time.Duration(1<<63-1) / 2
+
+// Delay implements ReconnectPolicy.
+func (r ExponentialReconnect) Delay(attempts int) (time.Duration, bool) {
+	if r.MaxAttempts > 0 && attempts >= r.MaxAttempts {
+		return 0, false
+	}
+	max := r.Max
+	if max <= 0 {
+		max = maxReconnectDelay
+	}
+	delay := r.Base
+	if delay <= 0 {
+		delay = DefaultReconnectBase
+	}
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay, true
+}
+
+// DefaultReconnectPolicy is used by bootstrap managers created without a
+// policy. It never gives up.
+var DefaultReconnectPolicy ReconnectPolicy = ExponentialReconnect{Base: time.Second, Max: 5 * time.Minute}
+
+// BootstrapConfig configures a BootstrapManager.
+type BootstrapConfig struct {
+	// Peers are the bootstrap (or static) peers.
+	Peers []peer.AddrInfo
+	// MinConnected is the number of bootstrap peers to stay connected to.
+	// Zero means all of them.
+	MinConnected int
+	// Policy decides when to retry failing peers. Nil means
+	// DefaultReconnectPolicy.
+	Policy ReconnectPolicy
+
+	CheckInterval time.Duration
+	DialTimeout   time.Duration
+
+	// Clock is the source of time of the manager. Nil means
+	// network.RealClock.
+	Clock network.Clock
+}
+
+// BootstrapManager keeps a host connected to a minimum number of bootstrap
+// peers, reconnecting according to a ReconnectPolicy. It emits
+// event.EvtBootstrapHealthChanged and event.EvtBootstrapPeerFailed on the
+// host's event bus.
+type BootstrapManager interface {
+	// Start starts maintaining the connections, until Close is called. It
+	// returns ErrBootstrapManagerClosed after Close.
+	Start() error
+
+	// Peers returns the bootstrap peers.
+	Peers() []peer.AddrInfo
+
+	// SetPeers replaces the bootstrap peers, e.g. after a configuration
+	// reload. The failure counts of the peers kept are preserved, except
+	// for the peers given up on, which are retried.
+	SetPeers([]peer.AddrInfo)
+
+	// Connected returns the number of bootstrap peers currently connected.
+	Connected() int
+
+	// Close stops maintaining the connections. It doesn't close them.
+	Close() error
+}
+
+type bootstrapPeer struct {
+	failures int
+	next     time.Time
+	gaveUp   bool
+}
+
+type bootstrapManager struct {
+	h   Host
+	cfg BootstrapConfig
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	emitHealth, emitFailed event.Emitter
+
+	lk      sync.Mutex
+	peers   []peer.AddrInfo
+	state   map[peer.ID]*bootstrapPeer
+	healthy bool
+	started bool
+	closed  bool
+}
+
+// NewBootstrapManager creates a BootstrapManager for the host.
+func NewBootstrapManager(h Host, cfg BootstrapConfig) BootstrapManager {
+	if cfg.Policy == nil {
+		cfg.Policy = DefaultReconnectPolicy
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = DefaultBootstrapCheckInterval
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = DefaultBootstrapDialTimeout
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = network.RealClock
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &bootstrapManager{
+		h:      h,
+		cfg:    cfg,
+		ctx:    ctx,
+		cancel: cancel,
+		state:  make(map[peer.ID]*bootstrapPeer),
+	}
+	m.SetPeers(cfg.Peers)
+	return m
+}
+
+func (m *bootstrapManager) Start() error {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	if m.closed {
+		return ErrBootstrapManagerClosed
+	}
+	if m.started {
+		return nil
+	}
+	var err error
+	if m.emitHealth, err = m.h.EventBus().Emitter(new(event.EvtBootstrapHealthChanged)); err != nil {
+		return err
+	}
+	if m.emitFailed, err = m.h.EventBus().Emitter(new(event.EvtBootstrapPeerFailed)); err != nil {
+		m.emitHealth.Close()
+		return err
+	}
+	m.started = true
+	m.wg.Add(1)
+	go m.loop()
+	return nil
+}
+
+func (m *bootstrapManager) Peers() []peer.AddrInfo {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	return append([]peer.AddrInfo(nil), m.peers...)
+}
+
+func (m *bootstrapManager) SetPeers(peers []peer.AddrInfo) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	m.peers = append([]peer.AddrInfo(nil), peers...)
+	state := make(map[peer.ID]*bootstrapPeer, len(peers))
+	for _, pi := range peers {
+		if s, ok := m.state[pi.ID]; ok && !s.gaveUp {
+			state[pi.ID] = s
+		} else {
+			state[pi.ID] = new(bootstrapPeer)
+		}
+	}
+	m.state = state
+}
+
+func (m *bootstrapManager) Connected() int {
+	n := 0
+	for _, pi := range m.Peers() {
+		if m.h.Network().Connectedness(pi.ID) == network.Connected {
+			n++
+		}
+	}
+	return n
+}
+
+func (m *bootstrapManager) Close() error {
+	m.cancel()
+	m.wg.Wait()
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	m.closed = true
+	if m.started {
+		m.emitHealth.Close()
+		m.emitFailed.Close()
+		m.started = false
+	}
+	return nil
+}
+
+func (m *bootstrapManager) loop() {
+	defer m.wg.Done()
+	timer := m.cfg.Clock.NewTimer(m.cfg.CheckInterval)
+	defer timer.Stop()
+	for {
+		m.check()
+		select {
+		case <-timer.C():
+			timer.Reset(m.cfg.CheckInterval)
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+// target returns the minimum number of connected peers, given the number of
+// bootstrap peers.
+func (m *bootstrapManager) target(n int) int {
+	if m.cfg.MinConnected <= 0 || m.cfg.MinConnected > n {
+		return n
+	}
+	return m.cfg.MinConnected
+}
+
+// check connects to the disconnected peers that are due for an attempt, if
+// fewer than the target are connected, then reports the health.
+func (m *bootstrapManager) check() {
+	peers := m.Peers()
+	var disconnected []peer.AddrInfo
+	for _, pi := range peers {
+		if m.h.Network().Connectedness(pi.ID) != network.Connected {
+			disconnected = append(disconnected, pi)
+		} else {
+			// Connected by other means, e.g. an inbound connection.
+			m.reset(pi.ID)
+		}
+	}
+	if len(peers)-len(disconnected) < m.target(len(peers)) {
+		now := m.cfg.Clock.Now()
+		var wg sync.WaitGroup
+		for _, pi := range disconnected {
+			if !m.due(pi.ID, now) {
+				continue
+			}
+			wg.Add(1)
+			go func(pi peer.AddrInfo) {
+				defer wg.Done()
+				m.connect(pi)
+			}(pi)
+		}
+		wg.Wait()
+	}
+
+	connected := m.Connected()
+	target := m.target(len(peers))
+	healthy := connected >= target
+
+	m.lk.Lock()
+	changed := healthy != m.healthy
+	m.healthy = healthy
+	m.lk.Unlock()
+	if changed {
+		m.emitHealth.Emit(event.EvtBootstrapHealthChanged{
+			Connected: connected,
+			Target:    target,
+			Healthy:   healthy,
+		})
+	}
+}
+
+func (m *bootstrapManager) due(p peer.ID, now time.Time) bool {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	s, ok := m.state[p]
+	return ok && !s.gaveUp && !now.Before(s.next)
+}
+
+// reset clears the failures of the peer.
+func (m *bootstrapManager) reset(p peer.ID) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	if s, ok := m.state[p]; ok {
+		*s = bootstrapPeer{}
+	}
+}
+
+func (m *bootstrapManager) connect(pi peer.AddrInfo) {
+	ctx, cancel := context.WithTimeout(m.ctx, m.cfg.DialTimeout)
+	defer cancel()
+	err := m.h.Connect(ctx, pi)
+	if m.ctx.Err() != nil {
+		return
+	}
+
+	m.lk.Lock()
+	s, ok := m.state[pi.ID]
+	if !ok {
+		// Removed by SetPeers meanwhile.
+		m.lk.Unlock()
+		return
+	}
+	if err == nil {
+		*s = bootstrapPeer{}
+		m.lk.Unlock()
+		return
+	}
+	s.failures++
+	evt := event.EvtBootstrapPeerFailed{Peer: pi.ID, Attempts: s.failures, Err: err}
+	if delay, retry := m.cfg.Policy.Delay(s.failures); retry {
+		s.next = m.cfg.Clock.Now().Add(delay)
+		evt.NextAttempt = s.next
+	} else {
+		s.gaveUp = true
+	}
+	m.lk.Unlock()
+	m.emitFailed.Emit(evt)
+}
@@ -0,0 +1,211 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// fakeNetwork tracks the connectedness of peers.
+type fakeNetwork struct {
+	network.Network
+
+	lk        sync.Mutex
+	connected map[peer.ID]bool
+}
+
+func newFakeNetwork() *fakeNetwork {
+	return &fakeNetwork{connected: make(map[peer.ID]bool)}
+}
+
+func (n *fakeNetwork) Connectedness(p peer.ID) network.Connectedness {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	if n.connected[p] {
+		return network.Connected
+	}
+	return network.NotConnected
+}
+
+func (n *fakeNetwork) setConnected(p peer.ID, connected bool) {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	n.connected[p] = connected
+}
+
+// chanBus delivers every emitted event on a channel.
+type chanBus struct {
+	events chan interface{}
+}
+
+type chanEmitter struct {
+	events chan interface{}
+}
+
+func (e chanEmitter) Emit(evt interface{}) { e.events <- evt }
+func (e chanEmitter) Close() error         { return nil }
+
+func (b *chanBus) Subscribe(interface{}, ...event.SubscriptionOpt) (event.Subscription, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (b *chanBus) Emitter(interface{}, ...event.EmitterOpt) (event.Emitter, error) {
+	return chanEmitter{b.events}, nil
+}
+
+// fakeHost connects with its connect function.
+type fakeHost struct {
+	Host
+	net     *fakeNetwork
+	bus     *chanBus
+	connect func(context.Context, peer.AddrInfo) error
+}
+
+func newFakeHost(connect func(context.Context, peer.AddrInfo) error) *fakeHost {
+	return &fakeHost{
+		net:     newFakeNetwork(),
+		bus:     &chanBus{events: make(chan interface{}, 16)},
+		connect: connect,
+	}
+}
+
+func (h *fakeHost) Network() network.Network { return h.net }
+func (h *fakeHost) EventBus() event.Bus      { return h.bus }
+func (h *fakeHost) Connect(ctx context.Context, pi peer.AddrInfo) error {
+	return h.connect(ctx, pi)
+}
+
+func TestExponentialReconnect(t *testing.T) {
+	r := ExponentialReconnect{Base: time.Second, Max: 5 * time.Second, MaxAttempts: 10}
+	for attempts, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second} {
+		if d, ok := r.Delay(attempts); !ok || d != want {
+			t.Fatalf("attempt %d: expected %s, got %s", attempts, want, d)
+		}
+	}
+	if _, ok := r.Delay(10); ok {
+		t.Fatal("expected to give up after MaxAttempts")
+	}
+
+	// Without Max, the delay keeps doubling without overflowing.
+	unbounded := ExponentialReconnect{Base: time.Second}
+	if d, _ := unbounded.Delay(10); d != 512*time.Second {
+		t.Fatalf("expected 512s, got %s", d)
+	}
+	if d, _ := unbounded.Delay(1000); d <= 0 {
+		t.Fatalf("expected a positive delay, got %s", d)
+	}
+	// Without Base, the delay starts at DefaultReconnectBase.
+	if d, _ := (ExponentialReconnect{}).Delay(1); d != DefaultReconnectBase {
+		t.Fatalf("expected %s, got %s", DefaultReconnectBase, d)
+	}
+}
+
+func TestBootstrapManager(t *testing.T) {
+	a, b := peer.ID("a"), peer.ID("b")
+	dials := make(chan peer.ID, 16)
+	var (
+		lk      sync.Mutex
+		failB   = true
+		errDial = errors.New("dial failed")
+	)
+	var h *fakeHost
+	h = newFakeHost(func(_ context.Context, pi peer.AddrInfo) error {
+		dials <- pi.ID
+		lk.Lock()
+		defer lk.Unlock()
+		if pi.ID == b && failB {
+			return errDial
+		}
+		h.net.setConnected(pi.ID, true)
+		return nil
+	})
+	clock := network.NewMockClock(time.Now())
+	m := NewBootstrapManager(h, BootstrapConfig{
+		Peers:         []peer.AddrInfo{{ID: a}, {ID: b}},
+		Policy:        ExponentialReconnect{Base: time.Minute, MaxAttempts: 2},
+		CheckInterval: 10 * time.Second,
+		Clock:         clock,
+	})
+
+	nextEvent := func() interface{} {
+		select {
+		case evt := <-h.bus.events:
+			return evt
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an event")
+			return nil
+		}
+	}
+	// waitDial advances the clock until p is dialed, and returns the time
+	// it took.
+	waitDial := func(p peer.ID) time.Duration {
+		start := clock.Now()
+		for i := 0; i < 100; i++ {
+			select {
+			case got := <-dials:
+				if got != p {
+					t.Fatalf("unexpected dial of %s", got)
+				}
+				return clock.Now().Sub(start)
+			case <-time.After(5 * time.Millisecond):
+				clock.Advance(10 * time.Second)
+			}
+		}
+		t.Fatalf("%s wasn't dialed", p)
+		return 0
+	}
+
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	first := map[peer.ID]bool{<-dials: true, <-dials: true}
+	if !first[a] || !first[b] {
+		t.Fatalf("expected both peers to be dialed, got %v", first)
+	}
+	evt, ok := nextEvent().(event.EvtBootstrapPeerFailed)
+	if !ok || evt.Peer != b || evt.Attempts != 1 || !evt.NextAttempt.Equal(clock.Now().Add(time.Minute)) {
+		t.Fatalf("unexpected event %+v", evt)
+	}
+
+	// b is retried after the backoff, then given up on.
+	if d := waitDial(b); d < time.Minute {
+		t.Fatalf("expected b to be retried after a minute, got %s", d)
+	}
+	if evt := nextEvent().(event.EvtBootstrapPeerFailed); evt.Attempts != 2 || !evt.NextAttempt.IsZero() {
+		t.Fatalf("expected to give up on b, got %+v", evt)
+	}
+	for i := 0; i < 20; i++ {
+		clock.Advance(time.Minute)
+		select {
+		case p := <-dials:
+			t.Fatalf("unexpected dial of %s after giving up", p)
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	// Reloading the peers retries the peers given up on.
+	lk.Lock()
+	failB = false
+	lk.Unlock()
+	m.SetPeers([]peer.AddrInfo{{ID: a}, {ID: b}})
+	waitDial(b)
+	if evt, ok := nextEvent().(event.EvtBootstrapHealthChanged); !ok || !evt.Healthy || evt.Connected != 2 {
+		t.Fatalf("unexpected event %+v", evt)
+	}
+	if n := m.Connected(); n != 2 {
+		t.Fatalf("expected 2 connected peers, got %d", n)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Start(); err != ErrBootstrapManagerClosed {
+		t.Fatalf("expected ErrBootstrapManagerClosed, got %v", err)
+	}
+}
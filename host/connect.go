@@ -0,0 +1,99 @@
+package host
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// pCircuit is the multiaddr code of /p2p-circuit, registered by the relay
+// transport.
+const pCircuit = 0x0122
+
+// AddrAttempt is a failed dial to one of the addresses of a peer.
+type AddrAttempt struct {
+	Addr     ma.Multiaddr
+	Err      error
+	Duration time.Duration
+}
+
+// ConnectResult describes how a connection to a peer was established.
+type ConnectResult struct {
+	// Addr is the remote address of the connection, or nil if the
+	// connection failed.
+	Addr ma.Multiaddr
+	// Failed lists the addresses that were dialed unsuccessfully, if known.
+	Failed []AddrAttempt
+
+	// Relayed is true if the connection goes through a relay.
+	Relayed bool
+	// HolePunched is true if the connection was established by hole
+	// punching.
+	HolePunched bool
+	// Existing is true if the host was already connected to the peer.
+	Existing bool
+
+	// Duration is the total time taken to connect.
+	Duration time.Duration
+}
+
+// DetailedConnector is implemented by hosts that can report how connections
+// were established.
+type DetailedConnector interface {
+	// ConnectDetailed is like Host.Connect, but also describes the
+	// connection attempt. The result is returned even if the connection
+	// fails.
+	ConnectDetailed(ctx context.Context, pi peer.AddrInfo) (*ConnectResult, error)
+}
+
+// ConnectDetailed connects the host to the peer, and describes how. If the
+// host doesn't implement DetailedConnector, the failed addresses and whether
+// hole punching was used are unknown; the address and relaying are deduced
+// from the connection.
+func ConnectDetailed(ctx context.Context, h Host, pi peer.AddrInfo) (*ConnectResult, error) {
+	return ConnectDetailedWithClock(ctx, h, pi, network.RealClock)
+}
+
+// ConnectDetailedWithClock is like ConnectDetailed, measuring the duration
+// of the connection with the given clock when the host doesn't implement
+// DetailedConnector.
+func ConnectDetailedWithClock(ctx context.Context, h Host, pi peer.AddrInfo, c network.Clock) (*ConnectResult, error) {
+	if dc, ok := h.(DetailedConnector); ok {
+		return dc.ConnectDetailed(ctx, pi)
+	}
+
+	res := &ConnectResult{Existing: len(h.Network().ConnsToPeer(pi.ID)) > 0}
+	start := c.Now()
+	err := h.Connect(ctx, pi)
+	res.Duration = c.Now().Sub(start)
+	if err != nil {
+		return res, err
+	}
+
+	// Report the newest connection, as the one most likely to have been
+	// established by this call.
+	var opened time.Time
+	for _, c := range h.Network().ConnsToPeer(pi.ID) {
+		if t := c.Stat().Opened; res.Addr == nil || t.After(opened) {
+			res.Addr, opened = c.RemoteMultiaddr(), t
+		}
+	}
+	if res.Addr != nil {
+		res.Relayed = IsRelayAddr(res.Addr)
+	}
+	return res, nil
+}
+
+// IsRelayAddr returns true if the address goes through a relay.
+func IsRelayAddr(a ma.Multiaddr) bool {
+	for _, p := range a.Protocols() {
+		if p.Code == pCircuit {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func init() {
+	// Older multiaddr releases don't know /p2p-circuit.
+	if ma.ProtocolWithCode(pCircuit).Code == 0 {
+		if err := ma.AddProtocol(ma.Protocol{
+			Name:  "p2p-circuit",
+			Code:  pCircuit,
+			VCode: ma.CodeToVarint(pCircuit),
+		}); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// addrConn is a connection opened at a given time to an address.
+type addrConn struct {
+	network.Conn
+	addr   ma.Multiaddr
+	opened time.Time
+}
+
+func (c *addrConn) RemoteMultiaddr() ma.Multiaddr { return c.addr }
+func (c *addrConn) Stat() network.Stat            { return network.Stat{Opened: c.opened} }
+
+// connsNetwork lists the connections to each peer.
+type connsNetwork struct {
+	network.Network
+
+	lk    sync.Mutex
+	conns map[peer.ID][]network.Conn
+}
+
+func (n *connsNetwork) ConnsToPeer(p peer.ID) []network.Conn {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	return append([]network.Conn(nil), n.conns[p]...)
+}
+
+func (n *connsNetwork) addConn(p peer.ID, c network.Conn) {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	n.conns[p] = append(n.conns[p], c)
+}
+
+type connsHost struct {
+	Host
+	net     *connsNetwork
+	connect func(context.Context, peer.AddrInfo) error
+}
+
+func newConnsHost(connect func(context.Context, peer.AddrInfo) error) *connsHost {
+	return &connsHost{net: &connsNetwork{conns: make(map[peer.ID][]network.Conn)}, connect: connect}
+}
+
+func (h *connsHost) Network() network.Network { return h.net }
+func (h *connsHost) Connect(ctx context.Context, pi peer.AddrInfo) error {
+	return h.connect(ctx, pi)
+}
+
+// detailedHost implements DetailedConnector.
+type detailedHost struct {
+	Host
+	res *ConnectResult
+}
+
+func (h *detailedHost) ConnectDetailed(context.Context, peer.AddrInfo) (*ConnectResult, error) {
+	return h.res, nil
+}
+
+func TestConnectDetailed(t *testing.T) {
+	direct := ma.StringCast("/ip4/1.2.3.4/tcp/1")
+	relayed := ma.StringCast("/ip4/5.6.7.8/tcp/1/p2p-circuit")
+	clk := network.NewMockClock(time.Now())
+	errDial := errors.New("dial failed")
+
+	var h *connsHost
+	h = newConnsHost(func(_ context.Context, pi peer.AddrInfo) error {
+		clk.Advance(2 * time.Second)
+		if pi.ID == "unreachable" {
+			return errDial
+		}
+		h.net.addConn(pi.ID, &addrConn{addr: direct, opened: clk.Now().Add(-time.Second)})
+		h.net.addConn(pi.ID, &addrConn{addr: relayed, opened: clk.Now()})
+		return nil
+	})
+
+	// The newest connection is reported.
+	res, err := ConnectDetailedWithClock(context.Background(), h, peer.AddrInfo{ID: "a"}, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Addr.Equal(relayed) || !res.Relayed || res.Existing || res.Duration != 2*time.Second {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	res, err = ConnectDetailedWithClock(context.Background(), h, peer.AddrInfo{ID: "a"}, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Existing {
+		t.Fatal("expected the connection to be reported as existing")
+	}
+
+	// The result is returned along with connection errors.
+	res, err = ConnectDetailedWithClock(context.Background(), h, peer.AddrInfo{ID: "unreachable"}, clk)
+	if err != errDial {
+		t.Fatalf("expected errDial, got %v", err)
+	}
+	if res == nil || res.Addr != nil || res.Duration != 2*time.Second {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+
+	// Hosts implementing DetailedConnector describe the attempt
+	// themselves.
+	want := &ConnectResult{Addr: direct, HolePunched: true}
+	if res, err := ConnectDetailed(context.Background(), &detailedHost{res: want}, peer.AddrInfo{ID: "a"}); err != nil || res != want {
+		t.Fatalf("expected the host's result, got %+v, %v", res, err)
+	}
+}
+
+func TestIsRelayAddr(t *testing.T) {
+	if IsRelayAddr(ma.StringCast("/ip4/1.2.3.4/tcp/1")) {
+		t.Fatal("expected a direct address")
+	}
+	if !IsRelayAddr(ma.StringCast("/ip4/1.2.3.4/tcp/1/p2p-circuit")) {
+		t.Fatal("expected a relay address")
+	}
+}
@@ -0,0 +1,208 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/record"
+)
+
+// DelegationDomain is the signature domain of delegation records.
+const DelegationDomain = "libp2p-delegation-record"
+
+// DelegationCodec is the payload type of delegation records.
+var DelegationCodec = []byte("/libp2p/delegation-record")
+
+// MaxDelegationDepth is the maximum length of the delegation chains accepted
+// by VerifyDelegation.
+var MaxDelegationDepth = 8
+
+var (
+	// ErrDelegationExpired is returned when a delegation isn't valid yet,
+	// or isn't anymore.
+	ErrDelegationExpired = errors.New("delegation expired or not yet valid")
+	// ErrDelegationAudience is returned when a delegation was issued to
+	// another peer than the one presenting it.
+	ErrDelegationAudience = errors.New("delegation issued to another peer")
+	// ErrDelegationCapability is returned when a delegation doesn't grant
+	// the required capability.
+	ErrDelegationCapability = errors.New("capability not delegated")
+	// ErrDelegationIssuer is returned when a delegation isn't signed by its
+	// issuer, or a proof doesn't delegate to the issuer.
+	ErrDelegationIssuer = errors.New("delegation not signed by its issuer")
+	// ErrDelegationTooDeep is returned when a delegation chain is longer
+	// than MaxDelegationDepth.
+	ErrDelegationTooDeep = errors.New("delegation chain too long")
+)
+
+func init() {
+	record.RegisterType(&DelegationRecord{})
+}
+
+// DelegationRecord allows a peer, the audience, to act on behalf of another
+// identity, the issuer, for a set of capabilities (UCAN-style). It's exchanged
+// sealed in a record.Envelope signed by the issuer.
+//
+// Delegations can be chained: an issuer acting itself on behalf of another
+// identity includes the delegations it was granted as proofs, and can only
+// delegate the capabilities they grant.
+type DelegationRecord struct {
+	Issuer   peer.ID
+	Audience peer.ID
+
+	// Capabilities lists the delegated capabilities, e.g. "/myapp/publish".
+	// A capability ending with "/*" grants all the capabilities it's a
+	// prefix of, and "*" grants all capabilities.
+	Capabilities []string
+
+	NotBefore time.Time `json:",omitempty"`
+	Expires   time.Time
+
+	// Proofs are the marshaled envelopes of the delegations to the issuer
+	// this delegation derives from. It's empty if the issuer delegates on
+	// its own behalf.
+	Proofs [][]byte `json:",omitempty"`
+}
+
+var _ record.Record = (*DelegationRecord)(nil)
+
+// Domain implements record.Record.
+func (r *DelegationRecord) Domain() string { return DelegationDomain }
+
+// Codec implements record.Record.
+func (r *DelegationRecord) Codec() []byte { return DelegationCodec }
+
+// MarshalRecord implements record.Record.
+func (r *DelegationRecord) MarshalRecord() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// UnmarshalRecord implements record.Record.
+func (r *DelegationRecord) UnmarshalRecord(data []byte) error {
+	return json.Unmarshal(data, r)
+}
+
+// Valid returns true if the delegation is valid at time now.
+func (r *DelegationRecord) Valid(now time.Time) bool {
+	return !now.Before(r.NotBefore) && now.Before(r.Expires)
+}
+
+// Grants returns true if the delegation grants the capability.
+func (r *DelegationRecord) Grants(capability string) bool {
+	for _, c := range r.Capabilities {
+		if c == capability || c == "*" {
+			return true
+		}
+		if strings.HasSuffix(c, "/*") && strings.HasPrefix(capability, c[:len(c)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delegate issues a delegation of the capabilities to the audience, valid for
+// ttl, signed with the issuer's key, and returns the marshaled envelope.
+// Proofs are the marshaled delegations to the issuer, if it acts on behalf of
+// another identity.
+func Delegate(key crypto.PrivKey, audience peer.ID, capabilities []string, ttl time.Duration, proofs ...[]byte) ([]byte, error) {
+	issuer, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	env, err := record.Seal(&DelegationRecord{
+		Issuer:       issuer,
+		Audience:     audience,
+		Capabilities: capabilities,
+		NotBefore:    now,
+		Expires:      now.Add(ttl),
+		Proofs:       proofs,
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+	return env.Marshal()
+}
+
+// VerifyDelegation verifies that the marshaled delegation allows the audience
+// to exercise the capability at time now, following the chain of proofs, and
+// returns the identity at the root of the chain, on whose behalf the audience
+// acts. Revocations aren't checked, see VerifyCheckedDelegation.
+func VerifyDelegation(data []byte, audience peer.ID, capability string, now time.Time) (peer.ID, error) {
+	return VerifyCheckedDelegation(data, audience, capability, now, nil)
+}
+
+// VerifyCheckedDelegation is VerifyDelegation, also rejecting the chains with
+// a delegation revoked according to the checker, with record.ErrRevoked. A
+// nil checker disables revocation checks.
+func VerifyCheckedDelegation(data []byte, audience peer.ID, capability string, now time.Time, c record.RevocationChecker) (peer.ID, error) {
+	return verifyDelegation(data, audience, capability, now, c, 0)
+}
+
+func verifyDelegation(data []byte, audience peer.ID, capability string, now time.Time, c record.RevocationChecker, depth int) (peer.ID, error) {
+	if depth >= MaxDelegationDepth {
+		return "", ErrDelegationTooDeep
+	}
+	rec := new(DelegationRecord)
+	env, err := record.ConsumeCheckedTypedEnvelope(data, rec, c)
+	if err != nil {
+		return "", err
+	}
+	if !rec.Issuer.MatchesPublicKey(env.PublicKey) {
+		return "", ErrDelegationIssuer
+	}
+	if rec.Audience != audience {
+		return "", ErrDelegationAudience
+	}
+	if !rec.Valid(now) {
+		return "", ErrDelegationExpired
+	}
+	if !rec.Grants(capability) {
+		return "", ErrDelegationCapability
+	}
+	if len(rec.Proofs) == 0 {
+		return rec.Issuer, nil
+	}
+
+	// The issuer must itself hold the capability through one of its
+	// proofs.
+	var errs []string
+	cause := ErrDelegationIssuer
+	for _, proof := range rec.Proofs {
+		root, err := verifyDelegation(proof, rec.Issuer, capability, now, c, depth+1)
+		if err == nil {
+			return root, nil
+		}
+		if err == ErrDelegationTooDeep {
+			return "", err
+		}
+		if errors.Is(err, record.ErrRevoked) {
+			// Report revoked chains as such.
+			cause = record.ErrRevoked
+		}
+		errs = append(errs, err.Error())
+	}
+	return "", fmt.Errorf("%w: no valid proof: %s", cause, strings.Join(errs, "; "))
+}
+
+// VerifyStreamDelegation verifies that the marshaled delegation, presented by
+// the remote peer of the stream, allows it to exercise the capability, and
+// returns the identity it acts on behalf of. Stream handlers use it to
+// authorize peers acting for other identities. Revocations aren't checked,
+// see VerifyCheckedStreamDelegation.
+func VerifyStreamDelegation(s network.Stream, data []byte, capability string) (peer.ID, error) {
+	return VerifyCheckedStreamDelegation(s, data, capability, nil)
+}
+
+// VerifyCheckedStreamDelegation is VerifyStreamDelegation, also rejecting the
+// chains with a delegation revoked according to the checker. A nil checker
+// disables revocation checks.
+func VerifyCheckedStreamDelegation(s network.Stream, data []byte, capability string, c record.RevocationChecker) (peer.ID, error) {
+	return VerifyCheckedDelegation(data, s.Conn().RemotePeer(), capability, time.Now(), c)
+}
@@ -0,0 +1,185 @@
+package host
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/record"
+)
+
+func genIdentity(t *testing.T) (crypto.PrivKey, peer.ID) {
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sk, id
+}
+
+func delegate(t *testing.T, key crypto.PrivKey, audience peer.ID, caps []string, proofs ...[]byte) []byte {
+	data, err := Delegate(key, audience, caps, time.Hour, proofs...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDelegationChain(t *testing.T) {
+	rootSk, root := genIdentity(t)
+	midSk, mid := genIdentity(t)
+	_, leaf := genIdentity(t)
+
+	toMid := delegate(t, rootSk, mid, []string{"/app/*"})
+	toLeaf := delegate(t, midSk, leaf, []string{"/app/publish"}, toMid)
+	now := time.Now()
+
+	got, err := VerifyDelegation(toLeaf, leaf, "/app/publish", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != root {
+		t.Fatalf("expected the chain to act on behalf of %s, got %s", root, got)
+	}
+	if _, err := VerifyDelegation(toLeaf, leaf, "/app/subscribe", now); !errors.Is(err, ErrDelegationCapability) {
+		t.Fatalf("expected ErrDelegationCapability, got %v", err)
+	}
+	if _, err := VerifyDelegation(toLeaf, mid, "/app/publish", now); !errors.Is(err, ErrDelegationAudience) {
+		t.Fatalf("expected ErrDelegationAudience, got %v", err)
+	}
+}
+
+func TestDelegationValidity(t *testing.T) {
+	issuerSk, _ := genIdentity(t)
+	_, audience := genIdentity(t)
+	now := time.Now()
+
+	d := delegate(t, issuerSk, audience, []string{"*"})
+	if _, err := VerifyDelegation(d, audience, "/any", now.Add(-time.Minute)); !errors.Is(err, ErrDelegationExpired) {
+		t.Fatalf("expected a not yet valid delegation to be rejected, got %v", err)
+	}
+	if _, err := VerifyDelegation(d, audience, "/any", now.Add(2*time.Hour)); !errors.Is(err, ErrDelegationExpired) {
+		t.Fatalf("expected an expired delegation to be rejected, got %v", err)
+	}
+
+	// An expired proof invalidates the chain.
+	midSk, mid := genIdentity(t)
+	expired, err := Delegate(issuerSk, mid, []string{"*"}, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chained := delegate(t, midSk, audience, []string{"*"}, expired)
+	if _, err := VerifyDelegation(chained, audience, "/any", now.Add(time.Second)); !errors.Is(err, ErrDelegationIssuer) {
+		t.Fatalf("expected the expired proof to be rejected, got %v", err)
+	}
+}
+
+func TestDelegationEscalation(t *testing.T) {
+	rootSk, _ := genIdentity(t)
+	midSk, mid := genIdentity(t)
+	_, leaf := genIdentity(t)
+
+	// mid only holds "/a/*", so it can't delegate "/b".
+	toMid := delegate(t, rootSk, mid, []string{"/a/*"})
+	toLeaf := delegate(t, midSk, leaf, []string{"/b", "/a/x"}, toMid)
+	if _, err := VerifyDelegation(toLeaf, leaf, "/b", time.Now()); !errors.Is(err, ErrDelegationIssuer) {
+		t.Fatalf("expected the escalation to be rejected, got %v", err)
+	}
+	if _, err := VerifyDelegation(toLeaf, leaf, "/a/x", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	// "/a/*" doesn't grant capabilities merely sharing its prefix.
+	if (&DelegationRecord{Capabilities: []string{"/a/*"}}).Grants("/ab") {
+		t.Fatal("expected /a/* not to grant /ab")
+	}
+}
+
+func TestDelegationForgedIssuer(t *testing.T) {
+	_, victim := genIdentity(t)
+	attackerSk, _ := genIdentity(t)
+	_, audience := genIdentity(t)
+
+	// The attacker claims to be the victim, signing with its own key.
+	env, err := record.Seal(&DelegationRecord{
+		Issuer:       victim,
+		Audience:     audience,
+		Capabilities: []string{"*"},
+		Expires:      time.Now().Add(time.Hour),
+	}, attackerSk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := env.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyDelegation(data, audience, "/any", time.Now()); !errors.Is(err, ErrDelegationIssuer) {
+		t.Fatalf("expected ErrDelegationIssuer, got %v", err)
+	}
+}
+
+func TestDelegationDepth(t *testing.T) {
+	sk, _ := genIdentity(t)
+	var proof [][]byte
+	var last []byte
+	var audience peer.ID
+	for i := 0; i <= MaxDelegationDepth; i++ {
+		nextSk, next := genIdentity(t)
+		last = delegate(t, sk, next, []string{"*"}, proof...)
+		proof = [][]byte{last}
+		sk, audience = nextSk, next
+		if i == MaxDelegationDepth-1 {
+			if _, err := VerifyDelegation(last, audience, "/any", time.Now()); err != nil {
+				t.Fatalf("expected a chain of %d delegations to be accepted, got %v", MaxDelegationDepth, err)
+			}
+		}
+	}
+	if _, err := VerifyDelegation(last, audience, "/any", time.Now()); !errors.Is(err, ErrDelegationTooDeep) {
+		t.Fatalf("expected ErrDelegationTooDeep, got %v", err)
+	}
+}
+
+func TestDelegationRevoked(t *testing.T) {
+	rootSk, _ := genIdentity(t)
+	midSk, mid := genIdentity(t)
+	_, leaf := genIdentity(t)
+
+	toMid := delegate(t, rootSk, mid, []string{"/app/*"})
+	toLeaf := delegate(t, midSk, leaf, []string{"/app/publish"}, toMid)
+	now := time.Now()
+
+	// The root revokes the delegation to mid, invalidating the chain.
+	e, err := record.UnmarshalEnvelope(toMid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := record.EnvelopeHash(e, DelegationDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev, err := record.Seal(&record.RevocationRecord{EnvelopeHash: h}, rootSk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revData, err := rev.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := record.NewRevocationList()
+	if err := list.Add(revData); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := VerifyCheckedDelegation(toLeaf, leaf, "/app/publish", now, list); !errors.Is(err, record.ErrRevoked) {
+		t.Fatalf("expected ErrRevoked, got %v", err)
+	}
+	if _, err := VerifyDelegation(toLeaf, leaf, "/app/publish", now); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,91 @@
+package host
+
+import (
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// HandlerOptions configures a stream handler registered with
+// SetStreamHandlerWithOptions.
+type HandlerOptions struct {
+	// MaxStreamsPerPeer limits the number of streams of the protocol each
+	// peer may have in flight, i.e. being handled. Streams over the limit
+	// are reset before the handler is invoked. Zero means no limit.
+	MaxStreamsPerPeer int
+}
+
+// HandlerOption is a single stream handler option.
+type HandlerOption func(*HandlerOptions)
+
+// Apply applies the given options to these HandlerOptions.
+func (o *HandlerOptions) Apply(opts ...HandlerOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// MaxStreamsPerPeer limits the number of concurrent in-flight streams per
+// peer.
+func MaxStreamsPerPeer(n int) HandlerOption {
+	return func(o *HandlerOptions) {
+		o.MaxStreamsPerPeer = n
+	}
+}
+
+// HandlerOptionsHost is implemented by hosts accepting options when
+// registering stream handlers, enforced in the host's stream setup.
+type HandlerOptionsHost interface {
+	Host
+
+	SetStreamHandlerWithOptions(pid protocol.ID, handler network.StreamHandler, opts ...HandlerOption)
+}
+
+// SetStreamHandlerWithOptions sets the protocol handler with the given
+// options. If the host doesn't implement HandlerOptionsHost, the handler is
+// wrapped with LimitStreamHandler instead.
+func SetStreamHandlerWithOptions(h Host, pid protocol.ID, handler network.StreamHandler, opts ...HandlerOption) {
+	if oh, ok := h.(HandlerOptionsHost); ok {
+		oh.SetStreamHandlerWithOptions(pid, handler, opts...)
+		return
+	}
+	var o HandlerOptions
+	o.Apply(opts...)
+	h.SetStreamHandler(pid, LimitStreamHandler(handler, o.MaxStreamsPerPeer))
+}
+
+// LimitStreamHandler wraps the handler so that each peer has at most
+// maxPerPeer streams being handled at once; a stream is in flight until the
+// handler returns. Streams over the limit are reset without invoking the
+// handler. A non-positive limit returns the handler unchanged.
+func LimitStreamHandler(handler network.StreamHandler, maxPerPeer int) network.StreamHandler {
+	if maxPerPeer <= 0 {
+		return handler
+	}
+	var (
+		lk       sync.Mutex
+		inflight = make(map[peer.ID]int)
+	)
+	return func(s network.Stream) {
+		p := s.Conn().RemotePeer()
+		lk.Lock()
+		if inflight[p] >= maxPerPeer {
+			lk.Unlock()
+			s.Reset()
+			return
+		}
+		inflight[p]++
+		lk.Unlock()
+
+		defer func() {
+			lk.Lock()
+			if inflight[p]--; inflight[p] == 0 {
+				delete(inflight, p)
+			}
+			lk.Unlock()
+		}()
+		handler(s)
+	}
+}
@@ -0,0 +1,90 @@
+package host
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+type fakeConn struct {
+	network.Conn
+	remote peer.ID
+}
+
+func (c *fakeConn) RemotePeer() peer.ID { return c.remote }
+
+// fakeStream records whether it was reset.
+type fakeStream struct {
+	network.Stream
+	conn  *fakeConn
+	reset int32
+}
+
+func newFakeStream(p peer.ID) *fakeStream {
+	return &fakeStream{conn: &fakeConn{remote: p}}
+}
+
+func (s *fakeStream) Conn() network.Conn { return s.conn }
+func (s *fakeStream) Reset() error {
+	atomic.StoreInt32(&s.reset, 1)
+	return nil
+}
+func (s *fakeStream) wasReset() bool { return atomic.LoadInt32(&s.reset) == 1 }
+
+func TestLimitStreamHandler(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan *fakeStream, 8)
+	handler := LimitStreamHandler(func(s network.Stream) {
+		started <- s.(*fakeStream)
+		<-release
+	}, 2)
+
+	handled := make(chan struct{}, 8)
+	handle := func(s *fakeStream) {
+		go func() {
+			handler(s)
+			handled <- struct{}{}
+		}()
+	}
+
+	// Two streams of peer a are acquired and held by the handler.
+	a1, a2 := newFakeStream("a"), newFakeStream("a")
+	handle(a1)
+	handle(a2)
+	<-started
+	<-started
+
+	// A third stream of peer a overflows and is reset without invoking
+	// the handler, while peer b has its own budget.
+	a3 := newFakeStream("a")
+	handler(a3)
+	if !a3.wasReset() {
+		t.Fatal("expected the stream over the limit to be reset")
+	}
+	b1 := newFakeStream("b")
+	handle(b1)
+	if s := <-started; s != b1 {
+		t.Fatal("expected the stream of another peer to be handled")
+	}
+
+	// Returning from the handler releases the slots.
+	close(release)
+	for i := 0; i < 3; i++ {
+		<-handled
+	}
+	for _, s := range []*fakeStream{a1, a2, b1} {
+		if s.wasReset() {
+			t.Fatal("expected streams within the limit not to be reset")
+		}
+	}
+	a4 := newFakeStream("a")
+	handler(a4)
+	if a4.wasReset() {
+		t.Fatal("expected the slots to be released when the handler returns")
+	}
+	if s := <-started; s != a4 {
+		t.Fatal("expected the stream to be handled")
+	}
+}
@@ -0,0 +1,165 @@
+package host
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// HealthProtocolID is the protocol orchestration systems use to health-check
+// libp2p nodes over libp2p itself.
+//
+// The protocol is a single exchange: the dialer opens a stream and the
+// listener writes its HealthReport, JSON-encoded, and closes the stream.
+const HealthProtocolID protocol.ID = "/libp2p/health/1.0.0"
+
+// MaxHealthReportSize is the maximum size of a HealthReport accepted by
+// ReadHealthReport.
+const MaxHealthReportSize = 64 << 10
+
+// DefaultHealthReportTimeout is the default time HealthHandler gives the
+// HealthReporter to produce a report.
+var DefaultHealthReportTimeout = 10 * time.Second
+
+// HealthStatus is the health of a node or one of its subsystems.
+type HealthStatus string
+
+const (
+	HealthOK        HealthStatus = "ok"
+	HealthDegraded  HealthStatus = "degraded"
+	HealthUnhealthy HealthStatus = "unhealthy"
+)
+
+func (s HealthStatus) severity() int {
+	switch s {
+	case HealthOK:
+		return 0
+	case HealthDegraded:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// SubsystemHealth is the health of a single subsystem (e.g. "dht", "relay").
+type SubsystemHealth struct {
+	Name    string
+	Status  HealthStatus
+	Message string `json:",omitempty"`
+}
+
+// HealthReport describes the health of a node.
+type HealthReport struct {
+	// Status is the overall status, usually the worst subsystem status.
+	Status HealthStatus
+	Uptime time.Duration
+	// Reachability is the node's reachability as far as it knows
+	// ("public", "private" or "unknown").
+	Reachability string `json:",omitempty"`
+	// ResourcePressure is the resource pressure level (see
+	// connmgr.PressureLevel).
+	ResourcePressure string `json:",omitempty"`
+
+	Subsystems []SubsystemHealth `json:",omitempty"`
+}
+
+// WorstStatus returns the worst of the subsystem statuses, or HealthOK if
+// there are none.
+func WorstStatus(subsystems []SubsystemHealth) HealthStatus {
+	worst := HealthOK
+	for _, s := range subsystems {
+		if s.Status.severity() > worst.severity() {
+			worst = s.Status
+		}
+	}
+	return worst
+}
+
+// HealthReporter produces the health report served by HealthHandler.
+type HealthReporter interface {
+	HealthReport(ctx context.Context) HealthReport
+}
+
+// HealthReporterFunc is a function implementing HealthReporter.
+type HealthReporterFunc func(ctx context.Context) HealthReport
+
+// HealthReport calls f(ctx).
+func (f HealthReporterFunc) HealthReport(ctx context.Context) HealthReport {
+	return f(ctx)
+}
+
+// HealthOptions configures the stream handler returned by HealthHandler.
+type HealthOptions struct {
+	// ReportTimeout bounds the context passed to the HealthReporter.
+	// Defaults to DefaultHealthReportTimeout.
+	ReportTimeout time.Duration
+}
+
+// HealthOption is a single HealthHandler option.
+type HealthOption func(*HealthOptions)
+
+// Apply applies the given options to these HealthOptions.
+func (o *HealthOptions) Apply(opts ...HealthOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// HealthReportTimeout sets the time the HealthReporter is given to produce a
+// report.
+func HealthReportTimeout(d time.Duration) HealthOption {
+	return func(o *HealthOptions) {
+		o.ReportTimeout = d
+	}
+}
+
+// HealthHandler returns a stream handler serving the reports of r. Register it
+// with:
+//
+//	h.SetStreamHandler(host.HealthProtocolID, host.HealthHandler(r))
+func HealthHandler(r HealthReporter, opts ...HealthOption) network.StreamHandler {
+	o := HealthOptions{ReportTimeout: DefaultHealthReportTimeout}
+	o.Apply(opts...)
+	if o.ReportTimeout <= 0 {
+		o.ReportTimeout = DefaultHealthReportTimeout
+	}
+	return func(s network.Stream) {
+		ctx, cancel := context.WithTimeout(context.Background(), o.ReportTimeout)
+		defer cancel()
+
+		report := r.HealthReport(ctx)
+		if err := json.NewEncoder(s).Encode(&report); err != nil {
+			s.Reset()
+			return
+		}
+		s.Close()
+	}
+}
+
+// ReadHealthReport reads a health report from the stream.
+func ReadHealthReport(s io.Reader) (*HealthReport, error) {
+	report := new(HealthReport)
+	if err := json.NewDecoder(io.LimitReader(s, MaxHealthReportSize)).Decode(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// CheckHealth asks the peer for its health report.
+func CheckHealth(ctx context.Context, h Host, p peer.ID) (*HealthReport, error) {
+	s, err := h.NewStream(ctx, p, HealthProtocolID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Reset()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		s.SetReadDeadline(deadline)
+	}
+	return ReadHealthReport(s)
+}
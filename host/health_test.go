@@ -0,0 +1,47 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+// bufFakeStream is a fakeStream buffering what's written to it.
+type bufFakeStream struct {
+	*fakeStream
+	buf bytes.Buffer
+}
+
+func (s *bufFakeStream) Write(b []byte) (int, error) { return s.buf.Write(b) }
+func (s *bufFakeStream) Close() error                { return nil }
+
+func TestHealthHandlerTimeout(t *testing.T) {
+	var got time.Duration
+	r := HealthReporterFunc(func(ctx context.Context) HealthReport {
+		deadline, _ := ctx.Deadline()
+		got = time.Until(deadline)
+		return HealthReport{Status: HealthOK}
+	})
+
+	for _, tc := range []struct {
+		opts []HealthOption
+		want time.Duration
+	}{
+		{nil, DefaultHealthReportTimeout},
+		{[]HealthOption{HealthReportTimeout(time.Minute)}, time.Minute},
+	} {
+		s := &bufFakeStream{fakeStream: newFakeStream("a")}
+		HealthHandler(r, tc.opts...)(s)
+		if got > tc.want || got < tc.want-time.Second {
+			t.Fatalf("expected a %s timeout, got %s", tc.want, got)
+		}
+		report, err := ReadHealthReport(&s.buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Status != HealthOK {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+	}
+}
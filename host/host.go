@@ -61,6 +61,8 @@ type Host interface {
 	// NewStream opens a new stream to given peer p, and writes a p2p/protocol
 	// header with given ProtocolID. If there is no connection to p, attempts
 	// to create one. If ProtocolID is "", writes no header.
+	// Stream options (negotiation timeout, size hints, ...) can be set on ctx
+	// with network.WithStreamOptions.
 	// (Threadsafe)
 	NewStream(ctx context.Context, p peer.ID, pids ...protocol.ID) (network.Stream, error)
 
@@ -0,0 +1,47 @@
+package host
+
+import (
+	"errors"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// ErrStopListeningNotSupported is returned by StopListening when the host
+// can't stop listening at runtime.
+var ErrStopListeningNotSupported = errors.New("host doesn't support removing listen addresses")
+
+// ListenHost is implemented by hosts that can add and remove listen
+// addresses at runtime, so nodes can follow interface changes (VPN up/down,
+// mobile network switch) without restarting.
+//
+// Hosts implementing it emit an event.EvtLocalListenAddrsUpdated after each
+// change.
+type ListenHost interface {
+	Host
+
+	// Listen starts listening on the given addresses.
+	Listen(addrs ...ma.Multiaddr) error
+
+	// StopListening closes the listeners of the given addresses. Addresses
+	// the host isn't listening on are ignored.
+	StopListening(addrs ...ma.Multiaddr) error
+}
+
+// Listen makes the host listen on the given addresses, using the host's
+// Network directly if it doesn't implement ListenHost.
+func Listen(h Host, addrs ...ma.Multiaddr) error {
+	if lh, ok := h.(ListenHost); ok {
+		return lh.Listen(addrs...)
+	}
+	return h.Network().Listen(addrs...)
+}
+
+// StopListening makes the host stop listening on the given addresses. It
+// returns ErrStopListeningNotSupported if the host doesn't implement
+// ListenHost.
+func StopListening(h Host, addrs ...ma.Multiaddr) error {
+	if lh, ok := h.(ListenHost); ok {
+		return lh.StopListening(addrs...)
+	}
+	return ErrStopListeningNotSupported
+}
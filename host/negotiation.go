@@ -0,0 +1,166 @@
+package host
+
+import (
+	"container/list"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// NegotiationCache remembers which protocol peers selected when offered a set
+// of protocols, so hosts can skip the multistream-select round trips when
+// opening further streams to well-known peers.
+//
+// Hosts consult the cache in NewStream before negotiating, and must
+// invalidate a peer's entries when its protocols change (see
+// InvalidateOnProtocolUpdates) or when a cached selection is refused.
+//
+// Implementations must be safe for concurrent use.
+type NegotiationCache interface {
+	// Lookup returns the protocol the peer selected when last offered the
+	// given set of protocols.
+	Lookup(p peer.ID, protos []protocol.ID) (protocol.ID, bool)
+
+	// Store records the protocol the peer selected among the given ones.
+	Store(p peer.ID, protos []protocol.ID, selected protocol.ID)
+
+	// Invalidate forgets all the selections of the peer.
+	Invalidate(p peer.ID)
+}
+
+// protocolSetKey returns a key identifying the set of protocols, whatever
+// their order.
+func protocolSetKey(protos []protocol.ID) string {
+	ss := make([]string, len(protos))
+	for i, p := range protos {
+		ss[i] = string(p)
+	}
+	sort.Strings(ss)
+	return strings.Join(ss, "\n")
+}
+
+// DefaultNegotiationCachePeers is the number of peers remembered by
+// negotiation caches created with a non-positive size.
+const DefaultNegotiationCachePeers = 1024
+
+// MemoryNegotiationCache is a NegotiationCache kept in memory. It remembers
+// the selections of a bounded number of peers, evicting the least recently
+// used ones; use InvalidateOnDisconnect to also forget peers as soon as they
+// disconnect.
+type MemoryNegotiationCache struct {
+	size int
+
+	lk      sync.Mutex
+	entries map[peer.ID]*list.Element
+	lru     *list.List
+}
+
+type negotiationEntry struct {
+	peer     peer.ID
+	selected map[string]protocol.ID
+}
+
+var _ NegotiationCache = (*MemoryNegotiationCache)(nil)
+
+// NewMemoryNegotiationCache creates an empty MemoryNegotiationCache
+// remembering up to DefaultNegotiationCachePeers peers.
+func NewMemoryNegotiationCache() *MemoryNegotiationCache {
+	return NewMemoryNegotiationCacheWithSize(DefaultNegotiationCachePeers)
+}
+
+// NewMemoryNegotiationCacheWithSize creates an empty MemoryNegotiationCache
+// remembering up to peers peers.
+func NewMemoryNegotiationCacheWithSize(peers int) *MemoryNegotiationCache {
+	if peers <= 0 {
+		peers = DefaultNegotiationCachePeers
+	}
+	return &MemoryNegotiationCache{
+		size:    peers,
+		entries: make(map[peer.ID]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (c *MemoryNegotiationCache) Lookup(p peer.ID, protos []protocol.ID) (protocol.ID, bool) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	e, ok := c.entries[p]
+	if !ok {
+		return "", false
+	}
+	c.lru.MoveToFront(e)
+	selected, ok := e.Value.(*negotiationEntry).selected[protocolSetKey(protos)]
+	return selected, ok
+}
+
+func (c *MemoryNegotiationCache) Store(p peer.ID, protos []protocol.ID, selected protocol.ID) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	e, ok := c.entries[p]
+	if ok {
+		c.lru.MoveToFront(e)
+	} else {
+		e = c.lru.PushFront(&negotiationEntry{peer: p, selected: make(map[string]protocol.ID)})
+		c.entries[p] = e
+		if c.lru.Len() > c.size {
+			oldest := c.lru.Back()
+			c.lru.Remove(oldest)
+			delete(c.entries, oldest.Value.(*negotiationEntry).peer)
+		}
+	}
+	e.Value.(*negotiationEntry).selected[protocolSetKey(protos)] = selected
+}
+
+func (c *MemoryNegotiationCache) Invalidate(p peer.ID) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	if e, ok := c.entries[p]; ok {
+		c.lru.Remove(e)
+		delete(c.entries, p)
+	}
+}
+
+// Len returns the number of peers with cached selections.
+func (c *MemoryNegotiationCache) Len() int {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	return c.lru.Len()
+}
+
+// InvalidateOnProtocolUpdates invalidates the entries of peers whose
+// protocols change, as announced by event.EvtPeerProtocolsUpdated on the bus,
+// until the returned subscription is closed.
+func InvalidateOnProtocolUpdates(bus event.Bus, c NegotiationCache) (event.Subscription, error) {
+	sub, err := bus.Subscribe(new(event.EvtPeerProtocolsUpdated))
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for e := range sub.Out() {
+			c.Invalidate(e.(event.EvtPeerProtocolsUpdated).Peer)
+		}
+	}()
+	return sub, nil
+}
+
+// InvalidateOnDisconnect invalidates the entries of peers once their last
+// connection closes, since they may run different software when they come
+// back. It returns the notifiee registered on the network, to be passed to
+// StopNotify.
+func InvalidateOnDisconnect(n network.Network, c NegotiationCache) network.Notifiee {
+	nb := &network.NotifyBundle{
+		DisconnectedF: func(n network.Network, conn network.Conn) {
+			p := conn.RemotePeer()
+			if n.Connectedness(p) != network.Connected {
+				c.Invalidate(p)
+			}
+		},
+	}
+	n.Notify(nb)
+	return nb
+}
@@ -0,0 +1,67 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+func TestMemoryNegotiationCacheEviction(t *testing.T) {
+	c := NewMemoryNegotiationCacheWithSize(2)
+	protos := []protocol.ID{"/a/1", "/a/2"}
+	c.Store("a", protos, "/a/2")
+	c.Store("b", protos, "/a/1")
+
+	// Looking a up makes b the least recently used.
+	if selected, ok := c.Lookup("a", []protocol.ID{"/a/2", "/a/1"}); !ok || selected != "/a/2" {
+		t.Fatalf("expected /a/2 whatever the order, got %q", selected)
+	}
+	c.Store("c", protos, "/a/1")
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 peers, got %d", c.Len())
+	}
+	if _, ok := c.Lookup("b", protos); ok {
+		t.Fatal("expected the least recently used peer to be evicted")
+	}
+	if _, ok := c.Lookup("a", protos); !ok {
+		t.Fatal("expected a recently used peer to be kept")
+	}
+
+	c.Invalidate("a")
+	if _, ok := c.Lookup("a", protos); ok || c.Len() != 1 {
+		t.Fatal("expected the peer to be invalidated")
+	}
+}
+
+// notifyNetwork records its notifiee.
+type notifyNetwork struct {
+	*fakeNetwork
+	notifiee network.Notifiee
+}
+
+func (n *notifyNetwork) Notify(nf network.Notifiee) { n.notifiee = nf }
+
+func TestInvalidateOnDisconnect(t *testing.T) {
+	n := &notifyNetwork{fakeNetwork: newFakeNetwork()}
+	c := NewMemoryNegotiationCache()
+	protos := []protocol.ID{"/a/1"}
+	c.Store("a", protos, "/a/1")
+	nf := InvalidateOnDisconnect(n, c)
+	if n.notifiee != nf {
+		t.Fatal("expected the notifiee to be registered")
+	}
+
+	// The peer still has another connection.
+	n.setConnected("a", true)
+	nf.Disconnected(n, &fakeConn{remote: "a"})
+	if _, ok := c.Lookup("a", protos); !ok {
+		t.Fatal("expected a connected peer to be kept")
+	}
+
+	n.setConnected("a", false)
+	nf.Disconnected(n, &fakeConn{remote: "a"})
+	if _, ok := c.Lookup("a", protos); ok {
+		t.Fatal("expected a disconnected peer to be invalidated")
+	}
+}
@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"net"
+
+	"github.com/libp2p/go-flow-metrics"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// AddrClass is the class of the address traffic is exchanged with, which
+// tells free intra-cluster traffic from billable egress.
+type AddrClass int
+
+const (
+	// ClassUnknown is the class of addresses without an IP, e.g. DNS
+	// addresses not resolved yet.
+	ClassUnknown AddrClass = iota
+	ClassLoopback
+	// ClassLAN is the class of private and link-local addresses.
+	ClassLAN
+	// ClassWAN is the class of public addresses.
+	ClassWAN
+	// ClassRelay is the class of relayed addresses, whatever the address of
+	// the relay.
+	ClassRelay
+	// ClassCGNAT is the class of carrier-grade NAT addresses
+	// (100.64.0.0/10). They're shared by an ISP's customers, so traffic to
+	// them usually leaves the local network, unlike ClassLAN traffic.
+	ClassCGNAT
+)
+
+func (c AddrClass) String() string {
+	switch c {
+	case ClassLoopback:
+		return "loopback"
+	case ClassLAN:
+		return "lan"
+	case ClassWAN:
+		return "wan"
+	case ClassRelay:
+		return "relay"
+	case ClassCGNAT:
+		return "cgnat"
+	default:
+		return "unknown"
+	}
+}
+
+// pCircuit is the multicodec of the p2p-circuit protocol.
+const pCircuit = 0x0122
+
+var cgnatNet = func() *net.IPNet {
+	_, n, err := net.ParseCIDR("100.64.0.0/10")
+	if err != nil {
+		panic(err)
+	}
+	return n
+}()
+
+var privateNets = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{
+		"10.0.0.0/8",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"169.254.0.0/16",
+		"fc00::/7",
+		"fe80::/10",
+	} {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// ClassifyAddr returns the class of the address.
+func ClassifyAddr(a ma.Multiaddr) AddrClass {
+	if a == nil {
+		return ClassUnknown
+	}
+	var ip net.IP
+	for _, p := range a.Protocols() {
+		switch p.Code {
+		case pCircuit:
+			return ClassRelay
+		case ma.P_IP4, ma.P_IP6:
+			if ip == nil {
+				v, err := a.ValueForProtocol(p.Code)
+				if err != nil {
+					return ClassUnknown
+				}
+				ip = net.ParseIP(v)
+			}
+		}
+	}
+	switch {
+	case ip == nil:
+		return ClassUnknown
+	case ip.IsLoopback():
+		return ClassLoopback
+	case cgnatNet.Contains(ip):
+		return ClassCGNAT
+	}
+	for _, n := range privateNets {
+		if n.Contains(ip) {
+			return ClassLAN
+		}
+	}
+	return ClassWAN
+}
+
+// AddrClassReporter is implemented by reporters that can split traffic by the
+// class of the remote address.
+type AddrClassReporter interface {
+	Reporter
+
+	LogSentMessageClass(size int64, class AddrClass)
+	LogRecvMessageClass(size int64, class AddrClass)
+	GetBandwidthForClass(class AddrClass) Stats
+	GetBandwidthByClass() map[AddrClass]Stats
+}
+
+var _ AddrClassReporter = (*BandwidthCounter)(nil)
+
+// LogSentMessageClass records the size of an outgoing message sent to an
+// address of the given class.
+func (bwc *BandwidthCounter) LogSentMessageClass(size int64, class AddrClass) {
+	bwc.classOut.Get(class.String()).Mark(uint64(size))
+}
+
+// LogRecvMessageClass records the size of an incoming message received from
+// an address of the given class.
+func (bwc *BandwidthCounter) LogRecvMessageClass(size int64, class AddrClass) {
+	bwc.classIn.Get(class.String()).Mark(uint64(size))
+}
+
+// GetBandwidthForClass returns the metrics associated with the given address
+// class.
+func (bwc *BandwidthCounter) GetBandwidthForClass(class AddrClass) Stats {
+	inSnap := bwc.classIn.Get(class.String()).Snapshot()
+	outSnap := bwc.classOut.Get(class.String()).Snapshot()
+
+	return Stats{
+		TotalIn:  int64(inSnap.Total),
+		TotalOut: int64(outSnap.Total),
+		RateIn:   inSnap.Rate,
+		RateOut:  outSnap.Rate,
+	}
+}
+
+// GetBandwidthByClass returns the metrics of every address class that
+// carried traffic.
+func (bwc *BandwidthCounter) GetBandwidthByClass() map[AddrClass]Stats {
+	classes := make(map[AddrClass]Stats)
+	byName := make(map[string]AddrClass)
+	for c := ClassUnknown; c <= ClassCGNAT; c++ {
+		byName[c.String()] = c
+	}
+
+	bwc.classIn.ForEach(func(name string, meter *flow.Meter) {
+		c := byName[name]
+		snap := meter.Snapshot()
+
+		stat := classes[c]
+		stat.TotalIn = int64(snap.Total)
+		stat.RateIn = snap.Rate
+		classes[c] = stat
+	})
+
+	bwc.classOut.ForEach(func(name string, meter *flow.Meter) {
+		c := byName[name]
+		snap := meter.Snapshot()
+
+		stat := classes[c]
+		stat.TotalOut = int64(snap.Total)
+		stat.RateOut = snap.Rate
+		classes[c] = stat
+	})
+
+	return classes
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestClassifyAddr(t *testing.T) {
+	for addr, want := range map[string]AddrClass{
+		"/ip4/127.0.0.1/tcp/4001":    ClassLoopback,
+		"/ip6/::1/tcp/4001":          ClassLoopback,
+		"/ip4/192.168.1.10/tcp/4001": ClassLAN,
+		"/ip4/10.1.2.3/udp/4001":     ClassLAN,
+		"/ip6/fd00::1/tcp/4001":      ClassLAN,
+		"/ip4/100.64.1.2/tcp/4001":   ClassCGNAT,
+		"/ip4/1.2.3.4/tcp/4001":      ClassWAN,
+		"/ip6/2001:db8::1/tcp/4001":  ClassWAN,
+	} {
+		if got := ClassifyAddr(ma.StringCast(addr)); got != want {
+			t.Errorf("%s: expected %s, got %s", addr, want, got)
+		}
+	}
+	if got := ClassifyAddr(nil); got != ClassUnknown {
+		t.Errorf("expected nil address to be unknown, got %s", got)
+	}
+}
+
+func TestBandwidthByClass(t *testing.T) {
+	bwc := NewBandwidthCounter()
+	bwc.LogSentMessageClass(100, ClassWAN)
+	bwc.LogRecvMessageClass(10, ClassLAN)
+
+	// Meters are updated in the background.
+	deadline := time.Now().Add(5 * time.Second)
+	for bwc.GetBandwidthForClass(ClassWAN).TotalOut != 100 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the meters to update")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	stats := bwc.GetBandwidthByClass()
+	if len(stats) != 2 || stats[ClassWAN].TotalOut != 100 || stats[ClassLAN].TotalIn != 10 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
@@ -19,6 +19,14 @@ type BandwidthCounter struct {
 
 	peerIn  flow.MeterRegistry
 	peerOut flow.MeterRegistry
+
+	serviceIn     flow.MeterRegistry
+	serviceOut    flow.MeterRegistry
+	serviceMsgIn  flow.MeterRegistry
+	serviceMsgOut flow.MeterRegistry
+
+	classIn  flow.MeterRegistry
+	classOut flow.MeterRegistry
 }
 
 // NewBandwidthCounter creates a new BandwidthCounter.
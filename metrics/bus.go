@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// DefaultSampleInterval is the default interval between bandwidth samples
+// emitted by a BusEmitter.
+var DefaultSampleInterval = 10 * time.Second
+
+// BusEmitterOpts configures a BusEmitter.
+type BusEmitterOpts struct {
+	// Interval between samples. Defaults to DefaultSampleInterval.
+	Interval time.Duration
+	// ByProtocol includes per-protocol statistics in every sample. This may
+	// be moderately expensive.
+	ByProtocol bool
+	// ByPeer includes per-peer statistics in every sample. This may be very
+	// expensive.
+	ByPeer bool
+	// Clock is the source of time of the emitter. Nil means
+	// network.RealClock.
+	Clock network.Clock
+}
+
+// BusEmitter periodically samples a Reporter and emits the samples onto an
+// event bus as event.EvtBandwidthSample, so that bandwidth can be consumed
+// through a regular bus subscription.
+type BusEmitter struct {
+	reporter Reporter
+	emitter  event.Emitter
+	opts     BusEmitterOpts
+	timer    network.Timer
+
+	closeOnce sync.Once
+	closeErr  error
+	closing   chan struct{}
+	closed    chan struct{}
+}
+
+// NewBusEmitter starts emitting samples of the reporter onto the bus. Call
+// Close to stop.
+func NewBusEmitter(r Reporter, bus event.Bus, opts BusEmitterOpts) (*BusEmitter, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultSampleInterval
+	}
+	if opts.Clock == nil {
+		opts.Clock = network.RealClock
+	}
+	em, err := bus.Emitter(new(event.EvtBandwidthSample))
+	if err != nil {
+		return nil, err
+	}
+	be := &BusEmitter{
+		reporter: r,
+		emitter:  em,
+		opts:     opts,
+		timer:    opts.Clock.NewTimer(opts.Interval),
+		closing:  make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+	go be.loop()
+	return be, nil
+}
+
+func (be *BusEmitter) loop() {
+	defer close(be.closed)
+
+	defer be.timer.Stop()
+
+	for {
+		select {
+		case now := <-be.timer.C():
+			be.emitter.Emit(be.sample(now))
+			be.timer.Reset(be.opts.Interval)
+		case <-be.closing:
+			return
+		}
+	}
+}
+
+func (be *BusEmitter) sample(now time.Time) event.EvtBandwidthSample {
+	evt := event.EvtBandwidthSample{
+		Time:   now,
+		Totals: toBandwidthStats(be.reporter.GetBandwidthTotals()),
+	}
+	if be.opts.ByProtocol {
+		byProto := be.reporter.GetBandwidthByProtocol()
+		evt.ByProtocol = make(map[protocol.ID]event.BandwidthStats, len(byProto))
+		for p, s := range byProto {
+			evt.ByProtocol[p] = toBandwidthStats(s)
+		}
+	}
+	if be.opts.ByPeer {
+		byPeer := be.reporter.GetBandwidthByPeer()
+		evt.ByPeer = make(map[peer.ID]event.BandwidthStats, len(byPeer))
+		for p, s := range byPeer {
+			evt.ByPeer[p] = toBandwidthStats(s)
+		}
+	}
+	return evt
+}
+
+// Close stops emitting samples and closes the underlying emitter. It's safe
+// to call more than once, concurrently: the emitter is closed once, and every
+// call returns the result of closing it.
+func (be *BusEmitter) Close() error {
+	be.closeOnce.Do(func() {
+		close(be.closing)
+		<-be.closed
+		be.closeErr = be.emitter.Close()
+	})
+	return be.closeErr
+}
+
+func toBandwidthStats(s Stats) event.BandwidthStats {
+	return event.BandwidthStats{
+		TotalIn:  s.TotalIn,
+		TotalOut: s.TotalOut,
+		RateIn:   s.RateIn,
+		RateOut:  s.RateOut,
+	}
+}
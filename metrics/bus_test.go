@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/event"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// chanBus is a minimal event.Bus delivering every emitted event on a channel.
+type chanBus struct {
+	ch     chan interface{}
+	closes int32
+}
+
+type chanEmitter struct {
+	ch     chan interface{}
+	closes *int32
+}
+
+func (e *chanEmitter) Emit(evt interface{}) { e.ch <- evt }
+func (e *chanEmitter) Close() error {
+	atomic.AddInt32(e.closes, 1)
+	return nil
+}
+
+func (b *chanBus) Subscribe(interface{}, ...event.SubscriptionOpt) (event.Subscription, error) {
+	panic("not implemented")
+}
+
+func (b *chanBus) Emitter(interface{}, ...event.EmitterOpt) (event.Emitter, error) {
+	return &chanEmitter{ch: b.ch, closes: &b.closes}, nil
+}
+
+func TestBusEmitter(t *testing.T) {
+	bwc := NewBandwidthCounter()
+	bwc.LogSentMessageStream(100, protocol.TestingID, "peer")
+
+	start := time.Now()
+	clock := network.NewMockClock(start)
+	bus := &chanBus{ch: make(chan interface{})}
+	be, err := NewBusEmitter(bwc, bus, BusEmitterOpts{Interval: time.Minute, ByProtocol: true, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(time.Minute - time.Second)
+	select {
+	case e := <-bus.ch:
+		t.Fatalf("didn't expect a sample before the interval elapsed, got %v", e)
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(time.Second)
+
+	select {
+	case e := <-bus.ch:
+		evt, ok := e.(event.EvtBandwidthSample)
+		if !ok {
+			t.Fatalf("unexpected event %T", e)
+		}
+		if _, ok := evt.ByProtocol[protocol.TestingID]; !ok {
+			t.Fatal("expected per-protocol stats")
+		}
+		if evt.ByPeer != nil {
+			t.Fatal("didn't expect per-peer stats")
+		}
+		if !evt.Time.Equal(start.Add(time.Minute)) {
+			t.Fatalf("expected the sample to be taken at the mock clock's time, got %s", evt.Time)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a sample")
+	}
+
+	// Drain so Close doesn't block on a pending emit.
+	go func() {
+		for range bus.ch {
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := be.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&bus.closes); n != 1 {
+		t.Fatalf("expected the emitter to be closed once, got %d", n)
+	}
+	close(bus.ch)
+}
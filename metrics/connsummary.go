@@ -0,0 +1,179 @@
+package metrics
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// ErrConnSummariesNotSupported is returned by SetConnSummarySink when the
+// network doesn't emit connection summaries.
+var ErrConnSummariesNotSupported = errors.New("network does not emit connection summaries")
+
+// ConnSummary summarizes the lifetime of a closed connection, giving
+// postmortem data without high-cardinality live metrics.
+type ConnSummary struct {
+	Peer          peer.ID
+	Local, Remote ma.Multiaddr
+	Direction     network.Direction
+	// Transport is the name of the transport protocol, e.g. "tcp" or
+	// "quic".
+	Transport string
+
+	Opened, Closed time.Time
+
+	// BytesIn and BytesOut count the bytes exchanged over the connection,
+	// if known.
+	BytesIn, BytesOut int64
+	// StreamsByProtocol counts the streams opened over the connection per
+	// protocol. Streams closed before their protocol was negotiated are
+	// counted under "".
+	StreamsByProtocol map[protocol.ID]int
+
+	// CloseReason describes why the connection was closed, or
+	// network.CloseReasonUnknown if unknown.
+	CloseReason network.CloseReason
+}
+
+// Duration returns how long the connection was open, or 0 if its opening time
+// is unknown.
+func (s *ConnSummary) Duration() time.Duration {
+	if s.Opened.IsZero() {
+		return 0
+	}
+	return s.Closed.Sub(s.Opened)
+}
+
+// ConnSummarySink receives the summaries of closed connections. It's called
+// synchronously by the network, and must not block.
+type ConnSummarySink interface {
+	ConnClosed(ConnSummary)
+}
+
+// ConnSummarySinkFunc is a ConnSummarySink function.
+type ConnSummarySinkFunc func(ConnSummary)
+
+// ConnClosed implements ConnSummarySink.
+func (f ConnSummarySinkFunc) ConnClosed(s ConnSummary) { f(s) }
+
+// ConnSummaryNetwork is implemented by networks emitting a summary of each
+// connection they close.
+type ConnSummaryNetwork interface {
+	network.Network
+
+	// SetConnSummarySink sets the sink receiving the summaries of the
+	// connections closed after the call. Nil disables summaries.
+	SetConnSummarySink(ConnSummarySink)
+}
+
+// SetConnSummarySink sets the network's connection summary sink, or returns
+// ErrConnSummariesNotSupported if the network doesn't implement
+// ConnSummaryNetwork.
+func SetConnSummarySink(n network.Network, sink ConnSummarySink) error {
+	sn, ok := n.(ConnSummaryNetwork)
+	if !ok {
+		return ErrConnSummariesNotSupported
+	}
+	sn.SetConnSummarySink(sink)
+	return nil
+}
+
+// SummarizeConn fills in a summary from the connection's addresses and stat,
+// for networks implementing ConnSummaryNetwork. Byte counts and stream counts
+// must be tracked by the network during the connection's lifetime, as its
+// streams are gone by the time it's closed.
+func SummarizeConn(c network.Conn, closed time.Time, reason network.CloseReason) ConnSummary {
+	stat := c.Stat()
+	return ConnSummary{
+		Peer:              c.RemotePeer(),
+		Local:             c.LocalMultiaddr(),
+		Remote:            c.RemoteMultiaddr(),
+		Direction:         stat.Direction,
+		Transport:         transportName(c.RemoteMultiaddr()),
+		Opened:            stat.Opened,
+		Closed:            closed,
+		StreamsByProtocol: make(map[protocol.ID]int),
+		CloseReason:       reason,
+	}
+}
+
+// transportProtocols are the protocols naming a transport. The outermost one
+// of an address names its transport, e.g. "quic" for /ip4/.../udp/.../quic.
+// Components that don't, such as /certhash, /sni or /p2p, are ignored.
+var transportProtocols = map[string]bool{
+	"tcp":           true,
+	"udp":           true,
+	"quic":          true,
+	"quic-v1":       true,
+	"ws":            true,
+	"wss":           true,
+	"webtransport":  true,
+	"webrtc":        true,
+	"webrtc-direct": true,
+	"p2p-circuit":   true,
+	"unix":          true,
+}
+
+// transportName returns the name of the transport of the address, or "" if
+// it has none.
+func transportName(a ma.Multiaddr) string {
+	if a == nil {
+		return ""
+	}
+	var name string
+	for _, p := range a.Protocols() {
+		if transportProtocols[p.Name] {
+			name = p.Name
+		}
+	}
+	return name
+}
+
+// ConnSummaryLog is a ConnSummarySink keeping the most recent summaries in
+// memory.
+type ConnSummaryLog struct {
+	lk        sync.Mutex
+	summaries []ConnSummary
+	next      int
+	full      bool
+}
+
+var _ ConnSummarySink = (*ConnSummaryLog)(nil)
+
+// NewConnSummaryLog creates a ConnSummaryLog keeping up to size summaries.
+func NewConnSummaryLog(size int) *ConnSummaryLog {
+	if size <= 0 {
+		size = 1
+	}
+	return &ConnSummaryLog{summaries: make([]ConnSummary, size)}
+}
+
+// ConnClosed implements ConnSummarySink.
+func (l *ConnSummaryLog) ConnClosed(s ConnSummary) {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	l.summaries[l.next] = s
+	l.next++
+	if l.next == len(l.summaries) {
+		l.next = 0
+		l.full = true
+	}
+}
+
+// Summaries returns the kept summaries, oldest first.
+func (l *ConnSummaryLog) Summaries() []ConnSummary {
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	if !l.full {
+		return append([]ConnSummary(nil), l.summaries[:l.next]...)
+	}
+	out := make([]ConnSummary, 0, len(l.summaries))
+	out = append(out, l.summaries[l.next:]...)
+	return append(out, l.summaries[:l.next]...)
+}
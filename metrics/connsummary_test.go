@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// pWS is the multicodec of /ws.
+const pWS = 0x01dd
+
+func init() {
+	// Older multiaddr releases don't know /ws.
+	if ma.ProtocolWithCode(pWS).Code == 0 {
+		if err := ma.AddProtocol(ma.Protocol{
+			Name:  "ws",
+			Code:  pWS,
+			VCode: ma.CodeToVarint(pWS),
+		}); err != nil {
+			panic(err)
+		}
+	}
+}
+
+type summaryConn struct {
+	network.Conn
+	opened time.Time
+}
+
+func (c summaryConn) RemotePeer() peer.ID          { return "remote" }
+func (c summaryConn) LocalMultiaddr() ma.Multiaddr { return ma.StringCast("/ip4/127.0.0.1/tcp/4001") }
+func (c summaryConn) RemoteMultiaddr() ma.Multiaddr {
+	return ma.StringCast("/ip4/1.2.3.4/udp/4001/quic")
+}
+func (c summaryConn) Stat() network.Stat {
+	return network.Stat{Direction: network.DirInbound, Opened: c.opened}
+}
+
+func TestConnSummary(t *testing.T) {
+	opened := time.Now()
+	s := SummarizeConn(summaryConn{opened: opened}, opened.Add(time.Minute), network.CloseReasonLocal)
+	if s.Peer != "remote" || s.Transport != "quic" || s.Direction != network.DirInbound || s.CloseReason != network.CloseReasonLocal {
+		t.Fatalf("unexpected summary: %+v", s)
+	}
+	if s.Duration() != time.Minute {
+		t.Fatalf("unexpected duration %s", s.Duration())
+	}
+}
+
+func TestTransportName(t *testing.T) {
+	for addr, want := range map[string]string{
+		"/ip4/1.2.3.4/tcp/4001":  "tcp",
+		"/ip4/1.2.3.4/tcp/80/ws": "ws",
+		// Trailing components that don't name a transport are skipped.
+		"/ip4/1.2.3.4/tcp/80/http": "tcp",
+		"/ip4/1.2.3.4/udp/4001/quic/p2p/QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC": "quic",
+		"/ip4/1.2.3.4": "",
+	} {
+		if got := transportName(ma.StringCast(addr)); got != want {
+			t.Errorf("%s: expected %q, got %q", addr, want, got)
+		}
+	}
+}
+
+func TestConnSummaryLog(t *testing.T) {
+	l := NewConnSummaryLog(2)
+	for _, reason := range []network.CloseReason{network.CloseReasonLocal, network.CloseReasonRemote, network.CloseReasonError} {
+		l.ConnClosed(ConnSummary{CloseReason: reason})
+	}
+	got := l.Summaries()
+	if len(got) != 2 || got[0].CloseReason != network.CloseReasonRemote || got[1].CloseReason != network.CloseReasonError {
+		t.Fatalf("unexpected summaries: %+v", got)
+	}
+}
@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+var (
+	// DefaultPushInterval is the default interval between the pushes of a
+	// PushScheduler.
+	DefaultPushInterval = time.Minute
+
+	// DefaultPushMaxBackoff is the default upper bound on the delay between
+	// pushes while they fail.
+	DefaultPushMaxBackoff = 30 * time.Minute
+
+	// DefaultPushFlushTimeout is the default timeout of the final push made
+	// when a PushScheduler is closed.
+	DefaultPushFlushTimeout = 5 * time.Second
+)
+
+// Snapshot is a point-in-time snapshot of a Reporter.
+type Snapshot struct {
+	Time   time.Time
+	Totals Stats
+	// ByProtocol and ByPeer are only filled in if requested in the
+	// PushSchedulerOpts.
+	ByProtocol map[protocol.ID]Stats
+	ByPeer     map[peer.ID]Stats
+}
+
+// PushFunc pushes a snapshot to a metrics backend, e.g. a push gateway.
+type PushFunc func(ctx context.Context, s Snapshot) error
+
+// PushSchedulerOpts configures a PushScheduler.
+type PushSchedulerOpts struct {
+	// Interval between pushes. Defaults to DefaultPushInterval.
+	Interval time.Duration
+	// Jitter randomizes each interval by up to this fraction of it, in
+	// both directions, so fleets of devices don't push in lockstep. It's
+	// capped at 1.
+	Jitter float64
+	// MaxBackoff bounds the delay between pushes, which doubles after
+	// every consecutive failure. Defaults to DefaultPushMaxBackoff.
+	MaxBackoff time.Duration
+	// FlushTimeout bounds the final push made by Close. Defaults to
+	// DefaultPushFlushTimeout.
+	FlushTimeout time.Duration
+
+	// ByProtocol and ByPeer include per-protocol and per-peer statistics
+	// in every snapshot. They may be expensive.
+	ByProtocol bool
+	ByPeer     bool
+
+	// Clock is the source of time of the scheduler. Nil means
+	// network.RealClock.
+	Clock network.Clock
+}
+
+// PushScheduler periodically snapshots a Reporter and pushes the snapshots
+// with a user callback, so that embedded devices can push metrics without
+// serving a scrape endpoint. Failed pushes are retried with exponential
+// backoff, and a final snapshot is pushed on Close.
+type PushScheduler struct {
+	reporter Reporter
+	push     PushFunc
+	opts     PushSchedulerOpts
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	closed  chan struct{}
+	flushed error
+}
+
+// NewPushScheduler starts pushing snapshots of the reporter. Call Close to
+// stop.
+func NewPushScheduler(r Reporter, push PushFunc, opts PushSchedulerOpts) *PushScheduler {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultPushInterval
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultPushMaxBackoff
+	}
+	if opts.FlushTimeout <= 0 {
+		opts.FlushTimeout = DefaultPushFlushTimeout
+	}
+	if opts.Jitter > 1 {
+		opts.Jitter = 1
+	}
+	if opts.Clock == nil {
+		opts.Clock = network.RealClock
+	}
+	ps := &PushScheduler{
+		reporter: r,
+		push:     push,
+		opts:     opts,
+		closed:   make(chan struct{}),
+	}
+	ps.ctx, ps.cancel = context.WithCancel(context.Background())
+	go ps.loop()
+	return ps
+}
+
+// delay returns the delay before the next push, given the number of
+// consecutive failures.
+func (ps *PushScheduler) delay(failures int) time.Duration {
+	d := ps.opts.Interval
+	for i := 0; i < failures && d < ps.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	if failures > 0 && d > ps.opts.MaxBackoff {
+		d = ps.opts.MaxBackoff
+	}
+	if ps.opts.Jitter > 0 {
+		d += time.Duration(ps.opts.Jitter * (2*rand.Float64() - 1) * float64(d))
+	}
+	return d
+}
+
+func (ps *PushScheduler) loop() {
+	defer close(ps.closed)
+
+	var failures int
+	timer := ps.opts.Clock.NewTimer(ps.delay(0))
+	defer timer.Stop()
+	for {
+		select {
+		case now := <-timer.C():
+			if err := ps.push(ps.ctx, ps.snapshot(now)); err != nil {
+				failures++
+			} else {
+				failures = 0
+			}
+			timer.Reset(ps.delay(failures))
+		case <-ps.ctx.Done():
+			ctx, cancel := context.WithTimeout(context.Background(), ps.opts.FlushTimeout)
+			ps.flushed = ps.push(ctx, ps.snapshot(ps.opts.Clock.Now()))
+			cancel()
+			return
+		}
+	}
+}
+
+func (ps *PushScheduler) snapshot(now time.Time) Snapshot {
+	s := Snapshot{Time: now, Totals: ps.reporter.GetBandwidthTotals()}
+	if ps.opts.ByProtocol {
+		s.ByProtocol = ps.reporter.GetBandwidthByProtocol()
+	}
+	if ps.opts.ByPeer {
+		s.ByPeer = ps.reporter.GetBandwidthByPeer()
+	}
+	return s
+}
+
+// Close stops the scheduler, pushing a final snapshot, and returns the error
+// of that push.
+func (ps *PushScheduler) Close() error {
+	ps.cancel()
+	<-ps.closed
+	return ps.flushed
+}
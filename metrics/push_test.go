@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+)
+
+func TestPushScheduler(t *testing.T) {
+	bwc := NewBandwidthCounter()
+	clock := network.NewMockClock(time.Now())
+	pushes := make(chan Snapshot, 4)
+	fail := int32(1)
+	ps := NewPushScheduler(bwc, func(_ context.Context, s Snapshot) error {
+		pushes <- s
+		if atomic.LoadInt32(&fail) == 1 {
+			return errors.New("gateway down")
+		}
+		return nil
+	}, PushSchedulerOpts{Interval: time.Minute, MaxBackoff: 3 * time.Minute, Clock: clock})
+
+	for failures, expected := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		if d := ps.delay(failures); d != expected {
+			t.Fatalf("%d failures: expected a %s delay, got %s", failures, expected, d)
+		}
+	}
+
+	// The first push is due after an interval.
+	var s Snapshot
+	for {
+		select {
+		case s = <-pushes:
+		case <-time.After(10 * time.Millisecond):
+			clock.Advance(time.Minute)
+			continue
+		}
+		break
+	}
+	if s.Time.IsZero() || s.ByPeer != nil {
+		t.Fatalf("unexpected snapshot %+v", s)
+	}
+
+	atomic.StoreInt32(&fail, 0)
+	if err := ps.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-pushes:
+	default:
+		t.Fatal("expected a final push on close")
+	}
+}
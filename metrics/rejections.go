@@ -0,0 +1,82 @@
+package metrics
+
+import "sync"
+
+// ScopeKind is the kind of resource manager scope a reservation was denied
+// in.
+type ScopeKind string
+
+const (
+	ScopeSystem    ScopeKind = "system"
+	ScopeTransient ScopeKind = "transient"
+	ScopeService   ScopeKind = "service"
+	ScopeProtocol  ScopeKind = "protocol"
+	ScopePeer      ScopeKind = "peer"
+	ScopeConn      ScopeKind = "conn"
+	ScopeStream    ScopeKind = "stream"
+)
+
+// ResourceKind is the kind of resource a denied reservation was for.
+type ResourceKind string
+
+const (
+	ResourceMemory  ResourceKind = "memory"
+	ResourceStreams ResourceKind = "streams"
+	ResourceConns   ResourceKind = "conns"
+	ResourceFD      ResourceKind = "fd"
+)
+
+// RejectionKey identifies a rejection counter.
+type RejectionKey struct {
+	Scope    ScopeKind
+	Resource ResourceKind
+}
+
+// RejectionReporter counts the reservations denied by the resource manager,
+// so limits can be tuned without enabling full tracing.
+type RejectionReporter interface {
+	// LogRejection records a denied reservation.
+	LogRejection(scope ScopeKind, resource ResourceKind)
+
+	// GetRejections returns the number of denied reservations per scope
+	// kind and resource.
+	GetRejections() map[RejectionKey]int64
+}
+
+// RejectionCounter is a RejectionReporter keeping counts in memory.
+type RejectionCounter struct {
+	lk     sync.Mutex
+	counts map[RejectionKey]int64
+}
+
+var _ RejectionReporter = (*RejectionCounter)(nil)
+
+// NewRejectionCounter creates a new RejectionCounter.
+func NewRejectionCounter() *RejectionCounter {
+	return &RejectionCounter{counts: make(map[RejectionKey]int64)}
+}
+
+// LogRejection records a denied reservation.
+func (rc *RejectionCounter) LogRejection(scope ScopeKind, resource ResourceKind) {
+	rc.lk.Lock()
+	rc.counts[RejectionKey{Scope: scope, Resource: resource}]++
+	rc.lk.Unlock()
+}
+
+// GetRejections returns a copy of the counters.
+func (rc *RejectionCounter) GetRejections() map[RejectionKey]int64 {
+	rc.lk.Lock()
+	defer rc.lk.Unlock()
+	out := make(map[RejectionKey]int64, len(rc.counts))
+	for k, v := range rc.counts {
+		out[k] = v
+	}
+	return out
+}
+
+// Reset clears all counters.
+func (rc *RejectionCounter) Reset() {
+	rc.lk.Lock()
+	rc.counts = make(map[RejectionKey]int64)
+	rc.lk.Unlock()
+}
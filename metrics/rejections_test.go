@@ -0,0 +1,20 @@
+package metrics
+
+import "testing"
+
+func TestRejectionCounter(t *testing.T) {
+	rc := NewRejectionCounter()
+	rc.LogRejection(ScopePeer, ResourceStreams)
+	rc.LogRejection(ScopePeer, ResourceStreams)
+	rc.LogRejection(ScopeSystem, ResourceMemory)
+
+	counts := rc.GetRejections()
+	if counts[RejectionKey{ScopePeer, ResourceStreams}] != 2 || counts[RejectionKey{ScopeSystem, ResourceMemory}] != 1 {
+		t.Fatalf("unexpected counts: %v", counts)
+	}
+
+	rc.Reset()
+	if len(rc.GetRejections()) != 0 {
+		t.Fatal("expected counters to be reset")
+	}
+}
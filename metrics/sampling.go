@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"sync/atomic"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// SamplingReporter wraps a Reporter to reduce the cost of per-message metrics
+// on hot paths. Totals (LogSentMessage and LogRecvMessage) are always
+// forwarded, so byte totals stay exact. Only one in N per-stream calls
+// (LogSentMessageStream and LogRecvMessageStream) is forwarded, with its size
+// scaled by N, so per-peer and per-protocol figures are unbiased estimates.
+//
+// The sampling rate can be changed at any time with SetRate.
+type SamplingReporter struct {
+	Reporter
+
+	rate  uint64
+	count uint64
+}
+
+var _ Reporter = (*SamplingReporter)(nil)
+
+// NewSamplingReporter wraps the reporter, sampling one in rate per-stream
+// calls. A rate of 1 or less forwards every call.
+func NewSamplingReporter(r Reporter, rate int) *SamplingReporter {
+	s := &SamplingReporter{Reporter: r}
+	s.SetRate(rate)
+	return s
+}
+
+// SetRate changes the sampling rate. A rate of 1 or less forwards every call.
+func (s *SamplingReporter) SetRate(rate int) {
+	if rate < 1 {
+		rate = 1
+	}
+	atomic.StoreUint64(&s.rate, uint64(rate))
+}
+
+// Rate returns the current sampling rate.
+func (s *SamplingReporter) Rate() int {
+	return int(atomic.LoadUint64(&s.rate))
+}
+
+// sample returns the factor to scale the call's size with, or 0 if the call
+// is skipped.
+func (s *SamplingReporter) sample() int64 {
+	rate := atomic.LoadUint64(&s.rate)
+	if rate <= 1 {
+		return 1
+	}
+	if atomic.AddUint64(&s.count, 1)%rate != 0 {
+		return 0
+	}
+	return int64(rate)
+}
+
+// LogSentMessageStream forwards one in N calls, scaling the size by N.
+func (s *SamplingReporter) LogSentMessageStream(size int64, proto protocol.ID, p peer.ID) {
+	if n := s.sample(); n > 0 {
+		s.Reporter.LogSentMessageStream(size*n, proto, p)
+	}
+}
+
+// LogRecvMessageStream forwards one in N calls, scaling the size by N.
+func (s *SamplingReporter) LogRecvMessageStream(size int64, proto protocol.ID, p peer.ID) {
+	if n := s.sample(); n > 0 {
+		s.Reporter.LogRecvMessageStream(size*n, proto, p)
+	}
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+type countingReporter struct {
+	Reporter
+	total, stream, streamCalls int64
+}
+
+func (r *countingReporter) LogSentMessage(size int64) { r.total += size }
+func (r *countingReporter) LogSentMessageStream(size int64, _ protocol.ID, _ peer.ID) {
+	r.stream += size
+	r.streamCalls++
+}
+
+func TestSamplingReporter(t *testing.T) {
+	r := new(countingReporter)
+	s := NewSamplingReporter(r, 4)
+	for i := 0; i < 100; i++ {
+		s.LogSentMessage(10)
+		s.LogSentMessageStream(10, "/p", "peer")
+	}
+
+	if r.total != 1000 {
+		t.Fatalf("expected exact totals, got %d", r.total)
+	}
+	if r.streamCalls != 25 || r.stream != 1000 {
+		t.Fatalf("expected 25 calls estimating 1000 bytes, got %d calls for %d bytes", r.streamCalls, r.stream)
+	}
+
+	s.SetRate(0)
+	if s.Rate() != 1 {
+		t.Fatalf("expected rate to be clamped to 1, got %d", s.Rate())
+	}
+	s.LogSentMessageStream(7, "/p", "peer")
+	if r.streamCalls != 26 || r.stream != 1007 {
+		t.Fatal("expected every call to be forwarded")
+	}
+}
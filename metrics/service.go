@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"github.com/libp2p/go-flow-metrics"
+	"github.com/libp2p/go-libp2p-core/network"
+)
+
+// ServiceStats represents a point-in-time snapshot of the metrics of a
+// service: its bandwidth, and the number of messages sent / received.
+type ServiceStats struct {
+	Stats
+
+	MessagesIn  int64
+	MessagesOut int64
+}
+
+// ServiceReporter is implemented by reporters that can attribute traffic to
+// services (e.g. "kad-dht" vs "pubsub" vs user services), as identified by
+// the service scopes of the resource manager.
+type ServiceReporter interface {
+	Reporter
+
+	LogSentMessageService(size int64, service string)
+	LogRecvMessageService(size int64, service string)
+	GetBandwidthForService(service string) ServiceStats
+	GetBandwidthByService() map[string]ServiceStats
+}
+
+// StreamService returns the name of the service the stream is attached to, or
+// "" if it isn't attached to any (or isn't accounted by a resource manager).
+func StreamService(s network.Stream) string {
+	ss, ok := s.(network.ScopedStream)
+	if !ok {
+		return ""
+	}
+	scope := ss.Scope()
+	if scope == nil {
+		return ""
+	}
+	svc := scope.ServiceScope()
+	if svc == nil {
+		return ""
+	}
+	return svc.Name()
+}
+
+// LogStreamSent records an outgoing message on stream s in the
+// reporter, attributing it to the stream's protocol and peer and, if the
+// reporter is a ServiceReporter, to the stream's service and, if it's an
+// AddrClassReporter, to the class of the remote address.
+func LogStreamSent(r Reporter, size int64, s network.Stream) {
+	r.LogSentMessageStream(size, s.Protocol(), s.Conn().RemotePeer())
+	if sr, ok := r.(ServiceReporter); ok {
+		if svc := StreamService(s); svc != "" {
+			sr.LogSentMessageService(size, svc)
+		}
+	}
+	if cr, ok := r.(AddrClassReporter); ok {
+		cr.LogSentMessageClass(size, ClassifyAddr(s.Conn().RemoteMultiaddr()))
+	}
+}
+
+// LogStreamRecv records an incoming message on stream s in the
+// reporter, attributing it to the stream's protocol and peer and, if the
+// reporter is a ServiceReporter, to the stream's service and, if it's an
+// AddrClassReporter, to the class of the remote address.
+func LogStreamRecv(r Reporter, size int64, s network.Stream) {
+	r.LogRecvMessageStream(size, s.Protocol(), s.Conn().RemotePeer())
+	if sr, ok := r.(ServiceReporter); ok {
+		if svc := StreamService(s); svc != "" {
+			sr.LogRecvMessageService(size, svc)
+		}
+	}
+	if cr, ok := r.(AddrClassReporter); ok {
+		cr.LogRecvMessageClass(size, ClassifyAddr(s.Conn().RemoteMultiaddr()))
+	}
+}
+
+var _ ServiceReporter = (*BandwidthCounter)(nil)
+
+// LogSentMessageService records the size of an outgoing message sent on
+// behalf of the given service.
+func (bwc *BandwidthCounter) LogSentMessageService(size int64, service string) {
+	bwc.serviceOut.Get(service).Mark(uint64(size))
+	bwc.serviceMsgOut.Get(service).Mark(1)
+}
+
+// LogRecvMessageService records the size of an incoming message received on
+// behalf of the given service.
+func (bwc *BandwidthCounter) LogRecvMessageService(size int64, service string) {
+	bwc.serviceIn.Get(service).Mark(uint64(size))
+	bwc.serviceMsgIn.Get(service).Mark(1)
+}
+
+// GetBandwidthForService returns the metrics associated with the given
+// service.
+func (bwc *BandwidthCounter) GetBandwidthForService(service string) ServiceStats {
+	inSnap := bwc.serviceIn.Get(service).Snapshot()
+	outSnap := bwc.serviceOut.Get(service).Snapshot()
+
+	return ServiceStats{
+		Stats: Stats{
+			TotalIn:  int64(inSnap.Total),
+			TotalOut: int64(outSnap.Total),
+			RateIn:   inSnap.Rate,
+			RateOut:  outSnap.Rate,
+		},
+		MessagesIn:  int64(bwc.serviceMsgIn.Get(service).Snapshot().Total),
+		MessagesOut: int64(bwc.serviceMsgOut.Get(service).Snapshot().Total),
+	}
+}
+
+// GetBandwidthByService returns a map of all remembered services and the
+// metrics with respect to each.
+func (bwc *BandwidthCounter) GetBandwidthByService() map[string]ServiceStats {
+	services := make(map[string]ServiceStats)
+
+	update := func(reg *flow.MeterRegistry, f func(*ServiceStats, flow.Snapshot)) {
+		reg.ForEach(func(svc string, meter *flow.Meter) {
+			stat := services[svc]
+			f(&stat, meter.Snapshot())
+			services[svc] = stat
+		})
+	}
+	update(&bwc.serviceIn, func(s *ServiceStats, snap flow.Snapshot) {
+		s.TotalIn = int64(snap.Total)
+		s.RateIn = snap.Rate
+	})
+	update(&bwc.serviceOut, func(s *ServiceStats, snap flow.Snapshot) {
+		s.TotalOut = int64(snap.Total)
+		s.RateOut = snap.Rate
+	})
+	update(&bwc.serviceMsgIn, func(s *ServiceStats, snap flow.Snapshot) {
+		s.MessagesIn = int64(snap.Total)
+	})
+	update(&bwc.serviceMsgOut, func(s *ServiceStats, snap flow.Snapshot) {
+		s.MessagesOut = int64(snap.Total)
+	})
+
+	return services
+}
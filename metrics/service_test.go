@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+)
+
+type svcScope struct {
+	network.ResourceScope
+	name string
+}
+
+func (s svcScope) Name() string { return s.name }
+
+type streamScope struct {
+	network.ResourceScope
+	svc network.ServiceScope
+}
+
+func (s *streamScope) SetService(name string) error {
+	s.svc = svcScope{network.NullScope, name}
+	return nil
+}
+
+func (s *streamScope) ServiceScope() network.ServiceScope { return s.svc }
+
+type scopedStream struct {
+	network.Stream
+	scope *streamScope
+}
+
+func (s scopedStream) Scope() network.StreamScope { return s.scope }
+
+func TestStreamService(t *testing.T) {
+	scope := &streamScope{ResourceScope: network.NullScope}
+	s := scopedStream{scope: scope}
+	if svc := StreamService(s); svc != "" {
+		t.Fatalf("expected no service, got %q", svc)
+	}
+	scope.SetService("kad-dht")
+	if svc := StreamService(s); svc != "kad-dht" {
+		t.Fatalf("expected kad-dht, got %q", svc)
+	}
+}
+
+func TestBandwidthByService(t *testing.T) {
+	bwc := NewBandwidthCounter()
+	bwc.LogSentMessageService(100, "kad-dht")
+	bwc.LogSentMessageService(50, "kad-dht")
+	bwc.LogRecvMessageService(10, "pubsub")
+
+	// Meters are updated in the background.
+	deadline := time.Now().Add(5 * time.Second)
+	for bwc.GetBandwidthForService("kad-dht").TotalOut != 150 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the meters to update")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	stats := bwc.GetBandwidthByService()
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 services, got %d", len(stats))
+	}
+	if s := stats["kad-dht"]; s.MessagesOut != 2 || s.TotalOut != 150 {
+		t.Fatalf("unexpected kad-dht stats: %+v", s)
+	}
+	if s := stats["pubsub"]; s.MessagesIn != 1 || s.TotalIn != 10 {
+		t.Fatalf("unexpected pubsub stats: %+v", s)
+	}
+}
@@ -0,0 +1,262 @@
+package metrics
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// DefaultTopKCapacity is the number of keys tracked by the sketches of a
+// TopKReporter created with a non-positive capacity.
+const DefaultTopKCapacity = 256
+
+// DefaultTopKWindow is the window of a TopKReporter created with a
+// non-positive window.
+const DefaultTopKWindow = time.Minute
+
+// PeerBandwidth is the bandwidth used by a peer.
+type PeerBandwidth struct {
+	Peer peer.ID
+	// Bytes is the estimated number of bytes sent and received. It may
+	// overestimate the real count by up to Error bytes.
+	Bytes int64
+	Error int64
+}
+
+// ProtocolBandwidth is the bandwidth used by a protocol.
+type ProtocolBandwidth struct {
+	Protocol protocol.ID
+	// Bytes is the estimated number of bytes sent and received. It may
+	// overestimate the real count by up to Error bytes.
+	Bytes int64
+	Error int64
+}
+
+// HeavyHitterReporter is implemented by reporters that can list the peers and
+// protocols using the most bandwidth, without enumerating all of them.
+type HeavyHitterReporter interface {
+	// GetTopPeers returns the k peers using the most bandwidth over the
+	// trailing window, heaviest first.
+	GetTopPeers(k int) []PeerBandwidth
+
+	// GetTopProtocols returns the k protocols using the most bandwidth
+	// over the trailing window, heaviest first.
+	GetTopProtocols(k int) []ProtocolBandwidth
+}
+
+// TopKReporter wraps a Reporter to track the heavy hitters among peers and
+// protocols, with Space-Saving sketches: memory is bounded by the capacity
+// whatever the number of peers, and any key using more than 1/capacity of the
+// bandwidth is guaranteed to be tracked.
+//
+// Sketches are rotated every half window, and queries merge the current and
+// previous ones, so they cover between half a window and a full window of
+// traffic.
+type TopKReporter struct {
+	Reporter
+
+	capacity int
+	window   time.Duration
+	clock    network.Clock
+
+	lk                 sync.Mutex
+	rotated            time.Time
+	peers, prevPeers   *spaceSaving
+	protos, prevProtos *spaceSaving
+}
+
+var (
+	_ Reporter            = (*TopKReporter)(nil)
+	_ HeavyHitterReporter = (*TopKReporter)(nil)
+)
+
+// NewTopKReporter wraps the reporter, tracking up to capacity peers and
+// protocols over the trailing window. A non-positive capacity or window
+// selects DefaultTopKCapacity or DefaultTopKWindow.
+func NewTopKReporter(r Reporter, capacity int, window time.Duration) *TopKReporter {
+	if capacity <= 0 {
+		capacity = DefaultTopKCapacity
+	}
+	if window <= 0 {
+		window = DefaultTopKWindow
+	}
+	return &TopKReporter{
+		Reporter:   r,
+		capacity:   capacity,
+		window:     window,
+		clock:      network.RealClock,
+		rotated:    time.Now(),
+		peers:      newSpaceSaving(capacity),
+		prevPeers:  newSpaceSaving(capacity),
+		protos:     newSpaceSaving(capacity),
+		prevProtos: newSpaceSaving(capacity),
+	}
+}
+
+// SetClock sets the clock windows are measured with, network.RealClock by
+// default. It must be called before the reporter is used.
+func (t *TopKReporter) SetClock(c network.Clock) {
+	t.clock = c
+	t.rotated = c.Now()
+}
+
+// rotate must be called with the lock held.
+func (t *TopKReporter) rotate(now time.Time) {
+	half := t.window / 2
+	if now.Sub(t.rotated) < half {
+		return
+	}
+	if now.Sub(t.rotated) >= t.window {
+		// Nothing was rotated for a whole window: the previous
+		// sketches are stale too.
+		t.prevPeers, t.prevProtos = newSpaceSaving(t.capacity), newSpaceSaving(t.capacity)
+	} else {
+		t.prevPeers, t.prevProtos = t.peers, t.protos
+	}
+	t.peers, t.protos = newSpaceSaving(t.capacity), newSpaceSaving(t.capacity)
+	t.rotated = now
+}
+
+func (t *TopKReporter) record(size int64, proto protocol.ID, p peer.ID) {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	t.rotate(t.clock.Now())
+	t.peers.add(string(p), size)
+	t.protos.add(string(proto), size)
+}
+
+// LogSentMessageStream records the message and forwards it to the wrapped
+// reporter.
+func (t *TopKReporter) LogSentMessageStream(size int64, proto protocol.ID, p peer.ID) {
+	t.record(size, proto, p)
+	t.Reporter.LogSentMessageStream(size, proto, p)
+}
+
+// LogRecvMessageStream records the message and forwards it to the wrapped
+// reporter.
+func (t *TopKReporter) LogRecvMessageStream(size int64, proto protocol.ID, p peer.ID) {
+	t.record(size, proto, p)
+	t.Reporter.LogRecvMessageStream(size, proto, p)
+}
+
+func (t *TopKReporter) top(k int, protos bool) []ssCounter {
+	t.lk.Lock()
+	defer t.lk.Unlock()
+	t.rotate(t.clock.Now())
+	if protos {
+		return mergeTop(k, t.protos, t.prevProtos)
+	}
+	return mergeTop(k, t.peers, t.prevPeers)
+}
+
+// GetTopPeers implements HeavyHitterReporter.
+func (t *TopKReporter) GetTopPeers(k int) []PeerBandwidth {
+	top := t.top(k, false)
+	out := make([]PeerBandwidth, len(top))
+	for i, c := range top {
+		out[i] = PeerBandwidth{Peer: peer.ID(c.key), Bytes: c.count, Error: c.err}
+	}
+	return out
+}
+
+// GetTopProtocols implements HeavyHitterReporter.
+func (t *TopKReporter) GetTopProtocols(k int) []ProtocolBandwidth {
+	top := t.top(k, true)
+	out := make([]ProtocolBandwidth, len(top))
+	for i, c := range top {
+		out[i] = ProtocolBandwidth{Protocol: protocol.ID(c.key), Bytes: c.count, Error: c.err}
+	}
+	return out
+}
+
+// mergeTop sums the counters of the sketches and returns the k largest.
+func mergeTop(k int, sketches ...*spaceSaving) []ssCounter {
+	sums := make(map[string]ssCounter)
+	for _, s := range sketches {
+		for _, c := range s.heap {
+			sum := sums[c.key]
+			sum.key = c.key
+			sum.count += c.count
+			sum.err += c.err
+			sums[c.key] = sum
+		}
+	}
+	out := make([]ssCounter, 0, len(sums))
+	for _, c := range sums {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].count != out[j].count {
+			return out[i].count > out[j].count
+		}
+		return out[i].key < out[j].key
+	})
+	if k >= 0 && len(out) > k {
+		out = out[:k]
+	}
+	return out
+}
+
+type ssCounter struct {
+	key        string
+	count, err int64
+	index      int
+}
+
+// spaceSaving is a Space-Saving sketch: it tracks up to capacity keys; a new
+// key replaces the one with the smallest count, inheriting its count as error.
+type spaceSaving struct {
+	capacity int
+	keys     map[string]*ssCounter
+	heap     ssHeap
+}
+
+func newSpaceSaving(capacity int) *spaceSaving {
+	return &spaceSaving{capacity: capacity, keys: make(map[string]*ssCounter)}
+}
+
+func (s *spaceSaving) add(key string, n int64) {
+	if c, ok := s.keys[key]; ok {
+		c.count += n
+		heap.Fix(&s.heap, c.index)
+		return
+	}
+	if len(s.heap) < s.capacity {
+		c := &ssCounter{key: key, count: n}
+		s.keys[key] = c
+		heap.Push(&s.heap, c)
+		return
+	}
+	min := s.heap[0]
+	delete(s.keys, min.key)
+	min.key, min.err = key, min.count
+	min.count += n
+	s.keys[key] = min
+	heap.Fix(&s.heap, 0)
+}
+
+// ssHeap is a min-heap of counters.
+type ssHeap []*ssCounter
+
+func (h ssHeap) Len() int           { return len(h) }
+func (h ssHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h ssHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+func (h *ssHeap) Push(x interface{}) {
+	c := x.(*ssCounter)
+	c.index = len(*h)
+	*h = append(*h, c)
+}
+func (h *ssHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
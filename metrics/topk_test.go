@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+type nopReporter struct {
+	Reporter
+}
+
+func (nopReporter) LogSentMessageStream(int64, protocol.ID, peer.ID) {}
+func (nopReporter) LogRecvMessageStream(int64, protocol.ID, peer.ID) {}
+
+func TestTopKReporter(t *testing.T) {
+	r := NewTopKReporter(nopReporter{}, 8, time.Hour)
+
+	// Many small peers, and two heavy hitters.
+	for i := 0; i < 1000; i++ {
+		r.LogSentMessageStream(10, "/small", peer.ID(fmt.Sprintf("peer-%d", i)))
+		r.LogRecvMessageStream(1000, "/heavy", "heavy-a")
+		if i%2 == 0 {
+			r.LogSentMessageStream(1000, "/heavy", "heavy-b")
+		}
+	}
+
+	top := r.GetTopPeers(2)
+	if len(top) != 2 || top[0].Peer != "heavy-a" || top[1].Peer != "heavy-b" {
+		t.Fatalf("unexpected heavy hitters: %v", top)
+	}
+	if top[0].Bytes < 1000*1000 || top[0].Bytes-top[0].Error > 1000*1000 {
+		t.Fatalf("estimate out of bounds: %v", top[0])
+	}
+
+	protos := r.GetTopProtocols(10)
+	if len(protos) != 2 || protos[0].Protocol != "/heavy" || protos[0].Bytes != 1500*1000 {
+		t.Fatalf("unexpected protocols: %v", protos)
+	}
+}
+
+func TestSpaceSavingBounded(t *testing.T) {
+	s := newSpaceSaving(4)
+	for i := 0; i < 100; i++ {
+		s.add(fmt.Sprint(i), 1)
+	}
+	if len(s.heap) != 4 || len(s.keys) != 4 {
+		t.Fatalf("sketch grew beyond its capacity: %d", len(s.heap))
+	}
+}
+
+func TestTopKReporterDefaultWindow(t *testing.T) {
+	r := NewTopKReporter(nopReporter{}, 8, 0)
+	r.LogSentMessageStream(10, "/p", "a")
+	r.LogSentMessageStream(10, "/p", "a")
+	if top := r.GetTopPeers(1); len(top) != 1 || top[0].Bytes != 20 {
+		t.Fatalf("expected the samples to share a window, got %v", top)
+	}
+}
@@ -0,0 +1,61 @@
+package mux
+
+import (
+	"io"
+	"time"
+)
+
+// SendStream is the sending half of a unidirectional stream.
+type SendStream interface {
+	io.Writer
+
+	// Close closes the stream, signaling the end of the data to the
+	// remote side.
+	io.Closer
+
+	// Reset aborts the stream. The remote side gets an error on read.
+	Reset() error
+
+	SetWriteDeadline(time.Time) error
+}
+
+// ReceiveStream is the receiving half of a unidirectional stream.
+type ReceiveStream interface {
+	io.Reader
+
+	// Reset aborts the stream, telling the remote side to stop sending.
+	Reset() error
+
+	SetReadDeadline(time.Time) error
+}
+
+// UniStreamConn is implemented by muxed connections that natively support
+// unidirectional streams (e.g. QUIC). They are cheaper than bidirectional
+// streams, and suited to one-way flows such as telemetry or gossip.
+type UniStreamConn interface {
+	MuxedConn
+
+	// OpenUniStream opens a stream only the local side can write to.
+	OpenUniStream() (SendStream, error)
+
+	// AcceptUniStream accepts a unidirectional stream opened by the other
+	// side. These streams are never returned by AcceptStream.
+	AcceptUniStream() (ReceiveStream, error)
+}
+
+// SupportsUniStreams returns true if the connection natively supports
+// unidirectional streams.
+func SupportsUniStreams(c MuxedConn) bool {
+	_, ok := c.(UniStreamConn)
+	return ok
+}
+
+// OpenUniStream opens a unidirectional stream if the connection supports
+// them, and a regular stream otherwise. The remote side then gets the stream
+// from AcceptStream, and should just not write to it.
+func OpenUniStream(c MuxedConn) (SendStream, error) {
+	if uc, ok := c.(UniStreamConn); ok {
+		return uc.OpenUniStream()
+	}
+	return c.OpenStream()
+}
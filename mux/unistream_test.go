@@ -0,0 +1,51 @@
+package mux
+
+import "testing"
+
+type fakeStream struct {
+	MuxedStream
+	uni bool
+}
+
+// fakeConn opens bidirectional streams.
+type fakeConn struct {
+	MuxedConn
+}
+
+func (fakeConn) OpenStream() (MuxedStream, error) { return &fakeStream{}, nil }
+
+// fakeUniConn also opens native unidirectional streams.
+type fakeUniConn struct {
+	fakeConn
+}
+
+func (fakeUniConn) OpenUniStream() (SendStream, error) { return &fakeStream{uni: true}, nil }
+func (fakeUniConn) AcceptUniStream() (ReceiveStream, error) {
+	return &fakeStream{uni: true}, nil
+}
+
+var _ UniStreamConn = fakeUniConn{}
+
+func TestOpenUniStream(t *testing.T) {
+	if SupportsUniStreams(fakeConn{}) {
+		t.Fatal("expected a plain muxed connection not to support unidirectional streams")
+	}
+	s, err := OpenUniStream(fakeConn{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.(*fakeStream).uni {
+		t.Fatal("expected a regular stream as fallback")
+	}
+
+	if !SupportsUniStreams(fakeUniConn{}) {
+		t.Fatal("expected unidirectional stream support")
+	}
+	s, err = OpenUniStream(fakeUniConn{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.(*fakeStream).uni {
+		t.Fatal("expected a native unidirectional stream")
+	}
+}
@@ -0,0 +1,111 @@
+package network
+
+// Admission is the decision of an AdmissionController about a pending inbound
+// connection.
+type Admission int
+
+const (
+	// Admit upgrades the connection right away.
+	Admit Admission = iota
+	// Deprioritize upgrades the connection once no admitted connection is
+	// waiting for an upgrade slot.
+	Deprioritize
+	// Shed closes the connection without upgrading it.
+	Shed
+)
+
+func (a Admission) String() string {
+	switch a {
+	case Admit:
+		return "admit"
+	case Deprioritize:
+		return "deprioritize"
+	case Shed:
+		return "shed"
+	default:
+		return "unknown"
+	}
+}
+
+// UpgradeLoad describes the inbound upgrades in progress when a connection is
+// accepted.
+type UpgradeLoad struct {
+	// Pending is the number of inbound connections accepted but not yet
+	// upgraded, including the ones waiting for an upgrade slot.
+	Pending int
+	// MaxPending is the number of upgrade slots, i.e. of concurrent
+	// handshakes.
+	MaxPending int
+}
+
+// AdmissionController is consulted when an inbound connection is accepted,
+// before spending any handshake CPU on it. It's an admission-control point
+// ahead of the connection gater, which can only see the peer once the
+// security handshake completed: under load, the node can deprioritize or shed
+// connections by source address.
+//
+// Implementations are called from the accept loop, and must be fast and safe
+// for concurrent use.
+type AdmissionController interface {
+	AdmitInbound(addrs ConnMultiaddrs, load UpgradeLoad) Admission
+}
+
+// AdmissionControllerFunc is a function implementing AdmissionController.
+type AdmissionControllerFunc func(addrs ConnMultiaddrs, load UpgradeLoad) Admission
+
+// AdmitInbound calls f(addrs, load).
+func (f AdmissionControllerFunc) AdmitInbound(addrs ConnMultiaddrs, load UpgradeLoad) Admission {
+	return f(addrs, load)
+}
+
+// AdmissionNetwork is implemented by networks consulting an
+// AdmissionController for inbound connections.
+type AdmissionNetwork interface {
+	Network
+
+	// SetAdmissionController sets the controller consulted for every
+	// inbound connection accepted after the call. Nil admits all
+	// connections.
+	SetAdmissionController(AdmissionController)
+}
+
+// SetAdmissionController sets the network's admission controller, or returns
+// ErrAdmissionNotSupported if the network doesn't implement
+// AdmissionNetwork.
+func SetAdmissionController(n Network, c AdmissionController) error {
+	an, ok := n.(AdmissionNetwork)
+	if !ok {
+		return ErrAdmissionNotSupported
+	}
+	an.SetAdmissionController(c)
+	return nil
+}
+
+// LoadShedder is an AdmissionController admitting everything under normal
+// load, deprioritizing connections from non-preferred sources once Pending
+// reaches HighWater, and shedding them once it reaches Critical. Zero
+// thresholds are disabled.
+type LoadShedder struct {
+	HighWater, Critical int
+
+	// Preferred returns true for sources that are always admitted, e.g.
+	// private networks or known infrastructure. Nil prefers no source.
+	Preferred func(ConnMultiaddrs) bool
+}
+
+var _ AdmissionController = (*LoadShedder)(nil)
+
+// AdmitInbound implements AdmissionController.
+func (l *LoadShedder) AdmitInbound(addrs ConnMultiaddrs, load UpgradeLoad) Admission {
+	if l.Preferred != nil && l.Preferred(addrs) {
+		return Admit
+	}
+	switch {
+	case l.Critical > 0 && load.Pending >= l.Critical:
+		return Shed
+	case l.HighWater > 0 && load.Pending >= l.HighWater:
+		return Deprioritize
+	default:
+		return Admit
+	}
+}
@@ -0,0 +1,45 @@
+package network
+
+import (
+	"testing"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+type remoteAddrs struct {
+	ConnMultiaddrs
+	remote ma.Multiaddr
+}
+
+func (a remoteAddrs) RemoteMultiaddr() ma.Multiaddr { return a.remote }
+
+func TestLoadShedder(t *testing.T) {
+	preferred := remoteAddrs{remote: ma.StringCast("/ip4/10.0.0.1/tcp/4001")}
+	other := remoteAddrs{remote: ma.StringCast("/ip4/1.2.3.4/tcp/4001")}
+	l := &LoadShedder{
+		HighWater: 10,
+		Critical:  20,
+		Preferred: func(addrs ConnMultiaddrs) bool {
+			return addrs.RemoteMultiaddr().Equal(preferred.remote)
+		},
+	}
+
+	for _, tc := range []struct {
+		addrs   ConnMultiaddrs
+		pending int
+		want    Admission
+	}{
+		{other, 5, Admit},
+		{other, 10, Deprioritize},
+		{other, 20, Shed},
+		{preferred, 50, Admit},
+	} {
+		if got := l.AdmitInbound(tc.addrs, UpgradeLoad{Pending: tc.pending, MaxPending: 8}); got != tc.want {
+			t.Errorf("%s with %d pending: expected %s, got %s", tc.addrs.RemoteMultiaddr(), tc.pending, tc.want, got)
+		}
+	}
+
+	if err := SetAdmissionController(nil, l); err != ErrAdmissionNotSupported {
+		t.Fatalf("expected ErrAdmissionNotSupported, got %v", err)
+	}
+}
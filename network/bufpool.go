@@ -0,0 +1,69 @@
+package network
+
+import (
+	"math/bits"
+	"sync"
+)
+
+// maxPooledBufferSize is the capacity of the largest buffers kept by
+// BufferPool; larger buffers are allocated on demand and left to the garbage
+// collector.
+const maxPooledBufferSize = 1 << 24
+
+// GlobalBufferPool is a BufferPool shared by muxers and codecs.
+var GlobalBufferPool = new(BufferPool)
+
+// BufferPool is a pool of byte buffers whose memory is accounted in resource
+// scopes: Get reserves the memory of the buffer in the given scope, and Put
+// releases it. This lets muxers and codecs share pooled buffers while the
+// resource manager still sees how much memory each peer, protocol or service
+// holds.
+//
+// Buffers are pooled by power-of-two capacity; the capacity, not the
+// requested length, is what's reserved. The zero value is ready to use.
+type BufferPool struct {
+	// pools[i] holds buffers with a capacity of 1<<i.
+	pools [25]sync.Pool
+}
+
+// bufferClass returns the index of the pool of buffers large enough for
+// length bytes.
+func bufferClass(length int) int {
+	if length <= 1 {
+		return 0
+	}
+	return bits.Len(uint(length - 1))
+}
+
+// Get returns a buffer of the given length, reserving its memory in the scope
+// at the given priority. It fails if the reservation is denied.
+func (p *BufferPool) Get(scope ResourceScope, length int, prio uint8) ([]byte, error) {
+	if length > maxPooledBufferSize {
+		if err := scope.ReserveMemory(length, prio); err != nil {
+			return nil, err
+		}
+		return make([]byte, length), nil
+	}
+
+	class := bufferClass(length)
+	if err := scope.ReserveMemory(1<<class, prio); err != nil {
+		return nil, err
+	}
+	if b, ok := p.pools[class].Get().(*[]byte); ok {
+		return (*b)[:length], nil
+	}
+	return make([]byte, length, 1<<class), nil
+}
+
+// Put returns a buffer obtained with Get to the pool, releasing its memory in
+// the scope it was reserved in. The buffer must not be used afterwards.
+func (p *BufferPool) Put(scope ResourceScope, buf []byte) {
+	c := cap(buf)
+	scope.ReleaseMemory(c)
+	if c == 0 || c > maxPooledBufferSize || c&(c-1) != 0 {
+		// Not one of ours.
+		return
+	}
+	buf = buf[:0]
+	p.pools[bits.Len(uint(c))-1].Put(&buf)
+}
@@ -0,0 +1,61 @@
+package network
+
+import (
+	"errors"
+	"testing"
+)
+
+type limitedScope struct {
+	limit, used int
+}
+
+func (s *limitedScope) ReserveMemory(size int, _ uint8) error {
+	if s.used+size > s.limit {
+		return errors.New("limit exceeded")
+	}
+	s.used += size
+	return nil
+}
+
+func (s *limitedScope) ReleaseMemory(size int) { s.used -= size }
+func (s *limitedScope) Stat() ScopeStat        { return ScopeStat{Memory: int64(s.used)} }
+
+func TestBufferPool(t *testing.T) {
+	var pool BufferPool
+	scope := &limitedScope{limit: 4096}
+
+	b, err := pool.Get(scope, 1000, ReservationPriorityAlways)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 1000 || cap(b) != 1024 {
+		t.Fatalf("unexpected buffer: len %d, cap %d", len(b), cap(b))
+	}
+	if scope.used != 1024 {
+		t.Fatalf("expected the capacity to be reserved, got %d", scope.used)
+	}
+
+	if _, err := pool.Get(scope, 4000, ReservationPriorityAlways); err == nil {
+		t.Fatal("expected the reservation to be denied")
+	}
+	if scope.used != 1024 {
+		t.Fatalf("denied reservation changed the usage: %d", scope.used)
+	}
+
+	pool.Put(scope, b)
+	if scope.used != 0 {
+		t.Fatalf("expected the memory to be released, got %d", scope.used)
+	}
+
+	b, err = pool.Get(scope, 600, ReservationPriorityAlways)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 600 || cap(b) != 1024 {
+		t.Fatalf("unexpected buffer: len %d, cap %d", len(b), cap(b))
+	}
+	pool.Put(scope, b)
+	if scope.used != 0 {
+		t.Fatalf("expected the memory to be released, got %d", scope.used)
+	}
+}
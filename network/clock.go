@@ -0,0 +1,166 @@
+package network
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time of components using timers (backoffs, TTLs,
+// rate limits, ...). Components default to RealClock; simulations and tests
+// inject a MockClock to advance virtual time deterministically instead of
+// sleeping.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer creates a timer firing once after d.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by a Clock, like time.Timer.
+type Timer interface {
+	// C returns the channel the current time is sent on when the timer
+	// fires.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing. It returns false if the timer
+	// already fired or was stopped.
+	Stop() bool
+
+	// Reset changes the timer to fire after d. It returns false if the
+	// timer had fired or been stopped.
+	Reset(d time.Duration) bool
+}
+
+// RealClock is the Clock backed by the time package.
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time        { return t.t.C }
+func (t realTimer) Stop() bool                 { return t.t.Stop() }
+func (t realTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+// MockClock is a Clock whose time only moves when told to. Timers fire,
+// in order, when the clock is advanced past their deadline.
+//
+// A MockClock is safe for concurrent use.
+type MockClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*mockTimer
+}
+
+var _ Clock = (*MockClock)(nil)
+
+// NewMockClock creates a MockClock set to the given time.
+func NewMockClock(now time.Time) *MockClock {
+	return &MockClock{now: now}
+}
+
+// Now returns the clock's current time.
+func (c *MockClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer creates a timer firing once the clock is advanced by d.
+func (c *MockClock) NewTimer(d time.Duration) Timer {
+	t := &mockTimer{clock: c, c: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers that expire in the
+// meantime.
+func (c *MockClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.setLocked(c.now.Add(d))
+	c.mu.Unlock()
+}
+
+// Set moves the clock to the given time, which must not be before the current
+// time, firing the timers that expire in the meantime.
+func (c *MockClock) Set(t time.Time) {
+	c.mu.Lock()
+	if t.After(c.now) {
+		c.setLocked(t)
+	}
+	c.mu.Unlock()
+}
+
+func (c *MockClock) setLocked(t time.Time) {
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].deadline.Before(c.timers[j].deadline)
+	})
+	remaining := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.deadline.After(t) {
+			remaining = append(remaining, timer)
+			continue
+		}
+		// Timers see the time they were due at, as they would have
+		// with a real clock.
+		select {
+		case timer.c <- timer.deadline:
+		default:
+		}
+	}
+	c.timers = remaining
+	c.now = t
+}
+
+type mockTimer struct {
+	clock    *MockClock
+	c        chan time.Time
+	deadline time.Time
+}
+
+func (t *mockTimer) C() <-chan time.Time { return t.c }
+
+// stopLocked removes the timer from the clock, and returns true if it was
+// pending.
+func (t *mockTimer) stopLocked() bool {
+	for i, other := range t.clock.timers {
+		if other == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *mockTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.stopLocked()
+}
+
+func (t *mockTimer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pending := t.stopLocked()
+	t.deadline = c.now.Add(d)
+	if d <= 0 {
+		select {
+		case t.c <- c.now:
+		default:
+		}
+		return pending
+	}
+	c.timers = append(c.timers, t)
+	return pending
+}
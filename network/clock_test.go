@@ -0,0 +1,57 @@
+package network
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMockClock(t *testing.T) {
+	start := time.Unix(1000, 0)
+	c := NewMockClock(start)
+
+	t1 := c.NewTimer(time.Second)
+	t2 := c.NewTimer(3 * time.Second)
+	stopped := c.NewTimer(2 * time.Second)
+	if !stopped.Stop() {
+		t.Fatal("expected pending timer to stop")
+	}
+
+	c.Advance(500 * time.Millisecond)
+	select {
+	case <-t1.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	c.Advance(2 * time.Second)
+	select {
+	case at := <-t1.C():
+		if !at.Equal(start.Add(time.Second)) {
+			t.Fatalf("timer fired at %v", at)
+		}
+	default:
+		t.Fatal("expected timer to fire")
+	}
+	select {
+	case <-t2.C():
+		t.Fatal("timer fired early")
+	case <-stopped.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+
+	if t1.Reset(time.Second) {
+		t.Fatal("expected Reset of a fired timer to return false")
+	}
+	c.Set(start.Add(4 * time.Second))
+	for _, timer := range []Timer{t1, t2} {
+		select {
+		case <-timer.C():
+		default:
+			t.Fatal("expected timer to fire")
+		}
+	}
+	if now := c.Now(); !now.Equal(start.Add(4 * time.Second)) {
+		t.Fatalf("unexpected time %v", now)
+	}
+}
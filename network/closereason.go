@@ -0,0 +1,100 @@
+package network
+
+// CloseReason describes why a connection was closed, so logs and metrics can
+// tell operator action from failures.
+type CloseReason int
+
+const (
+	// CloseReasonUnknown is the reason of connections closed without one,
+	// or by networks not tracking reasons.
+	CloseReasonUnknown CloseReason = iota
+	// CloseReasonLocal means the connection was closed by a local policy or
+	// operator action, e.g. a connection manager trim or ClosePeer.
+	CloseReasonLocal
+	// CloseReasonRemote means the remote peer closed or reset the
+	// connection.
+	CloseReasonRemote
+	// CloseReasonIdleTimeout means the connection was closed after being
+	// idle for too long.
+	CloseReasonIdleTimeout
+	// CloseReasonResourceLimit means the connection was closed to stay
+	// within resource limits.
+	CloseReasonResourceLimit
+	// CloseReasonError means the connection failed, e.g. a transport or
+	// protocol error.
+	CloseReasonError
+	// CloseReasonOpen is the reason reported for connections that are
+	// still open, telling them apart from connections closed without a
+	// reason.
+	CloseReasonOpen
+)
+
+func (r CloseReason) String() string {
+	switch r {
+	case CloseReasonLocal:
+		return "local"
+	case CloseReasonRemote:
+		return "remote"
+	case CloseReasonIdleTimeout:
+		return "idle-timeout"
+	case CloseReasonResourceLimit:
+		return "resource-limit"
+	case CloseReasonError:
+		return "error"
+	case CloseReasonOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// ConnCloseReason is implemented by connections recording why they were
+// closed.
+type ConnCloseReason interface {
+	// CloseWithReason closes the connection, recording the reason. The
+	// reason of the first close is kept.
+	CloseWithReason(CloseReason) error
+
+	// CloseReason returns the reason the connection was closed, or
+	// CloseReasonOpen if it's still open.
+	CloseReason() CloseReason
+}
+
+// CloseConn closes the connection with the given reason, if the connection
+// implements ConnCloseReason. Otherwise, it's closed without one.
+func CloseConn(c Conn, reason CloseReason) error {
+	if cr, ok := c.(ConnCloseReason); ok {
+		return cr.CloseWithReason(reason)
+	}
+	return c.Close()
+}
+
+// GetCloseReason returns the reason the connection was closed, or
+// CloseReasonUnknown if the connection doesn't implement ConnCloseReason.
+func GetCloseReason(c Conn) CloseReason {
+	if cr, ok := c.(ConnCloseReason); ok {
+		return cr.CloseReason()
+	}
+	return CloseReasonUnknown
+}
+
+// ReasonNotifiee is implemented by notifiees receiving the reason connections
+// were closed. Networks notify them with DisconnectedWithReason instead of
+// Disconnected.
+type ReasonNotifiee interface {
+	Notifiee
+
+	// DisconnectedWithReason is called when a connection closed.
+	DisconnectedWithReason(Network, Conn, CloseReason)
+}
+
+// NotifyDisconnected notifies the notifiee that the connection closed, with
+// DisconnectedWithReason if it implements ReasonNotifiee, or Disconnected
+// otherwise. Networks call it for each notifiee.
+func NotifyDisconnected(n Network, f Notifiee, c Conn, reason CloseReason) {
+	if rn, ok := f.(ReasonNotifiee); ok {
+		rn.DisconnectedWithReason(n, c, reason)
+		return
+	}
+	f.Disconnected(n, c)
+}
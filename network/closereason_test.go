@@ -0,0 +1,58 @@
+package network
+
+import "testing"
+
+type reasonConn struct {
+	Conn
+	closed bool
+	reason CloseReason
+}
+
+func (c *reasonConn) CloseWithReason(r CloseReason) error {
+	if !c.closed {
+		c.closed, c.reason = true, r
+	}
+	return nil
+}
+
+func (c *reasonConn) CloseReason() CloseReason {
+	if !c.closed {
+		return CloseReasonOpen
+	}
+	return c.reason
+}
+
+func TestCloseReason(t *testing.T) {
+	c := &reasonConn{}
+	if r := GetCloseReason(c); r != CloseReasonOpen {
+		t.Fatalf("unexpected reason %s for an open connection", r)
+	}
+	if err := CloseConn(c, CloseReasonIdleTimeout); err != nil {
+		t.Fatal(err)
+	}
+	if r := GetCloseReason(c); r != CloseReasonIdleTimeout {
+		t.Fatalf("unexpected reason %s", r)
+	}
+	if r := GetCloseReason(struct{ Conn }{}); r != CloseReasonUnknown {
+		t.Fatalf("unexpected reason %s", r)
+	}
+
+	var (
+		notifee      NotifyBundle
+		disconnected int
+		got          CloseReason
+	)
+	notifee.DisconnectedF = func(Network, Conn) { disconnected++ }
+	NotifyDisconnected(nil, &notifee, c, CloseReasonLocal)
+	if disconnected != 1 {
+		t.Fatal("expected DisconnectedF to be called without DisconnectedWithReasonF")
+	}
+	notifee.DisconnectedWithReasonF = func(_ Network, _ Conn, r CloseReason) { got = r }
+	NotifyDisconnected(nil, &notifee, c, CloseReasonResourceLimit)
+	if got != CloseReasonResourceLimit || disconnected != 1 {
+		t.Fatalf("unexpected reason %s", got)
+	}
+
+	// Plain notifiees still get notified.
+	NotifyDisconnected(nil, GlobalNoopNotifiee, c, CloseReasonRemote)
+}
@@ -0,0 +1,249 @@
+package network
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// Names of the stream compression algorithms. Only identity is implemented
+// in core; implementations of the others are registered by the application.
+const (
+	CompressionIdentity = "identity"
+	CompressionZstd     = "zstd"
+	CompressionSnappy   = "snappy"
+)
+
+// ErrNoCommonCompression is returned when the two sides of a stream don't
+// support a common compression algorithm and identity is disallowed.
+var ErrNoCommonCompression = errors.New("no common stream compression algorithm")
+
+// Compressor is a stream compression algorithm.
+type Compressor interface {
+	// Name returns the name negotiated for the algorithm, e.g. "zstd".
+	Name() string
+
+	// MemoryPerStream is the memory the algorithm holds for each stream
+	// it compresses, reserved in the stream's resource scope.
+	MemoryPerStream() int
+
+	// NewWriter returns a writer compressing to w. Flush must write out
+	// all the data written so far, so that the remote side can decompress
+	// it.
+	NewWriter(w io.Writer) CompressWriter
+
+	// NewReader returns a reader decompressing from r.
+	NewReader(r io.Reader) io.Reader
+}
+
+// CompressWriter is a writer compressing data.
+type CompressWriter interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// IdentityCompressor is the Compressor leaving data as-is.
+var IdentityCompressor Compressor = identityCompressor{}
+
+type identityCompressor struct{}
+
+func (identityCompressor) Name() string                         { return CompressionIdentity }
+func (identityCompressor) MemoryPerStream() int                 { return 0 }
+func (identityCompressor) NewWriter(w io.Writer) CompressWriter { return nopCompressWriter{w} }
+func (identityCompressor) NewReader(r io.Reader) io.Reader      { return r }
+
+type nopCompressWriter struct {
+	io.Writer
+}
+
+func (nopCompressWriter) Flush() error { return nil }
+func (nopCompressWriter) Close() error { return nil }
+
+// Compression configures the compression negotiated on the streams of a
+// protocol.
+type Compression struct {
+	// Compressors lists the supported algorithms, most preferred first.
+	Compressors []Compressor
+
+	// DisallowIdentity makes the negotiation fail if no other algorithm
+	// is supported by both sides, instead of falling back to identity.
+	DisallowIdentity bool
+
+	// Timeout bounds the negotiation. Zero means DefaultHelloTimeout.
+	Timeout time.Duration
+}
+
+// CompressedStream is a stream whose data is compressed.
+type CompressedStream interface {
+	Stream
+
+	// Compression returns the name of the negotiated algorithm.
+	Compression() string
+}
+
+// NegotiateCompression negotiates the compression of the stream, right after
+// protocol negotiation: both sides send the names of the algorithms they
+// support, in a hello handshake, and the first algorithm of the stream
+// opener's list supported by the other side is chosen, falling back to
+// identity. The memory held by the algorithm is reserved in the stream's
+// resource scope, if it has one, and released when the stream is closed or
+// reset.
+//
+// The stream isn't reset on error: that's up to the caller.
+func NegotiateCompression(s Stream, c *Compression) (CompressedStream, error) {
+	hello := &Hello{
+		Local: func(Stream) ([]byte, error) {
+			names := make([]string, len(c.Compressors))
+			for i, comp := range c.Compressors {
+				names[i] = comp.Name()
+			}
+			return []byte(strings.Join(names, "\n")), nil
+		},
+		Verify: func(s Stream, remote []byte) (interface{}, error) {
+			return c.choose(s.Stat().Direction, strings.Split(string(remote), "\n"))
+		},
+		Timeout: c.Timeout,
+	}
+	session, err := RunHello(s, hello)
+	if err != nil {
+		return nil, err
+	}
+	comp := session.(Compressor)
+
+	scope := NullScope
+	if ss, ok := s.(ScopedStream); ok {
+		scope = ss.Scope()
+	}
+	mem := comp.MemoryPerStream()
+	if err := scope.ReserveMemory(mem, ReservationPriorityMedium); err != nil {
+		return nil, err
+	}
+	return &compressedStream{
+		Stream: s,
+		comp:   comp,
+		scope:  scope,
+		mem:    mem,
+		r:      comp.NewReader(s),
+		w:      comp.NewWriter(s),
+	}, nil
+}
+
+// choose picks the algorithm given the remote side's list: the stream
+// opener's preferences win.
+func (c *Compression) choose(dir Direction, remote []string) (Compressor, error) {
+	remoteSet := make(map[string]bool, len(remote))
+	for _, name := range remote {
+		remoteSet[name] = true
+	}
+	if dir == DirOutbound {
+		for _, comp := range c.Compressors {
+			if remoteSet[comp.Name()] {
+				return comp, nil
+			}
+		}
+	} else {
+		for _, name := range remote {
+			for _, comp := range c.Compressors {
+				if comp.Name() == name {
+					return comp, nil
+				}
+			}
+		}
+	}
+	if c.DisallowIdentity {
+		return nil, ErrNoCommonCompression
+	}
+	return IdentityCompressor, nil
+}
+
+type compressedStream struct {
+	Stream
+	comp  Compressor
+	scope ResourceScope
+	mem   int
+
+	r io.Reader
+
+	wlk sync.Mutex
+	w   CompressWriter
+
+	releaseOnce sync.Once
+}
+
+func (s *compressedStream) Compression() string { return s.comp.Name() }
+
+func (s *compressedStream) Read(b []byte) (int, error) {
+	return s.r.Read(b)
+}
+
+// Write compresses and flushes the data, so that every write is delivered.
+func (s *compressedStream) Write(b []byte) (int, error) {
+	s.wlk.Lock()
+	defer s.wlk.Unlock()
+	n, err := s.w.Write(b)
+	if err != nil {
+		return n, err
+	}
+	return n, s.w.Flush()
+}
+
+func (s *compressedStream) release() {
+	s.releaseOnce.Do(func() { s.scope.ReleaseMemory(s.mem) })
+}
+
+func (s *compressedStream) Close() error {
+	s.wlk.Lock()
+	err := s.w.Close()
+	s.wlk.Unlock()
+	s.release()
+	if cerr := s.Stream.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (s *compressedStream) Reset() error {
+	s.release()
+	return s.Stream.Reset()
+}
+
+// CompressionHandler wraps a stream handler so that compression is negotiated
+// before it's called with the CompressedStream. Streams whose negotiation
+// fails are reset.
+func CompressionHandler(c *Compression, handler StreamHandler) StreamHandler {
+	return func(s Stream) {
+		cs, err := NegotiateCompression(s, c)
+		if err != nil {
+			s.Reset()
+			return
+		}
+		handler(cs)
+	}
+}
+
+// CompressionNetwork is implemented by networks negotiating compression in
+// stream setup: on inbound streams before they're handed to their handler,
+// and on outbound ones before NewStream returns.
+type CompressionNetwork interface {
+	Network
+
+	// SetCompression sets the compression of the protocol's streams. Nil
+	// disables compression.
+	SetCompression(protocol.ID, *Compression)
+}
+
+// SetCompression sets the network's compression for the protocol, or returns
+// ErrCompressionNotSupported if the network doesn't implement
+// CompressionNetwork.
+func SetCompression(n Network, proto protocol.ID, c *Compression) error {
+	cn, ok := n.(CompressionNetwork)
+	if !ok {
+		return ErrCompressionNotSupported
+	}
+	cn.SetCompression(proto, c)
+	return nil
+}
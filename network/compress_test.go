@@ -0,0 +1,102 @@
+package network
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// xorCompressor "compresses" by flipping bits, which is enough to check the
+// stream's data goes through it.
+type xorCompressor struct{}
+
+func (xorCompressor) Name() string                         { return "xor" }
+func (xorCompressor) MemoryPerStream() int                 { return 64 }
+func (xorCompressor) NewWriter(w io.Writer) CompressWriter { return nopCompressWriter{xorWriter{w}} }
+func (xorCompressor) NewReader(r io.Reader) io.Reader      { return xorReader{r} }
+
+type xorWriter struct{ w io.Writer }
+
+func (x xorWriter) Write(b []byte) (int, error) {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ 0xff
+	}
+	return x.w.Write(out)
+}
+
+type xorReader struct{ r io.Reader }
+
+func (x xorReader) Read(b []byte) (int, error) {
+	n, err := x.r.Read(b)
+	for i := range b[:n] {
+		b[i] ^= 0xff
+	}
+	return n, err
+}
+
+type testStreamScope struct {
+	*limitedScope
+}
+
+func (testStreamScope) SetService(string) error    { return nil }
+func (testStreamScope) ServiceScope() ServiceScope { return nil }
+
+type compressTestStream struct {
+	helloTestStream
+	dir   Direction
+	scope *limitedScope
+}
+
+func (s *compressTestStream) Stat() Stat         { return Stat{Direction: s.dir} }
+func (s *compressTestStream) Scope() StreamScope { return testStreamScope{s.scope} }
+func (s *compressTestStream) Close() error       { return nil }
+
+func TestNegotiateCompression(t *testing.T) {
+	c := &Compression{Compressors: []Compressor{IdentityCompressor, xorCompressor{}}}
+
+	// The opener prefers identity, the other side xor: the opener wins.
+	s := &compressTestStream{dir: DirInbound, scope: &limitedScope{limit: 1024}}
+	s.queueHello([]byte("identity\nxor"), "")
+	cs, err := NegotiateCompression(s, &Compression{Compressors: []Compressor{xorCompressor{}, IdentityCompressor}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.Compression() != CompressionIdentity {
+		t.Fatalf("expected identity, got %s", cs.Compression())
+	}
+
+	s = &compressTestStream{dir: DirOutbound, scope: &limitedScope{limit: 1024}}
+	s.queueHello([]byte("xor"), "\x9e\x9d")
+	cs, err = NegotiateCompression(s, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.Compression() != "xor" || s.scope.used != 64 {
+		t.Fatalf("expected xor with its memory reserved, got %s with %d bytes", cs.Compression(), s.scope.used)
+	}
+	s.out.Reset()
+	if _, err := cs.Write([]byte{0x01}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(s.out.Bytes(), []byte{0xfe}) {
+		t.Fatalf("data wasn't compressed: %x", s.out.Bytes())
+	}
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(cs, buf); err != nil || !bytes.Equal(buf, []byte{0x61, 0x62}) {
+		t.Fatalf("data wasn't decompressed: %x, %v", buf, err)
+	}
+	cs.Close()
+	cs.Reset()
+	if s.scope.used != 0 {
+		t.Fatalf("expected the memory to be released once, %d bytes left", s.scope.used)
+	}
+
+	s = &compressTestStream{dir: DirOutbound, scope: &limitedScope{limit: 1024}}
+	s.queueHello([]byte("zstd"), "")
+	c.DisallowIdentity = true
+	c.Compressors = []Compressor{xorCompressor{}}
+	if _, err := NegotiateCompression(s, c); err != ErrNoCommonCompression {
+		t.Fatalf("expected ErrNoCommonCompression, got %v", err)
+	}
+}
@@ -38,3 +38,20 @@ func TestSettingTimeout(t *testing.T) {
 		t.Fatal("peer timeout doesn't match set timeout")
 	}
 }
+
+func TestStreamOptions(t *testing.T) {
+	opts := GetStreamOptions(context.Background())
+	if opts.NegotiationTimeout != DefaultNegotiationTimeout || !opts.WaitForDial {
+		t.Fatal("expected default stream options")
+	}
+
+	ctx := WithStreamOptions(context.Background(), NegotiationTimeout(time.Second), AllowLimitedConn(true))
+	ctx = WithStreamOptions(ctx, WaitForDial(false), ExpectedMessageSizes(10, 20))
+	opts = GetStreamOptions(ctx)
+	if opts.NegotiationTimeout != time.Second || !opts.AllowLimitedConn {
+		t.Fatal("expected options from the outer context to be kept")
+	}
+	if opts.WaitForDial || opts.ExpectedSendSize != 10 || opts.ExpectedRecvSize != 20 {
+		t.Fatal("expected options to be applied")
+	}
+}
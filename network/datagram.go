@@ -0,0 +1,90 @@
+package network
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// ErrDatagramTooLarge is returned when sending a datagram larger than the
+// connection's maximum datagram size.
+var ErrDatagramTooLarge = errors.New("datagram too large")
+
+// DatagramHandler is called with the datagrams of a protocol received over a
+// connection. The data is only valid during the call.
+type DatagramHandler func(c Conn, data []byte)
+
+// ConnDatagramer is implemented by connections that can carry unreliable,
+// bounded datagrams alongside their streams, e.g. QUIC connections with the
+// datagram extension. Protocols exchanging tiny messages, like NAT keepalives
+// or gossip beacons, avoid the cost of stream setup.
+//
+// Datagrams may be lost, duplicated or reordered.
+type ConnDatagramer interface {
+	// MaxDatagramSize returns the maximum size of the data of a datagram,
+	// excluding the protocol ID.
+	MaxDatagramSize() int
+
+	// SendDatagram sends a datagram of the protocol to the remote peer,
+	// which hands it to the protocol's DatagramHandler. It fails with
+	// ErrDatagramTooLarge if the data exceeds MaxDatagramSize.
+	SendDatagram(proto protocol.ID, data []byte) error
+}
+
+// SendDatagram sends a datagram of the protocol over the connection, or
+// returns ErrDatagramsNotSupported if the connection doesn't implement
+// ConnDatagramer.
+func SendDatagram(c Conn, proto protocol.ID, data []byte) error {
+	d, ok := c.(ConnDatagramer)
+	if !ok {
+		return ErrDatagramsNotSupported
+	}
+	if len(data) > d.MaxDatagramSize() {
+		return ErrDatagramTooLarge
+	}
+	return d.SendDatagram(proto, data)
+}
+
+// DatagramNetwork is implemented by networks dispatching the datagrams
+// received over their connections to handlers.
+type DatagramNetwork interface {
+	Network
+
+	// SetDatagramHandler sets the handler of the protocol's datagrams. Nil
+	// removes it; datagrams of protocols without a handler are dropped.
+	SetDatagramHandler(protocol.ID, DatagramHandler)
+}
+
+// SetDatagramHandler sets the network's handler for the protocol's datagrams,
+// or returns ErrDatagramsNotSupported if the network doesn't implement
+// DatagramNetwork.
+func SetDatagramHandler(n Network, proto protocol.ID, h DatagramHandler) error {
+	dn, ok := n.(DatagramNetwork)
+	if !ok {
+		return ErrDatagramsNotSupported
+	}
+	dn.SetDatagramHandler(proto, h)
+	return nil
+}
+
+// AppendDatagram appends the datagram framing used by connections
+// multiplexing protocols over a single datagram channel: the protocol ID,
+// prefixed with its length as an unsigned varint, followed by the data.
+func AppendDatagram(buf []byte, proto protocol.ID, data []byte) []byte {
+	var lbuf [binary.MaxVarintLen64]byte
+	buf = append(buf, lbuf[:binary.PutUvarint(lbuf[:], uint64(len(proto)))]...)
+	buf = append(buf, proto...)
+	return append(buf, data...)
+}
+
+// ParseDatagram parses a datagram framed with AppendDatagram. The returned
+// data aliases b.
+func ParseDatagram(b []byte) (protocol.ID, []byte, error) {
+	l, n := binary.Uvarint(b)
+	if n <= 0 || l > uint64(len(b)-n) {
+		return "", nil, errors.New("malformed datagram")
+	}
+	b = b[n:]
+	return protocol.ID(b[:l]), b[l:], nil
+}
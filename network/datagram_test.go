@@ -0,0 +1,47 @@
+package network
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+type datagramConn struct {
+	Conn
+	sent [][]byte
+}
+
+func (c *datagramConn) MaxDatagramSize() int { return 8 }
+
+func (c *datagramConn) SendDatagram(proto protocol.ID, data []byte) error {
+	c.sent = append(c.sent, AppendDatagram(nil, proto, data))
+	return nil
+}
+
+func TestDatagrams(t *testing.T) {
+	c := new(datagramConn)
+	if err := SendDatagram(c, "/ping", []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if err := SendDatagram(c, "/ping", make([]byte, 9)); err != ErrDatagramTooLarge {
+		t.Fatalf("expected ErrDatagramTooLarge, got %v", err)
+	}
+	if err := SendDatagram(struct{ Conn }{}, "/ping", nil); err != ErrDatagramsNotSupported {
+		t.Fatalf("expected ErrDatagramsNotSupported, got %v", err)
+	}
+	if len(c.sent) != 1 {
+		t.Fatalf("expected one datagram, got %d", len(c.sent))
+	}
+
+	proto, data, err := ParseDatagram(c.sent[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proto != "/ping" || !bytes.Equal(data, []byte("hi")) {
+		t.Fatalf("unexpected datagram %s: %q", proto, data)
+	}
+	if _, _, err := ParseDatagram([]byte{0x10, 'a'}); err == nil {
+		t.Fatal("expected a truncated datagram to be rejected")
+	}
+}
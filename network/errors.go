@@ -1,6 +1,9 @@
 package network
 
-import "errors"
+import (
+	"errors"
+	"net"
+)
 
 // ErrNoRemoteAddrs is returned when there are no addresses associated with a peer during a dial.
 var ErrNoRemoteAddrs = errors.New("no remote addresses")
@@ -8,3 +11,53 @@ var ErrNoRemoteAddrs = errors.New("no remote addresses")
 // ErrNoConn is returned when attempting to open a stream to a peer with the NoDial
 // option and no usable connection is available.
 var ErrNoConn = errors.New("no usable connection to peer")
+
+// ErrWrappersNotSupported is returned when registering a conn or stream wrapper
+// with a network that doesn't implement WrappingNetwork.
+var ErrWrappersNotSupported = errors.New("network does not support conn/stream wrappers")
+
+// ErrAdmissionNotSupported is returned when setting an admission controller on
+// a network that doesn't implement AdmissionNetwork.
+var ErrAdmissionNotSupported = errors.New("network does not support admission control")
+
+// ErrConnShed is returned when an inbound connection is shed by the admission
+// controller before its upgrade.
+var ErrConnShed = errors.New("inbound connection shed by admission control")
+
+// ErrHelloTooLarge is returned when the remote peer's hello exceeds the size
+// limit of the protocol's hello handshake.
+var ErrHelloTooLarge = errors.New("hello message too large")
+
+// ErrHelloNotSupported is returned when registering a hello handshake with a
+// network that doesn't implement HelloNetwork.
+var ErrHelloNotSupported = errors.New("network does not support hello handshakes")
+
+// ErrResourceSnapshotNotSupported is returned when saving or loading resource
+// snapshots with a network that doesn't implement ResourceSnapshotter.
+var ErrResourceSnapshotNotSupported = errors.New("network does not support resource snapshots")
+
+// ErrCompressionNotSupported is returned when setting stream compression on a
+// network that doesn't implement CompressionNetwork.
+var ErrCompressionNotSupported = errors.New("network does not support stream compression")
+
+// ErrDatagramsNotSupported is returned when sending datagrams over a connection
+// that doesn't implement ConnDatagramer, or setting a datagram handler on a
+// network that doesn't implement DatagramNetwork.
+var ErrDatagramsNotSupported = errors.New("datagrams not supported")
+
+// ErrThrottledStreamClosed is returned by the reads and writes of a
+// ThrottledStream interrupted by Close or Reset while waiting for bandwidth.
+var ErrThrottledStreamClosed = errors.New("throttled stream closed")
+
+// ErrDeadlineExceeded is returned by the reads and writes of a ThrottledStream
+// whose deadline passes while waiting for bandwidth. It implements net.Error,
+// and reports a timeout.
+var ErrDeadlineExceeded error = deadlineExceededError{}
+
+var _ net.Error = deadlineExceededError{}
+
+type deadlineExceededError struct{}
+
+func (deadlineExceededError) Error() string   { return "deadline exceeded" }
+func (deadlineExceededError) Timeout() bool   { return true }
+func (deadlineExceededError) Temporary() bool { return true }
@@ -0,0 +1,187 @@
+package network
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+var (
+	// DefaultMaxHelloSize is the size limit of hellos used when a Hello
+	// doesn't set one.
+	DefaultMaxHelloSize = 4 << 10
+
+	// DefaultHelloTimeout is the timeout of hello handshakes used when a
+	// Hello doesn't set one.
+	DefaultHelloTimeout = 10 * time.Second
+)
+
+// Hello is an application handshake run on a stream right after protocol
+// negotiation: both sides send a small hello message, e.g. carrying session
+// parameters, and verify the other side's. Hellos are authenticated by the
+// secure channel the stream runs over: the remote hello was sent by the
+// stream's remote peer.
+//
+// Running it in core stream setup gives every protocol the same size limit
+// and timeout handling, instead of ad-hoc handshakes.
+type Hello struct {
+	// Local returns the hello sent on the stream.
+	Local func(s Stream) ([]byte, error)
+
+	// Verify checks the remote peer's hello and returns the session
+	// parameters it carries, which are returned by RunHello, or, for hellos
+	// run by a HelloNetwork, made available through HelloStream. An error
+	// fails the handshake, and the stream is reset.
+	Verify func(s Stream, remote []byte) (interface{}, error)
+
+	// MaxSize is the size limit of the remote hello. Zero means
+	// DefaultMaxHelloSize.
+	MaxSize int
+
+	// Timeout bounds the whole handshake. Zero means DefaultHelloTimeout.
+	Timeout time.Duration
+}
+
+// HelloStream is implemented by the streams of HelloNetworks whose hello
+// handshake completed.
+type HelloStream interface {
+	Stream
+
+	// HelloSession returns the session parameters returned by Hello.Verify.
+	HelloSession() interface{}
+}
+
+// HelloStreamHandler is the handler of a stream whose hello handshake
+// completed, called with the session parameters returned by Hello.Verify.
+type HelloStreamHandler func(s Stream, session interface{})
+
+// RunHello runs the hello handshake on the stream: it sends the local hello,
+// reads the remote one, length-prefixed with an unsigned varint, and verifies
+// it. It returns the session parameters returned by Hello.Verify. The stream
+// isn't wrapped: the protocol keeps using s, along with the optional
+// interfaces it implements.
+//
+// The handshake's timeout is enforced with the stream's deadlines. Once it
+// completes, the deadlines are restored if the stream implements
+// DeadlineStream (earlier ones also bound the handshake), and cleared
+// otherwise.
+//
+// The stream isn't reset on error: that's up to the caller.
+func RunHello(s Stream, h *Hello) (interface{}, error) {
+	maxSize := h.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxHelloSize
+	}
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHelloTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	restore := func() error { return s.SetDeadline(time.Time{}) }
+	if ds, ok := s.(DeadlineStream); ok {
+		rd, wd := ds.ReadDeadline(), ds.WriteDeadline()
+		if err := s.SetReadDeadline(earliestDeadline(rd, deadline)); err != nil {
+			return nil, err
+		}
+		if err := s.SetWriteDeadline(earliestDeadline(wd, deadline)); err != nil {
+			return nil, err
+		}
+		restore = func() error {
+			if err := s.SetReadDeadline(rd); err != nil {
+				return err
+			}
+			return s.SetWriteDeadline(wd)
+		}
+	} else if err := s.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	local, err := h.Local(s)
+	if err != nil {
+		return nil, err
+	}
+	msg := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(local))
+	msg = append(msg[:binary.PutUvarint(msg, uint64(len(local)))], local...)
+	if _, err := s.Write(msg); err != nil {
+		return nil, err
+	}
+
+	size, err := binary.ReadUvarint(byteReader{s})
+	if err != nil {
+		return nil, err
+	}
+	if size > uint64(maxSize) {
+		return nil, ErrHelloTooLarge
+	}
+	remote := make([]byte, size)
+	if _, err := io.ReadFull(s, remote); err != nil {
+		return nil, err
+	}
+
+	session, err := h.Verify(s, remote)
+	if err != nil {
+		return nil, err
+	}
+	if err := restore(); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// earliestDeadline returns the earliest of the deadlines, the zero time
+// meaning none.
+func earliestDeadline(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
+
+// byteReader reads one byte at a time, so the hello's length prefix can be
+// decoded without reading ahead into the protocol's data.
+type byteReader struct {
+	r io.Reader
+}
+
+func (b byteReader) ReadByte() (byte, error) {
+	var buf [1]byte
+	_, err := io.ReadFull(b.r, buf[:])
+	return buf[0], err
+}
+
+// HelloHandler wraps a stream handler so that the hello handshake runs before
+// it's called with the stream and the session parameters. Streams whose
+// handshake fails are reset.
+func HelloHandler(h *Hello, handler HelloStreamHandler) StreamHandler {
+	return func(s Stream) {
+		session, err := RunHello(s, h)
+		if err != nil {
+			s.Reset()
+			return
+		}
+		handler(s, session)
+	}
+}
+
+// HelloNetwork is implemented by networks running hello handshakes in stream
+// setup: inbound streams before they're handed to their handler, and
+// outbound ones before NewStream returns.
+type HelloNetwork interface {
+	Network
+
+	// SetHello sets the hello handshake of the protocol. Nil removes it.
+	SetHello(protocol.ID, *Hello)
+}
+
+// SetHello sets the network's hello handshake for the protocol, or returns
+// ErrHelloNotSupported if the network doesn't implement HelloNetwork.
+func SetHello(n Network, proto protocol.ID, h *Hello) error {
+	hn, ok := n.(HelloNetwork)
+	if !ok {
+		return ErrHelloNotSupported
+	}
+	hn.SetHello(proto, h)
+	return nil
+}
@@ -0,0 +1,127 @@
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// helloTestStream reads the remote side's data from in, and writes to out.
+type helloTestStream struct {
+	Stream
+	in, out bytes.Buffer
+	reset   bool
+}
+
+func (s *helloTestStream) Read(b []byte) (int, error)  { return s.in.Read(b) }
+func (s *helloTestStream) Write(b []byte) (int, error) { return s.out.Write(b) }
+func (s *helloTestStream) SetDeadline(time.Time) error { return nil }
+func (s *helloTestStream) Reset() error                { s.reset = true; return nil }
+
+func (s *helloTestStream) queueHello(hello []byte, extra string) {
+	var lbuf [binary.MaxVarintLen64]byte
+	s.in.Write(lbuf[:binary.PutUvarint(lbuf[:], uint64(len(hello)))])
+	s.in.Write(hello)
+	s.in.WriteString(extra)
+}
+
+func TestHello(t *testing.T) {
+	h := &Hello{
+		Local: func(Stream) ([]byte, error) { return []byte("v=2"), nil },
+		Verify: func(_ Stream, remote []byte) (interface{}, error) {
+			if !bytes.HasPrefix(remote, []byte("v=")) {
+				return nil, errors.New("bad hello")
+			}
+			return string(remote[2:]), nil
+		},
+		MaxSize: 16,
+	}
+
+	s := new(helloTestStream)
+	s.queueHello([]byte("v=3"), "data")
+	session, err := RunHello(s, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session != "3" {
+		t.Fatalf("unexpected session %v", session)
+	}
+	if !bytes.Equal(s.out.Bytes(), []byte("\x03v=2")) {
+		t.Fatalf("unexpected local hello %q", s.out.Bytes())
+	}
+	if rest := s.in.String(); rest != "data" {
+		t.Fatalf("expected the protocol's data to be left unread, got %q", rest)
+	}
+
+	s = new(helloTestStream)
+	s.queueHello(make([]byte, 17), "")
+	if _, err := RunHello(s, h); err != ErrHelloTooLarge {
+		t.Fatalf("expected ErrHelloTooLarge, got %v", err)
+	}
+
+	s = new(helloTestStream)
+	s.queueHello([]byte("nope"), "")
+	called := false
+	HelloHandler(h, func(Stream, interface{}) { called = true })(s)
+	if called || !s.reset {
+		t.Fatal("expected a failed handshake to reset the stream")
+	}
+}
+
+func TestHelloHandlerKeepsStream(t *testing.T) {
+	h := &Hello{
+		Local:  func(Stream) ([]byte, error) { return nil, nil },
+		Verify: func(_ Stream, remote []byte) (interface{}, error) { return string(remote), nil },
+	}
+	s := new(deadlineStream)
+	s.queueHello([]byte("session"), "")
+	var got Stream
+	var session interface{}
+	HelloHandler(h, func(s Stream, sess interface{}) { got, session = s, sess })(s)
+	if session != "session" {
+		t.Fatalf("unexpected session %v", session)
+	}
+	if _, ok := got.(DeadlineStream); !ok || got != Stream(s) {
+		t.Fatal("expected the handler to get the stream itself, with its optional interfaces")
+	}
+}
+
+// deadlineStream tracks its deadlines.
+type deadlineStream struct {
+	helloTestStream
+	read, write time.Time
+}
+
+func (s *deadlineStream) SetDeadline(t time.Time) error      { s.read, s.write = t, t; return nil }
+func (s *deadlineStream) SetReadDeadline(t time.Time) error  { s.read = t; return nil }
+func (s *deadlineStream) SetWriteDeadline(t time.Time) error { s.write = t; return nil }
+func (s *deadlineStream) ReadDeadline() time.Time            { return s.read }
+func (s *deadlineStream) WriteDeadline() time.Time           { return s.write }
+
+func TestHelloRestoresDeadlines(t *testing.T) {
+	var during time.Time
+	h := &Hello{
+		Local: func(s Stream) ([]byte, error) {
+			during = s.(*deadlineStream).write
+			return nil, nil
+		},
+		Verify:  func(Stream, []byte) (interface{}, error) { return nil, nil },
+		Timeout: time.Minute,
+	}
+
+	read := time.Now().Add(time.Hour)
+	write := time.Now().Add(time.Second)
+	s := &deadlineStream{read: read, write: write}
+	s.queueHello(nil, "")
+	if _, err := RunHello(s, h); err != nil {
+		t.Fatal(err)
+	}
+	if !during.Equal(write) {
+		t.Fatal("expected an earlier deadline to bound the handshake")
+	}
+	if !s.read.Equal(read) || !s.write.Equal(write) {
+		t.Fatal("expected the deadlines to be restored")
+	}
+}
@@ -0,0 +1,50 @@
+package network
+
+import (
+	"io"
+	"net"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// NetworkMonitor watches the OS network interfaces and routes.
+//
+// Implementations emit an event.EvtLocalInterfacesChanged on the event bus
+// they're constructed with whenever the addresses of the local interfaces or
+// the default route change. This matters most on laptops and mobile devices,
+// which switch networks routinely.
+type NetworkMonitor interface {
+	io.Closer
+
+	// InterfaceAddrs returns the addresses of the local interfaces, as of
+	// the last change detected.
+	InterfaceAddrs() []ma.Multiaddr
+
+	// DefaultGateway returns the gateway of the default route, or nil if
+	// there is none.
+	DefaultGateway() net.IP
+}
+
+// DiffAddrs compares two sets of addresses, returning the addresses only
+// present in next (added) and those only present in prev (removed).
+func DiffAddrs(prev, next []ma.Multiaddr) (added, removed []ma.Multiaddr) {
+	seen := make(map[string]struct{}, len(prev))
+	for _, a := range prev {
+		seen[string(a.Bytes())] = struct{}{}
+	}
+	current := make(map[string]struct{}, len(next))
+	for _, a := range next {
+		k := string(a.Bytes())
+		current[k] = struct{}{}
+		if _, ok := seen[k]; !ok {
+			added = append(added, a)
+			seen[k] = struct{}{}
+		}
+	}
+	for _, a := range prev {
+		if _, ok := current[string(a.Bytes())]; !ok {
+			removed = append(removed, a)
+		}
+	}
+	return added, removed
+}
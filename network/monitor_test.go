@@ -0,0 +1,21 @@
+package network
+
+import (
+	"testing"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestDiffAddrs(t *testing.T) {
+	a := ma.StringCast("/ip4/10.0.0.1/tcp/1")
+	b := ma.StringCast("/ip4/192.168.1.2/tcp/1")
+	c := ma.StringCast("/ip6/::1/tcp/1")
+
+	added, removed := DiffAddrs([]ma.Multiaddr{a, b}, []ma.Multiaddr{b, c, c})
+	if len(added) != 1 || !added[0].Equal(c) {
+		t.Fatalf("unexpected added addresses: %v", added)
+	}
+	if len(removed) != 1 || !removed[0].Equal(a) {
+		t.Fatalf("unexpected removed addresses: %v", removed)
+	}
+}
@@ -8,6 +8,7 @@ package network
 import (
 	"context"
 	"io"
+	"time"
 
 	"github.com/jbenet/goprocess"
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -56,7 +57,18 @@ const (
 // Stat stores metadata pertaining to a given Stream/Conn.
 type Stat struct {
 	Direction Direction
-	Extra     map[interface{}]interface{}
+	// Opened is the time the Stream/Conn was opened, if known.
+	Opened time.Time
+
+	// NegotiationDuration is how long protocol negotiation took on a
+	// stream, or 0 if unknown (or not negotiated yet).
+	NegotiationDuration time.Duration
+	// FirstByte is the time between opening a stream and receiving its
+	// first byte of application data, or 0 if unknown (or nothing was
+	// received yet).
+	FirstByte time.Duration
+
+	Extra map[interface{}]interface{}
 }
 
 // StreamHandler is the type of function used to listen for
@@ -85,6 +97,7 @@ type Network interface {
 
 	// NewStream returns a new stream to given peer p.
 	// If there is no connection to p, attempts to create one.
+	// Stream options can be set on the context with WithStreamOptions.
 	NewStream(context.Context, peer.ID) (Stream, error)
 
 	// Listen tells the network to start listening on given multiaddrs.
@@ -29,11 +29,13 @@ type NotifyBundle struct {
 	ConnectedF    func(Network, Conn)
 	DisconnectedF func(Network, Conn)
 
+	DisconnectedWithReasonF func(Network, Conn, CloseReason)
+
 	OpenedStreamF func(Network, Stream)
 	ClosedStreamF func(Network, Stream)
 }
 
-var _ Notifiee = (*NotifyBundle)(nil)
+var _ ReasonNotifiee = (*NotifyBundle)(nil)
 
 // Listen calls ListenF if it is not null.
 func (nb *NotifyBundle) Listen(n Network, a ma.Multiaddr) {
@@ -63,6 +65,16 @@ func (nb *NotifyBundle) Disconnected(n Network, c Conn) {
 	}
 }
 
+// DisconnectedWithReason calls DisconnectedWithReasonF if it is not null, or
+// DisconnectedF otherwise.
+func (nb *NotifyBundle) DisconnectedWithReason(n Network, c Conn, reason CloseReason) {
+	if nb.DisconnectedWithReasonF != nil {
+		nb.DisconnectedWithReasonF(n, c, reason)
+		return
+	}
+	nb.Disconnected(n, c)
+}
+
 // OpenedStream calls OpenedStreamF if it is not null.
 func (nb *NotifyBundle) OpenedStream(n Network, s Stream) {
 	if nb.OpenedStreamF != nil {
@@ -0,0 +1,67 @@
+package network
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// PeerStats aggregates the state of the connections and streams to a peer.
+type PeerStats struct {
+	// NumConns and NumStreams count the open connections and streams.
+	NumConns   int
+	NumStreams int
+	// StreamsByProtocol counts the open streams per protocol. Streams whose
+	// protocol hasn't been negotiated yet are counted under "".
+	StreamsByProtocol map[protocol.ID]int
+
+	// ConnAges lists the ages of the open connections, for connections whose
+	// opening time is known (see Stat.Opened).
+	ConnAges []time.Duration
+
+	// BytesIn and BytesOut count the bytes exchanged with the peer, and
+	// LastActivity is the last time data was exchanged. They're only filled
+	// in by networks implementing PeerStatser.
+	BytesIn, BytesOut int64
+	LastActivity      time.Time
+
+	// Scope is the usage of the peer's resource scope, if the network
+	// accounts resources per peer.
+	Scope ScopeStat
+}
+
+// PeerStatser is implemented by networks that can report statistics for a
+// peer, combining resource scope data and connection stats.
+type PeerStatser interface {
+	PeerStats(peer.ID) PeerStats
+}
+
+// GetPeerStats returns the statistics of the peer. If the network doesn't
+// implement PeerStatser, they're computed from its connections and streams;
+// byte counts, last activity and scope usage are then unknown.
+func GetPeerStats(n Network, p peer.ID) PeerStats {
+	return GetPeerStatsWithClock(n, p, RealClock)
+}
+
+// GetPeerStatsWithClock is like GetPeerStats, measuring connection ages with
+// the given clock.
+func GetPeerStatsWithClock(n Network, p peer.ID, c Clock) PeerStats {
+	if ps, ok := n.(PeerStatser); ok {
+		return ps.PeerStats(p)
+	}
+
+	now := c.Now()
+	stats := PeerStats{StreamsByProtocol: make(map[protocol.ID]int)}
+	for _, c := range n.ConnsToPeer(p) {
+		stats.NumConns++
+		if opened := c.Stat().Opened; !opened.IsZero() {
+			stats.ConnAges = append(stats.ConnAges, now.Sub(opened))
+		}
+		for _, s := range c.GetStreams() {
+			stats.NumStreams++
+			stats.StreamsByProtocol[s.Protocol()]++
+		}
+	}
+	return stats
+}
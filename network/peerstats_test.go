@@ -0,0 +1,52 @@
+package network
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+type protoStream struct {
+	Stream
+	proto protocol.ID
+}
+
+func (s protoStream) Protocol() protocol.ID { return s.proto }
+
+type statConn struct {
+	Conn
+	opened  time.Time
+	streams []Stream
+}
+
+func (c statConn) Stat() Stat           { return Stat{Opened: c.opened} }
+func (c statConn) GetStreams() []Stream { return c.streams }
+
+type connsNetwork struct {
+	Network
+	conns []Conn
+}
+
+func (n connsNetwork) ConnsToPeer(peer.ID) []Conn { return n.conns }
+
+func TestGetPeerStats(t *testing.T) {
+	n := connsNetwork{conns: []Conn{
+		statConn{opened: time.Now().Add(-time.Minute), streams: []Stream{
+			protoStream{proto: "/a"}, protoStream{proto: "/a"}, protoStream{proto: "/b"},
+		}},
+		statConn{streams: []Stream{protoStream{}}},
+	}}
+
+	stats := GetPeerStats(n, "peer")
+	if stats.NumConns != 2 || stats.NumStreams != 4 {
+		t.Fatalf("unexpected counts: %+v", stats)
+	}
+	if stats.StreamsByProtocol["/a"] != 2 || stats.StreamsByProtocol["/b"] != 1 || stats.StreamsByProtocol[""] != 1 {
+		t.Fatalf("unexpected streams by protocol: %v", stats.StreamsByProtocol)
+	}
+	if len(stats.ConnAges) != 1 || stats.ConnAges[0] < time.Minute {
+		t.Fatalf("unexpected connection ages: %v", stats.ConnAges)
+	}
+}
@@ -0,0 +1,104 @@
+package network
+
+// ResourceScope is a scope in which the resource manager accounts resources.
+//
+// Components that allocate memory on behalf of a peer, a protocol, a service
+// or the whole node reserve it in the corresponding scope first, and release
+// it once they are done with it. Reservations fail when they would exceed the
+// limits of the scope (or of any scope it's nested in).
+type ResourceScope interface {
+	// ReserveMemory reserves size bytes of memory in the scope. The priority
+	// (see ReservationPriorityLow and friends) controls how close to the
+	// limit the reservation may bring the scope.
+	ReserveMemory(size int, prio uint8) error
+
+	// ReleaseMemory releases memory previously reserved with ReserveMemory.
+	ReleaseMemory(size int)
+
+	// Stat returns the current usage of the scope.
+	Stat() ScopeStat
+}
+
+const (
+	// ReservationPriorityLow allows a reservation if the scope stays at or
+	// below 40% of its memory limit.
+	ReservationPriorityLow uint8 = 101
+	// ReservationPriorityMedium allows a reservation if the scope stays at
+	// or below 60% of its memory limit.
+	ReservationPriorityMedium uint8 = 152
+	// ReservationPriorityHigh allows a reservation if the scope stays at or
+	// below 80% of its memory limit.
+	ReservationPriorityHigh uint8 = 203
+	// ReservationPriorityAlways allows a reservation as long as the scope
+	// stays within its memory limit.
+	ReservationPriorityAlways uint8 = 255
+)
+
+// ScopeStat is a snapshot of the resources used in a scope.
+type ScopeStat struct {
+	NumStreamsInbound  int
+	NumStreamsOutbound int
+	NumConnsInbound    int
+	NumConnsOutbound   int
+	NumFD              int
+
+	Memory int64
+}
+
+// NullScope is a ResourceScope without limits that doesn't account anything.
+var NullScope ResourceScope = nullScope{}
+
+type nullScope struct{}
+
+func (nullScope) ReserveMemory(int, uint8) error { return nil }
+func (nullScope) ReleaseMemory(int)              {}
+func (nullScope) Stat() ScopeStat                { return ScopeStat{} }
+
+// ConnStreamScope is implemented by resource scopes accounting connections
+// and streams, so that scopes nested in them, such as routing query scopes,
+// can acquire the connections and streams they open in them too.
+type ConnStreamScope interface {
+	ResourceScope
+
+	// AddConn accounts a connection in the given direction, failing if it
+	// would exceed the limits of the scope.
+	AddConn(dir Direction) error
+	// RemoveConn releases a connection accounted with AddConn.
+	RemoveConn(dir Direction)
+
+	// AddStream accounts a stream in the given direction, failing if it
+	// would exceed the limits of the scope.
+	AddStream(dir Direction) error
+	// RemoveStream releases a stream accounted with AddStream.
+	RemoveStream(dir Direction)
+}
+
+// ServiceScope is the scope of a service, such as "libp2p.identify" or
+// "kad-dht", accounting the resources of all the streams attached to it.
+type ServiceScope interface {
+	ResourceScope
+
+	// Name returns the name of the service.
+	Name() string
+}
+
+// StreamScope is the scope of a single stream.
+type StreamScope interface {
+	ResourceScope
+
+	// SetService attaches the stream to a service, accounting its resources
+	// in the service's scope from then on.
+	SetService(service string) error
+
+	// ServiceScope returns the scope of the service the stream is attached
+	// to, or nil if it isn't attached to any.
+	ServiceScope() ServiceScope
+}
+
+// ScopedStream is implemented by streams accounted by a resource manager.
+type ScopedStream interface {
+	Stream
+
+	// Scope returns the scope of the stream.
+	Scope() StreamScope
+}
@@ -0,0 +1,113 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// ResourceSnapshotVersion is the version of the ResourceSnapshot format.
+const ResourceSnapshotVersion = 1
+
+// PeerScopeSnapshot is the persisted accounting state of a peer scope.
+type PeerScopeSnapshot struct {
+	Peer peer.ID
+
+	// Stat is the usage of the scope when the snapshot was taken.
+	Stat ScopeStat
+
+	// Violations counts the times the peer exceeded its limits.
+	Violations int
+
+	// PenaltyUntil is the time until which the peer is throttled, if it's
+	// being penalized.
+	PenaltyUntil time.Time `json:",omitempty"`
+}
+
+// Penalized returns true if the peer is still penalized at the given time.
+func (s *PeerScopeSnapshot) Penalized(now time.Time) bool {
+	return now.Before(s.PenaltyUntil)
+}
+
+// ResourceSnapshot is a snapshot of a resource manager's accounting, saved
+// before a shutdown and loaded on restart so that the node doesn't
+// immediately re-admit the peers it was throttling.
+//
+// Snapshots are advisory: resource managers may ignore all or part of them,
+// and the usage they record isn't reserved again.
+type ResourceSnapshot struct {
+	Version int
+	Taken   time.Time
+	Peers   []PeerScopeSnapshot
+}
+
+// Expire drops the peers whose penalty is over at the given time and that
+// never exceeded their limits, returning the snapshot.
+func (s *ResourceSnapshot) Expire(now time.Time) *ResourceSnapshot {
+	peers := s.Peers[:0]
+	for _, p := range s.Peers {
+		if p.Penalized(now) || p.Violations > 0 {
+			peers = append(peers, p)
+		}
+	}
+	s.Peers = peers
+	return s
+}
+
+// ResourceSnapshotter is implemented by networks whose resource manager can
+// save and restore its accounting.
+type ResourceSnapshotter interface {
+	// SaveResourceSnapshot returns a snapshot of the current accounting.
+	SaveResourceSnapshot() (*ResourceSnapshot, error)
+
+	// LoadResourceSnapshot restores the accounting from a snapshot, e.g.
+	// reinstating the penalties still in force.
+	LoadResourceSnapshot(*ResourceSnapshot) error
+}
+
+// SaveResourceSnapshot writes a snapshot of the network's resource accounting
+// as JSON, or returns ErrResourceSnapshotNotSupported if the network doesn't
+// implement ResourceSnapshotter.
+func SaveResourceSnapshot(n Network, w io.Writer) error {
+	rs, ok := n.(ResourceSnapshotter)
+	if !ok {
+		return ErrResourceSnapshotNotSupported
+	}
+	s, err := rs.SaveResourceSnapshot()
+	if err != nil {
+		return err
+	}
+	s.Version = ResourceSnapshotVersion
+	return json.NewEncoder(w).Encode(s)
+}
+
+// LoadResourceSnapshot reads a snapshot written by SaveResourceSnapshot, drops
+// the entries expired at the given time, and loads it into the network's
+// resource manager. It returns ErrResourceSnapshotNotSupported if the network
+// doesn't implement ResourceSnapshotter.
+func LoadResourceSnapshot(n Network, r io.Reader, now time.Time) error {
+	rs, ok := n.(ResourceSnapshotter)
+	if !ok {
+		return ErrResourceSnapshotNotSupported
+	}
+	s, err := ReadResourceSnapshot(r)
+	if err != nil {
+		return err
+	}
+	return rs.LoadResourceSnapshot(s.Expire(now))
+}
+
+// ReadResourceSnapshot decodes a snapshot written by SaveResourceSnapshot.
+func ReadResourceSnapshot(r io.Reader) (*ResourceSnapshot, error) {
+	var s ResourceSnapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	if s.Version != ResourceSnapshotVersion {
+		return nil, fmt.Errorf("unsupported resource snapshot version %d", s.Version)
+	}
+	return &s, nil
+}
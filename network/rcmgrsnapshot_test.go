@@ -0,0 +1,59 @@
+package network
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/test"
+)
+
+type snapshotNetwork struct {
+	Network
+	snapshot *ResourceSnapshot
+}
+
+func (n *snapshotNetwork) SaveResourceSnapshot() (*ResourceSnapshot, error) {
+	return n.snapshot, nil
+}
+
+func (n *snapshotNetwork) LoadResourceSnapshot(s *ResourceSnapshot) error {
+	n.snapshot = s
+	return nil
+}
+
+func TestResourceSnapshot(t *testing.T) {
+	now := time.Now()
+	penalized, expired, violator := test.RandPeerIDFatal(t), test.RandPeerIDFatal(t), test.RandPeerIDFatal(t)
+	src := &snapshotNetwork{snapshot: &ResourceSnapshot{
+		Taken: now,
+		Peers: []PeerScopeSnapshot{
+			{Peer: penalized, PenaltyUntil: now.Add(time.Hour), Stat: ScopeStat{NumStreamsInbound: 3}},
+			{Peer: expired, PenaltyUntil: now.Add(-time.Minute)},
+			{Peer: violator, Violations: 2},
+		},
+	}}
+
+	var buf bytes.Buffer
+	if err := SaveResourceSnapshot(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+	dst := new(snapshotNetwork)
+	if err := LoadResourceSnapshot(dst, &buf, now); err != nil {
+		t.Fatal(err)
+	}
+	peers := dst.snapshot.Peers
+	if len(peers) != 2 || peers[0].Peer != penalized || peers[1].Peer != violator {
+		t.Fatalf("unexpected peers: %v", peers)
+	}
+	if !peers[0].Penalized(now) || peers[0].Stat.NumStreamsInbound != 3 {
+		t.Fatal("peer state wasn't preserved")
+	}
+
+	if err := SaveResourceSnapshot(connsNetwork{}, &buf); err != ErrResourceSnapshotNotSupported {
+		t.Fatalf("expected ErrResourceSnapshotNotSupported, got %v", err)
+	}
+	if _, err := ReadResourceSnapshot(bytes.NewBufferString(`{"Version":42}`)); err == nil {
+		t.Fatal("expected an unknown version to be rejected")
+	}
+}
@@ -1,6 +1,8 @@
 package network
 
 import (
+	"time"
+
 	"github.com/libp2p/go-libp2p-core/mux"
 	"github.com/libp2p/go-libp2p-core/protocol"
 )
@@ -22,3 +24,16 @@ type Stream interface {
 	// Conn returns the connection this stream is part of.
 	Conn() Conn
 }
+
+// DeadlineStream is implemented by streams reporting their deadlines, so that
+// code setting temporary deadlines (e.g. RunHello) can restore them.
+type DeadlineStream interface {
+	Stream
+
+	// ReadDeadline returns the read deadline, or the zero time if none is
+	// set.
+	ReadDeadline() time.Time
+	// WriteDeadline returns the write deadline, or the zero time if none
+	// is set.
+	WriteDeadline() time.Time
+}
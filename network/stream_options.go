@@ -0,0 +1,100 @@
+package network
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultNegotiationTimeout is the default time allowed for protocol
+// negotiation on a newly opened stream.
+var DefaultNegotiationTimeout = 60 * time.Second
+
+// StreamOptions configures how a new stream is opened. Obtain the options in
+// effect for a call with GetStreamOptions.
+type StreamOptions struct {
+	// NegotiationTimeout bounds the protocol negotiation on the new stream,
+	// independently of the deadline of the context passed to NewStream.
+	NegotiationTimeout time.Duration
+
+	// ExpectedSendSize and ExpectedRecvSize are hints about the total number
+	// of bytes the caller expects to write and read. Implementations may use
+	// them to reserve buffers or resources up front. Zero means unknown.
+	ExpectedSendSize int
+	ExpectedRecvSize int
+
+	// AllowLimitedConn allows the stream to be opened over a limited
+	// connection (e.g. a relayed connection with data or time caps).
+	AllowLimitedConn bool
+
+	// WaitForDial makes NewStream wait for an ongoing dial to the peer to
+	// complete instead of failing with ErrNoConn when no connection is
+	// currently open. It has no effect when a new dial is allowed.
+	WaitForDial bool
+}
+
+// StreamOption is a single stream option.
+type StreamOption func(*StreamOptions)
+
+// Apply applies the given options to these StreamOptions.
+func (o *StreamOptions) Apply(opts ...StreamOption) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// NegotiationTimeout sets the time allowed for protocol negotiation.
+func NegotiationTimeout(d time.Duration) StreamOption {
+	return func(o *StreamOptions) {
+		o.NegotiationTimeout = d
+	}
+}
+
+// ExpectedMessageSizes hints at the number of bytes the caller expects to
+// send and receive over the stream.
+func ExpectedMessageSizes(send, recv int) StreamOption {
+	return func(o *StreamOptions) {
+		o.ExpectedSendSize = send
+		o.ExpectedRecvSize = recv
+	}
+}
+
+// AllowLimitedConn sets whether the stream may be opened over a limited
+// connection.
+func AllowLimitedConn(allow bool) StreamOption {
+	return func(o *StreamOptions) {
+		o.AllowLimitedConn = allow
+	}
+}
+
+// WaitForDial sets whether to wait for an ongoing dial to the peer.
+func WaitForDial(wait bool) StreamOption {
+	return func(o *StreamOptions) {
+		o.WaitForDial = wait
+	}
+}
+
+type streamOptionsCtxKey struct{}
+
+func defaultStreamOptions() StreamOptions {
+	return StreamOptions{
+		NegotiationTimeout: DefaultNegotiationTimeout,
+		WaitForDial:        true,
+	}
+}
+
+// WithStreamOptions returns a new context carrying the given stream options,
+// layered on top of any options already set on ctx. Pass the returned
+// context to Host.NewStream or Network.NewStream.
+func WithStreamOptions(ctx context.Context, opts ...StreamOption) context.Context {
+	o := GetStreamOptions(ctx)
+	o.Apply(opts...)
+	return context.WithValue(ctx, streamOptionsCtxKey{}, o)
+}
+
+// GetStreamOptions returns the stream options set on ctx, or the defaults.
+func GetStreamOptions(ctx context.Context) StreamOptions {
+	if o, ok := ctx.Value(streamOptionsCtxKey{}).(StreamOptions); ok {
+		return o
+	}
+	return defaultStreamOptions()
+}
@@ -0,0 +1,164 @@
+package network
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// TeeQueueSize is the maximum number of chunks a TeeStream queues for its
+// observers. Chunks beyond that are dropped.
+var TeeQueueSize = 64
+
+// TeeStream wraps a Stream, mirroring the traffic flowing through it to
+// observer writers, e.g. for protocol debugging or wire capture.
+//
+// Mirrored data is copied and handed to the observers asynchronously, so a
+// slow observer never stalls the stream. The copies are accounted in a
+// ResourceScope: when the scope refuses a reservation, or the queue is full,
+// the chunk is not mirrored and is counted as dropped instead.
+//
+// Mirroring stops once the stream is reset, or once it has been closed for
+// writing and reads have returned an error (typically io.EOF).
+type TeeStream struct {
+	Stream
+
+	in, out io.Writer
+	scope   ResourceScope
+
+	lk          sync.Mutex
+	stopped     bool
+	writeClosed bool
+	readDone    bool
+	queue       chan teeChunk
+	done        chan struct{}
+
+	dropped int64
+}
+
+type teeChunk struct {
+	w io.Writer
+	b []byte
+}
+
+// NewTeeStream wraps the stream. Data read from the stream is mirrored to in
+// and data written to it is mirrored to out; either may be nil. Copies are
+// reserved in scope with ReservationPriorityLow.
+func NewTeeStream(s Stream, in, out io.Writer, scope ResourceScope) *TeeStream {
+	if scope == nil {
+		scope = NullScope
+	}
+	ts := &TeeStream{
+		Stream: s,
+		in:     in,
+		out:    out,
+		scope:  scope,
+		queue:  make(chan teeChunk, TeeQueueSize),
+		done:   make(chan struct{}),
+	}
+	go ts.run()
+	return ts
+}
+
+func (ts *TeeStream) run() {
+	defer close(ts.done)
+	for c := range ts.queue {
+		// Errors from observers are ignored, they must not affect the
+		// stream.
+		c.w.Write(c.b)
+		ts.scope.ReleaseMemory(len(c.b))
+	}
+}
+
+// Read reads from the stream, mirroring the data to the inbound observer.
+func (ts *TeeStream) Read(b []byte) (int, error) {
+	n, err := ts.Stream.Read(b)
+	if n > 0 {
+		ts.mirror(ts.in, b[:n])
+	}
+	if err != nil {
+		ts.lk.Lock()
+		ts.readDone = true
+		ts.maybeStopLocked()
+		ts.lk.Unlock()
+	}
+	return n, err
+}
+
+// Write writes to the stream, mirroring the written data to the outbound
+// observer.
+func (ts *TeeStream) Write(b []byte) (int, error) {
+	n, err := ts.Stream.Write(b)
+	if n > 0 {
+		ts.mirror(ts.out, b[:n])
+	}
+	return n, err
+}
+
+// Close closes the stream for writing.
+func (ts *TeeStream) Close() error {
+	err := ts.Stream.Close()
+	ts.lk.Lock()
+	ts.writeClosed = true
+	ts.maybeStopLocked()
+	ts.lk.Unlock()
+	return err
+}
+
+// Reset resets the stream and stops mirroring.
+func (ts *TeeStream) Reset() error {
+	err := ts.Stream.Reset()
+	ts.lk.Lock()
+	ts.stopLocked()
+	ts.lk.Unlock()
+	return err
+}
+
+// Dropped returns the number of bytes that couldn't be mirrored.
+func (ts *TeeStream) Dropped() int64 {
+	return atomic.LoadInt64(&ts.dropped)
+}
+
+// Done returns a channel that's closed once mirroring has stopped and all
+// queued data has been handed to the observers.
+func (ts *TeeStream) Done() <-chan struct{} {
+	return ts.done
+}
+
+func (ts *TeeStream) mirror(w io.Writer, b []byte) {
+	if w == nil {
+		return
+	}
+	if err := ts.scope.ReserveMemory(len(b), ReservationPriorityLow); err != nil {
+		atomic.AddInt64(&ts.dropped, int64(len(b)))
+		return
+	}
+	c := teeChunk{w: w, b: make([]byte, len(b))}
+	copy(c.b, b)
+
+	ts.lk.Lock()
+	defer ts.lk.Unlock()
+	if !ts.stopped {
+		select {
+		case ts.queue <- c:
+			return
+		default:
+		}
+	}
+	ts.scope.ReleaseMemory(len(b))
+	atomic.AddInt64(&ts.dropped, int64(len(b)))
+}
+
+func (ts *TeeStream) maybeStopLocked() {
+	if ts.writeClosed && ts.readDone {
+		ts.stopLocked()
+	}
+}
+
+func (ts *TeeStream) stopLocked() {
+	if ts.stopped {
+		return
+	}
+	ts.stopped = true
+	close(ts.queue)
+}
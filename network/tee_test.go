@@ -0,0 +1,95 @@
+package network
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+)
+
+type resetStream struct {
+	bufStream
+}
+
+func (s *resetStream) Close() error { return nil }
+func (s *resetStream) Reset() error { return nil }
+
+// memScope is a ResourceScope with a memory limit.
+type memScope struct {
+	mx    sync.Mutex
+	limit int
+	used  int
+}
+
+func (s *memScope) ReserveMemory(size int, _ uint8) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.used+size > s.limit {
+		return errors.New("limit exceeded")
+	}
+	s.used += size
+	return nil
+}
+
+func (s *memScope) ReleaseMemory(size int) {
+	s.mx.Lock()
+	s.used -= size
+	s.mx.Unlock()
+}
+
+func (s *memScope) Stat() ScopeStat {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return ScopeStat{Memory: int64(s.used)}
+}
+
+func TestTeeStream(t *testing.T) {
+	var in, out bytes.Buffer
+	scope := &memScope{limit: 1 << 20}
+	s := new(resetStream)
+	ts := NewTeeStream(s, &in, &out, scope)
+
+	if _, err := ts.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 3)
+	if _, err := ts.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	ts.Close()
+	// Drain the stream; the EOF stops mirroring.
+	for {
+		if _, err := ts.Read(buf); err != nil {
+			break
+		}
+	}
+	<-ts.Done()
+
+	if out.String() != "hello" || in.String() != "hello" {
+		t.Fatalf("unexpected mirrored data: in=%q out=%q", in.String(), out.String())
+	}
+	if scope.Stat().Memory != 0 {
+		t.Fatal("expected all memory to be released")
+	}
+	if ts.Dropped() != 0 {
+		t.Fatal("didn't expect drops")
+	}
+}
+
+func TestTeeStreamScopeLimit(t *testing.T) {
+	var out bytes.Buffer
+	ts := NewTeeStream(new(resetStream), nil, &out, &memScope{limit: 4})
+
+	if _, err := ts.Write([]byte("too large")); err != nil {
+		t.Fatal(err)
+	}
+	ts.Reset()
+	<-ts.Done()
+
+	if out.Len() != 0 {
+		t.Fatal("expected nothing to be mirrored")
+	}
+	if ts.Dropped() != int64(len("too large")) {
+		t.Fatalf("expected drops to be counted, got %d", ts.Dropped())
+	}
+}
@@ -0,0 +1,271 @@
+package network
+
+import (
+	"sync"
+	"time"
+)
+
+// BandwidthLimiter is an optional interface implemented by streams that can
+// throttle themselves. Use LimitBandwidth to throttle any stream.
+type BandwidthLimiter interface {
+	// SetBandwidthLimit limits the rate at which data is read from and
+	// written to the stream, in bytes per second, in each direction.
+	// A limit of 0 removes the limit.
+	SetBandwidthLimit(bytesPerSec int64)
+}
+
+// LimitBandwidth limits the bandwidth of the given stream to bytesPerSec in
+// each direction.
+//
+// If the stream implements BandwidthLimiter, the limit is applied natively
+// and the stream itself is returned. Otherwise, the stream is wrapped in a
+// ThrottledStream which must be used in its place.
+func LimitBandwidth(s Stream, bytesPerSec int64) Stream {
+	if bl, ok := s.(BandwidthLimiter); ok {
+		bl.SetBandwidthLimit(bytesPerSec)
+		return s
+	}
+	return NewThrottledStream(s, bytesPerSec)
+}
+
+// ThrottledStream wraps a Stream, limiting reads and writes with a token
+// bucket per direction. The bucket holds up to one second worth of data, so
+// short bursts are not delayed.
+//
+// Throttling only affects the wrapped stream, other streams on the same
+// connection are left alone.
+//
+// Waiting for bandwidth is interrupted by the stream's deadlines, failing
+// with ErrDeadlineExceeded, by Close for writes and by Reset, failing with
+// ErrThrottledStreamClosed.
+type ThrottledStream struct {
+	Stream
+
+	in, out tokenBucket
+
+	closeOnce, resetOnce sync.Once
+	// writeClosed is closed by Close and Reset, reset by Reset.
+	writeClosed, reset chan struct{}
+
+	dlLk                        sync.Mutex
+	readDeadline, writeDeadline time.Time
+}
+
+var (
+	_ BandwidthLimiter = (*ThrottledStream)(nil)
+	_ DeadlineStream   = (*ThrottledStream)(nil)
+)
+
+// NewThrottledStream wraps the stream, limiting it to bytesPerSec in each
+// direction.
+func NewThrottledStream(s Stream, bytesPerSec int64) *ThrottledStream {
+	ts := &ThrottledStream{
+		Stream:      s,
+		writeClosed: make(chan struct{}),
+		reset:       make(chan struct{}),
+	}
+	ts.SetBandwidthLimit(bytesPerSec)
+	return ts
+}
+
+// SetClock sets the clock the limits are measured with, RealClock by
+// default. It must be called before the stream is used.
+func (s *ThrottledStream) SetClock(c Clock) {
+	s.in.setClock(c)
+	s.out.setClock(c)
+}
+
+// SetBandwidthLimit changes the limit. A limit of 0 removes it.
+func (s *ThrottledStream) SetBandwidthLimit(bytesPerSec int64) {
+	s.in.setRate(bytesPerSec)
+	s.out.setRate(bytesPerSec)
+}
+
+// Read reads from the stream, blocking as needed to stay under the limit.
+func (s *ThrottledStream) Read(b []byte) (int, error) {
+	if burst := s.in.burst(); burst > 0 && len(b) > burst {
+		b = b[:burst]
+	}
+	n, err := s.Stream.Read(b)
+	if werr := s.in.take(n, s.deadline(&s.readDeadline), s.reset); err == nil {
+		err = werr
+	}
+	return n, err
+}
+
+// Write writes to the stream, blocking as needed to stay under the limit.
+func (s *ThrottledStream) Write(b []byte) (int, error) {
+	written := 0
+	for len(b) > 0 {
+		chunk := b
+		if burst := s.out.burst(); burst > 0 && len(chunk) > burst {
+			chunk = chunk[:burst]
+		}
+		if err := s.out.take(len(chunk), s.deadline(&s.writeDeadline), s.writeClosed); err != nil {
+			// Nothing was written, give the tokens back.
+			s.out.refund(len(chunk))
+			return written, err
+		}
+		n, err := s.Stream.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		b = b[n:]
+	}
+	return written, nil
+}
+
+// Close closes the stream for writing, interrupting the writes waiting for
+// bandwidth.
+func (s *ThrottledStream) Close() error {
+	s.closeOnce.Do(func() { close(s.writeClosed) })
+	return s.Stream.Close()
+}
+
+// Reset resets the stream, interrupting the reads and writes waiting for
+// bandwidth.
+func (s *ThrottledStream) Reset() error {
+	s.closeOnce.Do(func() { close(s.writeClosed) })
+	s.resetOnce.Do(func() { close(s.reset) })
+	return s.Stream.Reset()
+}
+
+// SetDeadline sets the read and write deadlines, which also bound the time
+// spent waiting for bandwidth.
+func (s *ThrottledStream) SetDeadline(t time.Time) error {
+	s.dlLk.Lock()
+	s.readDeadline, s.writeDeadline = t, t
+	s.dlLk.Unlock()
+	return s.Stream.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline, which also bounds the time spent
+// waiting for bandwidth after reads.
+func (s *ThrottledStream) SetReadDeadline(t time.Time) error {
+	s.dlLk.Lock()
+	s.readDeadline = t
+	s.dlLk.Unlock()
+	return s.Stream.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline, which also bounds the time spent
+// waiting for bandwidth before writes.
+func (s *ThrottledStream) SetWriteDeadline(t time.Time) error {
+	s.dlLk.Lock()
+	s.writeDeadline = t
+	s.dlLk.Unlock()
+	return s.Stream.SetWriteDeadline(t)
+}
+
+// ReadDeadline implements DeadlineStream.
+func (s *ThrottledStream) ReadDeadline() time.Time {
+	return s.deadline(&s.readDeadline)
+}
+
+// WriteDeadline implements DeadlineStream.
+func (s *ThrottledStream) WriteDeadline() time.Time {
+	return s.deadline(&s.writeDeadline)
+}
+
+func (s *ThrottledStream) deadline(dl *time.Time) time.Time {
+	s.dlLk.Lock()
+	defer s.dlLk.Unlock()
+	return *dl
+}
+
+// tokenBucket is a simple token bucket. Taking more tokens than available
+// puts the bucket into debt and waits until it's repaid.
+type tokenBucket struct {
+	mu     sync.Mutex
+	clock  Clock
+	rate   int64
+	tokens float64
+	last   time.Time
+}
+
+func (tb *tokenBucket) setClock(c Clock) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.clock = c
+	tb.last = c.Now()
+}
+
+// clockLocked returns the clock of the bucket, RealClock if unset.
+func (tb *tokenBucket) clockLocked() Clock {
+	if tb.clock == nil {
+		return RealClock
+	}
+	return tb.clock
+}
+
+func (tb *tokenBucket) setRate(rate int64) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	if rate < 0 {
+		rate = 0
+	}
+	tb.rate = rate
+	tb.tokens = float64(rate)
+	tb.last = tb.clockLocked().Now()
+}
+
+func (tb *tokenBucket) burst() int {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	return int(tb.rate)
+}
+
+func (tb *tokenBucket) refund(n int) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	if tb.rate != 0 {
+		tb.tokens += float64(n)
+	}
+}
+
+// take takes n tokens, waiting until the debt is repaid, the deadline (if
+// not zero) passes or closed is closed.
+func (tb *tokenBucket) take(n int, deadline time.Time, closed <-chan struct{}) error {
+	if n <= 0 {
+		return nil
+	}
+
+	tb.mu.Lock()
+	if tb.rate == 0 {
+		tb.mu.Unlock()
+		return nil
+	}
+	clock := tb.clockLocked()
+	now := clock.Now()
+	tb.tokens += now.Sub(tb.last).Seconds() * float64(tb.rate)
+	if max := float64(tb.rate); tb.tokens > max {
+		tb.tokens = max
+	}
+	tb.last = now
+	tb.tokens -= float64(n)
+
+	var wait time.Duration
+	if tb.tokens < 0 {
+		wait = time.Duration(-tb.tokens / float64(tb.rate) * float64(time.Second))
+	}
+	tb.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	err := error(nil)
+	if !deadline.IsZero() {
+		if untilDeadline := deadline.Sub(now); untilDeadline < wait {
+			wait, err = untilDeadline, ErrDeadlineExceeded
+		}
+	}
+	timer := clock.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return err
+	case <-closed:
+		return ErrThrottledStreamClosed
+	}
+}
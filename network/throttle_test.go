@@ -0,0 +1,126 @@
+package network
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+type bufStream struct {
+	Stream
+	buf bytes.Buffer
+}
+
+func (s *bufStream) Write(b []byte) (int, error) { return s.buf.Write(b) }
+func (s *bufStream) Read(b []byte) (int, error)  { return s.buf.Read(b) }
+func (s *bufStream) Close() error                { return nil }
+func (s *bufStream) Reset() error                { return nil }
+
+func (s *bufStream) SetWriteDeadline(time.Time) error { return nil }
+
+func TestThrottledStreamWrite(t *testing.T) {
+	s := new(bufStream)
+	ts := LimitBandwidth(s, 1000)
+
+	start := time.Now()
+	// The first 1000 bytes are covered by the burst, the rest must wait.
+	n, err := ts.Write(make([]byte, 1500))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1500 || s.buf.Len() != 1500 {
+		t.Fatalf("expected 1500 bytes written, got %d", n)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Fatalf("write wasn't throttled (took %s)", elapsed)
+	}
+}
+
+func TestThrottledStreamUnlimited(t *testing.T) {
+	s := new(bufStream)
+	ts := NewThrottledStream(s, 1)
+	ts.SetBandwidthLimit(0)
+
+	start := time.Now()
+	if _, err := ts.Write(make([]byte, 1<<20)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 1<<20)
+	if n, err := ts.Read(buf); err != nil || n != 1<<20 {
+		t.Fatalf("expected a full read, got %d (%v)", n, err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("unlimited stream was throttled (took %s)", elapsed)
+	}
+}
+
+func TestLimitBandwidthNative(t *testing.T) {
+	ts := NewThrottledStream(new(bufStream), 1)
+	if LimitBandwidth(ts, 10) != Stream(ts) {
+		t.Fatal("expected the native limiter to be used")
+	}
+	if ts.out.burst() != 10 {
+		t.Fatal("expected the limit to be updated")
+	}
+}
+
+func TestThrottledStreamInterrupted(t *testing.T) {
+	ts := NewThrottledStream(new(bufStream), 100)
+
+	done := make(chan error, 1)
+	go func() {
+		// Waits for 10s without interruption.
+		_, err := ts.Write(make([]byte, 1100))
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	ts.Close()
+	select {
+	case err := <-done:
+		if err != ErrThrottledStreamClosed {
+			t.Fatalf("expected ErrThrottledStreamClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("close didn't interrupt the write")
+	}
+
+	ts = NewThrottledStream(new(bufStream), 100)
+	ts.SetWriteDeadline(time.Now().Add(50 * time.Millisecond))
+	start := time.Now()
+	if _, err := ts.Write(make([]byte, 1100)); err != ErrDeadlineExceeded {
+		t.Fatalf("expected ErrDeadlineExceeded, got %v", err)
+	}
+	if err, ok := ErrDeadlineExceeded.(net.Error); !ok || !err.Timeout() {
+		t.Fatal("expected ErrDeadlineExceeded to be a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("deadline didn't interrupt the write (took %s)", elapsed)
+	}
+}
+
+func TestThrottledStreamClock(t *testing.T) {
+	clock := NewMockClock(time.Unix(0, 0))
+	ts := NewThrottledStream(new(bufStream), 1000)
+	ts.SetClock(clock)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := ts.Write(make([]byte, 1500))
+		done <- err
+	}()
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+			if elapsed := clock.Now().Sub(time.Unix(0, 0)); elapsed < 500*time.Millisecond {
+				t.Fatalf("write wasn't throttled (took %s of virtual time)", elapsed)
+			}
+			return
+		case <-time.After(time.Millisecond):
+			clock.Advance(100 * time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package network
+
+// ConnWrapper wraps a connection, e.g. to instrument or record it. Wrappers
+// must forward every method they don't intercept to the wrapped connection.
+type ConnWrapper func(Conn) Conn
+
+// StreamWrapper wraps a stream, e.g. to instrument, record or encrypt its
+// data. Wrappers must forward every method they don't intercept to the wrapped
+// stream; in particular, Conn must return the (wrapped) connection the stream
+// belongs to.
+type StreamWrapper func(Stream) Stream
+
+// WrappingNetwork is implemented by networks that apply wrappers to all of
+// their connections and streams, so that protocols don't need to wrap the
+// streams they use themselves.
+type WrappingNetwork interface {
+	Network
+
+	// AddConnWrapper registers a wrapper applied to every connection
+	// opened after the call, inbound and outbound, before it's handed to
+	// notifiees and the connection handler.
+	AddConnWrapper(ConnWrapper)
+
+	// AddStreamWrapper registers a wrapper applied to every stream opened
+	// after the call, inbound and outbound, before it's handed to the
+	// stream handler or returned by NewStream.
+	AddStreamWrapper(StreamWrapper)
+}
+
+// AddConnWrapper registers the wrapper with the network, or returns
+// ErrWrappersNotSupported if the network doesn't implement WrappingNetwork.
+func AddConnWrapper(n Network, w ConnWrapper) error {
+	wn, ok := n.(WrappingNetwork)
+	if !ok {
+		return ErrWrappersNotSupported
+	}
+	wn.AddConnWrapper(w)
+	return nil
+}
+
+// AddStreamWrapper registers the wrapper with the network, or returns
+// ErrWrappersNotSupported if the network doesn't implement WrappingNetwork.
+func AddStreamWrapper(n Network, w StreamWrapper) error {
+	wn, ok := n.(WrappingNetwork)
+	if !ok {
+		return ErrWrappersNotSupported
+	}
+	wn.AddStreamWrapper(w)
+	return nil
+}
+
+// ChainConnWrappers returns a wrapper applying the wrappers in order: the
+// first registered wrapper is the innermost one.
+func ChainConnWrappers(ws ...ConnWrapper) ConnWrapper {
+	return func(c Conn) Conn {
+		for _, w := range ws {
+			c = w(c)
+		}
+		return c
+	}
+}
+
+// ChainStreamWrappers returns a wrapper applying the wrappers in order: the
+// first registered wrapper is the innermost one.
+func ChainStreamWrappers(ws ...StreamWrapper) StreamWrapper {
+	return func(s Stream) Stream {
+		for _, w := range ws {
+			s = w(s)
+		}
+		return s
+	}
+}
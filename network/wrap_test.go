@@ -0,0 +1,40 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+type wrappingNetwork struct {
+	Network
+	streamWrappers []StreamWrapper
+}
+
+func (n *wrappingNetwork) AddConnWrapper(ConnWrapper) {}
+func (n *wrappingNetwork) AddStreamWrapper(w StreamWrapper) {
+	n.streamWrappers = append(n.streamWrappers, w)
+}
+
+func TestStreamWrappers(t *testing.T) {
+	if err := AddStreamWrapper(connsNetwork{}, nil); err != ErrWrappersNotSupported {
+		t.Fatalf("expected ErrWrappersNotSupported, got %v", err)
+	}
+
+	n := new(wrappingNetwork)
+	suffix := func(sfx protocol.ID) StreamWrapper {
+		return func(s Stream) Stream {
+			return protoStream{Stream: s, proto: s.Protocol() + sfx}
+		}
+	}
+	for _, w := range []StreamWrapper{suffix("/inner"), suffix("/outer")} {
+		if err := AddStreamWrapper(n, w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := ChainStreamWrappers(n.streamWrappers...)(protoStream{proto: "/a"})
+	if s.Protocol() != "/a/inner/outer" {
+		t.Fatalf("wrappers applied in the wrong order: %s", s.Protocol())
+	}
+}
@@ -0,0 +1,46 @@
+package network
+
+import "io"
+
+// ZeroCopyStream is implemented by streams that can move data between the
+// stream and a file or socket without copying it through user space, e.g.
+// with sendfile(2) or splice(2).
+//
+// This is only possible when the stream's data isn't transformed on its way
+// to the wire, i.e. on plaintext connections or when encryption is offloaded.
+// ZeroCopy reports whether that's currently the case; when it returns false,
+// ReadFrom and WriteTo still work, but copy through user space.
+type ZeroCopyStream interface {
+	Stream
+	io.ReaderFrom
+	io.WriterTo
+
+	// ZeroCopy returns true if ReadFrom and WriteTo can avoid copying the
+	// data through user space.
+	ZeroCopy() bool
+}
+
+// SupportsZeroCopy returns true if the stream provides a zero-copy fast path.
+func SupportsZeroCopy(s Stream) bool {
+	zc, ok := s.(ZeroCopyStream)
+	return ok && zc.ZeroCopy()
+}
+
+// CopyToStream copies from r to the stream until EOF, using the stream's
+// zero-copy fast path when available.
+func CopyToStream(s Stream, r io.Reader) (int64, error) {
+	if zc, ok := s.(ZeroCopyStream); ok && zc.ZeroCopy() {
+		return zc.ReadFrom(r)
+	}
+	// Hide any ReaderFrom implementation so io.Copy doesn't pick it up.
+	return io.Copy(struct{ io.Writer }{s}, r)
+}
+
+// CopyFromStream copies from the stream to w until EOF, using the stream's
+// zero-copy fast path when available.
+func CopyFromStream(w io.Writer, s Stream) (int64, error) {
+	if zc, ok := s.(ZeroCopyStream); ok && zc.ZeroCopy() {
+		return zc.WriteTo(w)
+	}
+	return io.Copy(w, struct{ io.Reader }{s})
+}
@@ -0,0 +1,54 @@
+package network
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+type zeroCopyStream struct {
+	bufStream
+	enabled        bool
+	readFromCalled bool
+	writeToCalled  bool
+}
+
+func (s *zeroCopyStream) ZeroCopy() bool { return s.enabled }
+
+func (s *zeroCopyStream) ReadFrom(r io.Reader) (int64, error) {
+	s.readFromCalled = true
+	return s.buf.ReadFrom(r)
+}
+
+func (s *zeroCopyStream) WriteTo(w io.Writer) (int64, error) {
+	s.writeToCalled = true
+	return s.buf.WriteTo(w)
+}
+
+func TestCopyZeroCopy(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		s := &zeroCopyStream{enabled: enabled}
+		if SupportsZeroCopy(s) != enabled {
+			t.Fatal("wrong zero-copy support detected")
+		}
+
+		if _, err := CopyToStream(s, strings.NewReader("payload")); err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		if _, err := CopyFromStream(&out, s); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != "payload" {
+			t.Fatalf("unexpected data: %q", out.String())
+		}
+		if s.readFromCalled != enabled || s.writeToCalled != enabled {
+			t.Fatalf("fast path used: %t/%t, expected %t", s.readFromCalled, s.writeToCalled, enabled)
+		}
+	}
+
+	if SupportsZeroCopy(new(bufStream)) {
+		t.Fatal("plain stream doesn't support zero-copy")
+	}
+}
@@ -0,0 +1,174 @@
+package peer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// AddrSource tags where an address was learned from.
+type AddrSource string
+
+// Well-known address sources.
+const (
+	SourceUnknown  AddrSource = ""
+	SourceManual   AddrSource = "manual"
+	SourceIdentify AddrSource = "identify"
+	SourceDHT      AddrSource = "dht"
+	SourceMDNS     AddrSource = "mdns"
+	SourceRelay    AddrSource = "relay"
+)
+
+// AddrEntry is an address along with the metadata used to order dials.
+type AddrEntry struct {
+	Addr ma.Multiaddr
+	// Priority weighs the address: addresses with higher priorities are
+	// dialed first.
+	Priority int
+	// Expires is the time after which the address shouldn't be dialed.
+	// The zero time means it doesn't expire.
+	Expires time.Time
+	Source  AddrSource
+}
+
+// Expired returns true if the address expired at time now.
+func (e AddrEntry) Expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !now.Before(e.Expires)
+}
+
+type addrEntryJSON struct {
+	Addr     string
+	Priority int        `json:",omitempty"`
+	Expires  *time.Time `json:",omitempty"`
+	Source   AddrSource `json:",omitempty"`
+}
+
+// MarshalJSON encodes the entry, with the address in its string form.
+func (e AddrEntry) MarshalJSON() ([]byte, error) {
+	out := addrEntryJSON{Addr: e.Addr.String(), Priority: e.Priority, Source: e.Source}
+	if !e.Expires.IsZero() {
+		out.Expires = &e.Expires
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes an entry encoded with MarshalJSON.
+func (e *AddrEntry) UnmarshalJSON(b []byte) error {
+	var in addrEntryJSON
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	addr, err := ma.NewMultiaddr(in.Addr)
+	if err != nil {
+		return err
+	}
+	*e = AddrEntry{Addr: addr, Priority: in.Priority, Source: in.Source}
+	if in.Expires != nil {
+		e.Expires = *in.Expires
+	}
+	return nil
+}
+
+// DetailedAddrInfo is an AddrInfo whose addresses carry metadata, so that
+// dial ordering can take priorities, expirations and sources into account
+// end-to-end.
+type DetailedAddrInfo struct {
+	ID    ID
+	Addrs []AddrEntry
+}
+
+func (pi DetailedAddrInfo) String() string {
+	return fmt.Sprintf("{%v: %v}", pi.ID, pi.Addrs)
+}
+
+// DetailedAddrInfoFrom returns the AddrInfo's addresses with the given source
+// and expiration, and no priority.
+func DetailedAddrInfoFrom(pi AddrInfo, source AddrSource, expires time.Time) DetailedAddrInfo {
+	d := DetailedAddrInfo{ID: pi.ID, Addrs: make([]AddrEntry, len(pi.Addrs))}
+	for i, a := range pi.Addrs {
+		d.Addrs[i] = AddrEntry{Addr: a, Expires: expires, Source: source}
+	}
+	return d
+}
+
+// Merge adds the entries, merging those of addresses already present: the
+// highest priority and the latest expiration are kept, along with the source
+// of the existing entry.
+func (pi *DetailedAddrInfo) Merge(entries ...AddrEntry) {
+	for _, e := range entries {
+		merged := false
+		for i := range pi.Addrs {
+			cur := &pi.Addrs[i]
+			if !cur.Addr.Equal(e.Addr) {
+				continue
+			}
+			if e.Priority > cur.Priority {
+				cur.Priority = e.Priority
+			}
+			if cur.Expires.IsZero() || e.Expires.IsZero() {
+				cur.Expires = time.Time{}
+			} else if e.Expires.After(cur.Expires) {
+				cur.Expires = e.Expires
+			}
+			merged = true
+			break
+		}
+		if !merged {
+			pi.Addrs = append(pi.Addrs, e)
+		}
+	}
+}
+
+// DialOrder returns the addresses not expired at time now, from highest to
+// lowest priority. Addresses with the same priority keep their order.
+func (pi *DetailedAddrInfo) DialOrder(now time.Time) []AddrEntry {
+	out := make([]AddrEntry, 0, len(pi.Addrs))
+	for _, e := range pi.Addrs {
+		if !e.Expired(now) {
+			out = append(out, e)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Priority > out[j].Priority
+	})
+	return out
+}
+
+// AddrInfo returns the AddrInfo of the addresses not expired at time now, in
+// dial order.
+func (pi *DetailedAddrInfo) AddrInfo(now time.Time) AddrInfo {
+	entries := pi.DialOrder(now)
+	out := AddrInfo{ID: pi.ID, Addrs: make([]ma.Multiaddr, len(entries))}
+	for i, e := range entries {
+		out.Addrs[i] = e.Addr
+	}
+	return out
+}
+
+// MarshalJSON encodes the info, with the peer ID in its string form.
+func (pi DetailedAddrInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ID    string
+		Addrs []AddrEntry
+	}{pi.ID.Pretty(), pi.Addrs})
+}
+
+// UnmarshalJSON decodes an info encoded with MarshalJSON.
+func (pi *DetailedAddrInfo) UnmarshalJSON(b []byte) error {
+	var in struct {
+		ID    string
+		Addrs []AddrEntry
+	}
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	id, err := IDB58Decode(in.ID)
+	if err != nil {
+		return err
+	}
+	*pi = DetailedAddrInfo{ID: id, Addrs: in.Addrs}
+	return nil
+}
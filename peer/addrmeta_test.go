@@ -0,0 +1,45 @@
+package peer_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+
+	. "github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestDetailedAddrInfo(t *testing.T) {
+	now := time.Now()
+	lan := ma.StringCast("/ip4/192.168.1.1/tcp/4001")
+	wan := ma.StringCast("/ip4/1.2.3.4/tcp/4001")
+	old := ma.StringCast("/ip4/1.2.3.5/tcp/4001")
+
+	pi := DetailedAddrInfoFrom(AddrInfo{ID: testID, Addrs: []ma.Multiaddr{wan, lan}}, SourceDHT, now.Add(time.Hour))
+	pi.Merge(
+		AddrEntry{Addr: lan, Priority: 10, Source: SourceMDNS},
+		AddrEntry{Addr: old, Priority: 20, Expires: now.Add(-time.Second)},
+	)
+	if len(pi.Addrs) != 3 || pi.Addrs[1].Source != SourceDHT || !pi.Addrs[1].Expires.IsZero() {
+		t.Fatalf("unexpected merge result: %v", pi.Addrs)
+	}
+
+	ai := pi.AddrInfo(now)
+	if len(ai.Addrs) != 2 || !ai.Addrs[0].Equal(lan) || !ai.Addrs[1].Equal(wan) {
+		t.Fatalf("unexpected dial order: %v", ai.Addrs)
+	}
+
+	data, err := json.Marshal(pi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded DetailedAddrInfo
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ID != testID || len(decoded.Addrs) != 3 || !decoded.Addrs[0].Addr.Equal(wan) ||
+		decoded.Addrs[1].Priority != 10 || !decoded.Addrs[0].Expires.Equal(pi.Addrs[0].Expires) {
+		t.Fatalf("unexpected decoded info: %v", decoded)
+	}
+}
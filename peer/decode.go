@@ -0,0 +1,162 @@
+package peer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+
+	cid "github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// libp2pKeyCodec is the multicodec of CIDs that encode peer IDs.
+const libp2pKeyCodec = 0x72
+
+// Rules checked when decoding peer IDs. Errors returned by DecodeIDStrict,
+// DecodeIDLenient and IDFromBytesStrict wrap one of these, see
+// IDDecodeError.
+var (
+	// ErrUnknownIDEncoding means the string is neither a base58btc multihash
+	// nor a multibase-encoded CID.
+	ErrUnknownIDEncoding = errors.New("neither a base58btc multihash nor a multibase CID")
+	// ErrBadMultihash means the bytes don't form exactly one valid multihash.
+	ErrBadMultihash = errors.New("not a valid multihash")
+	// ErrUnsupportedHash means the multihash uses a hash function other than
+	// sha2-256 or identity.
+	ErrUnsupportedHash = errors.New("hash function must be sha2-256 or identity")
+	// ErrBadDigestLength means a sha2-256 multihash doesn't hold 32 bytes.
+	ErrBadDigestLength = errors.New("sha2-256 digest must be 32 bytes")
+	// ErrInlineKeyTooLong means an identity multihash is longer than the
+	// maximum inlined key length.
+	ErrInlineKeyTooLong = fmt.Errorf("identity multihash digest exceeds %d bytes", maxInlineKeyLength)
+	// ErrBadInlineKey means an identity multihash doesn't hold a valid
+	// public key.
+	ErrBadInlineKey = errors.New("identity multihash doesn't hold a valid public key")
+	// ErrNotLibp2pKeyCID means a CID doesn't use the libp2p-key codec.
+	ErrNotLibp2pKeyCID = errors.New("CID codec must be libp2p-key")
+	// ErrNotCIDv1 means a multibase-encoded CID isn't a version 1 CID.
+	ErrNotCIDv1 = errors.New("CID must be version 1")
+)
+
+// IDDecodeError is returned when a peer ID fails to decode. It reports the
+// input and the rule that was violated.
+type IDDecodeError struct {
+	// Input is the offending input, as a string or hex-encoded bytes.
+	Input string
+	// Rule is one of the Err* rule errors of this package.
+	Rule error
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *IDDecodeError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("invalid peer ID %q: %s: %s", e.Input, e.Rule, e.Err)
+	}
+	return fmt.Sprintf("invalid peer ID %q: %s", e.Input, e.Rule)
+}
+
+// Unwrap returns the violated rule, so that errors.Is(err, ErrBadMultihash)
+// and friends work.
+func (e *IDDecodeError) Unwrap() error {
+	return e.Rule
+}
+
+// DecodeIDStrict decodes a peer ID from its string representation, accepting
+// only the encodings allowed by the peer ID spec: a base58btc-encoded
+// multihash (starting with "Qm" or "1") or a multibase-encoded CIDv1 with the
+// libp2p-key codec.
+//
+// The multihash must be a 32 byte sha2-256 hash, or an identity hash of at
+// most 42 bytes embedding a valid public key.
+func DecodeIDStrict(s string) (ID, error) {
+	return decodeID(s, true)
+}
+
+// DecodeIDLenient decodes a peer ID from its string representation. On top of
+// what DecodeIDStrict accepts, it tolerates surrounding whitespace, a leading
+// /p2p/ or /ipfs/, CIDs with any codec and any valid multihash, whatever its
+// hash function and length.
+//
+// Use it to ingest IDs from sources known to be sloppy.
+func DecodeIDLenient(s string) (ID, error) {
+	s = strings.TrimSpace(s)
+	for _, prefix := range []string{"/p2p/", "/ipfs/"} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	return decodeID(s, false)
+}
+
+// IDFromBytesStrict casts the bytes of a multihash to a peer ID, applying the
+// same multihash rules as DecodeIDStrict.
+func IDFromBytesStrict(b []byte) (ID, error) {
+	if err := validateMultihash(b, true); err != nil {
+		err.Input = fmt.Sprintf("%x", b)
+		return "", err
+	}
+	return ID(b), nil
+}
+
+func decodeID(s string, strict bool) (ID, error) {
+	if s == "" {
+		return "", &IDDecodeError{Input: s, Rule: ErrEmptyPeerID}
+	}
+
+	var hash []byte
+	if strings.HasPrefix(s, "Qm") || strings.HasPrefix(s, "1") {
+		m, err := mh.FromB58String(s)
+		if err != nil {
+			return "", &IDDecodeError{Input: s, Rule: ErrBadMultihash, Err: err}
+		}
+		hash = m
+	} else {
+		c, err := cid.Decode(s)
+		if err != nil {
+			return "", &IDDecodeError{Input: s, Rule: ErrUnknownIDEncoding, Err: err}
+		}
+		if strict {
+			if c.Version() != 1 {
+				return "", &IDDecodeError{Input: s, Rule: ErrNotCIDv1}
+			}
+			if c.Type() != libp2pKeyCodec {
+				return "", &IDDecodeError{Input: s, Rule: ErrNotLibp2pKeyCID, Err: fmt.Errorf("codec 0x%x", c.Type())}
+			}
+		}
+		hash = c.Hash()
+	}
+
+	if err := validateMultihash(hash, strict); err != nil {
+		err.Input = s
+		return "", err
+	}
+	return ID(hash), nil
+}
+
+func validateMultihash(b []byte, strict bool) *IDDecodeError {
+	dec, err := mh.Decode(b)
+	if err != nil {
+		return &IDDecodeError{Rule: ErrBadMultihash, Err: err}
+	}
+	if !strict {
+		return nil
+	}
+
+	switch dec.Code {
+	case mh.SHA2_256:
+		if len(dec.Digest) != 32 {
+			return &IDDecodeError{Rule: ErrBadDigestLength, Err: fmt.Errorf("got %d bytes", len(dec.Digest))}
+		}
+	case mh.ID:
+		if len(dec.Digest) > maxInlineKeyLength {
+			return &IDDecodeError{Rule: ErrInlineKeyTooLong, Err: fmt.Errorf("got %d bytes", len(dec.Digest))}
+		}
+		if _, err := ic.UnmarshalPublicKey(dec.Digest); err != nil {
+			return &IDDecodeError{Rule: ErrBadInlineKey, Err: err}
+		}
+	default:
+		return &IDDecodeError{Rule: ErrUnsupportedHash, Err: fmt.Errorf("got %s", dec.Name)}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package peer_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	. "github.com/libp2p/go-libp2p-core/peer"
+
+	cid "github.com/ipfs/go-cid"
+	b58 "github.com/mr-tron/base58/base58"
+	mh "github.com/multiformats/go-multihash"
+)
+
+func TestDecodeIDStrict(t *testing.T) {
+	_, pk, err := ic.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edID, err := IDFromPublicKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []ID{testID, edID} {
+		for _, s := range []string{
+			IDB58Encode(id),
+			cid.NewCidV1(0x72, mh.Multihash(id)).String(),
+		} {
+			got, err := DecodeIDStrict(s)
+			if err != nil {
+				t.Fatalf("failed to decode %s: %s", s, err)
+			}
+			if got != id {
+				t.Fatalf("decoded %s, expected %s", got, id)
+			}
+		}
+		if _, err := IDFromBytesStrict([]byte(id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sha1, _ := mh.Sum([]byte("foo"), mh.SHA1, -1)
+	garbage, _ := mh.Sum([]byte("not a key"), mh.ID, -1)
+	for s, rule := range map[string]error{
+		"":                                ErrEmptyPeerID,
+		"QmInvalid0":                      ErrBadMultihash,
+		"notanid":                         ErrUnknownIDEncoding,
+		cid.NewCidV1(0x72, sha1).String(): ErrUnsupportedHash,
+		b58.Encode(garbage):               ErrBadInlineKey,
+		cid.NewCidV1(cid.DagProtobuf, mh.Multihash(testID)).String(): ErrNotLibp2pKeyCID,
+	} {
+		_, err := DecodeIDStrict(s)
+		if !errors.Is(err, rule) {
+			t.Errorf("expected %q to violate %q, got %v", s, rule, err)
+		}
+	}
+
+	if _, err := IDFromBytesStrict(sha1); !errors.Is(err, ErrUnsupportedHash) {
+		t.Errorf("expected ErrUnsupportedHash, got %v", err)
+	}
+}
+
+func TestDecodeIDLenient(t *testing.T) {
+	for _, s := range []string{
+		"  " + IDB58Encode(testID) + "\n",
+		"/p2p/" + IDB58Encode(testID),
+		"/ipfs/" + IDB58Encode(testID),
+		cid.NewCidV1(cid.DagProtobuf, mh.Multihash(testID)).String(),
+	} {
+		got, err := DecodeIDLenient(s)
+		if err != nil {
+			t.Fatalf("failed to decode %q: %s", s, err)
+		}
+		if got != testID {
+			t.Fatalf("decoded %s, expected %s", got, testID)
+		}
+	}
+
+	if _, err := DecodeIDLenient("notanid"); !errors.Is(err, ErrUnknownIDEncoding) {
+		t.Fatalf("expected ErrUnknownIDEncoding, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package peer
+
+import (
+	"errors"
+	"fmt"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+
+	mh "github.com/multiformats/go-multihash"
+)
+
+// ErrHashPolicy means a peer ID's multihash function isn't the one required
+// by the IDHashPolicy it's validated against.
+var ErrHashPolicy = errors.New("multihash function not allowed by the ID hash policy")
+
+// maxIdentityKeyLength bounds the keys inlined in peer IDs validated against
+// IDHashIdentity, which fits RSA keys of up to 8192 bits.
+const maxIdentityKeyLength = 2048
+
+// ErrIdentityKeyTooLong means a peer ID validated against IDHashIdentity
+// inlines a key longer than keys of supported sizes.
+var ErrIdentityKeyTooLong = fmt.Errorf("identity multihash digest exceeds %d bytes", maxIdentityKeyLength)
+
+// IDHashPolicy selects the multihash function used to derive peer IDs from
+// public keys.
+type IDHashPolicy int
+
+const (
+	// IDHashDefault inlines keys of at most 42 bytes with the identity
+	// function (if AdvancedEnableInlining is set), and hashes larger keys
+	// with sha2-256. This is what IDFromPublicKey does.
+	IDHashDefault IDHashPolicy = iota
+	// IDHashIdentity always inlines the key with the identity function, so
+	// the key can be extracted from any peer ID (see ExtractPublicKey).
+	IDHashIdentity
+	// IDHashSHA256 always hashes the key with sha2-256.
+	IDHashSHA256
+)
+
+func (p IDHashPolicy) String() string {
+	switch p {
+	case IDHashDefault:
+		return "default"
+	case IDHashIdentity:
+		return "identity"
+	case IDHashSHA256:
+		return "sha2-256"
+	default:
+		return fmt.Sprintf("IDHashPolicy(%d)", int(p))
+	}
+}
+
+// IDFromPublicKeyWithPolicy returns the peer ID corresponding to the public
+// key pk, derived with the multihash function selected by the policy.
+func IDFromPublicKeyWithPolicy(pk ic.PubKey, policy IDHashPolicy) (ID, error) {
+	b, err := pk.Bytes()
+	if err != nil {
+		return "", err
+	}
+	var alg uint64
+	switch policy {
+	case IDHashIdentity:
+		alg = mh.ID
+	case IDHashSHA256:
+		alg = mh.SHA2_256
+	default:
+		return IDFromPublicKey(pk)
+	}
+	hash, _ := mh.Sum(b, alg, -1)
+	return ID(hash), nil
+}
+
+// MatchesPublicKeyWithPolicy tests whether the ID was derived from the public
+// key pk with the given policy.
+//
+// Unlike MatchesPublicKey, which only accepts the ID IDFromPublicKey derives,
+// this lets callers opt into the IDs of another policy. Accepting several
+// policies gives a key several IDs: callers relying on a one to one mapping
+// between keys and IDs must stick to a single policy.
+func (id ID) MatchesPublicKeyWithPolicy(pk ic.PubKey, policy IDHashPolicy) bool {
+	oid, err := IDFromPublicKeyWithPolicy(pk, policy)
+	if err != nil {
+		return false
+	}
+	return oid == id
+}
+
+// Validate checks that the peer ID was derived following the policy.
+// IDHashDefault applies the same rules as IDFromBytesStrict, IDHashIdentity
+// requires an identity multihash embedding a valid public key of at most
+// 2048 bytes, and IDHashSHA256 a 32 byte sha2-256 multihash. Errors are
+// *IDDecodeError values.
+func (p IDHashPolicy) Validate(id ID) error {
+	if p == IDHashDefault {
+		_, err := IDFromBytesStrict([]byte(id))
+		return err
+	}
+
+	input := fmt.Sprintf("%x", []byte(id))
+	dec, err := mh.Decode([]byte(id))
+	if err != nil {
+		return &IDDecodeError{Input: input, Rule: ErrBadMultihash, Err: err}
+	}
+	switch p {
+	case IDHashIdentity:
+		if dec.Code != mh.ID {
+			return &IDDecodeError{Input: input, Rule: ErrHashPolicy, Err: fmt.Errorf("got %s, want identity", dec.Name)}
+		}
+		if len(dec.Digest) > maxIdentityKeyLength {
+			return &IDDecodeError{Input: input, Rule: ErrIdentityKeyTooLong, Err: fmt.Errorf("got %d bytes", len(dec.Digest))}
+		}
+		if _, err := ic.UnmarshalPublicKey(dec.Digest); err != nil {
+			return &IDDecodeError{Input: input, Rule: ErrBadInlineKey, Err: err}
+		}
+	case IDHashSHA256:
+		if dec.Code != mh.SHA2_256 {
+			return &IDDecodeError{Input: input, Rule: ErrHashPolicy, Err: fmt.Errorf("got %s, want sha2-256", dec.Name)}
+		}
+		if len(dec.Digest) != 32 {
+			return &IDDecodeError{Input: input, Rule: ErrBadDigestLength, Err: fmt.Errorf("got %d bytes", len(dec.Digest))}
+		}
+	default:
+		return fmt.Errorf("unknown ID hash policy %s", p)
+	}
+	return nil
+}
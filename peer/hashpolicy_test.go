@@ -0,0 +1,80 @@
+package peer_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	. "github.com/libp2p/go-libp2p-core/peer"
+
+	mh "github.com/multiformats/go-multihash"
+)
+
+func TestIDHashPolicy(t *testing.T) {
+	_, rsaPk, err := ic.GenerateRSAKeyPair(2048, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edPk, err := ic.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inlined, err := IDFromPublicKeyWithPolicy(rsaPk, IDHashIdentity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, err := mh.Decode([]byte(inlined))
+	if err != nil || dec.Code != mh.ID {
+		t.Fatalf("expected an identity multihash, got %v (%v)", dec, err)
+	}
+	if pk, err := inlined.ExtractPublicKey(); err != nil || !pk.Equals(rsaPk) {
+		t.Fatal("expected the key to be extractable from the ID")
+	}
+	if inlined.MatchesPublicKey(rsaPk) {
+		t.Fatal("expected MatchesPublicKey to only accept the default ID")
+	}
+	if !inlined.MatchesPublicKeyWithPolicy(rsaPk, IDHashIdentity) {
+		t.Fatal("expected the inlined ID to match its key")
+	}
+	if inlined.MatchesPublicKeyWithPolicy(rsaPk, IDHashSHA256) {
+		t.Fatal("expected the inlined ID not to match the sha2-256 policy")
+	}
+	if err := IDHashIdentity.Validate(inlined); err != nil {
+		t.Fatal(err)
+	}
+	if err := IDHashDefault.Validate(inlined); !errors.Is(err, ErrInlineKeyTooLong) {
+		t.Fatalf("expected ErrInlineKeyTooLong, got %v", err)
+	}
+
+	hashed, err := IDFromPublicKeyWithPolicy(edPk, IDHashSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashed.MatchesPublicKey(edPk) || !hashed.MatchesPublicKeyWithPolicy(edPk, IDHashSHA256) {
+		t.Fatal("expected the hashed ID to match its key with its policy only")
+	}
+	if err := IDHashSHA256.Validate(hashed); err != nil {
+		t.Fatal(err)
+	}
+	if err := IDHashIdentity.Validate(hashed); !errors.Is(err, ErrHashPolicy) {
+		t.Fatalf("expected ErrHashPolicy, got %v", err)
+	}
+
+	def, err := IDFromPublicKeyWithPolicy(edPk, IDHashDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := IDFromPublicKey(edPk); def != other {
+		t.Fatal("expected the default policy to match IDFromPublicKey")
+	}
+	if err := IDHashSHA256.Validate(def); !errors.Is(err, ErrHashPolicy) {
+		t.Fatalf("expected ErrHashPolicy, got %v", err)
+	}
+
+	huge, _ := mh.Sum(make([]byte, 4096), mh.ID, -1)
+	if err := IDHashIdentity.Validate(ID(huge)); !errors.Is(err, ErrIdentityKeyTooLong) {
+		t.Fatalf("expected ErrIdentityKeyTooLong, got %v", err)
+	}
+}
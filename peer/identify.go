@@ -0,0 +1,70 @@
+package peer
+
+import (
+	"encoding/json"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// IdentifySnapshot is the information a peer announced about itself through
+// the identify protocol.
+type IdentifySnapshot struct {
+	AgentVersion    string
+	ProtocolVersion string
+	// Protocols lists the protocols the peer supports.
+	Protocols []string
+	// ListenAddrs lists the addresses the peer listens on.
+	ListenAddrs []ma.Multiaddr
+	// ObservedAddr is the address the peer observed us on, if any.
+	ObservedAddr ma.Multiaddr
+}
+
+type identifySnapshotJSON struct {
+	AgentVersion    string   `json:",omitempty"`
+	ProtocolVersion string   `json:",omitempty"`
+	Protocols       []string `json:",omitempty"`
+	ListenAddrs     []string `json:",omitempty"`
+	ObservedAddr    string   `json:",omitempty"`
+}
+
+func (s IdentifySnapshot) MarshalJSON() ([]byte, error) {
+	out := identifySnapshotJSON{
+		AgentVersion:    s.AgentVersion,
+		ProtocolVersion: s.ProtocolVersion,
+		Protocols:       s.Protocols,
+	}
+	for _, a := range s.ListenAddrs {
+		out.ListenAddrs = append(out.ListenAddrs, a.String())
+	}
+	if s.ObservedAddr != nil {
+		out.ObservedAddr = s.ObservedAddr.String()
+	}
+	return json.Marshal(out)
+}
+
+func (s *IdentifySnapshot) UnmarshalJSON(b []byte) error {
+	var in identifySnapshotJSON
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	s.AgentVersion = in.AgentVersion
+	s.ProtocolVersion = in.ProtocolVersion
+	s.Protocols = in.Protocols
+	s.ListenAddrs = nil
+	for _, a := range in.ListenAddrs {
+		addr, err := ma.NewMultiaddr(a)
+		if err != nil {
+			return err
+		}
+		s.ListenAddrs = append(s.ListenAddrs, addr)
+	}
+	s.ObservedAddr = nil
+	if in.ObservedAddr != "" {
+		addr, err := ma.NewMultiaddr(in.ObservedAddr)
+		if err != nil {
+			return err
+		}
+		s.ObservedAddr = addr
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package peer_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/libp2p/go-libp2p-core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestIdentifySnapshotJSON(t *testing.T) {
+	snap := IdentifySnapshot{
+		AgentVersion:    "go-libp2p/0.1.0",
+		ProtocolVersion: "ipfs/0.1.0",
+		Protocols:       []string{"/ipfs/id/1.0.0", "/ipfs/ping/1.0.0"},
+		ListenAddrs:     []ma.Multiaddr{ma.StringCast("/ip4/1.2.3.4/tcp/4001")},
+		ObservedAddr:    ma.StringCast("/ip4/5.6.7.8/tcp/1234"),
+	}
+	b, err := json.Marshal(snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap2 IdentifySnapshot
+	if err := json.Unmarshal(b, &snap2); err != nil {
+		t.Fatal(err)
+	}
+	if snap2.AgentVersion != snap.AgentVersion || snap2.ProtocolVersion != snap.ProtocolVersion ||
+		len(snap2.Protocols) != 2 || snap2.Protocols[1] != "/ipfs/ping/1.0.0" {
+		t.Fatalf("unexpected snapshot: %+v", snap2)
+	}
+	if len(snap2.ListenAddrs) != 1 || !snap2.ListenAddrs[0].Equal(snap.ListenAddrs[0]) ||
+		!snap2.ObservedAddr.Equal(snap.ObservedAddr) {
+		t.Fatalf("unexpected addresses: %+v", snap2)
+	}
+
+	if err := json.Unmarshal([]byte(`{"ListenAddrs":["not an addr"]}`), &snap2); err == nil {
+		t.Fatal("expected invalid address to be rejected")
+	}
+}
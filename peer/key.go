@@ -0,0 +1,67 @@
+package peer
+
+import (
+	"crypto/sha256"
+	"sort"
+	"strings"
+)
+
+// KeySize is the size of a Key.
+const KeySize = 48
+
+// maxKeyInline is the length of the longest ID stored as is in a Key. All the
+// IDs derived from public keys, including inlined ones, fit.
+const maxKeyInline = KeySize - 1
+
+// hashedKeyTag marks Keys holding the hash of a long ID instead of the ID.
+const hashedKeyTag = 0xff
+
+// Key is a fixed-size, comparable representation of a peer ID, for use as an
+// array-keyed map key or in sorted arrays without allocating strings.
+//
+// IDs up to KeySize-1 bytes (which covers every ID derived from a public key)
+// are stored as is, prefixed with their length; longer ones are replaced by
+// their sha2-256 hash. Keys don't sort like the IDs they represent.
+type Key [KeySize]byte
+
+// Key returns the compact key of the ID.
+func (id ID) Key() Key {
+	var k Key
+	if len(id) <= maxKeyInline {
+		k[0] = byte(len(id))
+		copy(k[1:], id)
+		return k
+	}
+	k[0] = hashedKeyTag
+	h := sha256.Sum256([]byte(id))
+	copy(k[1:], h[:])
+	return k
+}
+
+// ID returns the peer ID the key represents. It returns false if the ID was
+// too long to be stored in the key.
+func (k Key) ID() (ID, bool) {
+	if k[0] > maxKeyInline {
+		return "", false
+	}
+	return ID(k[1 : 1+int(k[0])]), true
+}
+
+// Compare returns an integer comparing two IDs by their bytes: 0 if
+// id == other, -1 if id < other, and +1 if id > other.
+func (id ID) Compare(other ID) int {
+	return strings.Compare(string(id), string(other))
+}
+
+// SortIDs sorts the IDs in increasing order, as IDSlice does.
+func SortIDs(ids []ID) {
+	sort.Sort(IDSlice(ids))
+}
+
+// SearchIDs returns the index of id in the sorted IDs, or the index where it
+// would be inserted if it isn't present.
+func SearchIDs(ids []ID, id ID) int {
+	return sort.Search(len(ids), func(i int) bool {
+		return ids[i] >= id
+	})
+}
@@ -0,0 +1,48 @@
+package peer_test
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestIDKey(t *testing.T) {
+	short := ID("QmShort")
+	long := ID(strings.Repeat("x", 100))
+
+	if id, ok := short.Key().ID(); !ok || id != short {
+		t.Fatalf("expected key to round-trip, got %q, %v", id, ok)
+	}
+	if _, ok := long.Key().ID(); ok {
+		t.Fatal("expected a long ID not to be recoverable from its key")
+	}
+
+	m := map[Key]int{short.Key(): 1, long.Key(): 2}
+	if m[ID("QmShort").Key()] != 1 || m[ID(strings.Repeat("x", 100)).Key()] != 2 {
+		t.Fatal("expected equal IDs to have equal keys")
+	}
+	if short.Key() == ID("QmShort\x00").Key() {
+		t.Fatal("expected IDs differing by a trailing zero to have different keys")
+	}
+	if id, ok := (Key{}).ID(); !ok || id != "" {
+		t.Fatal("expected the zero key to be the empty ID")
+	}
+}
+
+func TestIDOrdering(t *testing.T) {
+	if ID("a").Compare("b") != -1 || ID("b").Compare("a") != 1 || ID("a").Compare("a") != 0 {
+		t.Fatal("unexpected comparison")
+	}
+	ids := []ID{"c", "a", "b"}
+	SortIDs(ids)
+	if ids[0] != "a" || ids[1] != "b" || ids[2] != "c" {
+		t.Fatalf("unexpected order %v", ids)
+	}
+	if i := SearchIDs(ids, "b"); i != 1 {
+		t.Fatalf("expected b at 1, got %d", i)
+	}
+	if i := SearchIDs(ids, "bb"); i != 2 {
+		t.Fatalf("expected bb to be inserted at 2, got %d", i)
+	}
+}
@@ -26,12 +26,31 @@ func NewLimitedSet(size int) *Set {
 	return ps
 }
 
+// NewSetFromIDs returns an unlimited set holding the given peers.
+func NewSetFromIDs(ids ...ID) *Set {
+	ps := NewSet()
+	for _, p := range ids {
+		ps.ps[p] = struct{}{}
+	}
+	return ps
+}
+
 func (ps *Set) Add(p ID) {
 	ps.lk.Lock()
 	ps.ps[p] = struct{}{}
 	ps.lk.Unlock()
 }
 
+// Remove removes the given peer from the set, returning true if it was
+// present.
+func (ps *Set) Remove(p ID) bool {
+	ps.lk.Lock()
+	_, ok := ps.ps[p]
+	delete(ps.ps, p)
+	ps.lk.Unlock()
+	return ok
+}
+
 func (ps *Set) Contains(p ID) bool {
 	ps.lk.RLock()
 	_, ok := ps.ps[p]
@@ -45,6 +64,12 @@ func (ps *Set) Size() int {
 	return len(ps.ps)
 }
 
+// Limit returns the maximum number of peers TryAdd will admit, or -1 if the
+// set is unlimited.
+func (ps *Set) Limit() int {
+	return ps.size
+}
+
 // TryAdd Attempts to add the given peer into the set.
 // This operation can fail for one of two reasons:
 // 1) The given peer is already in the set
@@ -60,12 +85,57 @@ func (ps *Set) TryAdd(p ID) bool {
 	return success
 }
 
+// Peers returns a snapshot of the peers in the set.
 func (ps *Set) Peers() []ID {
-	ps.lk.Lock()
+	ps.lk.RLock()
 	out := make([]ID, 0, len(ps.ps))
-	for p, _ := range ps.ps {
+	for p := range ps.ps {
 		out = append(out, p)
 	}
-	ps.lk.Unlock()
+	ps.lk.RUnlock()
+	return out
+}
+
+// ForEach calls f for every peer in a snapshot of the set, stopping early if
+// f returns false. The set isn't locked while f runs, so f may freely modify
+// it.
+func (ps *Set) ForEach(f func(ID) bool) {
+	for _, p := range ps.Peers() {
+		if !f(p) {
+			return
+		}
+	}
+}
+
+// Union returns a new, unlimited set containing the peers of both sets.
+func (ps *Set) Union(other *Set) *Set {
+	// Snapshot the other set first so we never hold both locks at once.
+	theirs := other.Peers()
+
+	out := NewSet()
+	ps.lk.RLock()
+	for p := range ps.ps {
+		out.ps[p] = struct{}{}
+	}
+	ps.lk.RUnlock()
+	for _, p := range theirs {
+		out.ps[p] = struct{}{}
+	}
+	return out
+}
+
+// Intersect returns a new, unlimited set containing the peers present in
+// both sets.
+func (ps *Set) Intersect(other *Set) *Set {
+	theirs := other.Peers()
+
+	out := NewSet()
+	ps.lk.RLock()
+	for _, p := range theirs {
+		if _, ok := ps.ps[p]; ok {
+			out.ps[p] = struct{}{}
+		}
+	}
+	ps.lk.RUnlock()
 	return out
 }
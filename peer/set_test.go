@@ -0,0 +1,94 @@
+package peer_test
+
+import (
+	"sort"
+	"testing"
+
+	. "github.com/libp2p/go-libp2p-core/peer"
+)
+
+func sortedPeers(s *Set) IDSlice {
+	out := IDSlice(s.Peers())
+	sort.Sort(out)
+	return out
+}
+
+func TestSetAddRemove(t *testing.T) {
+	s := NewSet()
+	s.Add("a")
+	s.Add("b")
+	s.Add("a")
+	if s.Size() != 2 {
+		t.Fatalf("expected 2 peers, got %d", s.Size())
+	}
+	if !s.Remove("a") {
+		t.Fatal("expected a to be removed")
+	}
+	if s.Remove("a") {
+		t.Fatal("expected a to be gone already")
+	}
+	if s.Contains("a") || !s.Contains("b") {
+		t.Fatal("unexpected set contents")
+	}
+}
+
+func TestLimitedSet(t *testing.T) {
+	s := NewLimitedSet(2)
+	if s.Limit() != 2 {
+		t.Fatalf("expected limit 2, got %d", s.Limit())
+	}
+	if !s.TryAdd("a") || !s.TryAdd("b") {
+		t.Fatal("expected adds to succeed")
+	}
+	if s.TryAdd("c") {
+		t.Fatal("expected add over the limit to fail")
+	}
+	if s.TryAdd("a") {
+		t.Fatal("expected duplicate add to fail")
+	}
+	if NewSet().Limit() != -1 {
+		t.Fatal("expected unlimited set")
+	}
+}
+
+func TestSetUnionIntersect(t *testing.T) {
+	a := NewSetFromIDs("a", "b", "c")
+	b := NewSetFromIDs("b", "c", "d")
+
+	u := sortedPeers(a.Union(b))
+	if len(u) != 4 || u[0] != "a" || u[3] != "d" {
+		t.Fatalf("unexpected union %v", u)
+	}
+
+	i := sortedPeers(a.Intersect(b))
+	if len(i) != 2 || i[0] != "b" || i[1] != "c" {
+		t.Fatalf("unexpected intersection %v", i)
+	}
+
+	if self := a.Union(a); self.Size() != 3 {
+		t.Fatalf("expected union with self to be a copy, got %d peers", self.Size())
+	}
+}
+
+func TestSetForEach(t *testing.T) {
+	s := NewSetFromIDs("a", "b", "c")
+
+	// Mutating the set while iterating must not deadlock.
+	s.ForEach(func(p ID) bool {
+		s.Remove(p)
+		return true
+	})
+	if s.Size() != 0 {
+		t.Fatalf("expected empty set, got %d peers", s.Size())
+	}
+
+	s = NewSetFromIDs("a", "b", "c")
+	n := 0
+	s.ForEach(func(ID) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Fatalf("expected iteration to stop after 1 peer, got %d", n)
+	}
+}
@@ -0,0 +1,129 @@
+package peerstore
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// ErrKeyMismatch is returned when storing a key that doesn't match the peer
+// ID it's stored under.
+var ErrKeyMismatch = errors.New("key does not match peer ID")
+
+// KeyInconsistency describes a KeyBook entry whose key doesn't match the peer
+// ID it's stored under.
+type KeyInconsistency struct {
+	Peer peer.ID
+	// Private is true if the inconsistent key is the private key.
+	Private bool
+}
+
+// KeyAuditor is implemented by KeyBooks that can list their inconsistent
+// entries more efficiently than by checking every key.
+type KeyAuditor interface {
+	AuditKeys() []KeyInconsistency
+}
+
+// AuditKeys lists the entries of the KeyBook whose keys don't match the peer
+// ID they're stored under, which can only happen if keys were stored without
+// verification. It uses KeyAuditor if the KeyBook implements it.
+func AuditKeys(kb KeyBook) []KeyInconsistency {
+	if a, ok := kb.(KeyAuditor); ok {
+		return a.AuditKeys()
+	}
+
+	var out []KeyInconsistency
+	for _, p := range kb.PeersWithKeys() {
+		if pk := kb.PubKey(p); pk != nil && !p.MatchesPublicKey(pk) {
+			out = append(out, KeyInconsistency{Peer: p})
+		}
+		if sk := kb.PrivKey(p); sk != nil && !p.MatchesPrivateKey(sk) {
+			out = append(out, KeyInconsistency{Peer: p, Private: true})
+		}
+	}
+	return out
+}
+
+// DefaultRejectedKeys is the number of rejected insertions remembered by the
+// KeyBooks created with NewAttestingKeyBook.
+const DefaultRejectedKeys = 1024
+
+// NewAttestingKeyBook wraps the KeyBook so that keys are verified against the
+// peer ID before being stored; mismatching keys are rejected with
+// ErrKeyMismatch. Rejected insertions are remembered and reported by
+// AuditKeys, along with inconsistent entries already in the wrapped KeyBook.
+func NewAttestingKeyBook(kb KeyBook) KeyBook {
+	return NewAttestingKeyBookWithSize(kb, DefaultRejectedKeys)
+}
+
+// NewAttestingKeyBookWithSize is like NewAttestingKeyBook, but remembers up to
+// size rejected insertions, forgetting the least recent ones first.
+func NewAttestingKeyBookWithSize(kb KeyBook, size int) KeyBook {
+	if size <= 0 {
+		size = DefaultRejectedKeys
+	}
+	return &attestingKeyBook{
+		KeyBook:  kb,
+		size:     size,
+		order:    list.New(),
+		rejected: make(map[KeyInconsistency]*list.Element),
+	}
+}
+
+type attestingKeyBook struct {
+	KeyBook
+
+	mu   sync.Mutex
+	size int
+	// order lists the rejected insertions, least recent first.
+	order    *list.List
+	rejected map[KeyInconsistency]*list.Element
+}
+
+var _ KeyAuditor = (*attestingKeyBook)(nil)
+
+func (kb *attestingKeyBook) reject(ki KeyInconsistency) error {
+	kb.mu.Lock()
+	defer kb.mu.Unlock()
+	if e, ok := kb.rejected[ki]; ok {
+		kb.order.MoveToBack(e)
+		return ErrKeyMismatch
+	}
+	kb.rejected[ki] = kb.order.PushBack(ki)
+	if kb.order.Len() > kb.size {
+		oldest := kb.order.Front()
+		kb.order.Remove(oldest)
+		delete(kb.rejected, oldest.Value.(KeyInconsistency))
+	}
+	return ErrKeyMismatch
+}
+
+func (kb *attestingKeyBook) AddPubKey(p peer.ID, pk ic.PubKey) error {
+	if !p.MatchesPublicKey(pk) {
+		return kb.reject(KeyInconsistency{Peer: p})
+	}
+	return kb.KeyBook.AddPubKey(p, pk)
+}
+
+func (kb *attestingKeyBook) AddPrivKey(p peer.ID, sk ic.PrivKey) error {
+	if !p.MatchesPrivateKey(sk) {
+		return kb.reject(KeyInconsistency{Peer: p, Private: true})
+	}
+	return kb.KeyBook.AddPrivKey(p, sk)
+}
+
+// AuditKeys lists the inconsistent entries of the wrapped KeyBook followed by
+// the most recent rejected insertions.
+func (kb *attestingKeyBook) AuditKeys() []KeyInconsistency {
+	out := AuditKeys(kb.KeyBook)
+
+	kb.mu.Lock()
+	defer kb.mu.Unlock()
+	for e := kb.order.Front(); e != nil; e = e.Next() {
+		out = append(out, e.Value.(KeyInconsistency))
+	}
+	return out
+}
@@ -0,0 +1,109 @@
+package peerstore
+
+import (
+	"crypto/rand"
+	"testing"
+
+	ic "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+type mapKeyBook struct {
+	pub  map[peer.ID]ic.PubKey
+	priv map[peer.ID]ic.PrivKey
+}
+
+func newMapKeyBook() *mapKeyBook {
+	return &mapKeyBook{pub: make(map[peer.ID]ic.PubKey), priv: make(map[peer.ID]ic.PrivKey)}
+}
+
+func (kb *mapKeyBook) PubKey(p peer.ID) ic.PubKey   { return kb.pub[p] }
+func (kb *mapKeyBook) PrivKey(p peer.ID) ic.PrivKey { return kb.priv[p] }
+
+func (kb *mapKeyBook) AddPubKey(p peer.ID, pk ic.PubKey) error {
+	kb.pub[p] = pk
+	return nil
+}
+
+func (kb *mapKeyBook) AddPrivKey(p peer.ID, sk ic.PrivKey) error {
+	kb.priv[p] = sk
+	return nil
+}
+
+func (kb *mapKeyBook) PeersWithKeys() peer.IDSlice {
+	var out peer.IDSlice
+	for p := range kb.pub {
+		out = append(out, p)
+	}
+	return out
+}
+
+func genIdentity(t *testing.T) (peer.ID, ic.PrivKey, ic.PubKey) {
+	sk, pk, err := ic.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPublicKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id, sk, pk
+}
+
+func TestAttestingKeyBook(t *testing.T) {
+	a, aSk, aPk := genIdentity(t)
+	b, _, bPk := genIdentity(t)
+	_, _, otherPk := genIdentity(t)
+
+	inner := newMapKeyBook()
+	// Poison the underlying store.
+	inner.AddPubKey(b, otherPk)
+
+	if got := AuditKeys(inner); len(got) != 1 || got[0] != (KeyInconsistency{Peer: b}) {
+		t.Fatalf("unexpected audit of the poisoned store: %v", got)
+	}
+
+	kb := NewAttestingKeyBook(inner)
+	if err := kb.AddPubKey(a, aPk); err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.AddPrivKey(a, aSk); err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.AddPubKey(a, bPk); err != ErrKeyMismatch {
+		t.Fatalf("expected ErrKeyMismatch, got %v", err)
+	}
+	if !kb.PubKey(a).Equals(aPk) {
+		t.Fatal("rejected key must not be stored")
+	}
+
+	got := AuditKeys(kb)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 inconsistencies, got %v", got)
+	}
+	seen := make(map[KeyInconsistency]bool)
+	for _, ki := range got {
+		seen[ki] = true
+	}
+	if !seen[KeyInconsistency{Peer: a}] || !seen[KeyInconsistency{Peer: b}] {
+		t.Fatalf("unexpected audit: %v", got)
+	}
+}
+
+func TestAttestingKeyBookBounded(t *testing.T) {
+	_, _, pk := genIdentity(t)
+	kb := NewAttestingKeyBookWithSize(newMapKeyBook(), 2)
+
+	var ids []peer.ID
+	for i := 0; i < 3; i++ {
+		id, _, _ := genIdentity(t)
+		ids = append(ids, id)
+		if err := kb.AddPubKey(id, pk); err != ErrKeyMismatch {
+			t.Fatalf("expected ErrKeyMismatch, got %v", err)
+		}
+	}
+	got := AuditKeys(kb)
+	if len(got) != 2 || got[0].Peer != ids[1] || got[1].Peer != ids[2] {
+		t.Fatalf("expected the 2 most recent rejections, got %v", got)
+	}
+}
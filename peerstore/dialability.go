@@ -0,0 +1,108 @@
+package peerstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// AddrDialStats records the outcomes of the dials to an address.
+type AddrDialStats struct {
+	// LastSuccess is the last time the address was dialed successfully,
+	// confirming it's dialable.
+	LastSuccess time.Time
+	// LastFailure is the time of the last failed dial.
+	LastFailure time.Time
+	// ConsecutiveFailures counts the failed dials since the last success.
+	ConsecutiveFailures int
+}
+
+// DialabilityBook is implemented by AddrBooks that keep track of dial
+// outcomes, fed back by the network layer, so that dials start with the
+// addresses known to work rather than in insertion order.
+type DialabilityBook interface {
+	// RecordDialOutcome records the outcome of a dial to the address of
+	// the peer: a success if err is nil, a failure otherwise.
+	RecordDialOutcome(p peer.ID, addr ma.Multiaddr, err error)
+
+	// AddrDialStats returns the dial outcomes of the address of the peer.
+	AddrDialStats(p peer.ID, addr ma.Multiaddr) AddrDialStats
+
+	// BestAddrs returns the valid addresses of the peer, best first (see
+	// SortAddrsByDialability).
+	BestAddrs(p peer.ID) []ma.Multiaddr
+}
+
+// RecordDialOutcome records the outcome of a dial if the AddrBook implements
+// DialabilityBook, and does nothing otherwise.
+func RecordDialOutcome(ab AddrBook, p peer.ID, addr ma.Multiaddr, err error) {
+	if db, ok := ab.(DialabilityBook); ok {
+		db.RecordDialOutcome(p, addr, err)
+	}
+}
+
+// BestAddrs returns the addresses of the peer, best first, if the AddrBook
+// implements DialabilityBook, and in the AddrBook's order otherwise.
+func BestAddrs(ab AddrBook, p peer.ID) []ma.Multiaddr {
+	if db, ok := ab.(DialabilityBook); ok {
+		return db.BestAddrs(p)
+	}
+	return ab.Addrs(p)
+}
+
+// Record updates the stats with the outcome of a dial at time now.
+func (s *AddrDialStats) Record(err error, now time.Time) {
+	if err == nil {
+		s.LastSuccess = now
+		s.ConsecutiveFailures = 0
+		return
+	}
+	s.LastFailure = now
+	s.ConsecutiveFailures++
+}
+
+// SortAddrsByDialability sorts the addresses, best first: addresses
+// confirmed dialable (most recent success first), then addresses never
+// dialed, then addresses whose last dial failed (fewest consecutive failures
+// first). The sort is stable, so addresses with the same stats keep their
+// order.
+func SortAddrsByDialability(addrs []ma.Multiaddr, stats func(ma.Multiaddr) AddrDialStats) {
+	type entry struct {
+		addr  ma.Multiaddr
+		stats AddrDialStats
+	}
+	entries := make([]entry, len(addrs))
+	for i, a := range addrs {
+		entries[i] = entry{a, stats(a)}
+	}
+	rank := func(s AddrDialStats) int {
+		switch {
+		case s.ConsecutiveFailures > 0:
+			return 2
+		case !s.LastSuccess.IsZero():
+			return 0
+		default:
+			return 1
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		si, sj := entries[i].stats, entries[j].stats
+		ri, rj := rank(si), rank(sj)
+		if ri != rj {
+			return ri < rj
+		}
+		switch ri {
+		case 0:
+			return si.LastSuccess.After(sj.LastSuccess)
+		case 2:
+			return si.ConsecutiveFailures < sj.ConsecutiveFailures
+		}
+		return false
+	})
+	for i, e := range entries {
+		addrs[i] = e.addr
+	}
+}
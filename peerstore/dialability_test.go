@@ -0,0 +1,48 @@
+package peerstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestSortAddrsByDialability(t *testing.T) {
+	addr := func(s string) ma.Multiaddr {
+		a, err := ma.NewMultiaddr(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	failing := addr("/ip4/1.2.3.4/tcp/1")
+	flaky := addr("/ip4/1.2.3.4/tcp/2")
+	unknown := addr("/ip4/1.2.3.4/tcp/3")
+	old := addr("/ip4/1.2.3.4/tcp/4")
+	recent := addr("/ip4/1.2.3.4/tcp/5")
+
+	now := time.Now()
+	stats := map[string]*AddrDialStats{}
+	for _, a := range []ma.Multiaddr{failing, flaky, unknown, old, recent} {
+		stats[a.String()] = new(AddrDialStats)
+	}
+	dialErr := errors.New("dial failed")
+	stats[failing.String()].Record(dialErr, now)
+	stats[failing.String()].Record(dialErr, now)
+	stats[flaky.String()].Record(nil, now.Add(-time.Hour))
+	stats[flaky.String()].Record(dialErr, now)
+	stats[old.String()].Record(nil, now.Add(-time.Hour))
+	stats[recent.String()].Record(dialErr, now.Add(-time.Minute))
+	stats[recent.String()].Record(nil, now)
+
+	addrs := []ma.Multiaddr{failing, flaky, unknown, old, recent}
+	SortAddrsByDialability(addrs, func(a ma.Multiaddr) AddrDialStats { return *stats[a.String()] })
+
+	expected := []ma.Multiaddr{recent, old, unknown, flaky, failing}
+	for i := range expected {
+		if !addrs[i].Equal(expected[i]) {
+			t.Fatalf("unexpected order: %v", addrs)
+		}
+	}
+}
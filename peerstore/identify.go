@@ -0,0 +1,69 @@
+package peerstore
+
+import (
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Metadata keys under which identify implementations traditionally store the
+// versions announced by peers.
+const (
+	AgentVersionKey    = "AgentVersion"
+	ProtocolVersionKey = "ProtocolVersion"
+)
+
+// IdentifyBook is implemented by peerstores that store the identify
+// information of peers as a whole.
+type IdentifyBook interface {
+	// IdentifySnapshot returns the last identify snapshot recorded for the
+	// peer, or ErrNotFound.
+	IdentifySnapshot(peer.ID) (*peer.IdentifySnapshot, error)
+
+	// SetIdentifySnapshot records the identify snapshot of the peer.
+	SetIdentifySnapshot(peer.ID, *peer.IdentifySnapshot) error
+}
+
+// GetIdentifySnapshot returns the identify information of the peer. If the
+// peerstore doesn't implement IdentifyBook, the snapshot is assembled from
+// the versions stored under AgentVersionKey and ProtocolVersionKey, the
+// ProtoBook and the AddrBook; the observed address is then unknown.
+func GetIdentifySnapshot(ps Peerstore, p peer.ID) (*peer.IdentifySnapshot, error) {
+	if ib, ok := ps.(IdentifyBook); ok {
+		return ib.IdentifySnapshot(p)
+	}
+
+	snap := new(peer.IdentifySnapshot)
+	if v, err := ps.Get(p, AgentVersionKey); err == nil {
+		snap.AgentVersion, _ = v.(string)
+	} else if err != ErrNotFound {
+		return nil, err
+	}
+	if v, err := ps.Get(p, ProtocolVersionKey); err == nil {
+		snap.ProtocolVersion, _ = v.(string)
+	} else if err != ErrNotFound {
+		return nil, err
+	}
+	protos, err := ps.GetProtocols(p)
+	if err != nil {
+		return nil, err
+	}
+	snap.Protocols = protos
+	snap.ListenAddrs = ps.Addrs(p)
+	return snap, nil
+}
+
+// SetIdentifySnapshot records the identify information of the peer. If the
+// peerstore doesn't implement IdentifyBook, the versions are stored under
+// AgentVersionKey and ProtocolVersionKey and the protocols in the ProtoBook;
+// addresses are left to the caller, who knows which TTL to use.
+func SetIdentifySnapshot(ps Peerstore, p peer.ID, snap *peer.IdentifySnapshot) error {
+	if ib, ok := ps.(IdentifyBook); ok {
+		return ib.SetIdentifySnapshot(p, snap)
+	}
+	if err := ps.Put(p, AgentVersionKey, snap.AgentVersion); err != nil {
+		return err
+	}
+	if err := ps.Put(p, ProtocolVersionKey, snap.ProtocolVersion); err != nil {
+		return err
+	}
+	return ps.SetProtocols(p, snap.Protocols...)
+}
@@ -0,0 +1,169 @@
+package peerstore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// LatencySampleTTL is how long a latency sample is considered when computing
+// the EWMA. Implementations of LatencyStatsMetrics, such as AgingMetrics,
+// forget samples older than this, so routing decisions aren't based on a
+// single RTT measured hours ago.
+var LatencySampleTTL = time.Hour
+
+// LatencyEWMASmoothing is the weight of each new sample in the EWMA computed
+// by AgingMetrics.
+var LatencyEWMASmoothing = 0.1
+
+// MaxLatencySamples is the number of samples AgingMetrics keeps per peer.
+const MaxLatencySamples = 64
+
+// LatencyStats describes the latency measurements recorded for a peer.
+type LatencyStats struct {
+	// EWMA is the exponentially-weighted moving average of the samples that
+	// haven't aged out yet, or 0 if there are none.
+	EWMA time.Duration
+	// Samples is the number of samples the EWMA is based on.
+	Samples int
+	// LastSample is the time of the most recent sample.
+	LastSample time.Time
+}
+
+// Age returns how long ago the most recent sample was recorded.
+func (s LatencyStats) Age(now time.Time) time.Duration {
+	return now.Sub(s.LastSample)
+}
+
+// Stale returns true if there are no samples, or if the most recent one is
+// older than maxAge.
+func (s LatencyStats) Stale(now time.Time, maxAge time.Duration) bool {
+	return s.Samples == 0 || s.Age(now) > maxAge
+}
+
+// LatencyStatsMetrics is implemented by Metrics that age out latency samples
+// and keep track of their count and age.
+type LatencyStatsMetrics interface {
+	Metrics
+
+	// LatencyStats returns the latency measurements recorded for the peer.
+	LatencyStats(peer.ID) LatencyStats
+}
+
+// GetLatencyStats returns the latency measurements recorded for the peer. The
+// second return value is false if m doesn't implement LatencyStatsMetrics, in
+// which case only the EWMA is known.
+func GetLatencyStats(m Metrics, p peer.ID) (LatencyStats, bool) {
+	if lm, ok := m.(LatencyStatsMetrics); ok {
+		return lm.LatencyStats(p), true
+	}
+	return LatencyStats{EWMA: m.LatencyEWMA(p)}, false
+}
+
+// FreshLatency returns the latency EWMA of the peer if its most recent sample
+// is at most maxAge old. If m doesn't track sample ages, the EWMA is returned
+// as is.
+func FreshLatency(m Metrics, p peer.ID, maxAge time.Duration) (time.Duration, bool) {
+	return FreshLatencyAt(m, p, maxAge, time.Now())
+}
+
+// FreshLatencyAt is like FreshLatency, measuring the age of the most recent
+// sample at time now. Components driven by a network.Clock pass its time.
+func FreshLatencyAt(m Metrics, p peer.ID, maxAge time.Duration, now time.Time) (time.Duration, bool) {
+	stats, ok := GetLatencyStats(m, p)
+	if !ok {
+		return stats.EWMA, stats.EWMA > 0
+	}
+	if stats.Stale(now, maxAge) {
+		return 0, false
+	}
+	return stats.EWMA, true
+}
+
+// AgingMetrics is a LatencyStatsMetrics keeping the most recent samples of
+// each peer, up to MaxLatencySamples, and computing the EWMA of those recorded
+// within LatencySampleTTL.
+type AgingMetrics struct {
+	lk      sync.Mutex
+	samples map[peer.ID][]latencySample
+}
+
+type latencySample struct {
+	rtt time.Duration
+	at  time.Time
+}
+
+var _ LatencyStatsMetrics = (*AgingMetrics)(nil)
+
+// NewAgingMetrics creates an empty AgingMetrics.
+func NewAgingMetrics() *AgingMetrics {
+	return &AgingMetrics{samples: make(map[peer.ID][]latencySample)}
+}
+
+// RecordLatency records a new latency measurement.
+func (m *AgingMetrics) RecordLatency(p peer.ID, rtt time.Duration) {
+	m.RecordLatencyAt(p, rtt, time.Now())
+}
+
+// RecordLatencyAt records a latency measurement taken at time now, forgetting
+// the samples of the peer that aged out.
+func (m *AgingMetrics) RecordLatencyAt(p peer.ID, rtt time.Duration, now time.Time) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	samples := append(freshSamples(m.samples[p], now), latencySample{rtt: rtt, at: now})
+	if len(samples) > MaxLatencySamples {
+		samples = samples[len(samples)-MaxLatencySamples:]
+	}
+	m.samples[p] = samples
+}
+
+// LatencyEWMA returns the EWMA of the peer's samples recorded within
+// LatencySampleTTL.
+func (m *AgingMetrics) LatencyEWMA(p peer.ID) time.Duration {
+	return m.LatencyStats(p).EWMA
+}
+
+// LatencyStats returns the latency measurements of the peer recorded within
+// LatencySampleTTL.
+func (m *AgingMetrics) LatencyStats(p peer.ID) LatencyStats {
+	return m.LatencyStatsAt(p, time.Now())
+}
+
+// LatencyStatsAt is like LatencyStats, ageing samples out at time now.
+func (m *AgingMetrics) LatencyStatsAt(p peer.ID, now time.Time) LatencyStats {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	samples := freshSamples(m.samples[p], now)
+	if len(samples) == 0 {
+		return LatencyStats{}
+	}
+	ewma := float64(samples[0].rtt)
+	for _, s := range samples[1:] {
+		ewma = (1-LatencyEWMASmoothing)*ewma + LatencyEWMASmoothing*float64(s.rtt)
+	}
+	return LatencyStats{
+		EWMA:       time.Duration(ewma),
+		Samples:    len(samples),
+		LastSample: samples[len(samples)-1].at,
+	}
+}
+
+// RemovePeer forgets the samples of the peer.
+func (m *AgingMetrics) RemovePeer(p peer.ID) {
+	m.lk.Lock()
+	defer m.lk.Unlock()
+	delete(m.samples, p)
+}
+
+// freshSamples returns the samples recorded within LatencySampleTTL of now.
+// Samples are in recording order, so the aged out ones come first.
+func freshSamples(samples []latencySample, now time.Time) []latencySample {
+	cutoff := now.Add(-LatencySampleTTL)
+	for i, s := range samples {
+		if s.at.After(cutoff) {
+			return samples[i:]
+		}
+	}
+	return nil
+}
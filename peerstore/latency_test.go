@@ -0,0 +1,80 @@
+package peerstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+type statsMetrics struct {
+	*fakePeerstore
+	stats map[peer.ID]LatencyStats
+}
+
+func (m statsMetrics) LatencyStats(p peer.ID) LatencyStats {
+	return m.stats[p]
+}
+
+func TestFreshLatency(t *testing.T) {
+	ps := &fakePeerstore{latency: map[peer.ID]time.Duration{"a": time.Millisecond}}
+	if l, ok := FreshLatency(ps, "a", time.Minute); !ok || l != time.Millisecond {
+		t.Fatal("expected the EWMA of metrics without stats")
+	}
+	if _, ok := FreshLatency(ps, "b", time.Minute); ok {
+		t.Fatal("expected unknown latency")
+	}
+
+	m := statsMetrics{ps, map[peer.ID]LatencyStats{
+		"a": {EWMA: time.Millisecond, Samples: 3, LastSample: time.Now().Add(-time.Hour)},
+		"b": {EWMA: 2 * time.Millisecond, Samples: 1, LastSample: time.Now()},
+	}}
+	if _, ok := FreshLatency(m, "a", time.Minute); ok {
+		t.Fatal("expected stale latency to be ignored")
+	}
+	if l, ok := FreshLatency(m, "b", time.Minute); !ok || l != 2*time.Millisecond {
+		t.Fatal("expected fresh latency")
+	}
+	if _, ok := FreshLatency(m, "c", time.Minute); ok {
+		t.Fatal("expected unknown latency")
+	}
+}
+
+func TestAgingMetrics(t *testing.T) {
+	m := NewAgingMetrics()
+	start := time.Now()
+
+	m.RecordLatencyAt("a", 100*time.Millisecond, start)
+	m.RecordLatencyAt("a", 100*time.Millisecond, start.Add(time.Minute))
+	stats := m.LatencyStatsAt("a", start.Add(time.Minute))
+	if stats.Samples != 2 || stats.EWMA != 100*time.Millisecond {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	// The first sample ages out; the EWMA is now based on the last two.
+	later := start.Add(LatencySampleTTL + time.Second)
+	m.RecordLatencyAt("a", 10*time.Millisecond, later)
+	stats = m.LatencyStatsAt("a", later)
+	if stats.Samples != 2 || !stats.LastSample.Equal(later) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if want := 91 * time.Millisecond; stats.EWMA != want {
+		t.Fatalf("expected an EWMA of %s, got %s", want, stats.EWMA)
+	}
+
+	// Eventually all samples age out.
+	if stats := m.LatencyStatsAt("a", later.Add(LatencySampleTTL)); stats.Samples != 0 || stats.EWMA != 0 {
+		t.Fatalf("expected all samples to age out, got %+v", stats)
+	}
+
+	for i := 0; i < 2*MaxLatencySamples; i++ {
+		m.RecordLatencyAt("b", time.Millisecond, later)
+	}
+	if stats := m.LatencyStatsAt("b", later); stats.Samples != MaxLatencySamples {
+		t.Fatalf("expected %d samples, got %d", MaxLatencySamples, stats.Samples)
+	}
+	m.RemovePeer("b")
+	if stats := m.LatencyStatsAt("b", later); stats.Samples != 0 {
+		t.Fatal("expected the peer's samples to be forgotten")
+	}
+}
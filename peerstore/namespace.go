@@ -0,0 +1,175 @@
+package peerstore
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Namespacer is implemented by peerstores providing namespaced views of
+// themselves, one per logical application (or tenant) sharing the peerstore.
+//
+// A view shares the addresses, keys and metrics of the underlying peerstore,
+// but has its own protocols and metadata, so that gateway processes hosting
+// several applications over one Host don't leak one application's view of
+// peers into another's.
+type Namespacer interface {
+	// Namespace returns the view of the namespace. Calls with the same name
+	// return views of the same data. Closing a view doesn't close the
+	// underlying peerstore.
+	Namespace(name string) Peerstore
+}
+
+// Namespaces provides namespaced views of a peerstore that doesn't implement
+// Namespacer, storing the protocols and metadata of each namespace in the
+// underlying peerstore's metadata under prefixed keys.
+//
+// The prefix of a namespace is "/ns/<length of name>/<name>/", so no two
+// names share a key space whatever characters they contain. Metadata keys
+// are stored under "<prefix>meta/", separately from the protocols.
+type Namespaces struct {
+	ps Peerstore
+
+	lk    sync.Mutex
+	views map[string]*nsPeerstore
+}
+
+var _ Namespacer = (*Namespaces)(nil)
+
+// NewNamespaces creates a Namespacer for the peerstore. If the peerstore
+// implements Namespacer itself, its views are returned instead.
+func NewNamespaces(ps Peerstore) Namespacer {
+	if ns, ok := ps.(Namespacer); ok {
+		return ns
+	}
+	return &Namespaces{ps: ps, views: make(map[string]*nsPeerstore)}
+}
+
+// Namespace implements Namespacer.
+func (n *Namespaces) Namespace(name string) Peerstore {
+	n.lk.Lock()
+	defer n.lk.Unlock()
+	v, ok := n.views[name]
+	if !ok {
+		v = &nsPeerstore{Peerstore: n.ps, prefix: fmt.Sprintf("/ns/%d/%s/", len(name), name)}
+		n.views[name] = v
+	}
+	return v
+}
+
+// nsPeerstore is a namespaced view of a peerstore.
+type nsPeerstore struct {
+	Peerstore
+	prefix string
+
+	// protoLk serializes the read-modify-write updates of the protocols.
+	protoLk sync.Mutex
+}
+
+func (ps *nsPeerstore) Get(p peer.ID, key string) (interface{}, error) {
+	return ps.Peerstore.Get(p, ps.metaKey(key))
+}
+
+func (ps *nsPeerstore) Put(p peer.ID, key string, val interface{}) error {
+	return ps.Peerstore.Put(p, ps.metaKey(key), val)
+}
+
+func (ps *nsPeerstore) metaKey(key string) string {
+	return ps.prefix + "meta/" + key
+}
+
+func (ps *nsPeerstore) protocolsKey() string {
+	return ps.prefix + "protocols"
+}
+
+func (ps *nsPeerstore) getProtocols(p peer.ID) ([]string, error) {
+	v, err := ps.Peerstore.Get(p, ps.protocolsKey())
+	switch err {
+	case nil:
+		protos, ok := v.([]string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected protocols of type %T in namespace %s", v, ps.prefix)
+		}
+		return protos, nil
+	case ErrNotFound:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (ps *nsPeerstore) GetProtocols(p peer.ID) ([]string, error) {
+	ps.protoLk.Lock()
+	defer ps.protoLk.Unlock()
+	protos, err := ps.getProtocols(p)
+	return append([]string(nil), protos...), err
+}
+
+func (ps *nsPeerstore) updateProtocols(p peer.ID, update func(map[string]struct{})) error {
+	ps.protoLk.Lock()
+	defer ps.protoLk.Unlock()
+	protos, err := ps.getProtocols(p)
+	if err != nil {
+		return err
+	}
+	set := make(map[string]struct{}, len(protos))
+	for _, proto := range protos {
+		set[proto] = struct{}{}
+	}
+	update(set)
+	protos = make([]string, 0, len(set))
+	for proto := range set {
+		protos = append(protos, proto)
+	}
+	return ps.Peerstore.Put(p, ps.protocolsKey(), protos)
+}
+
+func (ps *nsPeerstore) AddProtocols(p peer.ID, protos ...string) error {
+	return ps.updateProtocols(p, func(set map[string]struct{}) {
+		for _, proto := range protos {
+			set[proto] = struct{}{}
+		}
+	})
+}
+
+func (ps *nsPeerstore) SetProtocols(p peer.ID, protos ...string) error {
+	return ps.updateProtocols(p, func(set map[string]struct{}) {
+		for proto := range set {
+			delete(set, proto)
+		}
+		for _, proto := range protos {
+			set[proto] = struct{}{}
+		}
+	})
+}
+
+func (ps *nsPeerstore) RemoveProtocols(p peer.ID, protos ...string) error {
+	return ps.updateProtocols(p, func(set map[string]struct{}) {
+		for _, proto := range protos {
+			delete(set, proto)
+		}
+	})
+}
+
+func (ps *nsPeerstore) SupportsProtocols(p peer.ID, protos ...string) ([]string, error) {
+	have, err := ps.GetProtocols(p)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, want := range protos {
+		for _, proto := range have {
+			if proto == want {
+				out = append(out, want)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// Close doesn't close the underlying peerstore, which other namespaces share.
+func (ps *nsPeerstore) Close() error {
+	return nil
+}
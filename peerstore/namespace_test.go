@@ -0,0 +1,73 @@
+package peerstore
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+type metaKey struct {
+	p   peer.ID
+	key string
+}
+
+// metaPeerstore implements just enough of Peerstore for namespaced views.
+type metaPeerstore struct {
+	Peerstore
+	meta map[metaKey]interface{}
+}
+
+func (m *metaPeerstore) Get(p peer.ID, key string) (interface{}, error) {
+	v, ok := m.meta[metaKey{p, key}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+func (m *metaPeerstore) Put(p peer.ID, key string, val interface{}) error {
+	m.meta[metaKey{p, key}] = val
+	return nil
+}
+
+func TestNamespaces(t *testing.T) {
+	ns := NewNamespaces(&metaPeerstore{meta: make(map[metaKey]interface{})})
+	a, b := ns.Namespace("a"), ns.Namespace("b")
+
+	if err := a.AddProtocols("p", "/x", "/y"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RemoveProtocols("p", "/x"); err != nil {
+		t.Fatal(err)
+	}
+	if protos, _ := ns.Namespace("a").SupportsProtocols("p", "/x", "/y"); len(protos) != 1 || protos[0] != "/y" {
+		t.Fatalf("unexpected protocols: %v", protos)
+	}
+	if protos, _ := b.GetProtocols("p"); len(protos) != 0 {
+		t.Fatalf("protocols leaked across namespaces: %v", protos)
+	}
+
+	if err := a.Put("p", "agent", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Get("p", "agent"); err != ErrNotFound {
+		t.Fatalf("metadata leaked across namespaces: %v", err)
+	}
+	if v, err := a.Get("p", "agent"); err != nil || v != "a" {
+		t.Fatalf("unexpected metadata %v, %v", v, err)
+	}
+
+	// Neither names nor keys can reach into another key space.
+	if err := ns.Namespace("a").Put("p", "b/x", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ns.Namespace("a/b").Get("p", "x"); err != ErrNotFound {
+		t.Fatalf("metadata leaked across namespaces: %v", err)
+	}
+	if err := a.Put("p", "protocols", "not protocols"); err != nil {
+		t.Fatal(err)
+	}
+	if protos, _ := a.GetProtocols("p"); len(protos) != 1 || protos[0] != "/y" {
+		t.Fatalf("metadata overwrote the protocols: %v", protos)
+	}
+}
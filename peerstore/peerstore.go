@@ -150,6 +150,9 @@ type Metrics interface {
 
 	// LatencyEWMA returns an exponentially-weighted moving avg.
 	// of all measurements of a peer's latency.
+	//
+	// Implementations of LatencyStatsMetrics only consider the samples
+	// recorded within LatencySampleTTL.
 	LatencyEWMA(peer.ID) time.Duration
 }
 
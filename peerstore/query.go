@@ -0,0 +1,170 @@
+package peerstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// Filter is a predicate evaluated against a peer in a peerstore.
+type Filter func(ps Peerstore, p peer.ID) bool
+
+// HasProtocols matches peers known to support all of the given protocols.
+func HasProtocols(protos ...string) Filter {
+	return func(ps Peerstore, p peer.ID) bool {
+		supported, err := ps.SupportsProtocols(p, protos...)
+		return err == nil && len(supported) == len(protos)
+	}
+}
+
+// HasAnyProtocol matches peers known to support at least one of the given
+// protocols.
+func HasAnyProtocol(protos ...string) Filter {
+	return func(ps Peerstore, p peer.ID) bool {
+		supported, err := ps.SupportsProtocols(p, protos...)
+		return err == nil && len(supported) > 0
+	}
+}
+
+// HasAddrs matches peers with at least one valid address.
+func HasAddrs() Filter {
+	return func(ps Peerstore, p peer.ID) bool {
+		return len(ps.Addrs(p)) > 0
+	}
+}
+
+// LatencyBelow matches peers whose latency EWMA is known and lower than d.
+func LatencyBelow(d time.Duration) Filter {
+	return func(ps Peerstore, p peer.ID) bool {
+		l := ps.LatencyEWMA(p)
+		return l > 0 && l < d
+	}
+}
+
+// CertifiedAddrBook is implemented by AddrBooks that know which addresses of
+// a peer come from its signed peer record, rather than from unauthenticated
+// sources.
+type CertifiedAddrBook interface {
+	// CertifiedAddrs returns the valid addresses of the peer that come
+	// from its signed peer record.
+	CertifiedAddrs(p peer.ID) []ma.Multiaddr
+}
+
+// HasCertifiedAddrs matches peers with at least one valid certified address.
+// It matches no peer if the peerstore doesn't implement CertifiedAddrBook.
+func HasCertifiedAddrs() Filter {
+	return func(ps Peerstore, p peer.ID) bool {
+		cab, ok := ps.(CertifiedAddrBook)
+		return ok && len(cab.CertifiedAddrs(p)) > 0
+	}
+}
+
+// LastSeenBook is implemented by peerstores recording the last time each peer
+// was seen, i.e. connected to or heard from.
+type LastSeenBook interface {
+	// LastSeen returns the last time the peer was seen, or the zero time
+	// if it never was.
+	LastSeen(p peer.ID) time.Time
+}
+
+// LastSeen returns the last time the peer was seen. If the peerstore doesn't
+// implement LastSeenBook, the time of its most recent latency sample is used
+// if known (see LatencyStatsMetrics). The zero time means unknown.
+func LastSeen(ps Peerstore, p peer.ID) time.Time {
+	if lsb, ok := ps.(LastSeenBook); ok {
+		return lsb.LastSeen(p)
+	}
+	stats, _ := GetLatencyStats(ps, p)
+	return stats.LastSample
+}
+
+// SeenSince matches peers last seen at or after t (see LastSeen).
+func SeenSince(t time.Time) Filter {
+	return func(ps Peerstore, p peer.ID) bool {
+		seen := LastSeen(ps, p)
+		return !seen.IsZero() && !seen.Before(t)
+	}
+}
+
+// SeenWithin matches peers last seen at most d before the query is run (see
+// LastSeen).
+func SeenWithin(d time.Duration) Filter {
+	return func(ps Peerstore, p peer.ID) bool {
+		return SeenSince(time.Now().Add(-d))(ps, p)
+	}
+}
+
+// Not inverts a filter.
+func Not(f Filter) Filter {
+	return func(ps Peerstore, p peer.ID) bool {
+		return !f(ps, p)
+	}
+}
+
+// Query selects peers from a peerstore.
+type Query struct {
+	// Filters must all match for a peer to be returned.
+	Filters []Filter
+
+	// Cursor resumes a previous query. Pass the Next value of the previous
+	// QueryResult, or the empty string to start from the beginning.
+	Cursor peer.ID
+
+	// Limit is the maximum number of peers to return. Zero means no limit.
+	Limit int
+}
+
+// QueryResult is a page of peers matching a Query.
+type QueryResult struct {
+	Peers peer.IDSlice
+
+	// Next is the cursor to resume the query from. It's empty when there are
+	// no more results.
+	Next peer.ID
+}
+
+// Querier is implemented by peerstores that can evaluate queries more
+// efficiently than by scanning all peers.
+type Querier interface {
+	Query(Query) (QueryResult, error)
+}
+
+// QueryPeers runs the query against the peerstore.
+//
+// Peers are returned in ascending peer ID order, so that a cursor stays
+// valid while peers are added or removed. If the peerstore implements
+// Querier, the query is delegated to it.
+func QueryPeers(ps Peerstore, q Query) (QueryResult, error) {
+	if qps, ok := ps.(Querier); ok {
+		return qps.Query(q)
+	}
+
+	all := ps.Peers()
+	sort.Sort(all)
+
+	start := 0
+	if q.Cursor != "" {
+		start = sort.Search(len(all), func(i int) bool {
+			return all[i] > q.Cursor
+		})
+	}
+
+	var res QueryResult
+outer:
+	for _, p := range all[start:] {
+		for _, f := range q.Filters {
+			if !f(ps, p) {
+				continue outer
+			}
+		}
+		if q.Limit > 0 && len(res.Peers) == q.Limit {
+			res.Next = res.Peers[len(res.Peers)-1]
+			break
+		}
+		res.Peers = append(res.Peers, p)
+	}
+	return res, nil
+}
@@ -0,0 +1,146 @@
+package peerstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// fakePeerstore implements just enough of Peerstore for the query helpers.
+type fakePeerstore struct {
+	Peerstore
+
+	protos  map[peer.ID][]string
+	latency map[peer.ID]time.Duration
+}
+
+func (f *fakePeerstore) Peers() peer.IDSlice {
+	var out peer.IDSlice
+	for p := range f.protos {
+		out = append(out, p)
+	}
+	return out
+}
+
+func (f *fakePeerstore) SupportsProtocols(p peer.ID, protos ...string) ([]string, error) {
+	var out []string
+	for _, want := range protos {
+		for _, have := range f.protos[p] {
+			if want == have {
+				out = append(out, want)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (f *fakePeerstore) LatencyEWMA(p peer.ID) time.Duration {
+	return f.latency[p]
+}
+
+func TestQueryPeers(t *testing.T) {
+	ps := &fakePeerstore{
+		protos: map[peer.ID][]string{
+			"a": {"/x", "/y"},
+			"b": {"/x"},
+			"c": {"/x", "/y"},
+			"d": {"/y"},
+			"e": {"/x", "/y"},
+		},
+		latency: map[peer.ID]time.Duration{
+			"a": time.Millisecond,
+			"c": time.Second,
+			"e": 2 * time.Millisecond,
+		},
+	}
+
+	res, err := QueryPeers(ps, Query{Filters: []Filter{HasProtocols("/x", "/y")}, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Peers) != 2 || res.Peers[0] != "a" || res.Peers[1] != "c" || res.Next != "c" {
+		t.Fatalf("unexpected first page %v (next %q)", res.Peers, res.Next)
+	}
+
+	res, err = QueryPeers(ps, Query{Filters: []Filter{HasProtocols("/x", "/y")}, Limit: 2, Cursor: res.Next})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Peers) != 1 || res.Peers[0] != "e" || res.Next != "" {
+		t.Fatalf("unexpected second page %v (next %q)", res.Peers, res.Next)
+	}
+
+	res, err = QueryPeers(ps, Query{Filters: []Filter{HasAnyProtocol("/y"), LatencyBelow(100 * time.Millisecond)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Peers) != 2 || res.Peers[0] != "a" || res.Peers[1] != "e" {
+		t.Fatalf("unexpected latency query result %v", res.Peers)
+	}
+
+	res, err = QueryPeers(ps, Query{Filters: []Filter{Not(HasAnyProtocol("/x"))}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Peers) != 1 || res.Peers[0] != "d" {
+		t.Fatalf("unexpected negated query result %v", res.Peers)
+	}
+}
+
+// seenPeerstore knows the certified addresses of peers and when they were
+// last seen.
+type seenPeerstore struct {
+	fakePeerstore
+
+	certified map[peer.ID][]ma.Multiaddr
+	seen      map[peer.ID]time.Time
+}
+
+func (f *seenPeerstore) CertifiedAddrs(p peer.ID) []ma.Multiaddr { return f.certified[p] }
+func (f *seenPeerstore) LastSeen(p peer.ID) time.Time            { return f.seen[p] }
+
+func TestQueryCertifiedAndSeen(t *testing.T) {
+	now := time.Now()
+	ps := &seenPeerstore{
+		fakePeerstore: fakePeerstore{protos: map[peer.ID][]string{"a": nil, "b": nil, "c": nil}},
+		certified: map[peer.ID][]ma.Multiaddr{
+			"a": {ma.StringCast("/ip4/1.2.3.4/tcp/4001")},
+			"b": {ma.StringCast("/ip4/1.2.3.5/tcp/4001")},
+		},
+		seen: map[peer.ID]time.Time{
+			"a": now.Add(-time.Hour),
+			"b": now.Add(-time.Minute),
+			"c": now,
+		},
+	}
+
+	res, err := QueryPeers(ps, Query{Filters: []Filter{HasCertifiedAddrs(), SeenWithin(10 * time.Minute)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Peers) != 1 || res.Peers[0] != "b" {
+		t.Fatalf("unexpected query result %v", res.Peers)
+	}
+
+	res, err = QueryPeers(ps, Query{Filters: []Filter{SeenSince(now.Add(-2 * time.Hour))}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Peers) != 3 {
+		t.Fatalf("unexpected query result %v", res.Peers)
+	}
+
+	// Peerstores without certified addresses or last seen times match
+	// nothing.
+	res, err = QueryPeers(&ps.fakePeerstore, Query{Filters: []Filter{HasCertifiedAddrs()}})
+	if err != nil || len(res.Peers) != 0 {
+		t.Fatalf("unexpected query result %v (%v)", res.Peers, err)
+	}
+	res, err = QueryPeers(&ps.fakePeerstore, Query{Filters: []Filter{SeenWithin(time.Hour)}})
+	if err != nil || len(res.Peers) != 0 {
+		t.Fatalf("unexpected query result %v (%v)", res.Peers, err)
+	}
+}
@@ -0,0 +1,258 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	pb "github.com/libp2p/go-libp2p-core/record/pb"
+)
+
+// Envelope contains an arbitrary []byte payload, signed by a libp2p peer.
+//
+// Envelopes are signed in the context of a particular "domain", which is a
+// string specified when creating and verifying the envelope. You must know the
+// domain string used to produce the envelope in order to verify the signature
+// and access the payload.
+type Envelope struct {
+	// The public key that can be used to verify the signature and derive the
+	// peer id of the signer.
+	PublicKey crypto.PubKey
+
+	// A binary identifier that indicates what kind of data is contained in
+	// the payload.
+	PayloadType []byte
+
+	// The envelope payload.
+	RawPayload []byte
+
+	// The signature of the domain string :: type hint :: payload.
+	signature []byte
+
+	// the unmarshalled payload as a Record, cached on first access via the
+	// Record accessor method
+	cached         Record
+	unmarshalError error
+	unmarshalOnce  sync.Once
+}
+
+var (
+	// ErrEmptyDomain is returned when sealing or consuming an envelope
+	// with an empty domain.
+	ErrEmptyDomain = errors.New("envelope domain must not be empty")
+	// ErrEmptyPayloadType is returned when sealing a record whose Codec is
+	// empty.
+	ErrEmptyPayloadType = errors.New("payloadType must not be empty")
+	// ErrInvalidSignature is returned when an envelope's signature doesn't
+	// verify in the given domain.
+	ErrInvalidSignature = errors.New("invalid signature or incorrect domain")
+	// ErrMalformedEnvelope is returned when an envelope can't be decoded.
+	ErrMalformedEnvelope = errors.New("malformed envelope")
+)
+
+// Seal marshals the given Record, places the marshaled bytes inside an
+// Envelope, and signs with the given private key, which must allow
+// crypto.UsageRecordSigning. Records of payload types registered with
+// RegisterVersion are prefixed with their version.
+func Seal(rec Record, privateKey crypto.PrivKey) (*Envelope, error) {
+	payload, err := marshalPayload(rec)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling record: %v", err)
+	}
+
+	domain := rec.Domain()
+	payloadType := rec.Codec()
+	if domain == "" {
+		return nil, ErrEmptyDomain
+	}
+
+	if len(payloadType) == 0 {
+		return nil, ErrEmptyPayloadType
+	}
+
+	unsigned := makeUnsigned(domain, payloadType, payload)
+	sig, err := crypto.SignWithUsage(privateKey, crypto.UsageRecordSigning, unsigned)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		PublicKey:   privateKey.GetPublic(),
+		PayloadType: payloadType,
+		RawPayload:  payload,
+		signature:   sig,
+	}, nil
+}
+
+// ConsumeEnvelope unmarshals a serialized Envelope and validates its
+// signature using the provided 'domain' string. If validation fails, an error
+// is returned, along with the unmarshalled envelope so it can be inspected.
+//
+// On success, ConsumeEnvelope returns the Envelope itself, as well as the
+// inner payload, unmarshalled into a concrete Record type. The actual type of
+// the returned Record depends on what has been registered for the Envelope's
+// PayloadType (see RegisterType for details).
+func ConsumeEnvelope(data []byte, domain string) (envelope *Envelope, rec Record, err error) {
+	e, err := UnmarshalEnvelope(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed when unmarshalling the envelope: %w", err)
+	}
+
+	err = e.validate(domain)
+	if err != nil {
+		return e, nil, fmt.Errorf("failed to validate envelope: %w", err)
+	}
+
+	rec, err = e.Record()
+	if err != nil {
+		return e, nil, fmt.Errorf("failed to unmarshal envelope payload: %w", err)
+	}
+	return e, rec, nil
+}
+
+// ConsumeTypedEnvelope unmarshals a serialized Envelope and validates its
+// signature. If validation fails, an error is returned, along with the
+// unmarshalled envelope so it can be inspected.
+//
+// Unlike ConsumeEnvelope, ConsumeTypedEnvelope does not try to automatically
+// determine the type of Record to unmarshal the Envelope's payload into.
+// Instead, the caller provides a destination Record instance, which will
+// unmarshal the Envelope payload. It is the caller's responsibility to
+// determine whether the given Record type is able to unmarshal the payload
+// correctly. Payloads of types registered with RegisterVersion are upgraded to
+// the latest version, which destRecord must be.
+func ConsumeTypedEnvelope(data []byte, destRecord Record) (envelope *Envelope, err error) {
+	e, err := UnmarshalEnvelope(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed when unmarshalling the envelope: %w", err)
+	}
+
+	err = e.validate(destRecord.Domain())
+	if err != nil {
+		return e, fmt.Errorf("failed to validate envelope: %w", err)
+	}
+
+	err = unmarshalPayload(e.PayloadType, e.RawPayload, destRecord)
+	if err != nil {
+		return e, fmt.Errorf("failed to unmarshal envelope payload: %w", err)
+	}
+	e.cached = destRecord
+	return e, nil
+}
+
+// UnmarshalEnvelope unmarshals a serialized Envelope protobuf message,
+// without validating its contents. Most users should use ConsumeEnvelope.
+func UnmarshalEnvelope(data []byte) (*Envelope, error) {
+	var msg pb.Envelope
+	if err := msg.Unmarshal(data); err != nil {
+		return nil, err
+	}
+	if msg.PublicKey == nil {
+		return nil, ErrMalformedEnvelope
+	}
+
+	key, err := crypto.PublicKeyFromProto(msg.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		PublicKey:   key,
+		PayloadType: msg.PayloadType,
+		RawPayload:  msg.Payload,
+		signature:   msg.Signature,
+	}, nil
+}
+
+// Marshal returns a byte slice containing a serialized protobuf
+// representation of an Envelope.
+func (e *Envelope) Marshal() ([]byte, error) {
+	key, err := crypto.PublicKeyToProto(e.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := pb.Envelope{
+		PublicKey:   key,
+		PayloadType: e.PayloadType,
+		Payload:     e.RawPayload,
+		Signature:   e.signature,
+	}
+	return msg.Marshal()
+}
+
+// Equal returns true if the other Envelope has the same public key,
+// payload, payload type, and signature. This implies that they were also
+// created with the same domain string.
+func (e *Envelope) Equal(other *Envelope) bool {
+	if other == nil {
+		return e == nil
+	}
+	return e.PublicKey.Equals(other.PublicKey) &&
+		bytes.Equal(e.PayloadType, other.PayloadType) &&
+		bytes.Equal(e.signature, other.signature) &&
+		bytes.Equal(e.RawPayload, other.RawPayload)
+}
+
+// Record returns the Envelope's payload unmarshalled as a Record.
+// The concrete type of the returned Record depends on which Record
+// type was registered for the Envelope's PayloadType - see record.RegisterType.
+//
+// Once unmarshalled, the Record is cached for future access.
+func (e *Envelope) Record() (Record, error) {
+	e.unmarshalOnce.Do(func() {
+		if e.cached != nil {
+			return
+		}
+		e.cached, e.unmarshalError = UnmarshalRecordPayload(e.PayloadType, e.RawPayload)
+	})
+	return e.cached, e.unmarshalError
+}
+
+// TypedRecord unmarshals the Envelope's payload to the given Record instance.
+// It is the caller's responsibility to ensure that the Record type is capable
+// of unmarshalling the Envelope payload. Callers can inspect the Envelope's
+// PayloadType field to determine the correct type of Record to use.
+//
+// This method will always unmarshal the Envelope payload even if a cached
+// record exists. Versioned payloads are upgraded as in ConsumeTypedEnvelope.
+func (e *Envelope) TypedRecord(dest Record) error {
+	return unmarshalPayload(e.PayloadType, e.RawPayload, dest)
+}
+
+// validate returns nil if the envelope signature is valid for the given
+// 'domain', or an error if signature validation fails.
+func (e *Envelope) validate(domain string) error {
+	if domain == "" {
+		return ErrEmptyDomain
+	}
+	unsigned := makeUnsigned(domain, e.PayloadType, e.RawPayload)
+	valid, err := e.PublicKey.Verify(unsigned, e.signature)
+	if err != nil {
+		return fmt.Errorf("failed while verifying signature: %w", err)
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var lbuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lbuf[:], v)
+	return append(buf, lbuf[:n]...)
+}
+
+// makeUnsigned is a helper function that prepares a buffer to sign or verify.
+// The fields are each prefixed with their length as an unsigned varint.
+func makeUnsigned(domain string, payloadType []byte, payload []byte) []byte {
+	var buf []byte
+	for _, f := range [][]byte{[]byte(domain), payloadType, payload} {
+		buf = appendUvarint(buf, uint64(len(f)))
+		buf = append(buf, f...)
+	}
+	return buf
+}
@@ -0,0 +1,80 @@
+package record
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+)
+
+var testCodec = []byte("/libp2p/testdata")
+
+type simpleRecord struct {
+	message string
+}
+
+func (r *simpleRecord) Domain() string                 { return "libp2p-testing" }
+func (r *simpleRecord) Codec() []byte                  { return testCodec }
+func (r *simpleRecord) MarshalRecord() ([]byte, error) { return []byte(r.message), nil }
+func (r *simpleRecord) UnmarshalRecord(buf []byte) error {
+	r.message = string(buf)
+	return nil
+}
+
+func init() {
+	RegisterType(&simpleRecord{})
+}
+
+func TestEnvelopeHappyPath(t *testing.T) {
+	priv, pub, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope, err := Seal(&simpleRecord{"hello world!"}, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !envelope.PublicKey.Equals(pub) {
+		t.Fatal("envelope has unexpected public key")
+	}
+
+	serialized, err := envelope.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deserialized, rec, err := ConsumeEnvelope(serialized, "libp2p-testing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deserialized.Equal(envelope) {
+		t.Fatal("round trip failed")
+	}
+	if msg := rec.(*simpleRecord).message; msg != "hello world!" {
+		t.Fatalf("unexpected payload %q", msg)
+	}
+
+	if _, _, err := ConsumeEnvelope(serialized, "wrong-domain"); err == nil {
+		t.Fatal("expected the signature to fail in another domain")
+	}
+
+	serialized[len(serialized)-1] ^= 1
+	if _, _, err := ConsumeEnvelope(serialized, "libp2p-testing"); err == nil {
+		t.Fatal("expected tampered envelope to be rejected")
+	}
+}
+
+func TestEnvelopeRejectsEmptyDomain(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &emptyDomainRecord{}
+	if _, err := Seal(rec, priv); err != ErrEmptyDomain {
+		t.Fatalf("expected ErrEmptyDomain, got %v", err)
+	}
+}
+
+type emptyDomainRecord struct{ simpleRecord }
+
+func (r *emptyDomainRecord) Domain() string { return "" }
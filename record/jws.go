@@ -0,0 +1,442 @@
+package record
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	pb "github.com/libp2p/go-libp2p-core/crypto/pb"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	btcec "github.com/btcsuite/btcd/btcec"
+)
+
+var (
+	// ErrJWSUnsupportedKey is returned when rendering a record as a JWS with
+	// a key that has no JWS algorithm, e.g. an ECDSA key on a curve other
+	// than P-256.
+	ErrJWSUnsupportedKey = errors.New("key type not supported by JWS")
+	// ErrMalformedJWS is returned when a JWS can't be decoded, or doesn't
+	// carry the libp2p headers.
+	ErrMalformedJWS = errors.New("malformed JWS")
+	// ErrJWSMismatch is returned when a JWS's domain or payload type doesn't
+	// match the record it's consumed into.
+	ErrJWSMismatch = errors.New("JWS domain or payload type doesn't match the record")
+	// ErrJWSKeyMismatch is returned when a JWS's "kid" or "jwk" header
+	// doesn't designate the libp2p key the JWS is verified with, so JOSE
+	// libraries trusting them would accept a different signer.
+	ErrJWSKeyMismatch = errors.New("JWS kid or jwk doesn't match the libp2p key")
+)
+
+// JWS is a record rendered as a JSON Web Signature (RFC 7515), in the
+// flattened JSON serialization, so web services can verify libp2p-signed
+// records with standard JOSE libraries, and sign records libp2p nodes verify.
+//
+// The protected header carries the algorithm, the signer's key as a JWK and
+// its peer ID as "kid", and the libp2p signature domain and payload type in
+// the "libp2p-domain" and "libp2p-payload-type" parameters. The signature
+// covers the standard JWS signing input, not the envelope's: converting
+// between a JWS and an Envelope requires the signer's key.
+//
+// Ed25519 (EdDSA), RSA (RS256), ECDSA P-256 (ES256) and secp256k1 (ES256K)
+// keys are supported.
+type JWS struct {
+	Protected string `json:"protected"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+type jwsHeader struct {
+	Alg         string          `json:"alg"`
+	Kid         string          `json:"kid,omitempty"`
+	JWK         json.RawMessage `json:"jwk,omitempty"`
+	Domain      string          `json:"libp2p-domain"`
+	PayloadType string          `json:"libp2p-payload-type"`
+	Key         string          `json:"libp2p-key"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// SealJWS marshals the record and signs it as a JWS with the given private
+// key, which must allow crypto.UsageRecordSigning.
+func SealJWS(rec Record, key crypto.PrivKey) (*JWS, error) {
+	if rec.Domain() == "" {
+		return nil, ErrEmptyDomain
+	}
+	if len(rec.Codec()) == 0 {
+		return nil, ErrEmptyPayloadType
+	}
+	payload, err := marshalPayload(rec)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling record: %v", err)
+	}
+	return signJWS(rec.Domain(), rec.Codec(), payload, key)
+}
+
+func signJWS(domain string, payloadType, payload []byte, key crypto.PrivKey) (*JWS, error) {
+	pub := key.GetPublic()
+	alg, err := jwsAlg(pub)
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := marshalJWK(pub)
+	if err != nil {
+		return nil, err
+	}
+	pubBytes, err := crypto.MarshalPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	id, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(&jwsHeader{
+		Alg:         alg,
+		Kid:         id.Pretty(),
+		JWK:         jwk,
+		Domain:      domain,
+		PayloadType: b64.EncodeToString(payloadType),
+		Key:         b64.EncodeToString(pubBytes),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	j := &JWS{
+		Protected: b64.EncodeToString(header),
+		Payload:   b64.EncodeToString(payload),
+	}
+	sig, err := crypto.SignWithUsage(key, crypto.UsageRecordSigning, j.signingInput())
+	if err != nil {
+		return nil, err
+	}
+	if alg == "ES256" || alg == "ES256K" {
+		// libp2p ECDSA signatures are DER encoded, JWS ones are R || S.
+		if sig, err = derToRawSig(sig); err != nil {
+			return nil, err
+		}
+	}
+	j.Signature = b64.EncodeToString(sig)
+	return j, nil
+}
+
+// ConsumeJWS verifies the JWS and unmarshals its payload into destRecord. The
+// JWS must have been signed in destRecord's domain, for its payload type. It
+// returns the signer's public key.
+func ConsumeJWS(j *JWS, destRecord Record) (crypto.PubKey, error) {
+	return ConsumeCheckedJWS(j, destRecord, nil)
+}
+
+// ConsumeCheckedJWS is ConsumeJWS, also rejecting the records revoked
+// according to the checker, like ConsumeCheckedTypedEnvelope: a JWS is
+// revoked by the revocations of the envelope with the same signer, domain,
+// payload type and payload. A nil checker disables revocation checks.
+func ConsumeCheckedJWS(j *JWS, destRecord Record, c RevocationChecker) (crypto.PubKey, error) {
+	hdr, payload, pub, err := j.verify()
+	if err != nil {
+		return nil, err
+	}
+	if hdr.Domain != destRecord.Domain() || hdr.PayloadType != b64.EncodeToString(destRecord.Codec()) {
+		return nil, ErrJWSMismatch
+	}
+	if err := unmarshalPayload(destRecord.Codec(), payload, destRecord); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JWS payload: %w", err)
+	}
+	if c != nil {
+		e := &Envelope{PublicKey: pub, PayloadType: destRecord.Codec(), RawPayload: payload}
+		if err := c.CheckRevocation(e, destRecord); err != nil {
+			return nil, err
+		}
+	}
+	return pub, nil
+}
+
+// EnvelopeToJWS renders the envelope, signed in the given domain, as a JWS.
+// The envelope is verified first, and the JWS is signed with key, which must
+// be the envelope's signer key.
+func EnvelopeToJWS(e *Envelope, domain string, key crypto.PrivKey) (*JWS, error) {
+	if err := e.validate(domain); err != nil {
+		return nil, err
+	}
+	if !key.GetPublic().Equals(e.PublicKey) {
+		return nil, ErrInvalidSignature
+	}
+	return signJWS(domain, e.PayloadType, e.RawPayload, key)
+}
+
+// JWSToEnvelope verifies the JWS and seals its payload in an envelope signed
+// with key, which must be the JWS's signer key. Records revoked according to
+// the checker are rejected; a nil checker disables revocation checks.
+func JWSToEnvelope(j *JWS, key crypto.PrivKey, c RevocationChecker) (*Envelope, error) {
+	hdr, payload, pub, err := j.verify()
+	if err != nil {
+		return nil, err
+	}
+	if !key.GetPublic().Equals(pub) {
+		return nil, ErrInvalidSignature
+	}
+	payloadType, err := b64.DecodeString(hdr.PayloadType)
+	if err != nil || len(payloadType) == 0 {
+		return nil, ErrMalformedJWS
+	}
+	if hdr.Domain == "" {
+		return nil, ErrEmptyDomain
+	}
+	e := &Envelope{
+		PublicKey:   pub,
+		PayloadType: payloadType,
+		RawPayload:  payload,
+	}
+	if c != nil {
+		rec, err := e.Record()
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal JWS payload: %w", err)
+		}
+		if err := c.CheckRevocation(e, rec); err != nil {
+			return nil, err
+		}
+	}
+	e.signature, err = crypto.SignWithUsage(key, crypto.UsageRecordSigning, makeUnsigned(hdr.Domain, payloadType, payload))
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Compact returns the JWS compact serialization.
+func (j *JWS) Compact() string {
+	return j.Protected + "." + j.Payload + "." + j.Signature
+}
+
+// ParseCompactJWS parses a JWS in the compact serialization. The JWS isn't
+// verified.
+func ParseCompactJWS(s string) (*JWS, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedJWS
+	}
+	return &JWS{Protected: parts[0], Payload: parts[1], Signature: parts[2]}, nil
+}
+
+func (j *JWS) signingInput() []byte {
+	return []byte(j.Protected + "." + j.Payload)
+}
+
+// verify checks the JWS's signature against the libp2p key in its header, and
+// returns the decoded header, payload and key.
+func (j *JWS) verify() (*jwsHeader, []byte, crypto.PubKey, error) {
+	headerBytes, err := b64.DecodeString(j.Protected)
+	if err != nil {
+		return nil, nil, nil, ErrMalformedJWS
+	}
+	var hdr jwsHeader
+	if err := json.Unmarshal(headerBytes, &hdr); err != nil {
+		return nil, nil, nil, ErrMalformedJWS
+	}
+	payload, err := b64.DecodeString(j.Payload)
+	if err != nil {
+		return nil, nil, nil, ErrMalformedJWS
+	}
+	sig, err := b64.DecodeString(j.Signature)
+	if err != nil {
+		return nil, nil, nil, ErrMalformedJWS
+	}
+	keyBytes, err := b64.DecodeString(hdr.Key)
+	if err != nil {
+		return nil, nil, nil, ErrMalformedJWS
+	}
+	pub, err := crypto.UnmarshalPublicKey(keyBytes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// The algorithm must be the key's: never let the header pick it.
+	alg, err := jwsAlg(pub)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if hdr.Alg != alg {
+		return nil, nil, nil, ErrMalformedJWS
+	}
+	if err := hdr.checkKeyIDs(pub); err != nil {
+		return nil, nil, nil, err
+	}
+	if alg == "ES256" || alg == "ES256K" {
+		if sig, err = rawToDERSig(sig); err != nil {
+			return nil, nil, nil, ErrInvalidSignature
+		}
+	}
+	ok, err := pub.Verify(j.signingInput(), sig)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed while verifying signature: %w", err)
+	}
+	if !ok {
+		return nil, nil, nil, ErrInvalidSignature
+	}
+	return &hdr, payload, pub, nil
+}
+
+// checkKeyIDs returns ErrJWSKeyMismatch unless the "kid" and "jwk" headers,
+// if present, designate pub.
+func (hdr *jwsHeader) checkKeyIDs(pub crypto.PubKey) error {
+	if hdr.Kid != "" {
+		id, err := peer.IDFromPublicKey(pub)
+		if err != nil {
+			return err
+		}
+		if hdr.Kid != id.Pretty() {
+			return ErrJWSKeyMismatch
+		}
+	}
+	if len(hdr.JWK) > 0 {
+		expected, err := marshalJWK(pub)
+		if err != nil {
+			return err
+		}
+		var got, want map[string]string
+		if err := json.Unmarshal(hdr.JWK, &got); err != nil {
+			return ErrMalformedJWS
+		}
+		if err := json.Unmarshal(expected, &want); err != nil {
+			return err
+		}
+		if len(got) != len(want) {
+			return ErrJWSKeyMismatch
+		}
+		for k, v := range want {
+			if got[k] != v {
+				return ErrJWSKeyMismatch
+			}
+		}
+	}
+	return nil
+}
+
+// jwsAlg returns the JWS algorithm matching the signatures made by libp2p
+// keys of the type of pub.
+func jwsAlg(pub crypto.PubKey) (string, error) {
+	switch pub.Type() {
+	case pb.KeyType_Ed25519:
+		return "EdDSA", nil
+	case pb.KeyType_RSA:
+		return "RS256", nil
+	case pb.KeyType_Secp256k1:
+		return "ES256K", nil
+	case pb.KeyType_ECDSA:
+		k, err := ecdsaKey(pub)
+		if err != nil {
+			return "", err
+		}
+		// libp2p ECDSA keys always sign sha2-256 digests.
+		if k.Curve != elliptic.P256() {
+			return "", ErrJWSUnsupportedKey
+		}
+		return "ES256", nil
+	default:
+		return "", ErrJWSUnsupportedKey
+	}
+}
+
+func ecdsaKey(pub crypto.PubKey) (*ecdsa.PublicKey, error) {
+	raw, err := pub.Raw()
+	if err != nil {
+		return nil, err
+	}
+	k, err := x509.ParsePKIXPublicKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	ek, ok := k.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrJWSUnsupportedKey
+	}
+	return ek, nil
+}
+
+// marshalJWK renders the public key as a JWK (RFC 7517).
+func marshalJWK(pub crypto.PubKey) ([]byte, error) {
+	raw, err := pub.Raw()
+	if err != nil {
+		return nil, err
+	}
+	var jwk map[string]string
+	switch pub.Type() {
+	case pb.KeyType_Ed25519:
+		jwk = map[string]string{"kty": "OKP", "crv": "Ed25519", "x": b64.EncodeToString(raw)}
+	case pb.KeyType_RSA:
+		k, err := x509.ParsePKIXPublicKey(raw)
+		if err != nil {
+			return nil, err
+		}
+		rk, ok := k.(*rsa.PublicKey)
+		if !ok {
+			return nil, ErrJWSUnsupportedKey
+		}
+		jwk = map[string]string{
+			"kty": "RSA",
+			"n":   b64.EncodeToString(rk.N.Bytes()),
+			"e":   b64.EncodeToString(big.NewInt(int64(rk.E)).Bytes()),
+		}
+	case pb.KeyType_ECDSA:
+		ek, err := ecdsaKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		jwk = map[string]string{"kty": "EC", "crv": "P-256", "x": coord(ek.X), "y": coord(ek.Y)}
+	case pb.KeyType_Secp256k1:
+		k, err := btcec.ParsePubKey(raw, btcec.S256())
+		if err != nil {
+			return nil, err
+		}
+		jwk = map[string]string{"kty": "EC", "crv": "secp256k1", "x": coord(k.X), "y": coord(k.Y)}
+	default:
+		return nil, ErrJWSUnsupportedKey
+	}
+	return json.Marshal(jwk)
+}
+
+// coord encodes a 256-bit curve coordinate.
+func coord(n *big.Int) string {
+	return b64.EncodeToString(padInt(n, 32))
+}
+
+func padInt(n *big.Int, size int) []byte {
+	b := n.Bytes()
+	if len(b) >= size {
+		return b
+	}
+	return append(bytes.Repeat([]byte{0}, size-len(b)), b...)
+}
+
+type ecdsaSig struct {
+	R, S *big.Int
+}
+
+func derToRawSig(der []byte) ([]byte, error) {
+	var sig ecdsaSig
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, err
+	}
+	return append(padInt(sig.R, 32), padInt(sig.S, 32)...), nil
+}
+
+func rawToDERSig(raw []byte) ([]byte, error) {
+	if len(raw) != 64 {
+		return nil, ErrInvalidSignature
+	}
+	return asn1.Marshal(ecdsaSig{
+		R: new(big.Int).SetBytes(raw[:32]),
+		S: new(big.Int).SetBytes(raw[32:]),
+	})
+}
@@ -0,0 +1,197 @@
+package record
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestJWSRoundTrip(t *testing.T) {
+	for _, typ := range []int{crypto.Ed25519, crypto.ECDSA, crypto.Secp256k1, crypto.RSA} {
+		priv, pub, err := crypto.GenerateKeyPair(typ, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j, err := SealJWS(&simpleRecord{"hello"}, priv)
+		if err != nil {
+			t.Fatalf("key type %d: %v", typ, err)
+		}
+		parsed, err := ParseCompactJWS(j.Compact())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rec simpleRecord
+		signer, err := ConsumeJWS(parsed, &rec)
+		if err != nil {
+			t.Fatalf("key type %d: %v", typ, err)
+		}
+		if rec.message != "hello" || !signer.Equals(pub) {
+			t.Fatalf("key type %d: unexpected record %q", typ, rec.message)
+		}
+
+		tampered := *j
+		tampered.Payload = b64.EncodeToString([]byte("bye"))
+		if _, err := ConsumeJWS(&tampered, &rec); err == nil {
+			t.Fatalf("key type %d: expected a tampered JWS to be rejected", typ)
+		}
+	}
+}
+
+// TestJWSStandardVerification checks that JWS signatures verify with the
+// standard library, given the JWK in the header.
+func TestJWSStandardVerification(t *testing.T) {
+	for _, typ := range []int{crypto.Ed25519, crypto.ECDSA} {
+		priv, _, err := crypto.GenerateKeyPair(typ, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j, err := SealJWS(&simpleRecord{"hello"}, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		headerBytes, _ := b64.DecodeString(j.Protected)
+		var hdr struct {
+			JWK map[string]string `json:"jwk"`
+		}
+		if err := json.Unmarshal(headerBytes, &hdr); err != nil {
+			t.Fatal(err)
+		}
+		sig, _ := b64.DecodeString(j.Signature)
+		input := []byte(j.Protected + "." + j.Payload)
+
+		var ok bool
+		switch typ {
+		case crypto.Ed25519:
+			x, _ := b64.DecodeString(hdr.JWK["x"])
+			ok = ed25519.Verify(ed25519.PublicKey(x), input, sig)
+		case crypto.ECDSA:
+			x, _ := b64.DecodeString(hdr.JWK["x"])
+			y, _ := b64.DecodeString(hdr.JWK["y"])
+			pub := &ecdsa.PublicKey{Curve: crypto.ECDSACurve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+			h := sha256.Sum256(input)
+			ok = ecdsa.Verify(pub, h[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:]))
+		}
+		if !ok {
+			t.Fatalf("key type %d: signature doesn't verify with the standard library", typ)
+		}
+	}
+}
+
+func TestEnvelopeJWSConversion(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := Seal(&simpleRecord{"hello"}, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := EnvelopeToJWS(e, "libp2p-testing", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EnvelopeToJWS(e, "other-domain", priv); err != ErrInvalidSignature {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+	e2, err := JWSToEnvelope(j, priv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.Equal(e2) {
+		t.Fatal("expected the round trip to produce the same envelope")
+	}
+}
+
+func TestJWSRevocation(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := Seal(&simpleRecord{"revoked"}, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := EnvelopeToJWS(e, "libp2p-testing", priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := EnvelopeHash(e, "libp2p-testing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := NewRevocationList()
+	_, rev := sealBytes(t, &RevocationRecord{EnvelopeHash: h}, priv)
+	if err := list.Add(rev); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ConsumeCheckedJWS(j, &simpleRecord{}, list); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("expected ErrRevoked, got %v", err)
+	}
+	if _, err := JWSToEnvelope(j, priv, list); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("expected ErrRevoked, got %v", err)
+	}
+	if _, err := ConsumeJWS(j, &simpleRecord{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestJWSForgedKeyIDs checks that a JWS can't claim a signer, in the headers
+// JOSE libraries trust, other than the libp2p key it's verified with.
+func TestJWSForgedKeyIDs(t *testing.T) {
+	attacker, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	victim, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	victimID, err := peer.IDFromPrivateKey(victim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	victimJWK, err := marshalJWK(victim.GetPublic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forge := func(edit func(*jwsHeader)) *JWS {
+		j, err := SealJWS(&simpleRecord{"hello"}, attacker)
+		if err != nil {
+			t.Fatal(err)
+		}
+		headerBytes, _ := b64.DecodeString(j.Protected)
+		var hdr jwsHeader
+		if err := json.Unmarshal(headerBytes, &hdr); err != nil {
+			t.Fatal(err)
+		}
+		edit(&hdr)
+		headerBytes, _ = json.Marshal(&hdr)
+		j.Protected = b64.EncodeToString(headerBytes)
+		sig, err := attacker.Sign(j.signingInput())
+		if err != nil {
+			t.Fatal(err)
+		}
+		j.Signature = b64.EncodeToString(sig)
+		return j
+	}
+
+	if _, err := ConsumeJWS(forge(func(*jwsHeader) {}), &simpleRecord{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ConsumeJWS(forge(func(h *jwsHeader) { h.Kid = victimID.Pretty() }), &simpleRecord{}); err != ErrJWSKeyMismatch {
+		t.Fatalf("expected ErrJWSKeyMismatch, got %v", err)
+	}
+	if _, err := ConsumeJWS(forge(func(h *jwsHeader) { h.JWK = victimJWK }), &simpleRecord{}); err != ErrJWSKeyMismatch {
+		t.Fatalf("expected ErrJWSKeyMismatch, got %v", err)
+	}
+}
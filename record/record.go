@@ -0,0 +1,95 @@
+// Package record defines the types of signed, self-describing records that
+// libp2p peers exchange, and a registry mapping payload types to Go types.
+package record
+
+import (
+	"errors"
+	"reflect"
+)
+
+var (
+	// ErrPayloadTypeNotRegistered is returned from UnmarshalRecordPayload
+	// when the payload type isn't registered.
+	ErrPayloadTypeNotRegistered = errors.New("payload type is not registered")
+
+	payloadTypeRegistry = make(map[string]reflect.Type)
+)
+
+// Record represents a data type that can be used as the payload of a signed
+// envelope.
+//
+// Note that you don't need to register the Record type if you plan to
+// unmarshal payloads into a known Go type.
+type Record interface {
+	// Domain is the "signature domain" used when signing and verifying a
+	// particular Record type. The Domain string should be unique to your
+	// Record type, and all instances of the Record type must have the same
+	// Domain string.
+	Domain() string
+
+	// Codec is a binary identifier for this type of record, ideally a
+	// registered multicodec (see https://github.com/multiformats/multicodec).
+	// When a Record is put into an envelope, the Codec value will be used as
+	// the envelope's PayloadType.
+	Codec() []byte
+
+	// MarshalRecord converts a Record instance to a []byte, so that it can
+	// be used as an envelope payload.
+	MarshalRecord() ([]byte, error)
+
+	// UnmarshalRecord unmarshals a []byte payload into an instance of a
+	// particular Record type.
+	UnmarshalRecord([]byte) error
+}
+
+// RegisterType associates a binary payload type identifier with a concrete
+// Record type. This is used to automatically unmarshal Record payloads to
+// their correct type.
+//
+// Callers must provide an instance of the record type to be registered, which
+// must be a pointer type. Registration should be done in the init function of
+// the package where the Record type is defined:
+//
+//	func init() {
+//		record.RegisterType(&MyRecordType{})
+//	}
+func RegisterType(prototype Record) {
+	payloadTypeRegistry[string(prototype.Codec())] = getValueType(prototype)
+}
+
+// UnmarshalRecordPayload unmarshals the payload into a new instance of the
+// Record type registered for payloadType. Payload types registered with
+// RegisterVersion are unmarshaled with UnmarshalVersionedPayload.
+func UnmarshalRecordPayload(payloadType []byte, payloadBytes []byte) (Record, error) {
+	if _, ok := versionRegistry[string(payloadType)]; ok {
+		return UnmarshalVersionedPayload(payloadType, payloadBytes)
+	}
+	rec, err := blankRecordForPayloadType(payloadType)
+	if err != nil {
+		return nil, err
+	}
+	err = rec.UnmarshalRecord(payloadBytes)
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func blankRecordForPayloadType(payloadType []byte) (Record, error) {
+	valueType, ok := payloadTypeRegistry[string(payloadType)]
+	if !ok {
+		return nil, ErrPayloadTypeNotRegistered
+	}
+
+	val := reflect.New(valueType)
+	asRecord := val.Interface().(Record)
+	return asRecord, nil
+}
+
+func getValueType(i interface{}) reflect.Type {
+	valueType := reflect.TypeOf(i)
+	if valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+	return valueType
+}
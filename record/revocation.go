@@ -0,0 +1,298 @@
+package record
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// RevocationDomain is the signature domain of revocation records.
+const RevocationDomain = "libp2p-revocation-record"
+
+// RevocationCodec is the payload type of revocation records.
+var RevocationCodec = []byte("/libp2p/revocation-record")
+
+// ErrRevoked is returned when consuming an envelope that was revoked by its
+// signer.
+var ErrRevoked = errors.New("envelope has been revoked")
+
+func init() {
+	RegisterType(&RevocationRecord{})
+}
+
+// Sequenced is implemented by records carrying a sequence number, increasing
+// with each new version of the record issued by a signer. Records implementing
+// it can be revoked by sequence number (see RevocationRecord).
+type Sequenced interface {
+	Sequence() uint64
+}
+
+// RevocationRecord revokes envelopes previously signed with the same key, e.g.
+// signed peer records or vouchers issued with a compromised key. It revokes
+// either a single envelope, identified by its hash, or all the envelopes of a
+// payload type up to a sequence number.
+//
+// Revocation records must be sealed with the key that signed the revoked
+// envelopes; revocations signed with other keys have no effect.
+type RevocationRecord struct {
+	// EnvelopeHash is the EnvelopeHash of the revoked envelope.
+	EnvelopeHash []byte `json:",omitempty"`
+
+	// PayloadType and Seq revoke all the envelopes of the payload type whose
+	// record is Sequenced with a sequence number of at most Seq.
+	PayloadType []byte `json:",omitempty"`
+	Seq         uint64 `json:",omitempty"`
+
+	// Reason is a human readable explanation.
+	Reason string `json:",omitempty"`
+	Issued time.Time
+}
+
+var _ Record = (*RevocationRecord)(nil)
+
+// Domain implements Record.
+func (r *RevocationRecord) Domain() string { return RevocationDomain }
+
+// Codec implements Record.
+func (r *RevocationRecord) Codec() []byte { return RevocationCodec }
+
+// MarshalRecord implements Record.
+func (r *RevocationRecord) MarshalRecord() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// UnmarshalRecord implements Record.
+func (r *RevocationRecord) UnmarshalRecord(data []byte) error {
+	return json.Unmarshal(data, r)
+}
+
+// Revokes returns true if the record revokes the envelope holding rec, both
+// signed by the same key.
+func (r *RevocationRecord) Revokes(e *Envelope, rec Record) (bool, error) {
+	if len(r.EnvelopeHash) > 0 {
+		h, err := EnvelopeHash(e, rec.Domain())
+		if err != nil {
+			return false, err
+		}
+		if bytes.Equal(h, r.EnvelopeHash) {
+			return true, nil
+		}
+	}
+	if len(r.PayloadType) > 0 && bytes.Equal(r.PayloadType, e.PayloadType) {
+		if s, ok := rec.(Sequenced); ok && s.Sequence() <= r.Seq {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// EnvelopeHash returns the sha2-256 hash identifying the envelope, signed in
+// the given domain, in revocation records and timestamps. It covers the
+// signer's public key, the domain, the payload type and the payload, but not
+// the signature: some signature schemes are malleable, so re-encoding the
+// signature must not yield a different envelope.
+func EnvelopeHash(e *Envelope, domain string) ([]byte, error) {
+	key, err := crypto.MarshalPublicKey(e.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write(appendUvarint(nil, uint64(len(key))))
+	h.Write(key)
+	h.Write(makeUnsigned(domain, e.PayloadType, e.RawPayload))
+	return h.Sum(nil), nil
+}
+
+// RevocationChecker decides whether envelopes have been revoked.
+type RevocationChecker interface {
+	// CheckRevocation returns ErrRevoked (possibly wrapped) if the envelope,
+	// holding rec, has been revoked.
+	CheckRevocation(e *Envelope, rec Record) error
+}
+
+// ConsumeCheckedEnvelope is ConsumeEnvelope, also rejecting the envelopes
+// revoked according to the checker. A nil checker disables revocation
+// checks.
+func ConsumeCheckedEnvelope(data []byte, domain string, c RevocationChecker) (*Envelope, Record, error) {
+	e, rec, err := ConsumeEnvelope(data, domain)
+	if err != nil {
+		return e, nil, err
+	}
+	if c != nil {
+		if err := c.CheckRevocation(e, rec); err != nil {
+			return e, nil, err
+		}
+	}
+	return e, rec, nil
+}
+
+// ConsumeCheckedTypedEnvelope is ConsumeTypedEnvelope, also rejecting the
+// envelopes revoked according to the checker. A nil checker disables
+// revocation checks.
+func ConsumeCheckedTypedEnvelope(data []byte, destRecord Record, c RevocationChecker) (*Envelope, error) {
+	e, err := ConsumeTypedEnvelope(data, destRecord)
+	if err != nil {
+		return e, err
+	}
+	if c != nil {
+		if err := c.CheckRevocation(e, destRecord); err != nil {
+			return e, err
+		}
+	}
+	return e, nil
+}
+
+// Default limits of RevocationLists.
+var (
+	// DefaultMaxRevocationSigners is the default maximum number of signers
+	// a RevocationList holds revocations of.
+	DefaultMaxRevocationSigners = 4096
+	// DefaultMaxRevocationsPerSigner is the default maximum number of
+	// revocations by envelope hash, and of payload types revoked by
+	// sequence number, a RevocationList holds per signer.
+	DefaultMaxRevocationsPerSigner = 64
+)
+
+// ErrRevocationListFull is returned when adding a revocation by a new signer
+// to a RevocationList holding revocations of its maximum number of signers,
+// or a revocation by sequence number of a new payload type to a signer
+// revoking its maximum number of payload types.
+var ErrRevocationListFull = errors.New("revocation list full")
+
+// RevocationList is a RevocationChecker holding revocation records in memory.
+//
+// Anyone can revoke their own envelopes, so the list is bounded: it holds the
+// revocations of at most MaxSigners signers. Of each, it holds the latest
+// MaxPerSigner revocations by envelope hash, older ones being dropped, and
+// the revocations by sequence number of up to MaxPerSigner payload types,
+// which are never dropped: revocations of the same payload type are merged,
+// keeping the highest sequence number. This way, the holder of a compromised
+// key can't undo a revocation by sequence number by flooding the list with
+// revocations by hash.
+type RevocationList struct {
+	maxSigners, maxPerSigner int
+
+	lk          sync.RWMutex
+	revocations map[peer.ID]*signerRevocations
+}
+
+// signerRevocations are the revocations of a signer.
+type signerRevocations struct {
+	// hashes are the revocations by envelope hash, oldest first.
+	hashes []*RevocationRecord
+	// seqs are the revocations by sequence number, per payload type.
+	seqs map[string]*RevocationRecord
+}
+
+var _ RevocationChecker = (*RevocationList)(nil)
+
+// NewRevocationList creates an empty RevocationList with the default limits.
+func NewRevocationList() *RevocationList {
+	return NewRevocationListWithLimits(DefaultMaxRevocationSigners, DefaultMaxRevocationsPerSigner)
+}
+
+// NewRevocationListWithLimits creates an empty RevocationList with the given
+// limits. Non-positive limits are replaced by the defaults.
+func NewRevocationListWithLimits(maxSigners, maxPerSigner int) *RevocationList {
+	if maxSigners <= 0 {
+		maxSigners = DefaultMaxRevocationSigners
+	}
+	if maxPerSigner <= 0 {
+		maxPerSigner = DefaultMaxRevocationsPerSigner
+	}
+	return &RevocationList{
+		maxSigners:   maxSigners,
+		maxPerSigner: maxPerSigner,
+		revocations:  make(map[peer.ID]*signerRevocations),
+	}
+}
+
+// Add verifies the serialized envelope holding a revocation record, and adds
+// the revocation to the list. It returns ErrRevocationListFull if the list
+// holds revocations of its maximum number of signers, none of them being the
+// revocation's signer, or if the signer already revokes its maximum number of
+// payload types by sequence number.
+func (l *RevocationList) Add(data []byte) error {
+	rec := new(RevocationRecord)
+	e, err := ConsumeTypedEnvelope(data, rec)
+	if err != nil {
+		return err
+	}
+	signer, err := peer.IDFromPublicKey(e.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	l.lk.Lock()
+	defer l.lk.Unlock()
+	revs, ok := l.revocations[signer]
+	if !ok {
+		if len(l.revocations) >= l.maxSigners {
+			return ErrRevocationListFull
+		}
+		revs = &signerRevocations{seqs: make(map[string]*RevocationRecord)}
+	}
+	if len(rec.PayloadType) > 0 {
+		pt := string(rec.PayloadType)
+		if r, ok := revs.seqs[pt]; ok {
+			if rec.Seq > r.Seq {
+				revs.seqs[pt] = rec
+			}
+		} else if len(revs.seqs) >= l.maxPerSigner {
+			return ErrRevocationListFull
+		} else {
+			revs.seqs[pt] = rec
+		}
+	}
+	if len(rec.EnvelopeHash) > 0 {
+		if len(revs.hashes) >= l.maxPerSigner {
+			revs.hashes = append(revs.hashes[:0], revs.hashes[len(revs.hashes)-l.maxPerSigner+1:]...)
+		}
+		revs.hashes = append(revs.hashes, rec)
+	}
+	l.revocations[signer] = revs
+	return nil
+}
+
+// CheckRevocation returns ErrRevoked if one of the revocations signed with
+// the envelope's key revokes it. Revocation records can't be revoked.
+func (l *RevocationList) CheckRevocation(e *Envelope, rec Record) error {
+	if _, ok := rec.(*RevocationRecord); ok {
+		return nil
+	}
+	signer, err := peer.IDFromPublicKey(e.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	l.lk.RLock()
+	defer l.lk.RUnlock()
+	revs, ok := l.revocations[signer]
+	if !ok {
+		return nil
+	}
+	candidates := revs.hashes
+	if r, ok := revs.seqs[string(e.PayloadType)]; ok {
+		candidates = append([]*RevocationRecord{r}, candidates...)
+	}
+	for _, r := range candidates {
+		revoked, err := r.Revokes(e, rec)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			if r.Reason != "" {
+				return fmt.Errorf("%w: %s", ErrRevoked, r.Reason)
+			}
+			return ErrRevoked
+		}
+	}
+	return nil
+}
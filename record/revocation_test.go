@@ -0,0 +1,197 @@
+package record
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+)
+
+var testSeqCodec = []byte("/libp2p/testdata-seq")
+
+type seqRecord struct {
+	simpleRecord
+	seq uint64
+}
+
+func (r *seqRecord) Codec() []byte    { return testSeqCodec }
+func (r *seqRecord) Sequence() uint64 { return r.seq }
+func (r *seqRecord) MarshalRecord() ([]byte, error) {
+	return []byte{byte(r.seq)}, nil
+}
+func (r *seqRecord) UnmarshalRecord(b []byte) error {
+	r.seq = uint64(b[0])
+	return nil
+}
+
+func init() {
+	RegisterType(&seqRecord{})
+}
+
+func sealBytes(t *testing.T, rec Record, sk crypto.PrivKey) (*Envelope, []byte) {
+	e, err := Seal(rec, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := e.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, data
+}
+
+func TestRevocation(t *testing.T) {
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := NewRevocationList()
+
+	e, plain := sealBytes(t, &simpleRecord{"compromised"}, sk)
+	_, old := sealBytes(t, &seqRecord{seq: 3}, sk)
+	_, current := sealBytes(t, &seqRecord{seq: 4}, sk)
+
+	h, err := EnvelopeHash(e, "libp2p-testing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The hash doesn't depend on the encoding of the signature.
+	reencoded := &Envelope{
+		PublicKey:   e.PublicKey,
+		PayloadType: e.PayloadType,
+		RawPayload:  e.RawPayload,
+		signature:   append(append([]byte{}, e.signature...), 0),
+	}
+	if h2, _ := EnvelopeHash(reencoded, "libp2p-testing"); !bytes.Equal(h, h2) {
+		t.Fatal("expected the hash to ignore the signature")
+	}
+	if h2, _ := EnvelopeHash(e, "other-domain"); bytes.Equal(h, h2) {
+		t.Fatal("expected the hash to cover the domain")
+	}
+	_, rev := sealBytes(t, &RevocationRecord{EnvelopeHash: h, Reason: "key leaked", Issued: time.Now()}, sk)
+	if err := list.Add(rev); err != nil {
+		t.Fatal(err)
+	}
+	_, rev = sealBytes(t, &RevocationRecord{PayloadType: testSeqCodec, Seq: 3}, sk)
+	if err := list.Add(rev); err != nil {
+		t.Fatal(err)
+	}
+	// Revocations signed by other keys have no effect.
+	_, rev = sealBytes(t, &RevocationRecord{PayloadType: testSeqCodec, Seq: 10}, otherSk)
+	if err := list.Add(rev); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := ConsumeCheckedEnvelope(plain, "libp2p-testing", list); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("expected ErrRevoked, got %v", err)
+	}
+	if _, err := ConsumeCheckedTypedEnvelope(old, &seqRecord{}, list); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("expected ErrRevoked, got %v", err)
+	}
+	if _, _, err := ConsumeCheckedEnvelope(current, "libp2p-testing", list); err != nil {
+		t.Fatalf("expected newer record to be accepted, got %v", err)
+	}
+	// Revocation checks are explicit.
+	if _, _, err := ConsumeEnvelope(plain, "libp2p-testing"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRevocationListLimits(t *testing.T) {
+	genKey := func() crypto.PrivKey {
+		sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sk
+	}
+	sk := genKey()
+	list := NewRevocationListWithLimits(1, 2)
+
+	var envs [][]byte
+	for i := 0; i < 3; i++ {
+		e, data := sealBytes(t, &simpleRecord{fmt.Sprint(i)}, sk)
+		envs = append(envs, data)
+		h, err := EnvelopeHash(e, "libp2p-testing")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, rev := sealBytes(t, &RevocationRecord{EnvelopeHash: h}, sk)
+		if err := list.Add(rev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Only the latest two revocations are kept.
+	if _, _, err := ConsumeCheckedEnvelope(envs[0], "libp2p-testing", list); err != nil {
+		t.Fatalf("expected the oldest revocation to be dropped, got %v", err)
+	}
+	for _, data := range envs[1:] {
+		if _, _, err := ConsumeCheckedEnvelope(data, "libp2p-testing", list); !errors.Is(err, ErrRevoked) {
+			t.Fatalf("expected ErrRevoked, got %v", err)
+		}
+	}
+
+	// Sequence revocations of a payload type are merged.
+	for _, seq := range []uint64{5, 3, 7} {
+		_, rev := sealBytes(t, &RevocationRecord{PayloadType: testSeqCodec, Seq: seq}, sk)
+		if err := list.Add(rev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, seq7 := sealBytes(t, &seqRecord{seq: 7}, sk)
+	if _, err := ConsumeCheckedTypedEnvelope(seq7, &seqRecord{}, list); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("expected ErrRevoked, got %v", err)
+	}
+
+	_, rev := sealBytes(t, &RevocationRecord{PayloadType: testSeqCodec, Seq: 1}, genKey())
+	if err := list.Add(rev); err != ErrRevocationListFull {
+		t.Fatalf("expected ErrRevocationListFull, got %v", err)
+	}
+}
+
+// TestRevocationListFlood checks that revocations by hash can't push
+// revocations by sequence number out of the list.
+func TestRevocationListFlood(t *testing.T) {
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := NewRevocationListWithLimits(1, 2)
+	_, rev := sealBytes(t, &RevocationRecord{PayloadType: testSeqCodec, Seq: 10}, sk)
+	if err := list.Add(rev); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		_, rev := sealBytes(t, &RevocationRecord{EnvelopeHash: []byte{byte(i)}}, sk)
+		if err := list.Add(rev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, seq5 := sealBytes(t, &seqRecord{seq: 5}, sk)
+	if _, err := ConsumeCheckedTypedEnvelope(seq5, &seqRecord{}, list); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("expected ErrRevoked, got %v", err)
+	}
+
+	// Payload types revoked by sequence number are bounded by refusing
+	// new ones.
+	_, rev = sealBytes(t, &RevocationRecord{PayloadType: []byte("/other"), Seq: 1}, sk)
+	if err := list.Add(rev); err != nil {
+		t.Fatal(err)
+	}
+	_, rev = sealBytes(t, &RevocationRecord{PayloadType: []byte("/third"), Seq: 1}, sk)
+	if err := list.Add(rev); err != ErrRevocationListFull {
+		t.Fatalf("expected ErrRevocationListFull, got %v", err)
+	}
+	if _, err := ConsumeCheckedTypedEnvelope(seq5, &seqRecord{}, list); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("expected ErrRevoked, got %v", err)
+	}
+}
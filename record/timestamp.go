@@ -0,0 +1,185 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// TimestampDomain is the signature domain of timestamp records.
+const TimestampDomain = "libp2p-timestamp-record"
+
+// TimestampCodec is the payload type of timestamp records.
+var TimestampCodec = []byte("/libp2p/timestamp-record")
+
+// Kinds of timestamp attestations.
+const (
+	// TimestampKindPeer is the kind of timestamps whose token is an
+	// envelope holding a TimestampRecord, signed by a timestamping peer.
+	TimestampKindPeer = "libp2p-peer"
+	// TimestampKindRFC3161 is the kind of timestamps whose token is an
+	// RFC 3161 TimeStampToken. Verifiers for it are provided by the
+	// application.
+	TimestampKindRFC3161 = "rfc3161"
+)
+
+var (
+	// ErrTimestampMismatch is returned when a timestamp doesn't attest the
+	// envelope it's verified against.
+	ErrTimestampMismatch = errors.New("timestamp doesn't match the envelope")
+	// ErrUntrustedTimestamp is returned when a timestamp was issued by an
+	// authority that isn't trusted.
+	ErrUntrustedTimestamp = errors.New("timestamp issued by an untrusted authority")
+	// ErrUnknownTimestampKind is returned when verifying a timestamp of a
+	// kind without a verifier.
+	ErrUnknownTimestampKind = errors.New("unknown timestamp kind")
+)
+
+func init() {
+	RegisterType(&TimestampRecord{})
+}
+
+// Timestamp is a trusted-timestamp attestation that an envelope existed at a
+// given time, proving when a record was created, e.g. for audit trails and
+// expiring capabilities. It's detached from the envelope, and identifies it
+// by its EnvelopeHash.
+type Timestamp struct {
+	Kind  string
+	Token []byte
+}
+
+// TimestampAuthority issues timestamps, e.g. an RFC 3161 TSA client or a
+// PeerTimestampAuthority.
+type TimestampAuthority interface {
+	// Timestamp attests that the data with the given sha2-256 digest
+	// exists now.
+	Timestamp(ctx context.Context, digest []byte) (*Timestamp, error)
+}
+
+// TimestampVerifier verifies timestamps of one kind.
+type TimestampVerifier interface {
+	// VerifyTimestamp checks the token attests the digest, and was issued
+	// by a trusted authority, and returns the attested time.
+	VerifyTimestamp(token []byte, digest []byte) (time.Time, error)
+}
+
+// TimestampVerifiers maps timestamp kinds to their verifiers, e.g.
+// TimestampKindPeer to a PeerTimestampVerifier.
+type TimestampVerifiers map[string]TimestampVerifier
+
+// StampEnvelope obtains a timestamp of the envelope, signed in the given
+// domain, from the authority.
+func StampEnvelope(ctx context.Context, e *Envelope, domain string, tsa TimestampAuthority) (*Timestamp, error) {
+	digest, err := EnvelopeHash(e, domain)
+	if err != nil {
+		return nil, err
+	}
+	return tsa.Timestamp(ctx, digest)
+}
+
+// VerifyTimestamp checks the timestamp attests the envelope, signed in the
+// given domain, with the verifier of its kind, and returns the attested time.
+func (v TimestampVerifiers) VerifyTimestamp(e *Envelope, domain string, ts *Timestamp) (time.Time, error) {
+	tv, ok := v[ts.Kind]
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrUnknownTimestampKind, ts.Kind)
+	}
+	digest, err := EnvelopeHash(e, domain)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return tv.VerifyTimestamp(ts.Token, digest)
+}
+
+// TimestampRecord is the record signed by a timestamping peer, attesting that
+// the data with the given digest existed at the given time.
+type TimestampRecord struct {
+	// Digest is the sha2-256 digest of the timestamped data.
+	Digest []byte
+	Time   time.Time
+}
+
+var _ Record = (*TimestampRecord)(nil)
+
+// Domain implements Record.
+func (r *TimestampRecord) Domain() string { return TimestampDomain }
+
+// Codec implements Record.
+func (r *TimestampRecord) Codec() []byte { return TimestampCodec }
+
+// MarshalRecord implements Record.
+func (r *TimestampRecord) MarshalRecord() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// UnmarshalRecord implements Record.
+func (r *TimestampRecord) UnmarshalRecord(data []byte) error {
+	return json.Unmarshal(data, r)
+}
+
+// PeerTimestampAuthority is a TimestampAuthority signing TimestampRecords
+// with a peer's key.
+type PeerTimestampAuthority struct {
+	Key crypto.PrivKey
+	// Now returns the current time. Nil means time.Now.
+	Now func() time.Time
+}
+
+var _ TimestampAuthority = (*PeerTimestampAuthority)(nil)
+
+// Timestamp implements TimestampAuthority.
+func (a *PeerTimestampAuthority) Timestamp(_ context.Context, digest []byte) (*Timestamp, error) {
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+	e, err := Seal(&TimestampRecord{Digest: digest, Time: now()}, a.Key)
+	if err != nil {
+		return nil, err
+	}
+	token, err := e.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &Timestamp{Kind: TimestampKindPeer, Token: token}, nil
+}
+
+// PeerTimestampVerifier verifies the timestamps issued by the trusted
+// timestamping peers.
+type PeerTimestampVerifier struct {
+	Trusted []peer.ID
+	// Revocations, if not nil, rejects the timestamps revoked by their
+	// issuer.
+	Revocations RevocationChecker
+}
+
+var _ TimestampVerifier = (*PeerTimestampVerifier)(nil)
+
+// VerifyTimestamp implements TimestampVerifier.
+func (v *PeerTimestampVerifier) VerifyTimestamp(token []byte, digest []byte) (time.Time, error) {
+	rec := new(TimestampRecord)
+	e, err := ConsumeCheckedTypedEnvelope(token, rec, v.Revocations)
+	if err != nil {
+		return time.Time{}, err
+	}
+	trusted := false
+	for _, p := range v.Trusted {
+		if p.MatchesPublicKey(e.PublicKey) {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return time.Time{}, ErrUntrustedTimestamp
+	}
+	if !bytes.Equal(rec.Digest, digest) {
+		return time.Time{}, ErrTimestampMismatch
+	}
+	return rec.Time, nil
+}
@@ -0,0 +1,75 @@
+package record
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func TestTimestamp(t *testing.T) {
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tsaSk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tsaID, err := peer.IDFromPrivateKey(tsaSk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	tsa := &PeerTimestampAuthority{Key: tsaSk, Now: func() time.Time { return created }}
+	e, _ := sealBytes(t, &simpleRecord{message: "stamped"}, sk)
+	ts, err := StampEnvelope(context.Background(), e, "libp2p-testing", tsa)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := TimestampVerifiers{TimestampKindPeer: &PeerTimestampVerifier{Trusted: []peer.ID{tsaID}}}
+	at, err := v.VerifyTimestamp(e, "libp2p-testing", ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !at.Equal(created) {
+		t.Fatalf("unexpected timestamp %s", at)
+	}
+
+	other, _ := sealBytes(t, &simpleRecord{message: "other"}, sk)
+	if _, err := v.VerifyTimestamp(other, "libp2p-testing", ts); err != ErrTimestampMismatch {
+		t.Fatalf("expected ErrTimestampMismatch, got %v", err)
+	}
+	untrusted := TimestampVerifiers{TimestampKindPeer: &PeerTimestampVerifier{}}
+	if _, err := untrusted.VerifyTimestamp(e, "libp2p-testing", ts); err != ErrUntrustedTimestamp {
+		t.Fatalf("expected ErrUntrustedTimestamp, got %v", err)
+	}
+	if _, err := v.VerifyTimestamp(e, "libp2p-testing", &Timestamp{Kind: TimestampKindRFC3161}); !errors.Is(err, ErrUnknownTimestampKind) {
+		t.Fatalf("expected ErrUnknownTimestampKind, got %v", err)
+	}
+
+	// The timestamping peer revokes its timestamp.
+	tsEnv, err := UnmarshalEnvelope(ts.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := EnvelopeHash(tsEnv, TimestampDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := NewRevocationList()
+	_, rev := sealBytes(t, &RevocationRecord{EnvelopeHash: h}, tsaSk)
+	if err := list.Add(rev); err != nil {
+		t.Fatal(err)
+	}
+	checked := TimestampVerifiers{TimestampKindPeer: &PeerTimestampVerifier{Trusted: []peer.ID{tsaID}, Revocations: list}}
+	if _, err := checked.VerifyTimestamp(e, "libp2p-testing", ts); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("expected ErrRevoked, got %v", err)
+	}
+}
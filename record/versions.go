@@ -0,0 +1,152 @@
+package record
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrUnknownVersion is returned when unmarshaling a versioned payload whose
+// version isn't registered, typically because it was produced by a newer
+// implementation.
+var ErrUnknownVersion = errors.New("unknown payload version")
+
+// UpgradeFunc converts a record of the previous version of a payload type to
+// the version it's registered with.
+type UpgradeFunc func(prev Record) (Record, error)
+
+type versionChain struct {
+	types    map[uint64]reflect.Type
+	upgrades map[uint64]UpgradeFunc
+	latest   uint64
+}
+
+var (
+	versionRegistry = make(map[string]*versionChain)
+	typeVersions    = make(map[reflect.Type]uint64)
+)
+
+// RegisterVersion registers prototype as the given version of its payload
+// type, allowing long-lived records to evolve while keeping a single payload
+// type (and thus a single envelope type).
+//
+// Versions start at 1 and must be registered in order, each with a distinct Go
+// type. upgrade converts a record of the previous version to this one; it's
+// ignored for version 1 and required for later versions. Like RegisterType,
+// this should be called from an init function, and it panics on misuse.
+//
+// Payloads of versioned types are prefixed with their version (see
+// MarshalVersionedPayload). When unmarshaling, records of older versions are
+// upgraded to the latest registered one, while records of versions newer than
+// the latest fail with ErrUnknownVersion.
+func RegisterVersion(prototype Record, version uint64, upgrade UpgradeFunc) {
+	codec := string(prototype.Codec())
+	chain, ok := versionRegistry[codec]
+	if !ok {
+		chain = &versionChain{
+			types:    make(map[uint64]reflect.Type),
+			upgrades: make(map[uint64]UpgradeFunc),
+		}
+		versionRegistry[codec] = chain
+	}
+	if version != chain.latest+1 {
+		panic(fmt.Sprintf("record: version %d registered after version %d", version, chain.latest))
+	}
+	if version > 1 && upgrade == nil {
+		panic(fmt.Sprintf("record: version %d registered without an upgrade function", version))
+	}
+	valueType := getValueType(prototype)
+	if _, ok := typeVersions[valueType]; ok {
+		panic(fmt.Sprintf("record: type %s already registered as a version", valueType))
+	}
+
+	chain.types[version] = valueType
+	chain.upgrades[version] = upgrade
+	chain.latest = version
+	typeVersions[valueType] = version
+}
+
+// LatestVersion returns the latest registered version of the payload type.
+func LatestVersion(payloadType []byte) (uint64, bool) {
+	chain, ok := versionRegistry[string(payloadType)]
+	if !ok {
+		return 0, false
+	}
+	return chain.latest, true
+}
+
+// MarshalVersionedPayload marshals a record whose type was registered with
+// RegisterVersion, prefixing the payload with the record's version. Seal does
+// this for records of versioned payload types.
+func MarshalVersionedPayload(rec Record) ([]byte, error) {
+	version, ok := typeVersions[getValueType(rec)]
+	if !ok {
+		return nil, ErrPayloadTypeNotRegistered
+	}
+	payload, err := rec.MarshalRecord()
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(payload))
+	n := binary.PutUvarint(buf, version)
+	return append(buf[:n], payload...), nil
+}
+
+// UnmarshalVersionedPayload unmarshals a payload produced by
+// MarshalVersionedPayload, upgrading it to the latest registered version of
+// the payload type.
+func UnmarshalVersionedPayload(payloadType []byte, data []byte) (Record, error) {
+	chain, ok := versionRegistry[string(payloadType)]
+	if !ok {
+		return nil, ErrPayloadTypeNotRegistered
+	}
+	version, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, errors.New("invalid payload version prefix")
+	}
+	valueType, ok := chain.types[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	rec := reflect.New(valueType).Interface().(Record)
+	if err := rec.UnmarshalRecord(data[n:]); err != nil {
+		return nil, err
+	}
+	for v := version + 1; v <= chain.latest; v++ {
+		var err error
+		rec, err = chain.upgrades[v](rec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upgrade record to version %d: %w", v, err)
+		}
+	}
+	return rec, nil
+}
+
+// marshalPayload marshals the record as an envelope payload, prefixed with its
+// version if its payload type was registered with RegisterVersion.
+func marshalPayload(rec Record) ([]byte, error) {
+	if _, ok := versionRegistry[string(rec.Codec())]; ok {
+		return MarshalVersionedPayload(rec)
+	}
+	return rec.MarshalRecord()
+}
+
+// unmarshalPayload unmarshals the payload into dest. Versioned payloads are
+// upgraded to the latest registered version first, which dest must be.
+func unmarshalPayload(payloadType []byte, data []byte, dest Record) error {
+	if _, ok := versionRegistry[string(payloadType)]; !ok {
+		return dest.UnmarshalRecord(data)
+	}
+	rec, err := UnmarshalVersionedPayload(payloadType, data)
+	if err != nil {
+		return err
+	}
+	dv, rv := reflect.ValueOf(dest), reflect.ValueOf(rec)
+	if dv.Kind() != reflect.Ptr || dv.Type() != rv.Type() {
+		return fmt.Errorf("payload upgraded to %T, can't unmarshal into %T", rec, dest)
+	}
+	dv.Elem().Set(rv.Elem())
+	return nil
+}
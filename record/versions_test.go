@@ -0,0 +1,135 @@
+package record
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+)
+
+var testVersionedCodec = []byte("/libp2p/testdata-versioned")
+
+type testRecordV1 struct {
+	Name string
+}
+
+func (r *testRecordV1) Domain() string                 { return "testing" }
+func (r *testRecordV1) Codec() []byte                  { return testVersionedCodec }
+func (r *testRecordV1) MarshalRecord() ([]byte, error) { return []byte(r.Name), nil }
+func (r *testRecordV1) UnmarshalRecord(b []byte) error {
+	r.Name = string(b)
+	return nil
+}
+
+type testRecordV2 struct {
+	First, Last string
+}
+
+func (r *testRecordV2) Domain() string { return "testing" }
+func (r *testRecordV2) Codec() []byte  { return testVersionedCodec }
+func (r *testRecordV2) MarshalRecord() ([]byte, error) {
+	return []byte(r.First + "/" + r.Last), nil
+}
+func (r *testRecordV2) UnmarshalRecord(b []byte) error {
+	parts := strings.SplitN(string(b), "/", 2)
+	if len(parts) != 2 {
+		return errors.New("invalid record")
+	}
+	r.First, r.Last = parts[0], parts[1]
+	return nil
+}
+
+func init() {
+	RegisterVersion(&testRecordV1{}, 1, nil)
+	RegisterVersion(&testRecordV2{}, 2, func(prev Record) (Record, error) {
+		v1 := prev.(*testRecordV1)
+		return &testRecordV2{First: v1.Name}, nil
+	})
+}
+
+func TestVersionedPayloadUpgrade(t *testing.T) {
+	old, err := MarshalVersionedPayload(&testRecordV1{Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := UnmarshalRecordPayload(testVersionedCodec, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, ok := rec.(*testRecordV2)
+	if !ok || v2.First != "alice" || v2.Last != "" {
+		t.Fatalf("expected upgraded record, got %#v", rec)
+	}
+
+	cur, err := MarshalVersionedPayload(&testRecordV2{First: "bob", Last: "smith"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err = UnmarshalVersionedPayload(testVersionedCodec, cur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2 := rec.(*testRecordV2); v2.First != "bob" || v2.Last != "smith" {
+		t.Fatalf("unexpected record %#v", v2)
+	}
+
+	if latest, ok := LatestVersion(testVersionedCodec); !ok || latest != 2 {
+		t.Fatalf("expected latest version 2, got %d", latest)
+	}
+}
+
+func TestVersionedPayloadUnknownVersion(t *testing.T) {
+	_, err := UnmarshalVersionedPayload(testVersionedCodec, []byte{3, 'x'})
+	if !errors.Is(err, ErrUnknownVersion) {
+		t.Fatalf("expected ErrUnknownVersion, got %v", err)
+	}
+}
+
+func TestVersionedEnvelopeRoundTrip(t *testing.T) {
+	priv, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seal := func(rec Record) []byte {
+		e, err := Seal(rec, priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := e.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	cur := seal(&testRecordV2{First: "bob", Last: "smith"})
+	_, rec, err := ConsumeEnvelope(cur, "testing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2, ok := rec.(*testRecordV2); !ok || v2.First != "bob" || v2.Last != "smith" {
+		t.Fatalf("unexpected record %#v", rec)
+	}
+	var v2 testRecordV2
+	if _, err := ConsumeTypedEnvelope(cur, &v2); err != nil {
+		t.Fatal(err)
+	}
+	if v2.First != "bob" || v2.Last != "smith" {
+		t.Fatalf("unexpected record %#v", v2)
+	}
+
+	// Records of older versions are upgraded.
+	old := seal(&testRecordV1{Name: "alice"})
+	e, err := ConsumeTypedEnvelope(old, &v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2.First != "alice" || v2.Last != "" {
+		t.Fatalf("expected upgraded record, got %#v", v2)
+	}
+	if err := e.TypedRecord(&testRecordV1{}); err == nil {
+		t.Fatal("expected an error unmarshaling into an older version")
+	}
+}
@@ -0,0 +1,139 @@
+package routing
+
+import "strings"
+
+// LatencyClass is a coarse estimate of how long a router takes to answer.
+type LatencyClass int
+
+const (
+	// LatencyUnknown means the router didn't say.
+	LatencyUnknown LatencyClass = iota
+	// LatencyLocal routers answer from local data (caches, datastores).
+	LatencyLocal
+	// LatencyLow routers answer in a round trip or two (e.g. a delegated
+	// HTTP router).
+	LatencyLow
+	// LatencyHigh routers may take many round trips (e.g. a DHT walk).
+	LatencyHigh
+)
+
+func (l LatencyClass) String() string {
+	switch l {
+	case LatencyLocal:
+		return "local"
+	case LatencyLow:
+		return "low"
+	case LatencyHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// Operation is a routing operation, as advertised in Capabilities.
+type Operation int
+
+const (
+	OpProvide Operation = iota
+	OpFindProviders
+	OpFindPeer
+	OpPutValue
+	OpGetValue
+	OpSearchValue
+	OpWatch
+)
+
+// Capabilities describes what a router can serve, so that routers combining
+// several backends only send calls to the backends able to serve them.
+type Capabilities struct {
+	// Operations lists the supported operations.
+	Operations []Operation
+
+	// Namespaces lists the value namespaces (e.g. "pk", "ipns") the router
+	// has validators for. Empty means the router accepts all namespaces.
+	Namespaces []string
+
+	// Latency is the router's expected latency class.
+	Latency LatencyClass
+}
+
+// Supports returns true if the router supports the operation.
+func (c Capabilities) Supports(op Operation) bool {
+	for _, o := range c.Operations {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsKey returns true if the router accepts values under the key, of
+// the form /namespace/...
+func (c Capabilities) SupportsKey(key string) bool {
+	if len(c.Namespaces) == 0 {
+		return true
+	}
+	ns := strings.SplitN(strings.TrimPrefix(key, "/"), "/", 2)[0]
+	for _, n := range c.Namespaces {
+		if n == ns {
+			return true
+		}
+	}
+	return false
+}
+
+// CapableRouter is implemented by routers describing their capabilities.
+type CapableRouter interface {
+	Capabilities() Capabilities
+}
+
+// GetCapabilities returns the capabilities of the router. If it doesn't
+// implement CapableRouter, they're inferred from the routing interfaces it
+// implements, with all namespaces and an unknown latency.
+func GetCapabilities(r interface{}) Capabilities {
+	if cr, ok := r.(CapableRouter); ok {
+		return cr.Capabilities()
+	}
+
+	var caps Capabilities
+	if _, ok := r.(ContentRouting); ok {
+		caps.Operations = append(caps.Operations, OpProvide, OpFindProviders)
+	}
+	if _, ok := r.(PeerRouting); ok {
+		caps.Operations = append(caps.Operations, OpFindPeer)
+	}
+	if _, ok := r.(ValueStore); ok {
+		caps.Operations = append(caps.Operations, OpPutValue, OpGetValue, OpSearchValue)
+	}
+	return caps
+}
+
+// RoutersFor returns the routers able to serve the operation on the key,
+// ordered from the lowest expected latency to the highest, routers with an
+// unknown latency last. The key is ignored if empty.
+func RoutersFor(routers []interface{}, op Operation, key string) []interface{} {
+	var out []interface{}
+	var latencies []LatencyClass
+	for _, r := range routers {
+		caps := GetCapabilities(r)
+		if !caps.Supports(op) || (key != "" && !caps.SupportsKey(key)) {
+			continue
+		}
+		l := caps.Latency
+		if l == LatencyUnknown {
+			l = LatencyHigh + 1
+		}
+		// Insertion sort, keeping the order of routers of the same
+		// class.
+		i := len(out)
+		for i > 0 && latencies[i-1] > l {
+			i--
+		}
+		out = append(out, nil)
+		latencies = append(latencies, 0)
+		copy(out[i+1:], out[i:])
+		copy(latencies[i+1:], latencies[i:])
+		out[i], latencies[i] = r, l
+	}
+	return out
+}
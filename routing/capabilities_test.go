@@ -0,0 +1,41 @@
+package routing
+
+import "testing"
+
+type capableRouter struct {
+	ValueStore
+	caps Capabilities
+}
+
+func (r capableRouter) Capabilities() Capabilities { return r.caps }
+
+func TestRoutersFor(t *testing.T) {
+	dht := &capableRouter{caps: Capabilities{
+		Operations: []Operation{OpProvide, OpFindProviders, OpPutValue, OpGetValue},
+		Namespaces: []string{"pk", "ipns"},
+		Latency:    LatencyHigh,
+	}}
+	cache := &capableRouter{caps: Capabilities{
+		Operations: []Operation{OpGetValue},
+		Latency:    LatencyLocal,
+	}}
+	plain := &slowRouter{}
+
+	if caps := GetCapabilities(plain); !caps.Supports(OpFindProviders) || !caps.Supports(OpGetValue) || caps.Supports(OpWatch) {
+		t.Fatalf("unexpected inferred capabilities: %v", caps)
+	}
+
+	routers := []interface{}{plain, dht, cache}
+	got := RoutersFor(routers, OpGetValue, "/ipns/foo")
+	if len(got) != 3 || got[0] != cache || got[1] != dht || got[2] != plain {
+		t.Fatalf("unexpected routers: %v", got)
+	}
+	got = RoutersFor(routers, OpGetValue, "/other/foo")
+	if len(got) != 2 || got[0] != cache || got[1] != plain {
+		t.Fatalf("unexpected routers: %v", got)
+	}
+	got = RoutersFor(routers, OpProvide, "")
+	if len(got) != 2 || got[0] != dht || got[1] != plain {
+		t.Fatalf("unexpected routers: %v", got)
+	}
+}
@@ -0,0 +1,159 @@
+package routing
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+
+	sha256 "github.com/minio/sha256-simd"
+)
+
+// Distance orders peers by how close they are to a target key.
+//
+// It lets code that needs "the closest peers" work with routing algorithms
+// other than Kademlia's XOR metric.
+type Distance interface {
+	// Compare returns a negative number if a is closer to target than b, a
+	// positive number if b is closer, and 0 if they are equally close.
+	Compare(target []byte, a, b peer.ID) int
+}
+
+// DistanceFunc is a function implementing Distance.
+type DistanceFunc func(target []byte, a, b peer.ID) int
+
+// Compare calls f(target, a, b).
+func (f DistanceFunc) Compare(target []byte, a, b peer.ID) int {
+	return f(target, a, b)
+}
+
+// KeyedDistance is implemented by Distances that can compute the distance of
+// each peer to a target upfront, so that sorting doesn't recompute it (e.g.
+// hash the peer IDs) on every comparison.
+type KeyedDistance interface {
+	Distance
+
+	// DistanceKeys returns the distances of the peers to target, in the
+	// order of peers. A peer is closer than another if its key is smaller,
+	// as per bytes.Compare.
+	DistanceKeys(target []byte, peers []peer.ID) [][]byte
+}
+
+// XORDistance is the Kademlia metric: keys and peer IDs are hashed with
+// SHA-256 into a common key space, and the distance is their XOR.
+var XORDistance Distance = xorDistance{}
+
+type xorDistance struct{}
+
+var _ KeyedDistance = xorDistance{}
+
+func (xorDistance) Compare(target []byte, a, b peer.ID) int {
+	return compareXOR(target, a, b)
+}
+
+func (xorDistance) DistanceKeys(target []byte, peers []peer.ID) [][]byte {
+	t := KeySpaceID(target)
+	keys := make([][]byte, len(peers))
+	for i, p := range peers {
+		k := KeySpaceID([]byte(p))
+		for j := range k {
+			k[j] ^= t[j]
+		}
+		keys[i] = k
+	}
+	return keys
+}
+
+// KeySpaceID maps a key or peer ID into the key space used by XORDistance.
+func KeySpaceID(key []byte) []byte {
+	h := sha256.Sum256(key)
+	return h[:]
+}
+
+func compareXOR(target []byte, a, b peer.ID) int {
+	t := KeySpaceID(target)
+	ka := KeySpaceID([]byte(a))
+	kb := KeySpaceID([]byte(b))
+	for i := range t {
+		da := t[i] ^ ka[i]
+		db := t[i] ^ kb[i]
+		if da != db {
+			if da < db {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// LatencyDistance returns a Distance ignoring the target and ordering peers by
+// the latency recorded in m. Peers with unknown latency are the farthest.
+func LatencyDistance(m peerstore.Metrics) Distance {
+	return DistanceFunc(func(_ []byte, a, b peer.ID) int {
+		la, lb := m.LatencyEWMA(a), m.LatencyEWMA(b)
+		switch {
+		case la == lb:
+			return 0
+		case la == 0:
+			return 1
+		case lb == 0:
+			return -1
+		case la < lb:
+			return -1
+		default:
+			return 1
+		}
+	})
+}
+
+// SortClosest sorts peers in place, closest to target first. Ties are broken
+// by peer ID so the result is deterministic.
+//
+// If d implements KeyedDistance, the distances are computed once per peer
+// rather than on every comparison.
+func SortClosest(d Distance, target []byte, peers []peer.ID) {
+	if kd, ok := d.(KeyedDistance); ok {
+		sort.Stable(&keyedPeers{peers: peers, keys: kd.DistanceKeys(target, peers)})
+		return
+	}
+	sort.SliceStable(peers, func(i, j int) bool {
+		if c := d.Compare(target, peers[i], peers[j]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare([]byte(peers[i]), []byte(peers[j])) < 0
+	})
+}
+
+// Closest returns the count peers closest to target, closest first. The input
+// slice is not modified.
+func Closest(d Distance, target []byte, peers []peer.ID, count int) []peer.ID {
+	out := make([]peer.ID, len(peers))
+	copy(out, peers)
+	SortClosest(d, target, out)
+	if count >= 0 && count < len(out) {
+		out = out[:count]
+	}
+	return out
+}
+
+// keyedPeers sorts peers by their distance keys, then by peer ID.
+type keyedPeers struct {
+	peers []peer.ID
+	keys  [][]byte
+}
+
+func (k *keyedPeers) Len() int { return len(k.peers) }
+
+func (k *keyedPeers) Less(i, j int) bool {
+	if c := bytes.Compare(k.keys[i], k.keys[j]); c != 0 {
+		return c < 0
+	}
+	return bytes.Compare([]byte(k.peers[i]), []byte(k.peers[j])) < 0
+}
+
+func (k *keyedPeers) Swap(i, j int) {
+	k.peers[i], k.peers[j] = k.peers[j], k.peers[i]
+	k.keys[i], k.keys[j] = k.keys[j], k.keys[i]
+}
@@ -0,0 +1,70 @@
+package routing
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+func xor(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+func TestSortClosestXOR(t *testing.T) {
+	target := []byte("some key")
+	var peers []peer.ID
+	for _, s := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		peers = append(peers, peer.ID(s))
+	}
+
+	closest := Closest(XORDistance, target, peers, 4)
+	if len(closest) != 4 {
+		t.Fatalf("expected 4 peers, got %d", len(closest))
+	}
+	tk := KeySpaceID(target)
+	for i := 1; i < len(closest); i++ {
+		prev := xor(tk, KeySpaceID([]byte(closest[i-1])))
+		cur := xor(tk, KeySpaceID([]byte(closest[i])))
+		if bytes.Compare(prev, cur) > 0 {
+			t.Fatal("peers not sorted by XOR distance")
+		}
+	}
+	if peers[0] != "a" {
+		t.Fatal("Closest must not modify its input")
+	}
+	// Sorting by precomputed keys matches sorting by comparisons.
+	for i := 0; i < 100; i++ {
+		peers = append(peers, peer.ID([]byte{byte(i), byte(i * 7)}))
+	}
+	keyed := Closest(XORDistance, target, peers, -1)
+	compared := Closest(DistanceFunc(XORDistance.Compare), target, peers, -1)
+	for i := range keyed {
+		if keyed[i] != compared[i] {
+			t.Fatalf("keyed sort differs from the comparison sort at %d", i)
+		}
+	}
+}
+
+type latencies map[peer.ID]time.Duration
+
+func (l latencies) RecordLatency(peer.ID, time.Duration) {}
+func (l latencies) LatencyEWMA(p peer.ID) time.Duration  { return l[p] }
+
+func TestSortClosestLatency(t *testing.T) {
+	m := latencies{"a": 30 * time.Millisecond, "b": 10 * time.Millisecond, "d": 20 * time.Millisecond}
+	peers := []peer.ID{"a", "b", "c", "d"}
+	SortClosest(LatencyDistance(m), nil, peers)
+
+	expected := []peer.ID{"b", "d", "a", "c"}
+	for i := range expected {
+		if peers[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, peers)
+		}
+	}
+}
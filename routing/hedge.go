@@ -0,0 +1,116 @@
+package routing
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// DefaultHedgeDelay is the hedge delay used when a non-positive one is
+// given.
+var DefaultHedgeDelay = 100 * time.Millisecond
+
+// HedgedQueryFunc runs attempt i of a hedged query.
+type HedgedQueryFunc func(ctx context.Context, i int) (interface{}, error)
+
+// Hedge runs attempt 0 of the query, then starts the next attempt every time
+// delay elapses without a result, up to n attempts. An attempt failing
+// immediately starts the next one. The first successful attempt wins: the
+// others are canceled, and its result and index are returned.
+//
+// Attempts typically send the same request to different backends or peers,
+// so one slow backend doesn't hold the lookup up, cutting tail latency. If
+// all the attempts fail, the last error is returned.
+func Hedge(ctx context.Context, delay time.Duration, n int, query HedgedQueryFunc) (interface{}, int, error) {
+	return HedgeWithClock(ctx, nil, delay, n, query)
+}
+
+// HedgeWithClock is like Hedge, but measures the hedge delay with the given
+// clock. Nil means network.RealClock.
+func HedgeWithClock(ctx context.Context, clock network.Clock, delay time.Duration, n int, query HedgedQueryFunc) (interface{}, int, error) {
+	if n < 1 {
+		return nil, -1, ErrNotFound
+	}
+	if delay <= 0 {
+		delay = DefaultHedgeDelay
+	}
+	if clock == nil {
+		clock = network.RealClock
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		i   int
+		v   interface{}
+		err error
+	}
+	results := make(chan result, n)
+	start := func(i int) {
+		go func() {
+			v, err := query(ctx, i)
+			results <- result{i, v, err}
+		}()
+	}
+
+	timer := clock.NewTimer(delay)
+	defer timer.Stop()
+
+	started, done := 1, 0
+	start(0)
+	var lastErr error
+	for done < started {
+		select {
+		case r := <-results:
+			done++
+			if r.err == nil {
+				return r.v, r.i, nil
+			}
+			lastErr = r.err
+			if started < n {
+				start(started)
+				started++
+				if !timer.Stop() {
+					<-timer.C()
+				}
+				timer.Reset(delay)
+			}
+		case <-timer.C():
+			if started < n {
+				start(started)
+				started++
+				timer.Reset(delay)
+			}
+		case <-ctx.Done():
+			return nil, -1, ctx.Err()
+		}
+	}
+	return nil, -1, lastErr
+}
+
+// HedgedFindPeer looks the peer up with the routers in order, hedging to the
+// next one after delay.
+func HedgedFindPeer(ctx context.Context, delay time.Duration, routers []PeerRouting, p peer.ID) (peer.AddrInfo, error) {
+	v, _, err := Hedge(ctx, delay, len(routers), func(ctx context.Context, i int) (interface{}, error) {
+		return routers[i].FindPeer(ctx, p)
+	})
+	if err != nil {
+		return peer.AddrInfo{}, err
+	}
+	return v.(peer.AddrInfo), nil
+}
+
+// HedgedGetValue gets the value with the value stores in order, hedging to
+// the next one after delay.
+func HedgedGetValue(ctx context.Context, delay time.Duration, stores []ValueStore, key string, opts ...Option) ([]byte, error) {
+	v, _, err := Hedge(ctx, delay, len(stores), func(ctx context.Context, i int) (interface{}, error) {
+		return stores[i].GetValue(ctx, key, opts...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]byte), nil
+}
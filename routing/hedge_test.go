@@ -0,0 +1,100 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+type delayedPeerRouter struct {
+	delay time.Duration
+	err   error
+	id    peer.ID
+	// canceled is closed when a query is canceled.
+	canceled chan struct{}
+}
+
+func (r *delayedPeerRouter) FindPeer(ctx context.Context, _ peer.ID) (peer.AddrInfo, error) {
+	select {
+	case <-time.After(r.delay):
+	case <-ctx.Done():
+		if r.canceled != nil {
+			close(r.canceled)
+		}
+		return peer.AddrInfo{}, ctx.Err()
+	}
+	if r.err != nil {
+		return peer.AddrInfo{}, r.err
+	}
+	return peer.AddrInfo{ID: r.id}, nil
+}
+
+func TestHedgedFindPeer(t *testing.T) {
+	slow := &delayedPeerRouter{delay: time.Hour, id: "slow", canceled: make(chan struct{})}
+	fast := &delayedPeerRouter{delay: time.Millisecond, id: "fast"}
+	ai, err := HedgedFindPeer(context.Background(), 10*time.Millisecond, []PeerRouting{slow, fast}, "p")
+	if err != nil || ai.ID != "fast" {
+		t.Fatalf("expected the hedged router to win, got %v, %v", ai, err)
+	}
+	select {
+	case <-slow.canceled:
+	case <-time.After(time.Second):
+		t.Fatal("expected the slow query to be canceled")
+	}
+
+	// A failure hedges right away.
+	failing := &delayedPeerRouter{err: errors.New("boom")}
+	start := time.Now()
+	ai, err = HedgedFindPeer(context.Background(), time.Hour, []PeerRouting{failing, fast}, "p")
+	if err != nil || ai.ID != "fast" || time.Since(start) > time.Second {
+		t.Fatalf("expected the failure to hedge immediately, got %v, %v", ai, err)
+	}
+
+	if _, err := HedgedFindPeer(context.Background(), time.Hour, []PeerRouting{failing}, "p"); err != failing.err {
+		t.Fatalf("expected the last error, got %v", err)
+	}
+}
+
+func TestHedgeWithClock(t *testing.T) {
+	clock := network.NewMockClock(time.Now())
+	startedc := make(chan int, 2)
+	query := func(ctx context.Context, i int) (interface{}, error) {
+		startedc <- i
+		if i == 0 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return i, nil
+	}
+
+	type result struct {
+		v   interface{}
+		i   int
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, i, err := HedgeWithClock(context.Background(), clock, time.Minute, 2, query)
+		done <- result{v, i, err}
+	}()
+	if i := <-startedc; i != 0 {
+		t.Fatalf("expected attempt 0 to start first, got %d", i)
+	}
+
+	clock.Advance(time.Minute - time.Second)
+	select {
+	case i := <-startedc:
+		t.Fatalf("didn't expect attempt %d to start before the hedge delay", i)
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(time.Second)
+
+	r := <-done
+	if r.err != nil || r.i != 1 || r.v != 1 {
+		t.Fatalf("expected the hedged attempt to win, got %v, %d, %v", r.v, r.i, r.err)
+	}
+}
@@ -44,6 +44,9 @@ var Expired Option = func(opts *Options) error {
 }
 
 // Offline is an option that tells the routing system to operate offline (i.e., rely on cached/local data only).
+//
+// Routers that can't answer a request from local data alone must return
+// ErrOffline rather than querying the network.
 var Offline Option = func(opts *Options) error {
 	opts.Offline = true
 	return nil
@@ -0,0 +1,89 @@
+package routing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+// ErrPartialResult matches (with errors.Is) the errors returned along with
+// the results gathered by a query interrupted by its context.
+var ErrPartialResult = errors.New("routing: partial result")
+
+// PartialResultError is returned along with the results gathered by a query
+// whose context expired or was canceled before it completed. The results are
+// usable, but the query may have found more, or better, ones.
+type PartialResultError struct {
+	// Err is the context's error.
+	Err error
+}
+
+func (e *PartialResultError) Error() string {
+	return "routing: partial result: " + e.Err.Error()
+}
+
+// Is returns true for ErrPartialResult.
+func (e *PartialResultError) Is(target error) bool {
+	return target == ErrPartialResult
+}
+
+// Unwrap returns the context's error.
+func (e *PartialResultError) Unwrap() error {
+	return e.Err
+}
+
+// FindProviders searches for up to count providers of the content (all
+// providers if count is 0) and returns them once the search completes.
+//
+// If the context expires first, the providers found so far are returned
+// along with a *PartialResultError; if none were found, the context's error is
+// returned.
+func FindProviders(ctx context.Context, r ContentRouting, c cid.Cid, count int) ([]peer.AddrInfo, error) {
+	var found []peer.AddrInfo
+	for ai := range r.FindProvidersAsync(ctx, c, count) {
+		found = append(found, ai)
+	}
+	if count > 0 && len(found) >= count {
+		return found, nil
+	}
+	if err := ctx.Err(); err != nil {
+		if len(found) == 0 {
+			return nil, err
+		}
+		return found, &PartialResultError{Err: err}
+	}
+	return found, nil
+}
+
+// GetValue searches for the value corresponding to the key, returning the
+// best value found once the search completes.
+//
+// Unlike ValueStore.GetValue, if the context expires first, the best value
+// found so far is returned along with a *PartialResultError. If no value was
+// found, the context's error, or ErrNotFound, is returned.
+func GetValue(ctx context.Context, vs ValueStore, key string, opts ...Option) ([]byte, error) {
+	ch, err := vs.SearchValue(ctx, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// SearchValue sends better and better values, so the last one is the
+	// best.
+	var best []byte
+	for v := range ch {
+		best = v
+	}
+	if err := ctx.Err(); err != nil {
+		if best == nil {
+			return nil, err
+		}
+		return best, &PartialResultError{Err: err}
+	}
+	if best == nil {
+		return nil, ErrNotFound
+	}
+	return best, nil
+}
@@ -0,0 +1,103 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+// slowRouter sends its results, then hangs until the context is done if
+// stall is set.
+type slowRouter struct {
+	ContentRouting
+	ValueStore
+
+	providers []peer.AddrInfo
+	values    [][]byte
+	stall     bool
+}
+
+func (r *slowRouter) FindProvidersAsync(ctx context.Context, _ cid.Cid, _ int) <-chan peer.AddrInfo {
+	out := make(chan peer.AddrInfo)
+	go func() {
+		defer close(out)
+		for _, ai := range r.providers {
+			select {
+			case out <- ai:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if r.stall {
+			<-ctx.Done()
+		}
+	}()
+	return out
+}
+
+func (r *slowRouter) SearchValue(ctx context.Context, _ string, _ ...Option) (<-chan []byte, error) {
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		for _, v := range r.values {
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if r.stall {
+			<-ctx.Done()
+		}
+	}()
+	return out, nil
+}
+
+func TestFindProvidersPartial(t *testing.T) {
+	r := &slowRouter{providers: []peer.AddrInfo{{ID: "a"}, {ID: "b"}}}
+	found, err := FindProviders(context.Background(), r, cid.Cid{}, 0)
+	if err != nil || len(found) != 2 {
+		t.Fatalf("unexpected result: %v, %v", found, err)
+	}
+
+	r.stall = true
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	found, err = FindProviders(ctx, r, cid.Cid{}, 3)
+	if len(found) != 2 || !errors.Is(err, ErrPartialResult) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected partial result, got %v, %v", found, err)
+	}
+
+	r.providers = nil
+	found, err = FindProviders(ctx, r, cid.Cid{}, 3)
+	if found != nil || err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline error, got %v, %v", found, err)
+	}
+}
+
+func TestGetValuePartial(t *testing.T) {
+	r := &slowRouter{values: [][]byte{[]byte("old"), []byte("new")}}
+	v, err := GetValue(context.Background(), r, "/k")
+	if err != nil || string(v) != "new" {
+		t.Fatalf("unexpected result: %q, %v", v, err)
+	}
+
+	r.stall = true
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	v, err = GetValue(ctx, r, "/k")
+	if string(v) != "new" || !errors.Is(err, ErrPartialResult) {
+		t.Fatalf("expected partial result, got %q, %v", v, err)
+	}
+
+	r.stall = false
+	r.values = nil
+	if _, err := GetValue(context.Background(), r, "/k"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
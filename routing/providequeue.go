@@ -0,0 +1,367 @@
+package routing
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+var (
+	// DefaultProvideInterval is the minimum time between two provides of a
+	// ProvideQueue created with a zero Interval.
+	DefaultProvideInterval = 100 * time.Millisecond
+
+	// DefaultProvideRetryBase and DefaultProvideRetryMax bound the delay
+	// before retrying a failed provide, for ProvideQueues created with zero
+	// RetryBase and RetryMax. The delay doubles with every failure.
+	DefaultProvideRetryBase = 30 * time.Second
+	DefaultProvideRetryMax  = time.Hour
+)
+
+// ProvideEntry is a CID in a ProvideQueue.
+type ProvideEntry struct {
+	Cid cid.Cid
+	// Attempts counts the consecutive failed provides.
+	Attempts int
+	// NextAttempt is the earliest time the CID is provided.
+	NextAttempt time.Time
+}
+
+// ProvideStore persists the entries of a ProvideQueue across restarts.
+// Implementations must be safe for concurrent use.
+type ProvideStore interface {
+	// Put inserts or updates the entry of the CID.
+	Put(ProvideEntry) error
+	// Delete removes the entry of the CID, if any.
+	Delete(cid.Cid) error
+	// List returns all the entries.
+	List() ([]ProvideEntry, error)
+}
+
+// MemoryProvideStore is a ProvideStore keeping entries in memory, for queues
+// that don't need to survive restarts.
+type MemoryProvideStore struct {
+	lk      sync.Mutex
+	entries map[cid.Cid]ProvideEntry
+}
+
+var _ ProvideStore = (*MemoryProvideStore)(nil)
+
+// NewMemoryProvideStore creates an empty MemoryProvideStore.
+func NewMemoryProvideStore() *MemoryProvideStore {
+	return &MemoryProvideStore{entries: make(map[cid.Cid]ProvideEntry)}
+}
+
+// Put implements ProvideStore.
+func (s *MemoryProvideStore) Put(e ProvideEntry) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	s.entries[e.Cid] = e
+	return nil
+}
+
+// Delete implements ProvideStore.
+func (s *MemoryProvideStore) Delete(c cid.Cid) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	delete(s.entries, c)
+	return nil
+}
+
+// List implements ProvideStore.
+func (s *MemoryProvideStore) List() ([]ProvideEntry, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	out := make([]ProvideEntry, 0, len(s.entries))
+	for _, e := range s.entries {
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// ProvideQueueConfig configures a ProvideQueue.
+type ProvideQueueConfig struct {
+	// Router is the content router the CIDs are provided to.
+	Router ContentRouting
+	// Store persists the queue. Nil means a MemoryProvideStore.
+	Store ProvideStore
+
+	// Interval is the minimum time between two provides, rate limiting
+	// the queue. Zero means DefaultProvideInterval.
+	Interval time.Duration
+	// RetryBase and RetryMax bound the delay before retrying a failed
+	// provide. Zero means DefaultProvideRetryBase and
+	// DefaultProvideRetryMax.
+	RetryBase, RetryMax time.Duration
+	// MaxAttempts is the number of consecutive failures after which a CID
+	// is dropped. Zero means CIDs are retried forever.
+	MaxAttempts int
+
+	// Reprovide is the interval after which provided CIDs are provided
+	// again. Zero means CIDs leave the queue once provided.
+	Reprovide time.Duration
+
+	// OnDrop is called with the CIDs dropped after MaxAttempts failures,
+	// and the last error.
+	OnDrop func(cid.Cid, error)
+}
+
+// ProvideQueue provides CIDs to a content router in the background: CIDs are
+// provided one at a time, rate limited, and failed provides are retried with
+// exponential backoff. Entries are persisted in a ProvideStore, so that
+// pending (and, with Reprovide, provided) CIDs survive restarts.
+//
+// The store is only written to, not read, while the queue runs: the queue's
+// in-memory state is authoritative, and store errors in the background are
+// ignored.
+type ProvideQueue struct {
+	cfg   ProvideQueueConfig
+	clock network.Clock
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	startOnce sync.Once
+	wake      chan struct{}
+	done      chan struct{}
+
+	// nextSlot is the earliest time of the next provide. It's only used
+	// by the loop.
+	nextSlot time.Time
+
+	lk      sync.Mutex
+	entries map[cid.Cid]*queuedEntry
+	// pending orders the entries by NextAttempt.
+	pending provideHeap
+}
+
+// queuedEntry is an entry of a ProvideQueue, with its index in the heap.
+type queuedEntry struct {
+	ProvideEntry
+	index int
+}
+
+// provideHeap is a min-heap of entries ordered by NextAttempt.
+type provideHeap []*queuedEntry
+
+func (h provideHeap) Len() int { return len(h) }
+func (h provideHeap) Less(i, j int) bool {
+	return h[i].NextAttempt.Before(h[j].NextAttempt)
+}
+func (h provideHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *provideHeap) Push(x interface{}) {
+	e := x.(*queuedEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *provideHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return e
+}
+
+// NewProvideQueue creates a ProvideQueue, loading the entries of the store.
+// Call Start to start providing.
+func NewProvideQueue(cfg ProvideQueueConfig) (*ProvideQueue, error) {
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryProvideStore()
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultProvideInterval
+	}
+	if cfg.RetryBase <= 0 {
+		cfg.RetryBase = DefaultProvideRetryBase
+	}
+	if cfg.RetryMax <= 0 {
+		cfg.RetryMax = DefaultProvideRetryMax
+	}
+	saved, err := cfg.Store.List()
+	if err != nil {
+		return nil, err
+	}
+	q := &ProvideQueue{
+		cfg:     cfg,
+		clock:   network.RealClock,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		entries: make(map[cid.Cid]*queuedEntry, len(saved)),
+		pending: make(provideHeap, 0, len(saved)),
+	}
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+	for _, e := range saved {
+		qe := &queuedEntry{ProvideEntry: e, index: len(q.pending)}
+		q.entries[e.Cid] = qe
+		q.pending = append(q.pending, qe)
+	}
+	heap.Init(&q.pending)
+	return q, nil
+}
+
+// SetClock sets the clock of the queue. It must be called before Start.
+func (q *ProvideQueue) SetClock(c network.Clock) {
+	q.clock = c
+}
+
+// Start starts providing the queued CIDs, until Close is called. Calls after
+// the first, or after Close, do nothing.
+func (q *ProvideQueue) Start() {
+	q.startOnce.Do(func() {
+		go q.loop()
+	})
+}
+
+// Close stops the queue, waiting for the provide in progress, if any, to be
+// canceled. Queued CIDs stay in the store. It's safe to call more than once,
+// and whether or not the queue was started.
+func (q *ProvideQueue) Close() error {
+	q.cancel()
+	// If the queue wasn't started, prevent it from starting.
+	q.startOnce.Do(func() {
+		close(q.done)
+	})
+	<-q.done
+	return nil
+}
+
+// Enqueue queues the CID to be provided, unless it's already queued.
+func (q *ProvideQueue) Enqueue(c cid.Cid) error {
+	q.lk.Lock()
+	if _, ok := q.entries[c]; ok {
+		q.lk.Unlock()
+		return nil
+	}
+	e := &queuedEntry{ProvideEntry: ProvideEntry{Cid: c, NextAttempt: q.clock.Now()}}
+	q.entries[c] = e
+	heap.Push(&q.pending, e)
+	err := q.cfg.Store.Put(e.ProvideEntry)
+	q.lk.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return err
+}
+
+// Remove removes the CID from the queue, e.g. once the content is deleted.
+func (q *ProvideQueue) Remove(c cid.Cid) error {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+	q.remove(c)
+	return q.cfg.Store.Delete(c)
+}
+
+// remove removes the CID's entry, if any. q.lk must be held.
+func (q *ProvideQueue) remove(c cid.Cid) {
+	if e, ok := q.entries[c]; ok {
+		delete(q.entries, c)
+		heap.Remove(&q.pending, e.index)
+	}
+}
+
+// Len returns the number of queued CIDs.
+func (q *ProvideQueue) Len() int {
+	q.lk.Lock()
+	defer q.lk.Unlock()
+	return len(q.entries)
+}
+
+func (q *ProvideQueue) loop() {
+	defer close(q.done)
+	timer := q.clock.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-q.wake:
+		case <-timer.C():
+		}
+		if wait, ok := q.step(); ok {
+			timer.Reset(wait)
+		}
+	}
+}
+
+// step provides the most overdue CID, if any, and returns how long to wait
+// before the next step, or false to wait for an Enqueue.
+func (q *ProvideQueue) step() (time.Duration, bool) {
+	now := q.clock.Now()
+	if wait := q.nextSlot.Sub(now); wait > 0 {
+		return wait, true
+	}
+	q.lk.Lock()
+	if len(q.pending) == 0 {
+		q.lk.Unlock()
+		return 0, false
+	}
+	next := q.pending[0]
+	if wait := next.NextAttempt.Sub(now); wait > 0 {
+		q.lk.Unlock()
+		return wait, true
+	}
+	c := next.Cid
+	q.lk.Unlock()
+
+	err := q.cfg.Router.Provide(q.ctx, c, true)
+	if q.ctx.Err() != nil {
+		return 0, false
+	}
+
+	now = q.clock.Now()
+	q.nextSlot = now.Add(q.cfg.Interval)
+	q.lk.Lock()
+	e, ok := q.entries[c]
+	if !ok {
+		// Removed while being provided.
+		q.lk.Unlock()
+		return q.cfg.Interval, true
+	}
+	var dropped bool
+	switch {
+	case err == nil && q.cfg.Reprovide > 0:
+		e.Attempts = 0
+		e.NextAttempt = now.Add(q.cfg.Reprovide)
+		heap.Fix(&q.pending, e.index)
+		q.cfg.Store.Put(e.ProvideEntry)
+	case err == nil:
+		q.remove(c)
+		q.cfg.Store.Delete(c)
+	case q.cfg.MaxAttempts > 0 && e.Attempts+1 >= q.cfg.MaxAttempts:
+		q.remove(c)
+		q.cfg.Store.Delete(c)
+		dropped = true
+	default:
+		e.Attempts++
+		e.NextAttempt = now.Add(q.retryDelay(e.Attempts))
+		heap.Fix(&q.pending, e.index)
+		q.cfg.Store.Put(e.ProvideEntry)
+	}
+	q.lk.Unlock()
+
+	if dropped && q.cfg.OnDrop != nil {
+		q.cfg.OnDrop(c, err)
+	}
+	return q.cfg.Interval, true
+}
+
+func (q *ProvideQueue) retryDelay(attempts int) time.Duration {
+	delay := q.cfg.RetryBase
+	for i := 1; i < attempts && delay < q.cfg.RetryMax; i++ {
+		delay *= 2
+	}
+	if delay > q.cfg.RetryMax {
+		delay = q.cfg.RetryMax
+	}
+	return delay
+}
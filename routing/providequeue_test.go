@@ -0,0 +1,184 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+
+	cid "github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// provideRouter reports provides on calls, failing those of the fail CID.
+type provideRouter struct {
+	ContentRouting
+	fail  cid.Cid
+	calls chan cid.Cid
+}
+
+func (r *provideRouter) Provide(_ context.Context, c cid.Cid, _ bool) error {
+	r.calls <- c
+	if c == r.fail {
+		return errors.New("provide failed")
+	}
+	return nil
+}
+
+func testCid(t *testing.T, data string) cid.Cid {
+	h, err := mh.Sum([]byte(data), mh.SHA2_256, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cid.NewCidV1(cid.Raw, h)
+}
+
+func TestProvideQueue(t *testing.T) {
+	a, b := testCid(t, "a"), testCid(t, "b")
+	router := &provideRouter{fail: a, calls: make(chan cid.Cid, 8)}
+	store := NewMemoryProvideStore()
+	dropped := make(chan cid.Cid, 1)
+	q, err := NewProvideQueue(ProvideQueueConfig{
+		Router:      router,
+		Store:       store,
+		Interval:    time.Second,
+		RetryBase:   10 * time.Second,
+		MaxAttempts: 2,
+		OnDrop:      func(c cid.Cid, _ error) { dropped <- c },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := network.NewMockClock(time.Now())
+	q.SetClock(clock)
+
+	if err := q.Enqueue(a); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Millisecond)
+	if err := q.Enqueue(b); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := store.List(); len(saved) != 2 {
+		t.Fatalf("expected 2 persisted entries, got %d", len(saved))
+	}
+	q.Start()
+	defer q.Close()
+
+	// nextProvide advances the clock until the queue provides a CID.
+	nextProvide := func() cid.Cid {
+		for i := 0; i < 100; i++ {
+			select {
+			case c := <-router.calls:
+				return c
+			case <-time.After(10 * time.Millisecond):
+				clock.Advance(time.Second)
+			}
+		}
+		t.Fatal("timed out waiting for a provide")
+		return cid.Cid{}
+	}
+	for i, expected := range []cid.Cid{a, b, a} {
+		if c := nextProvide(); c != expected {
+			t.Fatalf("provide %d: expected %s, got %s", i, expected, c)
+		}
+	}
+	select {
+	case c := <-dropped:
+		if c != a {
+			t.Fatalf("unexpected dropped CID %s", c)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the failing CID to be dropped")
+	}
+	if q.Len() != 0 {
+		t.Fatalf("expected an empty queue, got %d entries", q.Len())
+	}
+	if saved, _ := store.List(); len(saved) != 0 {
+		t.Fatalf("expected no persisted entries, got %d", len(saved))
+	}
+
+	// Entries survive restarts.
+	store.Put(ProvideEntry{Cid: b})
+	q2, err := NewProvideQueue(ProvideQueueConfig{Router: router, Store: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q2.Len() != 1 {
+		t.Fatalf("expected the persisted entry to be loaded, got %d entries", q2.Len())
+	}
+}
+
+func TestProvideQueueLifecycle(t *testing.T) {
+	router := &provideRouter{calls: make(chan cid.Cid, 8)}
+	q, err := NewProvideQueue(ProvideQueueConfig{Router: router})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Closing a queue that wasn't started doesn't block, and starting it
+	// afterwards does nothing.
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	q.Start()
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err = NewProvideQueue(ProvideQueueConfig{Router: router})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.Start()
+	q.Start()
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProvideQueueOrder(t *testing.T) {
+	store := NewMemoryProvideStore()
+	now := time.Now()
+	var cids []cid.Cid
+	for i, delay := range []time.Duration{3, 1, 2} {
+		c := testCid(t, string(rune('a'+i)))
+		cids = append(cids, c)
+		store.Put(ProvideEntry{Cid: c, NextAttempt: now.Add(delay * time.Second)})
+	}
+	router := &provideRouter{calls: make(chan cid.Cid, 8)}
+	q, err := NewProvideQueue(ProvideQueueConfig{Router: router, Store: store, Interval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := network.NewMockClock(now)
+	q.SetClock(clock)
+	if err := q.Remove(cids[2]); err != nil {
+		t.Fatal(err)
+	}
+	q.Start()
+	defer q.Close()
+
+	for _, want := range []cid.Cid{cids[1], cids[0]} {
+		var got cid.Cid
+		for got == cid.Undef {
+			clock.Advance(100 * time.Millisecond)
+			select {
+			case got = <-router.calls:
+			case <-time.After(time.Millisecond):
+			}
+		}
+		if got != want {
+			t.Fatalf("expected %s to be provided next, got %s", want, got)
+		}
+	}
+	// The last entry is removed once its provide returns.
+	deadline := time.Now().Add(5 * time.Second)
+	for q.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected an empty queue, got %d entries", q.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,125 @@
+package routing
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+// TransferProtocol identifies a protocol content can be fetched over.
+type TransferProtocol string
+
+// Well-known transfer protocols.
+const (
+	TransferBitswap     TransferProtocol = "bitswap"
+	TransferGraphsync   TransferProtocol = "graphsync"
+	TransferHTTPGateway TransferProtocol = "http-gateway"
+)
+
+// ProviderInfo is a provider record: the provider's peer info, along with the
+// protocols it serves the content over.
+type ProviderInfo struct {
+	peer.AddrInfo
+
+	// Protocols lists the protocols the provider serves the content over.
+	// It's empty if the provider didn't advertise any.
+	Protocols []TransferProtocol
+}
+
+// Serves returns true if the provider serves the content over one of the
+// given protocols. Providers that didn't advertise their protocols are assumed
+// to serve all of them.
+func (pi ProviderInfo) Serves(protos ...TransferProtocol) bool {
+	if len(pi.Protocols) == 0 || len(protos) == 0 {
+		return true
+	}
+	for _, want := range protos {
+		for _, have := range pi.Protocols {
+			if want == have {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HintedContentRouting is implemented by content routers that can record
+// which protocols providers serve content over.
+type HintedContentRouting interface {
+	ContentRouting
+
+	// ProvideWithHints is like Provide, additionally advertising the
+	// protocols the content is served over.
+	ProvideWithHints(ctx context.Context, c cid.Cid, announce bool, protos []TransferProtocol) error
+
+	// FindProvidersWithHintsAsync is like FindProvidersAsync, but returns the
+	// protocols each provider advertised along with its peer info.
+	FindProvidersWithHintsAsync(ctx context.Context, c cid.Cid, count int) <-chan ProviderInfo
+}
+
+// ProvideWithHints provides the content, advertising the given protocols if
+// the router implements HintedContentRouting. Other routers provide the
+// content without the hints.
+func ProvideWithHints(ctx context.Context, r ContentRouting, c cid.Cid, announce bool, protos ...TransferProtocol) error {
+	if hr, ok := r.(HintedContentRouting); ok {
+		return hr.ProvideWithHints(ctx, c, announce, protos)
+	}
+	return r.Provide(ctx, c, announce)
+}
+
+// FindProvidersServing searches for up to count providers of the content
+// serving it over one of the given protocols (all providers if none are
+// given). Providers that didn't advertise their protocols, including all
+// providers found by routers not implementing HintedContentRouting, are
+// included.
+func FindProvidersServing(ctx context.Context, r ContentRouting, c cid.Cid, count int, protos ...TransferProtocol) <-chan ProviderInfo {
+	// The search isn't limited to count providers as some may be filtered
+	// out; it's canceled once enough were found instead.
+	ctx, cancel := context.WithCancel(ctx)
+
+	var in <-chan ProviderInfo
+	if hr, ok := r.(HintedContentRouting); ok {
+		in = hr.FindProvidersWithHintsAsync(ctx, c, 0)
+	} else {
+		in = wrapProviders(ctx, r.FindProvidersAsync(ctx, c, 0))
+	}
+
+	out := make(chan ProviderInfo)
+	go func() {
+		defer close(out)
+		defer cancel()
+		found := 0
+		for pi := range in {
+			if !pi.Serves(protos...) {
+				continue
+			}
+			select {
+			case out <- pi:
+			case <-ctx.Done():
+				return
+			}
+			found++
+			if count > 0 && found >= count {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func wrapProviders(ctx context.Context, in <-chan peer.AddrInfo) <-chan ProviderInfo {
+	out := make(chan ProviderInfo)
+	go func() {
+		defer close(out)
+		for ai := range in {
+			select {
+			case out <- ProviderInfo{AddrInfo: ai}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,59 @@
+package routing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+type hintedRouter struct {
+	ContentRouting
+	providers []ProviderInfo
+}
+
+func (r *hintedRouter) ProvideWithHints(context.Context, cid.Cid, bool, []TransferProtocol) error {
+	return nil
+}
+
+func (r *hintedRouter) FindProvidersWithHintsAsync(ctx context.Context, _ cid.Cid, _ int) <-chan ProviderInfo {
+	out := make(chan ProviderInfo)
+	go func() {
+		defer close(out)
+		for _, p := range r.providers {
+			select {
+			case out <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func TestFindProvidersServing(t *testing.T) {
+	r := &hintedRouter{providers: []ProviderInfo{
+		{AddrInfo: peer.AddrInfo{ID: "a"}, Protocols: []TransferProtocol{TransferBitswap}},
+		{AddrInfo: peer.AddrInfo{ID: "b"}, Protocols: []TransferProtocol{TransferHTTPGateway}},
+		{AddrInfo: peer.AddrInfo{ID: "c"}},
+		{AddrInfo: peer.AddrInfo{ID: "d"}, Protocols: []TransferProtocol{TransferGraphsync, TransferHTTPGateway}},
+	}}
+
+	var found []peer.ID
+	for pi := range FindProvidersServing(context.Background(), r, cid.Cid{}, 0, TransferHTTPGateway) {
+		found = append(found, pi.ID)
+	}
+	if len(found) != 3 || found[0] != "b" || found[1] != "c" || found[2] != "d" {
+		t.Fatalf("unexpected providers: %v", found)
+	}
+
+	found = found[:0]
+	for pi := range FindProvidersServing(context.Background(), r, cid.Cid{}, 1, TransferBitswap) {
+		found = append(found, pi.ID)
+	}
+	if len(found) != 1 || found[0] != "a" {
+		t.Fatalf("unexpected providers: %v", found)
+	}
+}
@@ -0,0 +1,253 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+var (
+	// ErrQueryResourceLimit is returned when a query exceeds the limits of
+	// its QueryScope.
+	ErrQueryResourceLimit = errors.New("query resource limit exceeded")
+	// ErrQueryScopeDone is returned when reserving resources in a
+	// QueryScope after Done.
+	ErrQueryScopeDone = errors.New("query scope done")
+)
+
+// QueryLimits are the resources a single query may use at once. Zero limits
+// mean DefaultQueryLimits; otherwise, a zero field means no limit.
+type QueryLimits struct {
+	// Dials is the number of concurrent dials.
+	Dials int
+	// Streams is the number of concurrent streams.
+	Streams int
+	// Memory is the number of bytes of memory.
+	Memory int64
+}
+
+// DefaultQueryLimits are the limits of queries scoped with zero limits.
+var DefaultQueryLimits = QueryLimits{
+	Dials:   32,
+	Streams: 64,
+	Memory:  4 << 20,
+}
+
+// QueryScope is a transactional resource scope for a single routing query,
+// so one runaway query (e.g. FindProviders) can't starve the rest of the
+// node. Routers acquire a dial or a stream with BeginDial and BeginStream
+// before opening it, and reserve memory with ReserveMemory. Memory, and dials
+// and streams if the parent implements network.ConnStreamScope, are also
+// acquired in the parent scope. Done ends the transaction, releasing all the
+// resources still acquired in the parent.
+type QueryScope struct {
+	parent   network.ResourceScope
+	counting network.ConnStreamScope
+	limits   QueryLimits
+
+	lk      sync.Mutex
+	dials   int
+	streams int
+	memory  int64
+	done    bool
+}
+
+var _ network.ResourceScope = (*QueryScope)(nil)
+
+// NewQueryScope returns a query scope nested in the parent scope, e.g. the
+// ServiceScope of the router. A nil parent means network.NullScope, and zero
+// limits mean DefaultQueryLimits.
+func NewQueryScope(parent network.ResourceScope, limits QueryLimits) *QueryScope {
+	if parent == nil {
+		parent = network.NullScope
+	}
+	if limits == (QueryLimits{}) {
+		limits = DefaultQueryLimits
+	}
+	counting, _ := parent.(network.ConnStreamScope)
+	return &QueryScope{parent: parent, counting: counting, limits: limits}
+}
+
+// BeginDial acquires a dial, which must be released with EndDial once the
+// dial completes. It fails with ErrQueryResourceLimit if the query has too
+// many dials in flight.
+func (s *QueryScope) BeginDial() error {
+	return s.acquire(&s.dials, s.limits.Dials, s.addConn)
+}
+
+// EndDial releases a dial acquired with BeginDial.
+func (s *QueryScope) EndDial() {
+	s.release(&s.dials, s.removeConn)
+}
+
+// BeginStream acquires a stream, which must be released with EndStream once
+// the stream is closed. It fails with ErrQueryResourceLimit if the query has
+// too many streams open.
+func (s *QueryScope) BeginStream() error {
+	return s.acquire(&s.streams, s.limits.Streams, s.addStream)
+}
+
+// EndStream releases a stream acquired with BeginStream.
+func (s *QueryScope) EndStream() {
+	s.release(&s.streams, s.removeStream)
+}
+
+func (s *QueryScope) addConn() error {
+	if s.counting == nil {
+		return nil
+	}
+	return s.counting.AddConn(network.DirOutbound)
+}
+
+func (s *QueryScope) removeConn() {
+	if s.counting != nil {
+		s.counting.RemoveConn(network.DirOutbound)
+	}
+}
+
+func (s *QueryScope) addStream() error {
+	if s.counting == nil {
+		return nil
+	}
+	return s.counting.AddStream(network.DirOutbound)
+}
+
+func (s *QueryScope) removeStream() {
+	if s.counting != nil {
+		s.counting.RemoveStream(network.DirOutbound)
+	}
+}
+
+func (s *QueryScope) acquire(n *int, limit int, acquireParent func() error) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	if s.done {
+		return ErrQueryScopeDone
+	}
+	if limit > 0 && *n >= limit {
+		return ErrQueryResourceLimit
+	}
+	if err := acquireParent(); err != nil {
+		return err
+	}
+	*n++
+	return nil
+}
+
+func (s *QueryScope) release(n *int, releaseParent func()) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	// After Done, the parent was already released.
+	if *n > 0 {
+		*n--
+		releaseParent()
+	}
+}
+
+// ReserveMemory implements network.ResourceScope, reserving the memory in the
+// parent scope too.
+func (s *QueryScope) ReserveMemory(size int, prio uint8) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	if s.done {
+		return ErrQueryScopeDone
+	}
+	if s.limits.Memory > 0 && s.memory+int64(size) > s.limits.Memory {
+		return ErrQueryResourceLimit
+	}
+	if err := s.parent.ReserveMemory(size, prio); err != nil {
+		return err
+	}
+	s.memory += int64(size)
+	return nil
+}
+
+// ReleaseMemory implements network.ResourceScope.
+func (s *QueryScope) ReleaseMemory(size int) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	if s.done {
+		// Already released by Done.
+		return
+	}
+	if int64(size) > s.memory {
+		size = int(s.memory)
+	}
+	s.memory -= int64(size)
+	s.parent.ReleaseMemory(size)
+}
+
+// Stat implements network.ResourceScope. Dials in flight are reported as
+// outbound connections.
+func (s *QueryScope) Stat() network.ScopeStat {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	return network.ScopeStat{
+		NumConnsOutbound:   s.dials,
+		NumStreamsOutbound: s.streams,
+		Memory:             s.memory,
+	}
+}
+
+// Done ends the query, releasing the memory, dials and streams still
+// acquired in the parent scope. Reservations fail with ErrQueryScopeDone
+// afterwards. It's safe to call more than once.
+func (s *QueryScope) Done() {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	if s.done {
+		return
+	}
+	s.done = true
+	if s.memory > 0 {
+		s.parent.ReleaseMemory(int(s.memory))
+		s.memory = 0
+	}
+	for ; s.dials > 0; s.dials-- {
+		s.removeConn()
+	}
+	for ; s.streams > 0; s.streams-- {
+		s.removeStream()
+	}
+}
+
+type queryScopeKey struct{}
+
+// WithQueryScope returns a context carrying the query scope, for the routers
+// serving the query.
+func WithQueryScope(ctx context.Context, s *QueryScope) context.Context {
+	return context.WithValue(ctx, queryScopeKey{}, s)
+}
+
+// GetQueryScope returns the query scope carried by the context, or nil if it
+// carries none.
+func GetQueryScope(ctx context.Context) *QueryScope {
+	s, _ := ctx.Value(queryScopeKey{}).(*QueryScope)
+	return s
+}
+
+// FindProvidersScoped runs FindProvidersAsync inside a new query scope with
+// the given limits, DefaultQueryLimits if zero, ending the scope once the
+// results are drained or the context is canceled.
+func FindProvidersScoped(ctx context.Context, r ContentRouting, parent network.ResourceScope, limits QueryLimits, c cid.Cid, count int) <-chan peer.AddrInfo {
+	scope := NewQueryScope(parent, limits)
+	in := r.FindProvidersAsync(WithQueryScope(ctx, scope), c, count)
+	out := make(chan peer.AddrInfo)
+	go func() {
+		defer close(out)
+		defer scope.Done()
+		for pi := range in {
+			select {
+			case out <- pi:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,181 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	cid "github.com/ipfs/go-cid"
+)
+
+// countingScope accounts memory without limits.
+type countingScope struct {
+	memory int64
+}
+
+func (s *countingScope) ReserveMemory(size int, _ uint8) error {
+	s.memory += int64(size)
+	return nil
+}
+
+func (s *countingScope) ReleaseMemory(size int) { s.memory -= int64(size) }
+
+func (s *countingScope) Stat() network.ScopeStat { return network.ScopeStat{Memory: s.memory} }
+
+var errParentLimit = errors.New("parent limit")
+
+// connStreamScope also accounts connections and streams, up to maxStreams
+// streams.
+type connStreamScope struct {
+	countingScope
+	conns, streams, maxStreams int
+}
+
+var _ network.ConnStreamScope = (*connStreamScope)(nil)
+
+func (s *connStreamScope) AddConn(network.Direction) error { s.conns++; return nil }
+func (s *connStreamScope) RemoveConn(network.Direction)    { s.conns-- }
+func (s *connStreamScope) AddStream(network.Direction) error {
+	if s.streams >= s.maxStreams {
+		return errParentLimit
+	}
+	s.streams++
+	return nil
+}
+func (s *connStreamScope) RemoveStream(network.Direction) { s.streams-- }
+
+func TestQueryScope(t *testing.T) {
+	parent := &countingScope{}
+	s := NewQueryScope(parent, QueryLimits{Dials: 1, Streams: 2, Memory: 100})
+
+	if err := s.BeginDial(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BeginDial(); err != ErrQueryResourceLimit {
+		t.Fatalf("expected ErrQueryResourceLimit, got %v", err)
+	}
+	s.EndDial()
+	if err := s.BeginDial(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.BeginStream(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.BeginStream(); err != ErrQueryResourceLimit {
+		t.Fatalf("expected ErrQueryResourceLimit, got %v", err)
+	}
+
+	if err := s.ReserveMemory(80, network.ReservationPriorityAlways); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ReserveMemory(30, network.ReservationPriorityAlways); err != ErrQueryResourceLimit {
+		t.Fatalf("expected ErrQueryResourceLimit, got %v", err)
+	}
+	s.ReleaseMemory(20)
+	if st := s.Stat(); st.Memory != 60 || st.NumConnsOutbound != 1 || st.NumStreamsOutbound != 2 {
+		t.Fatalf("unexpected stat %+v", st)
+	}
+	if parent.memory != 60 {
+		t.Fatalf("expected 60 bytes reserved in the parent, got %d", parent.memory)
+	}
+
+	s.Done()
+	if parent.memory != 0 {
+		t.Fatalf("expected Done to release the parent's memory, got %d", parent.memory)
+	}
+	s.ReleaseMemory(60)
+	if parent.memory != 0 {
+		t.Fatal("expected releases after Done to be ignored")
+	}
+	if err := s.ReserveMemory(1, network.ReservationPriorityAlways); err != ErrQueryScopeDone {
+		t.Fatalf("expected ErrQueryScopeDone, got %v", err)
+	}
+	if err := s.BeginStream(); err != ErrQueryScopeDone {
+		t.Fatalf("expected ErrQueryScopeDone, got %v", err)
+	}
+}
+
+func TestQueryScopeParentCounts(t *testing.T) {
+	parent := &connStreamScope{maxStreams: 1}
+	s := NewQueryScope(parent, QueryLimits{})
+	if s.limits != DefaultQueryLimits {
+		t.Fatalf("expected zero limits to mean DefaultQueryLimits, got %+v", s.limits)
+	}
+
+	if err := s.BeginDial(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.BeginStream(); err != nil {
+		t.Fatal(err)
+	}
+	if parent.conns != 1 || parent.streams != 1 {
+		t.Fatalf("expected the dial and the stream to be acquired in the parent, got %d and %d", parent.conns, parent.streams)
+	}
+	// The parent's limit applies, although the query's isn't reached.
+	if err := s.BeginStream(); err != errParentLimit {
+		t.Fatalf("expected the parent's error, got %v", err)
+	}
+	if st := s.Stat(); st.NumStreamsOutbound != 1 {
+		t.Fatalf("expected the failed stream not to be counted, got %d", st.NumStreamsOutbound)
+	}
+	s.EndStream()
+	if parent.streams != 0 {
+		t.Fatal("expected the stream to be released in the parent")
+	}
+
+	s.Done()
+	if parent.conns != 0 {
+		t.Fatal("expected Done to release the parent's dials")
+	}
+	s.EndDial()
+	if parent.conns != 0 {
+		t.Fatal("expected releases after Done to be ignored")
+	}
+}
+
+type scopedRouter struct {
+	ContentRouting
+	providers []peer.AddrInfo
+}
+
+func (r *scopedRouter) FindProvidersAsync(ctx context.Context, _ cid.Cid, _ int) <-chan peer.AddrInfo {
+	scope := GetQueryScope(ctx)
+	out := make(chan peer.AddrInfo)
+	go func() {
+		defer close(out)
+		for _, p := range r.providers {
+			if scope.ReserveMemory(10, network.ReservationPriorityLow) != nil {
+				return
+			}
+			select {
+			case out <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func TestFindProvidersScoped(t *testing.T) {
+	r := &scopedRouter{providers: []peer.AddrInfo{{ID: "a"}, {ID: "b"}, {ID: "c"}}}
+	parent := &countingScope{}
+	var found []peer.AddrInfo
+	for pi := range FindProvidersScoped(context.Background(), r, parent, QueryLimits{Memory: 20}, cid.Cid{}, 0) {
+		found = append(found, pi)
+	}
+	if len(found) != 2 {
+		t.Fatalf("expected the memory limit to stop the query after 2 providers, got %d", len(found))
+	}
+	if parent.memory != 0 {
+		t.Fatalf("expected the query's memory to be released, got %d", parent.memory)
+	}
+	if GetQueryScope(context.Background()) != nil {
+		t.Fatal("expected no query scope")
+	}
+}
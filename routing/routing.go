@@ -18,6 +18,11 @@ var ErrNotFound = errors.New("routing: not found")
 // type/operation.
 var ErrNotSupported = errors.New("routing: operation or key not supported")
 
+// ErrOffline is returned when the router is asked to operate offline (see the
+// Offline option) and the request can't be answered from local data without
+// querying the network.
+var ErrOffline = errors.New("routing: not available in offline mode")
+
 // ContentRouting is a value provider layer of indirection. It is used to find
 // information about who has what content.
 //
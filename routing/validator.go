@@ -0,0 +1,210 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidRecordType is returned by NamespacedValidator when no
+	// validator is registered for the namespace of a key.
+	ErrInvalidRecordType = errors.New("invalid record keytype")
+
+	// ErrInvalidKey is returned when a key isn't of the form
+	// /namespace/rest.
+	ErrInvalidKey = errors.New("invalid record key")
+
+	// ErrNoValues is returned by Select when there's no value to select.
+	ErrNoValues = errors.New("no values to select from")
+
+	// ErrNoValidators is returned by the validators built with AllOf
+	// without any validator, which reject every record rather than accept
+	// them all.
+	ErrNoValidators = errors.New("no validators to validate with")
+)
+
+// Validator validates the records stored in value stores, and chooses the
+// best one among conflicting records. Value stores must only accept and
+// return records their validator accepts.
+type Validator interface {
+	// Validate returns an error if the value isn't a valid record for the
+	// key.
+	Validate(key string, value []byte) error
+
+	// Select returns the index of the best value among valid values for
+	// the key.
+	Select(key string, values [][]byte) (int, error)
+}
+
+// ValidationError is returned when a record fails validation.
+type ValidationError struct {
+	Key string
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid record for key %q: %s", e.Key, e.Err)
+}
+
+// Unwrap returns the validator's error.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// SplitKey splits a key of the form /namespace/rest.
+func SplitKey(key string) (ns string, rest string, err error) {
+	if len(key) == 0 || key[0] != '/' {
+		return "", "", ErrInvalidKey
+	}
+	key = key[1:]
+	i := strings.IndexByte(key, '/')
+	if i <= 0 {
+		return "", "", ErrInvalidKey
+	}
+	return key[:i], key[i+1:], nil
+}
+
+// NamespacedValidator dispatches validation to the validator registered for
+// the namespace of each key, e.g. "pk" or "ipns".
+type NamespacedValidator map[string]Validator
+
+var _ Validator = NamespacedValidator(nil)
+
+// ValidatorByKey returns the validator of the namespace of the key, or nil.
+func (v NamespacedValidator) ValidatorByKey(key string) Validator {
+	ns, _, err := SplitKey(key)
+	if err != nil {
+		return nil
+	}
+	return v[ns]
+}
+
+// Validate implements Validator. Errors are returned as *ValidationError.
+func (v NamespacedValidator) Validate(key string, value []byte) error {
+	vi := v.ValidatorByKey(key)
+	if vi == nil {
+		return &ValidationError{Key: key, Err: ErrInvalidRecordType}
+	}
+	if err := vi.Validate(key, value); err != nil {
+		if _, ok := err.(*ValidationError); ok {
+			return err
+		}
+		return &ValidationError{Key: key, Err: err}
+	}
+	return nil
+}
+
+// Select implements Validator.
+func (v NamespacedValidator) Select(key string, values [][]byte) (int, error) {
+	if len(values) == 0 {
+		return 0, ErrNoValues
+	}
+	vi := v.ValidatorByKey(key)
+	if vi == nil {
+		return 0, &ValidationError{Key: key, Err: ErrInvalidRecordType}
+	}
+	return vi.Select(key, values)
+}
+
+// AllOf returns a validator accepting the records all the validators accept.
+// Selection is delegated to the first validator. Without validators, every
+// record is rejected with ErrNoValidators.
+func AllOf(validators ...Validator) Validator {
+	return allOf(validators)
+}
+
+type allOf []Validator
+
+func (vs allOf) Validate(key string, value []byte) error {
+	if len(vs) == 0 {
+		return ErrNoValidators
+	}
+	for _, v := range vs {
+		if err := v.Validate(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (vs allOf) Select(key string, values [][]byte) (int, error) {
+	if len(vs) == 0 || len(values) == 0 {
+		return 0, ErrNoValues
+	}
+	return vs[0].Select(key, values)
+}
+
+// AnyOf returns a validator accepting the records at least one of the
+// validators accepts, e.g. during a migration between record formats.
+// Selection is delegated to the first validator accepting all the values,
+// falling back to the first value.
+func AnyOf(validators ...Validator) Validator {
+	return anyOf(validators)
+}
+
+type anyOf []Validator
+
+// AnyOfError is returned by the validators built with AnyOf when all of their
+// validators reject a record. errors.Is and errors.As match any of the
+// validators' errors.
+type AnyOfError struct {
+	// Errs are the errors of the validators, in order.
+	Errs []error
+}
+
+func (e *AnyOfError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return "rejected by all validators: " + strings.Join(msgs, "; ")
+}
+
+// Is returns true if one of the validators' errors matches target.
+func (e *AnyOfError) Is(target error) bool {
+	for _, err := range e.Errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the validators' errors matching target.
+func (e *AnyOfError) As(target interface{}) bool {
+	for _, err := range e.Errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (vs anyOf) Validate(key string, value []byte) error {
+	var errs []error
+	for _, v := range vs {
+		err := v.Validate(key, value)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return &AnyOfError{Errs: errs}
+}
+
+func (vs anyOf) Select(key string, values [][]byte) (int, error) {
+	if len(values) == 0 {
+		return 0, ErrNoValues
+	}
+validators:
+	for _, v := range vs {
+		for _, value := range values {
+			if v.Validate(key, value) != nil {
+				continue validators
+			}
+		}
+		return v.Select(key, values)
+	}
+	return 0, nil
+}
@@ -0,0 +1,92 @@
+package routing
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// prefixValidator accepts values starting with its prefix, and selects the
+// longest one.
+type prefixValidator string
+
+func (p prefixValidator) Validate(_ string, value []byte) error {
+	if !bytes.HasPrefix(value, []byte(p)) {
+		return errors.New("bad prefix")
+	}
+	return nil
+}
+
+func (p prefixValidator) Select(_ string, values [][]byte) (int, error) {
+	best := 0
+	for i, v := range values {
+		if len(v) > len(values[best]) {
+			best = i
+		}
+	}
+	return best, nil
+}
+
+func TestNamespacedValidator(t *testing.T) {
+	v := NamespacedValidator{
+		"all": AllOf(prefixValidator("a"), prefixValidator("ab")),
+		"any": AnyOf(prefixValidator("a"), prefixValidator("b")),
+	}
+
+	if err := v.Validate("/all/k", []byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	var verr *ValidationError
+	if err := v.Validate("/all/k", []byte("ac")); !errors.As(err, &verr) || verr.Key != "/all/k" {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	if err := v.Validate("/any/k", []byte("bc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Validate("/any/k", []byte("c")); err == nil {
+		t.Fatal("expected a value rejected by all validators to be invalid")
+	}
+	if err := v.Validate("/none/k", []byte("a")); !errors.Is(err, ErrInvalidRecordType) {
+		t.Fatalf("expected ErrInvalidRecordType, got %v", err)
+	}
+	if err := v.Validate("nope", []byte("a")); !errors.Is(err, ErrInvalidRecordType) {
+		t.Fatalf("expected ErrInvalidRecordType, got %v", err)
+	}
+
+	if i, err := v.Select("/any/k", [][]byte{[]byte("b"), []byte("bbb")}); err != nil || i != 1 {
+		t.Fatalf("unexpected selection %d, %v", i, err)
+	}
+	if _, err := v.Select("/all/k", nil); err != ErrNoValues {
+		t.Fatalf("expected ErrNoValues, got %v", err)
+	}
+
+	if ns, rest, err := SplitKey("/pk/abc/def"); err != nil || ns != "pk" || rest != "abc/def" {
+		t.Fatalf("unexpected split %q, %q, %v", ns, rest, err)
+	}
+}
+
+var errTestRejected = errors.New("rejected")
+
+type rejectValidator struct{ prefixValidator }
+
+func (rejectValidator) Validate(string, []byte) error { return errTestRejected }
+
+func TestAnyOfError(t *testing.T) {
+	err := AnyOf(prefixValidator("a"), rejectValidator{}).Validate("/k", []byte("c"))
+	if !errors.Is(err, errTestRejected) {
+		t.Fatalf("expected the error to match errTestRejected, got %v", err)
+	}
+	var aerr *AnyOfError
+	if !errors.As(err, &aerr) || len(aerr.Errs) != 2 {
+		t.Fatalf("expected an AnyOfError with 2 errors, got %v", err)
+	}
+	if err.Error() != "rejected by all validators: bad prefix; rejected" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
+
+func TestAllOfEmpty(t *testing.T) {
+	if err := AllOf().Validate("/k", []byte("a")); err != ErrNoValidators {
+		t.Fatalf("expected ErrNoValidators, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package routing
+
+import (
+	"context"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// Provenance describes where a value returned by GetValueVerbose came from.
+type Provenance struct {
+	// Sources lists the peers that served the returned value. It's empty if
+	// the value was found locally.
+	Sources []peer.ID
+
+	// Validator names the validator that accepted the value (usually the key
+	// namespace, e.g. "pk" or "ipns").
+	Validator string
+
+	// Rejected maps peers that served invalid records to the error returned
+	// by the validator. Applications can use it to report misbehaving record
+	// servers.
+	Rejected map[peer.ID]error
+
+	// Outdated lists peers that served valid records that lost to the
+	// returned one.
+	Outdated []peer.ID
+}
+
+// VerboseValue is a value along with its provenance.
+type VerboseValue struct {
+	Value []byte
+	Provenance
+}
+
+// VerboseValueStore is implemented by value stores that can report the
+// provenance of the values they return.
+type VerboseValueStore interface {
+	// GetValueVerbose behaves like GetValue but also returns the peers that
+	// served the value and the validator that accepted it.
+	GetValueVerbose(context.Context, string, ...Option) (*VerboseValue, error)
+}
+
+// GetValueVerbose retrieves the value associated with the given key along with
+// its provenance.
+//
+// If the ValueStore doesn't implement VerboseValueStore, this method falls
+// back on GetValue and returns a value with an empty provenance.
+func GetValueVerbose(r ValueStore, ctx context.Context, key string, opts ...Option) (*VerboseValue, error) {
+	if vr, ok := r.(VerboseValueStore); ok {
+		return vr.GetValueVerbose(ctx, key, opts...)
+	}
+	val, err := r.GetValue(ctx, key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &VerboseValue{Value: val}, nil
+}
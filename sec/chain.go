@@ -0,0 +1,288 @@
+package sec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/record"
+)
+
+// CertificateDomain is the signature domain of certificates.
+const CertificateDomain = "libp2p-sec-certificate"
+
+// CertificateCodec is the payload type of certificates.
+var CertificateCodec = []byte("/libp2p/sec-certificate")
+
+var (
+	// ErrInvalidCertificate is returned when a certificate's signature
+	// doesn't verify or it isn't valid at the time of verification.
+	ErrInvalidCertificate = errors.New("invalid certificate")
+	// ErrBrokenChain is returned when the certificates of a chain don't link
+	// up, or the chain doesn't end with the node's key.
+	ErrBrokenChain = errors.New("certificate chain is broken")
+	// ErrUntrustedChain is returned by TrustedRootsPolicy when a chain isn't
+	// rooted in a trusted key.
+	ErrUntrustedChain = errors.New("certificate chain isn't rooted in a trusted key")
+)
+
+func init() {
+	record.RegisterType(&Certificate{})
+}
+
+// Certificate is a statement, signed by the issuer's key, binding the subject
+// key to a set of attributes (e.g. the operating organization) for a period
+// of time.
+//
+// Certificates are record.Record types, sealed in a record.Envelope signed by
+// the issuer. Modifying a certificate after signing it invalidates it.
+type Certificate struct {
+	Subject   crypto.PubKey
+	Issuer    crypto.PubKey
+	NotBefore time.Time
+	NotAfter  time.Time
+	// Attributes are free-form claims about the subject, e.g. "org".
+	Attributes map[string]string
+
+	// envelope is the envelope the certificate was sealed in, by Sign or
+	// UnmarshalCertChain.
+	envelope *record.Envelope
+}
+
+var _ record.Record = (*Certificate)(nil)
+
+type certJSON struct {
+	Subject    []byte
+	Issuer     []byte
+	NotBefore  time.Time
+	NotAfter   time.Time
+	Attributes map[string]string `json:",omitempty"`
+}
+
+// Domain implements record.Record.
+func (c *Certificate) Domain() string { return CertificateDomain }
+
+// Codec implements record.Record.
+func (c *Certificate) Codec() []byte { return CertificateCodec }
+
+// MarshalRecord implements record.Record.
+func (c *Certificate) MarshalRecord() ([]byte, error) {
+	subject, err := crypto.MarshalPublicKey(c.Subject)
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := crypto.MarshalPublicKey(c.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&certJSON{
+		Subject:    subject,
+		Issuer:     issuer,
+		NotBefore:  c.NotBefore,
+		NotAfter:   c.NotAfter,
+		Attributes: c.Attributes,
+	})
+}
+
+// UnmarshalRecord implements record.Record.
+func (c *Certificate) UnmarshalRecord(data []byte) error {
+	var cj certJSON
+	if err := json.Unmarshal(data, &cj); err != nil {
+		return err
+	}
+	subject, err := crypto.UnmarshalPublicKey(cj.Subject)
+	if err != nil {
+		return err
+	}
+	issuer, err := crypto.UnmarshalPublicKey(cj.Issuer)
+	if err != nil {
+		return err
+	}
+	*c = Certificate{
+		Subject:    subject,
+		Issuer:     issuer,
+		NotBefore:  cj.NotBefore,
+		NotAfter:   cj.NotAfter,
+		Attributes: cj.Attributes,
+	}
+	return nil
+}
+
+// Sign sets the issuer to the public key of issuerKey and seals the
+// certificate in an envelope signed with it.
+func (c *Certificate) Sign(issuerKey crypto.PrivKey) error {
+	c.Issuer = issuerKey.GetPublic()
+	e, err := record.Seal(c, issuerKey)
+	if err != nil {
+		return err
+	}
+	c.envelope = e
+	return nil
+}
+
+// Verify checks that the certificate was signed by its issuer and hasn't been
+// modified since, and that it's valid at time now.
+func (c *Certificate) Verify(now time.Time) error {
+	if now.Before(c.NotBefore) || now.After(c.NotAfter) {
+		return fmt.Errorf("%w: not valid at %s", ErrInvalidCertificate, now)
+	}
+	if c.envelope == nil {
+		return fmt.Errorf("%w: not signed", ErrInvalidCertificate)
+	}
+	data, err := c.MarshalRecord()
+	if err != nil {
+		return err
+	}
+	if !c.envelope.PublicKey.Equals(c.Issuer) || !bytes.Equal(data, c.envelope.RawPayload) {
+		return fmt.Errorf("%w: doesn't match the signed certificate", ErrInvalidCertificate)
+	}
+	return nil
+}
+
+// CertChain is a chain of certificates attesting a node's key. The first
+// certificate is issued by the root (e.g. the operator's key), each following
+// one by the subject of the previous one, and the last one's subject is the
+// node's key.
+type CertChain []*Certificate
+
+// Root returns the key the chain is rooted in.
+func (ch CertChain) Root() crypto.PubKey {
+	if len(ch) == 0 {
+		return nil
+	}
+	return ch[0].Issuer
+}
+
+// Verify checks that the chain links up, that all certificates are valid at
+// time now, and that the chain ends with nodeKey. It doesn't decide whether
+// the root is trusted; that's up to a ChainPolicy.
+func (ch CertChain) Verify(nodeKey crypto.PubKey, now time.Time) error {
+	if len(ch) == 0 {
+		return ErrBrokenChain
+	}
+	for i, c := range ch {
+		if i > 0 && !c.Issuer.Equals(ch[i-1].Subject) {
+			return ErrBrokenChain
+		}
+		if err := c.Verify(now); err != nil {
+			return err
+		}
+	}
+	if !ch[len(ch)-1].Subject.Equals(nodeKey) {
+		return ErrBrokenChain
+	}
+	return nil
+}
+
+// ChainPolicy decides whether the certificate chain presented by a peer during
+// the handshake is acceptable.
+type ChainPolicy interface {
+	// ValidateChain returns an error if the chain must be rejected. The chain
+	// is nil if the peer didn't present one.
+	ValidateChain(p peer.ID, remoteKey crypto.PubKey, chain CertChain) error
+}
+
+// ChainPolicyFunc is a function implementing ChainPolicy.
+type ChainPolicyFunc func(p peer.ID, remoteKey crypto.PubKey, chain CertChain) error
+
+// ValidateChain calls f(p, remoteKey, chain).
+func (f ChainPolicyFunc) ValidateChain(p peer.ID, remoteKey crypto.PubKey, chain CertChain) error {
+	return f(p, remoteKey, chain)
+}
+
+// TrustedRootsPolicy accepts chains that verify and are rooted in one of the
+// trusted keys.
+type TrustedRootsPolicy struct {
+	Roots []crypto.PubKey
+	// AllowMissing accepts peers that don't present a chain at all.
+	AllowMissing bool
+}
+
+// ValidateChain implements ChainPolicy.
+func (p *TrustedRootsPolicy) ValidateChain(_ peer.ID, remoteKey crypto.PubKey, chain CertChain) error {
+	if len(chain) == 0 {
+		if p.AllowMissing {
+			return nil
+		}
+		return ErrUntrustedChain
+	}
+	if err := chain.Verify(remoteKey, time.Now()); err != nil {
+		return err
+	}
+	root := chain.Root()
+	for _, r := range p.Roots {
+		if r.Equals(root) {
+			return nil
+		}
+	}
+	return ErrUntrustedChain
+}
+
+// ChainSecureConn is implemented by secure connections whose handshake carried
+// a certificate chain.
+type ChainSecureConn interface {
+	SecureConn
+
+	// RemoteCertChain returns the chain presented by the remote peer, or nil
+	// if it didn't present one.
+	RemoteCertChain() CertChain
+}
+
+// ChainSecureTransport is implemented by security transports that can carry
+// a certificate chain in their handshake.
+type ChainSecureTransport interface {
+	SecureTransport
+
+	// SetLocalCertChain sets the chain presented to remote peers. It must
+	// end with the transport's identity key.
+	SetLocalCertChain(CertChain) error
+
+	// SetChainPolicy sets the policy applied to the chains presented by
+	// remote peers. Handshakes fail if the policy rejects the chain.
+	SetChainPolicy(ChainPolicy)
+}
+
+// MarshalCertChain serializes the chain, e.g. to send it in a handshake, as
+// the list of the envelopes its certificates are sealed in.
+func MarshalCertChain(ch CertChain) ([]byte, error) {
+	out := make([][]byte, 0, len(ch))
+	for _, c := range ch {
+		if c.envelope == nil {
+			return nil, fmt.Errorf("%w: not signed", ErrInvalidCertificate)
+		}
+		data, err := c.envelope.Marshal()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data)
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalCertChain deserializes a chain serialized with MarshalCertChain.
+// The signature of every certificate is checked, but the chain isn't
+// verified.
+func UnmarshalCertChain(data []byte) (CertChain, error) {
+	var in [][]byte
+	if err := json.Unmarshal(data, &in); err != nil {
+		return nil, err
+	}
+	ch := make(CertChain, 0, len(in))
+	for _, certData := range in {
+		c := new(Certificate)
+		e, err := record.ConsumeTypedEnvelope(certData, c)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCertificate, err)
+		}
+		if !e.PublicKey.Equals(c.Issuer) {
+			return nil, fmt.Errorf("%w: not signed by its issuer", ErrInvalidCertificate)
+		}
+		c.envelope = e
+		ch = append(ch, c)
+	}
+	return ch, nil
+}
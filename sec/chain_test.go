@@ -0,0 +1,102 @@
+package sec_test
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/record"
+	"github.com/libp2p/go-libp2p-core/sec"
+)
+
+func genKey(t *testing.T) (crypto.PrivKey, crypto.PubKey) {
+	sk, pk, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sk, pk
+}
+
+func issue(t *testing.T, issuer crypto.PrivKey, subject crypto.PubKey, attrs map[string]string) *sec.Certificate {
+	c := &sec.Certificate{
+		Subject:    subject,
+		NotBefore:  time.Now().Add(-time.Hour),
+		NotAfter:   time.Now().Add(time.Hour),
+		Attributes: attrs,
+	}
+	if err := c.Sign(issuer); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCertChain(t *testing.T) {
+	operatorSk, operatorPk := genKey(t)
+	intermediateSk, intermediatePk := genKey(t)
+	_, nodePk := genKey(t)
+
+	chain := sec.CertChain{
+		issue(t, operatorSk, intermediatePk, map[string]string{"org": "acme"}),
+		issue(t, intermediateSk, nodePk, nil),
+	}
+
+	data, err := sec.MarshalCertChain(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, err = sec.UnmarshalCertChain(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy := &sec.TrustedRootsPolicy{Roots: []crypto.PubKey{operatorPk}}
+	if err := policy.ValidateChain("", nodePk, chain); err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherPk := genKey(t)
+	if err := chain.Verify(otherPk, time.Now()); err != sec.ErrBrokenChain {
+		t.Fatalf("expected ErrBrokenChain, got %v", err)
+	}
+	if err := (&sec.TrustedRootsPolicy{Roots: []crypto.PubKey{otherPk}}).ValidateChain("", nodePk, chain); err != sec.ErrUntrustedChain {
+		t.Fatalf("expected ErrUntrustedChain, got %v", err)
+	}
+	if err := policy.ValidateChain("", nodePk, nil); err != sec.ErrUntrustedChain {
+		t.Fatalf("expected missing chain to be rejected, got %v", err)
+	}
+
+	chain[0].Attributes["org"] = "evil"
+	if err := chain.Verify(nodePk, time.Now()); !errors.Is(err, sec.ErrInvalidCertificate) {
+		t.Fatalf("expected ErrInvalidCertificate, got %v", err)
+	}
+	chain[0].Attributes["org"] = "acme"
+	if err := chain.Verify(nodePk, time.Now().Add(2*time.Hour)); !errors.Is(err, sec.ErrInvalidCertificate) {
+		t.Fatalf("expected expired chain to be rejected, got %v", err)
+	}
+
+	// Certificates sealed by another key than their issuer's are rejected.
+	forged := &sec.Certificate{
+		Subject:   nodePk,
+		Issuer:    operatorPk,
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+	env, err := record.Seal(forged, intermediateSk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envData, err := env.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = json.Marshal([][]byte{envData})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sec.UnmarshalCertChain(data); !errors.Is(err, sec.ErrInvalidCertificate) {
+		t.Fatalf("expected ErrInvalidCertificate, got %v", err)
+	}
+}
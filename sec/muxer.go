@@ -0,0 +1,91 @@
+package sec
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// ErrNoCommonMuxer is returned by SelectMuxer when the peers don't support a
+// common stream muxer. Security transports negotiating the muxer inline
+// should not fail the handshake on it, but leave the muxer unset, so the
+// upgrader falls back to negotiating it with multistream.
+var ErrNoCommonMuxer = errors.New("no common stream muxer")
+
+// MuxerNegotiator is implemented by security transports able to negotiate the
+// stream muxer inline during the handshake (e.g. as a Noise handshake
+// extension or TLS ALPN), saving the multistream round trip otherwise needed
+// on every new connection. Both sides send the muxers they support and
+// select the same one with SelectMuxer, so the choice is mutually confirmed
+// by the authenticated handshake.
+type MuxerNegotiator interface {
+	SecureTransport
+
+	// SecureInboundWithMuxers secures an inbound connection, offering the
+	// given muxers, most preferred first.
+	SecureInboundWithMuxers(ctx context.Context, insecure net.Conn, muxers []protocol.ID) (SecureConn, error)
+
+	// SecureOutboundWithMuxers secures an outbound connection, offering the
+	// given muxers, most preferred first.
+	SecureOutboundWithMuxers(ctx context.Context, insecure net.Conn, p peer.ID, muxers []protocol.ID) (SecureConn, error)
+}
+
+// MuxerConn is implemented by secure connections surfacing the result of the
+// inline muxer negotiation.
+type MuxerConn interface {
+	SecureConn
+
+	// NegotiatedMuxer returns the muxer selected during the handshake, or
+	// the empty ID if none was.
+	NegotiatedMuxer() protocol.ID
+}
+
+// SecureInboundWithMuxers secures an inbound connection, negotiating the
+// muxer inline if the transport implements MuxerNegotiator.
+func SecureInboundWithMuxers(ctx context.Context, t SecureTransport, insecure net.Conn, muxers []protocol.ID) (SecureConn, error) {
+	if mn, ok := t.(MuxerNegotiator); ok {
+		return mn.SecureInboundWithMuxers(ctx, insecure, muxers)
+	}
+	return t.SecureInbound(ctx, insecure)
+}
+
+// SecureOutboundWithMuxers secures an outbound connection, negotiating the
+// muxer inline if the transport implements MuxerNegotiator.
+func SecureOutboundWithMuxers(ctx context.Context, t SecureTransport, insecure net.Conn, p peer.ID, muxers []protocol.ID) (SecureConn, error) {
+	if mn, ok := t.(MuxerNegotiator); ok {
+		return mn.SecureOutboundWithMuxers(ctx, insecure, p, muxers)
+	}
+	return t.SecureOutbound(ctx, insecure, p)
+}
+
+// NegotiatedMuxer returns the muxer selected during the handshake of the
+// connection. It returns false if the connection doesn't implement MuxerConn
+// or no muxer was selected, in which case the upgrader must negotiate one
+// with multistream.
+func NegotiatedMuxer(c SecureConn) (protocol.ID, bool) {
+	mc, ok := c.(MuxerConn)
+	if !ok {
+		return "", false
+	}
+	m := mc.NegotiatedMuxer()
+	return m, m != ""
+}
+
+// SelectMuxer selects the muxer from the muxers offered by the initiator
+// (outbound side) and the responder (inbound side) of a handshake: the
+// initiator's most preferred muxer also supported by the responder. Both
+// sides reach the same result, whichever calls it. It returns
+// ErrNoCommonMuxer if no muxer is supported by both.
+func SelectMuxer(initiator, responder []protocol.ID) (protocol.ID, error) {
+	for _, m := range initiator {
+		for _, r := range responder {
+			if m == r {
+				return m, nil
+			}
+		}
+	}
+	return "", ErrNoCommonMuxer
+}
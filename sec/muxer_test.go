@@ -0,0 +1,42 @@
+package sec_test
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/libp2p/go-libp2p-core/sec"
+)
+
+type muxerConn struct {
+	sec.SecureConn
+	muxer protocol.ID
+}
+
+func (c muxerConn) NegotiatedMuxer() protocol.ID { return c.muxer }
+
+func TestSelectMuxer(t *testing.T) {
+	const (
+		yamux  protocol.ID = "/yamux/1.0.0"
+		mplex  protocol.ID = "/mplex/6.7.0"
+		custom protocol.ID = "/custom/1.0.0"
+	)
+	if m, err := sec.SelectMuxer([]protocol.ID{yamux, mplex}, []protocol.ID{mplex, yamux}); err != nil || m != yamux {
+		t.Fatalf("expected the initiator's preference, got %s, %v", m, err)
+	}
+	if m, err := sec.SelectMuxer([]protocol.ID{custom, mplex}, []protocol.ID{yamux, mplex}); err != nil || m != mplex {
+		t.Fatalf("expected mplex, got %s, %v", m, err)
+	}
+	if _, err := sec.SelectMuxer([]protocol.ID{custom}, []protocol.ID{yamux}); err != sec.ErrNoCommonMuxer {
+		t.Fatalf("expected ErrNoCommonMuxer, got %v", err)
+	}
+
+	if m, ok := sec.NegotiatedMuxer(muxerConn{muxer: yamux}); !ok || m != yamux {
+		t.Fatalf("expected yamux, got %s", m)
+	}
+	if _, ok := sec.NegotiatedMuxer(muxerConn{}); ok {
+		t.Fatal("expected no muxer to be negotiated")
+	}
+	if _, ok := sec.NegotiatedMuxer(struct{ sec.SecureConn }{}); ok {
+		t.Fatal("expected no muxer without MuxerConn")
+	}
+}
@@ -0,0 +1,121 @@
+package sec
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// ErrSecurityPolicy is returned when the security protocols available, or the
+// one negotiated, don't satisfy the security policy for a peer.
+var ErrSecurityPolicy = errors.New("security protocol rejected by policy")
+
+// SecurityPolicy expresses per-peer security preferences, e.g. requiring TLS
+// for the peers of a compliance-bound fleet and preferring Noise otherwise.
+// It's consulted by the upgrader: before an outbound handshake, to order (and
+// restrict) the protocols offered; and after an inbound handshake, once the
+// remote peer is known, to check the protocol negotiated.
+type SecurityPolicy interface {
+	// SecurityPreference returns the security protocols acceptable for the
+	// peer, most preferred first. A nil slice means the peer has no
+	// preference, and all the transport's protocols are acceptable in
+	// their default order.
+	SecurityPreference(p peer.ID) []protocol.ID
+}
+
+// SecurityPolicyFunc is a function implementing SecurityPolicy.
+type SecurityPolicyFunc func(p peer.ID) []protocol.ID
+
+// SecurityPreference calls f(p).
+func (f SecurityPolicyFunc) SecurityPreference(p peer.ID) []protocol.ID {
+	return f(p)
+}
+
+// SecurityRule applies a preference to the peers it matches.
+type SecurityRule struct {
+	// Match returns true for the peers the rule applies to.
+	Match func(peer.ID) bool
+	// Protocols are the acceptable protocols, most preferred first.
+	Protocols []protocol.ID
+}
+
+// RuleSecurityPolicy is a SecurityPolicy applying the first matching rule,
+// or Default if no rule matches.
+type RuleSecurityPolicy struct {
+	Rules   []SecurityRule
+	Default []protocol.ID
+}
+
+var _ SecurityPolicy = (*RuleSecurityPolicy)(nil)
+
+// SecurityPreference implements SecurityPolicy.
+func (p *RuleSecurityPolicy) SecurityPreference(id peer.ID) []protocol.ID {
+	for _, r := range p.Rules {
+		if r.Match(id) {
+			return r.Protocols
+		}
+	}
+	return p.Default
+}
+
+// MatchPeers returns a SecurityRule matcher for the given peers.
+func MatchPeers(peers ...peer.ID) func(peer.ID) bool {
+	set := make(map[peer.ID]struct{}, len(peers))
+	for _, p := range peers {
+		set[p] = struct{}{}
+	}
+	return func(p peer.ID) bool {
+		_, ok := set[p]
+		return ok
+	}
+}
+
+// OrderSecurityProtocols returns the available protocols acceptable for the
+// peer, in order of preference. Protocols not available are skipped. It
+// returns ErrSecurityPolicy if none is acceptable.
+//
+// A nil policy, or one without a preference for the peer, leaves available
+// unchanged.
+func OrderSecurityProtocols(policy SecurityPolicy, p peer.ID, available []protocol.ID) ([]protocol.ID, error) {
+	if policy == nil {
+		return available, nil
+	}
+	pref := policy.SecurityPreference(p)
+	if pref == nil {
+		return available, nil
+	}
+	ordered := make([]protocol.ID, 0, len(pref))
+	for _, proto := range pref {
+		for _, a := range available {
+			if a == proto {
+				ordered = append(ordered, proto)
+				break
+			}
+		}
+	}
+	if len(ordered) == 0 {
+		return nil, fmt.Errorf("%w: no acceptable protocol available for peer %s", ErrSecurityPolicy, p)
+	}
+	return ordered, nil
+}
+
+// CheckSecurityProtocol returns ErrSecurityPolicy if the protocol negotiated
+// with the peer isn't acceptable. Upgraders call it after inbound handshakes,
+// where the remote peer isn't known in advance.
+func CheckSecurityProtocol(policy SecurityPolicy, p peer.ID, proto protocol.ID) error {
+	if policy == nil {
+		return nil
+	}
+	pref := policy.SecurityPreference(p)
+	if pref == nil {
+		return nil
+	}
+	for _, a := range pref {
+		if a == proto {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s isn't acceptable for peer %s", ErrSecurityPolicy, proto, p)
+}
@@ -0,0 +1,45 @@
+package sec_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/libp2p/go-libp2p-core/sec"
+)
+
+func TestSecurityPolicy(t *testing.T) {
+	const (
+		tls   protocol.ID = "/tls/1.0.0"
+		noise protocol.ID = "/noise"
+	)
+	policy := &sec.RuleSecurityPolicy{
+		Rules:   []sec.SecurityRule{{Match: sec.MatchPeers("regulated"), Protocols: []protocol.ID{tls}}},
+		Default: []protocol.ID{noise, tls},
+	}
+	available := []protocol.ID{tls, noise}
+
+	ordered, err := sec.OrderSecurityProtocols(policy, "other", available)
+	if err != nil || !reflect.DeepEqual(ordered, []protocol.ID{noise, tls}) {
+		t.Fatalf("unexpected order %v, %v", ordered, err)
+	}
+	ordered, err = sec.OrderSecurityProtocols(policy, "regulated", available)
+	if err != nil || !reflect.DeepEqual(ordered, []protocol.ID{tls}) {
+		t.Fatalf("unexpected order %v, %v", ordered, err)
+	}
+	if _, err := sec.OrderSecurityProtocols(policy, "regulated", []protocol.ID{noise}); !errors.Is(err, sec.ErrSecurityPolicy) {
+		t.Fatalf("expected ErrSecurityPolicy, got %v", err)
+	}
+	if ordered, _ := sec.OrderSecurityProtocols(nil, "other", available); !reflect.DeepEqual(ordered, available) {
+		t.Fatalf("expected no policy to keep the order, got %v", ordered)
+	}
+
+	if err := sec.CheckSecurityProtocol(policy, "regulated", noise); !errors.Is(err, sec.ErrSecurityPolicy) {
+		t.Fatalf("expected ErrSecurityPolicy, got %v", err)
+	}
+	if err := sec.CheckSecurityProtocol(policy, peer.ID("other"), noise); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"net"
 
+	"github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/libp2p/go-libp2p-core/network"
 	"github.com/libp2p/go-libp2p-core/peer"
 )
@@ -17,6 +18,10 @@ type SecureConn interface {
 
 // A SecureTransport turns inbound and outbound unauthenticated,
 // plain-text, native connections into authenticated, encrypted connections.
+//
+// Transports must sign with the identity key through SignHandshake, so that
+// keys restricted with crypto.RestrictKeyUsage are only used for handshakes
+// if they allow crypto.UsageHandshake.
 type SecureTransport interface {
 	// SecureInbound secures an inbound connection.
 	SecureInbound(ctx context.Context, insecure net.Conn) (SecureConn, error)
@@ -24,3 +29,10 @@ type SecureTransport interface {
 	// SecureOutbound secures an outbound connection.
 	SecureOutbound(ctx context.Context, insecure net.Conn, p peer.ID) (SecureConn, error)
 }
+
+// SignHandshake signs the handshake data with the identity key, for
+// crypto.UsageHandshake. It returns an error wrapping crypto.ErrKeyUsage if
+// the key is restricted to other usages.
+func SignHandshake(k crypto.PrivKey, data []byte) ([]byte, error) {
+	return crypto.SignWithUsage(k, crypto.UsageHandshake, data)
+}
@@ -0,0 +1,32 @@
+package sec_test
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/sec"
+)
+
+func TestSignHandshake(t *testing.T) {
+	priv, pub, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("handshake payload")
+
+	k := crypto.RestrictKeyUsage(priv, crypto.UsageHandshake)
+	sig, err := sec.SignHandshake(k, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := pub.Verify(data, sig); err != nil || !ok {
+		t.Fatalf("expected a valid signature, got %v", err)
+	}
+
+	k = crypto.RestrictKeyUsage(priv, crypto.UsageRecordSigning)
+	if _, err := sec.SignHandshake(k, data); !errors.Is(err, crypto.ErrKeyUsage) {
+		t.Fatalf("expected ErrKeyUsage, got %v", err)
+	}
+}
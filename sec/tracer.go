@@ -0,0 +1,208 @@
+package sec
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+)
+
+// ErrPeerIDMismatch should be returned by security transports when the remote
+// peer authenticated as a different peer than the one we dialed.
+var ErrPeerIDMismatch = errors.New("sec: remote peer ID does not match the dialed peer")
+
+// FailureClass categorizes handshake failures.
+type FailureClass int
+
+const (
+	// FailureUnknown is any failure that doesn't fit another class.
+	FailureUnknown FailureClass = iota
+	// FailureTimeout means the handshake didn't complete in time.
+	FailureTimeout
+	// FailureCanceled means the handshake context was canceled.
+	FailureCanceled
+	// FailurePeerIDMismatch means the remote authenticated as an unexpected
+	// peer.
+	FailurePeerIDMismatch
+	// FailureConnection means the underlying connection failed (reset,
+	// closed, EOF).
+	FailureConnection
+)
+
+func (c FailureClass) String() string {
+	switch c {
+	case FailureTimeout:
+		return "timeout"
+	case FailureCanceled:
+		return "canceled"
+	case FailurePeerIDMismatch:
+		return "peer-id-mismatch"
+	case FailureConnection:
+		return "connection"
+	default:
+		return "unknown"
+	}
+}
+
+// ClassifyFailure returns the FailureClass of an error returned by a
+// handshake.
+func ClassifyFailure(err error) FailureClass {
+	switch {
+	case err == nil:
+		return FailureUnknown
+	case errors.Is(err, context.DeadlineExceeded):
+		return FailureTimeout
+	case errors.Is(err, context.Canceled):
+		return FailureCanceled
+	case errors.Is(err, ErrPeerIDMismatch):
+		return FailurePeerIDMismatch
+	}
+	var nerr net.Error
+	if errors.As(err, &nerr) {
+		if nerr.Timeout() {
+			return FailureTimeout
+		}
+		return FailureConnection
+	}
+	return FailureUnknown
+}
+
+// HandshakeInfo describes a security handshake.
+type HandshakeInfo struct {
+	// Protocol is the ID of the security protocol (e.g. "/tls/1.0.0").
+	Protocol string
+	// Direction is DirOutbound for SecureOutbound and DirInbound for
+	// SecureInbound.
+	Direction network.Direction
+	// RemotePeer is the expected peer for outbound handshakes and the
+	// authenticated peer, once known, for inbound ones.
+	RemotePeer peer.ID
+	// RemoteAddr is the address of the remote end of the insecure
+	// connection.
+	RemoteAddr net.Addr
+}
+
+// Tracer receives handshake events from a security transport.
+//
+// Calls are made synchronously from the handshake goroutine, so
+// implementations must be fast and must not block.
+type Tracer interface {
+	// HandshakeStarted is called before the handshake starts.
+	HandshakeStarted(HandshakeInfo)
+	// HandshakeCompleted is called after a successful handshake.
+	HandshakeCompleted(HandshakeInfo, time.Duration)
+	// HandshakeFailed is called after a failed handshake.
+	HandshakeFailed(HandshakeInfo, time.Duration, FailureClass, error)
+}
+
+type tracedTransport struct {
+	SecureTransport
+
+	proto  string
+	tracer Tracer
+}
+
+// NewTracedTransport wraps a SecureTransport so that every handshake it
+// performs is reported to the tracer under the given protocol ID.
+//
+// The returned transport implements MuxerNegotiator and ChainSecureTransport
+// if t does, tracing the inline muxer negotiation handshakes as well.
+func NewTracedTransport(proto string, t SecureTransport, tracer Tracer) SecureTransport {
+	tt := &tracedTransport{SecureTransport: t, proto: proto, tracer: tracer}
+	_, muxers := t.(MuxerNegotiator)
+	cs, chain := t.(ChainSecureTransport)
+	switch {
+	case muxers && chain:
+		return &tracedChainMuxerTransport{tracedMuxerTransport{tt}, cs}
+	case muxers:
+		return &tracedMuxerTransport{tt}
+	case chain:
+		return &tracedChainTransport{tt, cs}
+	default:
+		return tt
+	}
+}
+
+func (t *tracedTransport) SecureInbound(ctx context.Context, insecure net.Conn) (SecureConn, error) {
+	return t.trace(t.info(network.DirInbound, insecure, ""), func() (SecureConn, error) {
+		return t.SecureTransport.SecureInbound(ctx, insecure)
+	})
+}
+
+func (t *tracedTransport) SecureOutbound(ctx context.Context, insecure net.Conn, p peer.ID) (SecureConn, error) {
+	return t.trace(t.info(network.DirOutbound, insecure, p), func() (SecureConn, error) {
+		return t.SecureTransport.SecureOutbound(ctx, insecure, p)
+	})
+}
+
+func (t *tracedTransport) info(dir network.Direction, insecure net.Conn, p peer.ID) HandshakeInfo {
+	return HandshakeInfo{
+		Protocol:   t.proto,
+		Direction:  dir,
+		RemotePeer: p,
+		RemoteAddr: insecure.RemoteAddr(),
+	}
+}
+
+func (t *tracedTransport) trace(info HandshakeInfo, handshake func() (SecureConn, error)) (SecureConn, error) {
+	start := time.Now()
+	t.tracer.HandshakeStarted(info)
+	sconn, err := handshake()
+	elapsed := time.Since(start)
+	if err != nil {
+		t.tracer.HandshakeFailed(info, elapsed, ClassifyFailure(err), err)
+		return sconn, err
+	}
+	info.RemotePeer = sconn.RemotePeer()
+	t.tracer.HandshakeCompleted(info, elapsed)
+	return sconn, err
+}
+
+// tracedMuxerTransport is a tracedTransport wrapping a MuxerNegotiator.
+type tracedMuxerTransport struct {
+	*tracedTransport
+}
+
+var _ MuxerNegotiator = (*tracedMuxerTransport)(nil)
+
+func (t *tracedMuxerTransport) SecureInboundWithMuxers(ctx context.Context, insecure net.Conn, muxers []protocol.ID) (SecureConn, error) {
+	return t.trace(t.info(network.DirInbound, insecure, ""), func() (SecureConn, error) {
+		return t.SecureTransport.(MuxerNegotiator).SecureInboundWithMuxers(ctx, insecure, muxers)
+	})
+}
+
+func (t *tracedMuxerTransport) SecureOutboundWithMuxers(ctx context.Context, insecure net.Conn, p peer.ID, muxers []protocol.ID) (SecureConn, error) {
+	return t.trace(t.info(network.DirOutbound, insecure, p), func() (SecureConn, error) {
+		return t.SecureTransport.(MuxerNegotiator).SecureOutboundWithMuxers(ctx, insecure, p, muxers)
+	})
+}
+
+// chainSetter holds the methods ChainSecureTransport adds to SecureTransport.
+type chainSetter interface {
+	SetLocalCertChain(CertChain) error
+	SetChainPolicy(ChainPolicy)
+}
+
+// tracedChainTransport is a tracedTransport wrapping a ChainSecureTransport.
+type tracedChainTransport struct {
+	*tracedTransport
+	chainSetter
+}
+
+var _ ChainSecureTransport = (*tracedChainTransport)(nil)
+
+// tracedChainMuxerTransport is a tracedTransport wrapping a transport
+// implementing both MuxerNegotiator and ChainSecureTransport.
+type tracedChainMuxerTransport struct {
+	tracedMuxerTransport
+	chainSetter
+}
+
+var (
+	_ MuxerNegotiator      = (*tracedChainMuxerTransport)(nil)
+	_ ChainSecureTransport = (*tracedChainMuxerTransport)(nil)
+)
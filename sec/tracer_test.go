@@ -0,0 +1,147 @@
+package sec_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/protocol"
+	"github.com/libp2p/go-libp2p-core/sec"
+	"github.com/libp2p/go-libp2p-core/sec/insecure"
+)
+
+type recordingTracer struct {
+	started   []sec.HandshakeInfo
+	completed []sec.HandshakeInfo
+	failed    []sec.FailureClass
+}
+
+func (r *recordingTracer) HandshakeStarted(i sec.HandshakeInfo) { r.started = append(r.started, i) }
+func (r *recordingTracer) HandshakeCompleted(i sec.HandshakeInfo, _ time.Duration) {
+	r.completed = append(r.completed, i)
+}
+func (r *recordingTracer) HandshakeFailed(_ sec.HandshakeInfo, _ time.Duration, c sec.FailureClass, _ error) {
+	r.failed = append(r.failed, c)
+}
+
+type failingTransport struct{ err error }
+
+func (f failingTransport) SecureInbound(context.Context, net.Conn) (sec.SecureConn, error) {
+	return nil, f.err
+}
+func (f failingTransport) SecureOutbound(context.Context, net.Conn, peer.ID) (sec.SecureConn, error) {
+	return nil, f.err
+}
+
+func TestTracedTransport(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	tracer := new(recordingTracer)
+	tpt := sec.NewTracedTransport(insecure.ID, insecure.New("local"), tracer)
+	if _, err := tpt.SecureOutbound(context.Background(), a, "remote"); err != nil {
+		t.Fatal(err)
+	}
+	if len(tracer.started) != 1 || len(tracer.completed) != 1 {
+		t.Fatal("expected one started and one completed event")
+	}
+	if tracer.completed[0].RemotePeer != "remote" || tracer.completed[0].Protocol != insecure.ID {
+		t.Fatalf("unexpected handshake info %+v", tracer.completed[0])
+	}
+
+	tpt = sec.NewTracedTransport("/fail", failingTransport{context.DeadlineExceeded}, tracer)
+	if _, err := tpt.SecureInbound(context.Background(), b); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(tracer.failed) != 1 || tracer.failed[0] != sec.FailureTimeout {
+		t.Fatalf("expected a timeout failure, got %v", tracer.failed)
+	}
+}
+
+// inlineMuxerTransport negotiates the first offered muxer inline and accepts
+// certificate chains.
+type inlineMuxerTransport struct {
+	*insecure.Transport
+	policy sec.ChainPolicy
+}
+
+func (t *inlineMuxerTransport) SecureInboundWithMuxers(ctx context.Context, c net.Conn, muxers []protocol.ID) (sec.SecureConn, error) {
+	sconn, err := t.SecureInbound(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	return muxerConn{sconn, muxers[0]}, nil
+}
+
+func (t *inlineMuxerTransport) SecureOutboundWithMuxers(ctx context.Context, c net.Conn, p peer.ID, muxers []protocol.ID) (sec.SecureConn, error) {
+	sconn, err := t.SecureOutbound(ctx, c, p)
+	if err != nil {
+		return nil, err
+	}
+	return muxerConn{sconn, muxers[0]}, nil
+}
+
+func (t *inlineMuxerTransport) SetLocalCertChain(sec.CertChain) error { return nil }
+func (t *inlineMuxerTransport) SetChainPolicy(p sec.ChainPolicy)      { t.policy = p }
+
+func TestTracedTransportInlineMuxer(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	const yamux protocol.ID = "/yamux/1.0.0"
+	inner := &inlineMuxerTransport{Transport: insecure.New("local")}
+	tracer := new(recordingTracer)
+	tpt := sec.NewTracedTransport(insecure.ID, inner, tracer)
+	if _, ok := tpt.(sec.MuxerNegotiator); !ok {
+		t.Fatal("expected the traced transport to negotiate muxers inline")
+	}
+	sconn, err := sec.SecureOutboundWithMuxers(context.Background(), tpt, a, "remote", []protocol.ID{yamux})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m, ok := sec.NegotiatedMuxer(sconn); !ok || m != yamux {
+		t.Fatalf("expected yamux to be negotiated inline, got %q", m)
+	}
+	if len(tracer.started) != 1 || len(tracer.completed) != 1 {
+		t.Fatal("expected the inline muxer handshake to be traced")
+	}
+	if tracer.completed[0].Direction != network.DirOutbound || tracer.completed[0].RemotePeer != "remote" {
+		t.Fatalf("unexpected handshake info %+v", tracer.completed[0])
+	}
+
+	cst, ok := tpt.(sec.ChainSecureTransport)
+	if !ok {
+		t.Fatal("expected the traced transport to accept certificate chains")
+	}
+	cst.SetChainPolicy(&sec.TrustedRootsPolicy{})
+	if inner.policy == nil {
+		t.Fatal("expected the chain policy to be forwarded")
+	}
+
+	tpt = sec.NewTracedTransport(insecure.ID, insecure.New("local"), tracer)
+	if _, ok := tpt.(sec.MuxerNegotiator); ok {
+		t.Fatal("didn't expect a plain transport to negotiate muxers inline")
+	}
+	if _, ok := tpt.(sec.ChainSecureTransport); ok {
+		t.Fatal("didn't expect a plain transport to accept certificate chains")
+	}
+}
+
+func TestClassifyFailure(t *testing.T) {
+	for err, class := range map[error]sec.FailureClass{
+		context.Canceled: sec.FailureCanceled,
+		fmt.Errorf("wrapped: %w", sec.ErrPeerIDMismatch): sec.FailurePeerIDMismatch,
+		errors.New("boom"): sec.FailureUnknown,
+	} {
+		if c := sec.ClassifyFailure(err); c != class {
+			t.Errorf("expected %s for %q, got %s", class, err, c)
+		}
+	}
+}
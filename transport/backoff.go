@@ -0,0 +1,140 @@
+package transport
+
+import (
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+var (
+	// BackoffBase is the delay applied by NewExponentialBackoff after the
+	// first failed dial to an address. It doubles with every further
+	// failure.
+	BackoffBase = 5 * time.Second
+
+	// BackoffMax is the upper bound on the delay applied by
+	// NewExponentialBackoff.
+	BackoffMax = 5 * time.Minute
+)
+
+// Backoff tracks failed dials so repeated dials to dead addresses are
+// suppressed consistently by transports and the swarm. It acts as a circuit
+// breaker per peer and address: after a failure, the address isn't dialed
+// again until NextAttempt, and a success closes the circuit.
+//
+// Implementations must be safe for concurrent use.
+type Backoff interface {
+	// RecordFailure records a failed dial to the address of the peer.
+	RecordFailure(p peer.ID, a ma.Multiaddr)
+
+	// RecordSuccess records a successful dial, resetting the backoff.
+	RecordSuccess(p peer.ID, a ma.Multiaddr)
+
+	// NextAttempt returns the earliest time the address of the peer may be
+	// dialed again. The zero time means it may be dialed right away.
+	NextAttempt(p peer.ID, a ma.Multiaddr) time.Time
+}
+
+// CanDial returns true if the backoff allows dialing the address of the peer
+// at time now.
+func CanDial(b Backoff, p peer.ID, a ma.Multiaddr, now time.Time) bool {
+	return !now.Before(b.NextAttempt(p, a))
+}
+
+type backoffKey struct {
+	p    peer.ID
+	addr string
+}
+
+type backoffState struct {
+	failures int
+	next     time.Time
+}
+
+// ExponentialBackoff is a Backoff doubling the delay after each consecutive
+// failure, from a base delay up to a maximum.
+type ExponentialBackoff struct {
+	base, max time.Duration
+	clock     network.Clock
+
+	lk    sync.Mutex
+	state map[backoffKey]*backoffState
+}
+
+var _ Backoff = (*ExponentialBackoff)(nil)
+
+// NewExponentialBackoff creates an ExponentialBackoff using BackoffBase and
+// BackoffMax.
+func NewExponentialBackoff() *ExponentialBackoff {
+	return &ExponentialBackoff{
+		base:  BackoffBase,
+		max:   BackoffMax,
+		clock: network.RealClock,
+		state: make(map[backoffKey]*backoffState),
+	}
+}
+
+// SetClock sets the clock the delays are computed from, network.RealClock by
+// default. It must be called before the backoff is used.
+func (b *ExponentialBackoff) SetClock(c network.Clock) {
+	b.clock = c
+}
+
+func (b *ExponentialBackoff) key(p peer.ID, a ma.Multiaddr) backoffKey {
+	return backoffKey{p: p, addr: string(a.Bytes())}
+}
+
+// RecordFailure implements Backoff.
+func (b *ExponentialBackoff) RecordFailure(p peer.ID, a ma.Multiaddr) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	k := b.key(p, a)
+	s, ok := b.state[k]
+	if !ok {
+		s = new(backoffState)
+		b.state[k] = s
+	}
+	s.failures++
+	delay := b.base
+	for i := 1; i < s.failures && delay < b.max; i++ {
+		delay *= 2
+	}
+	if delay > b.max {
+		delay = b.max
+	}
+	s.next = b.clock.Now().Add(delay)
+}
+
+// RecordSuccess implements Backoff.
+func (b *ExponentialBackoff) RecordSuccess(p peer.ID, a ma.Multiaddr) {
+	b.lk.Lock()
+	delete(b.state, b.key(p, a))
+	b.lk.Unlock()
+}
+
+// NextAttempt implements Backoff.
+func (b *ExponentialBackoff) NextAttempt(p peer.ID, a ma.Multiaddr) time.Time {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+	if s, ok := b.state[b.key(p, a)]; ok {
+		return s.next
+	}
+	return time.Time{}
+}
+
+// Prune forgets the addresses whose backoff expired before now, so the state
+// doesn't grow without bounds.
+func (b *ExponentialBackoff) Prune(now time.Time) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+	for k, s := range b.state {
+		if now.After(s.next) {
+			delete(b.state, k)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package transport
+
+import (
+	"testing"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	b := NewExponentialBackoff()
+	addr := ma.StringCast("/ip4/1.2.3.4/tcp/1")
+	other := ma.StringCast("/ip4/1.2.3.4/tcp/2")
+
+	if !CanDial(b, "peer", addr, time.Now()) {
+		t.Fatal("expected a fresh address to be dialable")
+	}
+
+	b.RecordFailure("peer", addr)
+	first := b.NextAttempt("peer", addr)
+	if CanDial(b, "peer", addr, time.Now()) {
+		t.Fatal("expected the address to be backed off")
+	}
+	if !CanDial(b, "peer", other, time.Now()) || !CanDial(b, "other", addr, time.Now()) {
+		t.Fatal("backoff must be per peer and address")
+	}
+
+	b.RecordFailure("peer", addr)
+	if second := b.NextAttempt("peer", addr); second.Sub(first) < BackoffBase/2 {
+		t.Fatal("expected the delay to grow")
+	}
+
+	for i := 0; i < 20; i++ {
+		b.RecordFailure("peer", addr)
+	}
+	if d := time.Until(b.NextAttempt("peer", addr)); d > BackoffMax {
+		t.Fatalf("delay exceeds the maximum: %s", d)
+	}
+
+	b.RecordSuccess("peer", addr)
+	if !CanDial(b, "peer", addr, time.Now()) {
+		t.Fatal("expected success to reset the backoff")
+	}
+
+	b.RecordFailure("peer", addr)
+	b.Prune(time.Now().Add(BackoffMax + time.Second))
+	if !b.NextAttempt("peer", addr).IsZero() {
+		t.Fatal("expected expired state to be pruned")
+	}
+}
@@ -0,0 +1,87 @@
+package transport
+
+import (
+	"context"
+	"sync"
+
+	mh "github.com/multiformats/go-multihash"
+)
+
+// CertManager manages the self-signed certificates of browser transports
+// (WebRTC, WebTransport). Browsers can't validate these certificates against a
+// CA; instead, they check them against the hashes advertised in the node's
+// addresses (the /certhash components), so the advertised hashes must stay in
+// sync with the certificates across rotations.
+//
+// Implementations must be safe for concurrent use.
+type CertManager interface {
+	// CertHashes returns the hashes of the certificates currently accepted
+	// by the transport. During a rotation, this includes both the outgoing
+	// and the incoming certificate, so browsers holding either set of
+	// addresses can connect.
+	CertHashes() []mh.Multihash
+
+	// Rotate generates a new certificate, starts serving it, and retires
+	// the oldest one.
+	Rotate(ctx context.Context) error
+
+	// NotifyCertHashes registers a function called with the new set of
+	// hashes each time it changes, so the address pipeline can update the
+	// advertised addresses.
+	NotifyCertHashes(func([]mh.Multihash))
+}
+
+// CertHashSet is a concurrency-safe set of certificate hashes notifying
+// listeners of changes. It's meant to be used by CertManager implementations.
+// The zero value is ready to use.
+type CertHashSet struct {
+	mu        sync.Mutex
+	hashes    []mh.Multihash
+	listeners []func([]mh.Multihash)
+}
+
+// CertHashes returns a copy of the current hashes.
+func (s *CertHashSet) CertHashes() []mh.Multihash {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]mh.Multihash(nil), s.hashes...)
+}
+
+// Set replaces the hashes, and notifies the listeners.
+func (s *CertHashSet) Set(hashes []mh.Multihash) {
+	s.update(func([]mh.Multihash) []mh.Multihash {
+		return append([]mh.Multihash(nil), hashes...)
+	})
+}
+
+// Rotate adds the hash of a new certificate and retires the oldest hashes so
+// that at most keep remain, and notifies the listeners.
+func (s *CertHashSet) Rotate(next mh.Multihash, keep int) {
+	s.update(func(cur []mh.Multihash) []mh.Multihash {
+		hashes := append(append([]mh.Multihash(nil), cur...), next)
+		if keep > 0 && len(hashes) > keep {
+			hashes = hashes[len(hashes)-keep:]
+		}
+		return hashes
+	})
+}
+
+func (s *CertHashSet) update(f func([]mh.Multihash) []mh.Multihash) {
+	s.mu.Lock()
+	s.hashes = f(s.hashes)
+	hashes := s.hashes
+	listeners := append(([]func([]mh.Multihash))(nil), s.listeners...)
+	s.mu.Unlock()
+
+	for _, l := range listeners {
+		l(append([]mh.Multihash(nil), hashes...))
+	}
+}
+
+// NotifyCertHashes registers a function called with the new hashes each time
+// they change.
+func (s *CertHashSet) NotifyCertHashes(f func([]mh.Multihash)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, f)
+}
@@ -0,0 +1,34 @@
+package transport
+
+import (
+	"testing"
+
+	mh "github.com/multiformats/go-multihash"
+)
+
+func TestCertHashSet(t *testing.T) {
+	hash := func(s string) mh.Multihash {
+		h, err := mh.Sum([]byte(s), mh.SHA2_256, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	a, b, c := hash("a"), hash("b"), hash("c")
+
+	var s CertHashSet
+	var notified [][]mh.Multihash
+	s.NotifyCertHashes(func(hs []mh.Multihash) { notified = append(notified, hs) })
+
+	s.Set([]mh.Multihash{a})
+	s.Rotate(b, 2)
+	s.Rotate(c, 2)
+
+	got := s.CertHashes()
+	if len(got) != 2 || got[0].B58String() != b.B58String() || got[1].B58String() != c.B58String() {
+		t.Fatalf("unexpected hashes after rotation: %v", got)
+	}
+	if len(notified) != 3 || len(notified[1]) != 2 {
+		t.Fatalf("unexpected notifications: %v", notified)
+	}
+}
@@ -0,0 +1,96 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/record"
+)
+
+// RedirectDomain is the signature domain of redirect records.
+const RedirectDomain = "libp2p-redirect-record"
+
+// RedirectCodec is the payload type of redirect records.
+var RedirectCodec = []byte("/libp2p/redirect-record")
+
+func init() {
+	record.RegisterType(&RedirectRecord{})
+}
+
+// RedirectRecord is sent by a draining listener to the peers connecting to
+// it, pointing them to other nodes (e.g. the other replicas of a service)
+// while it restarts. It's sealed in an envelope signed by the draining node.
+type RedirectRecord struct {
+	// Peers are the nodes to connect to instead.
+	Peers []peer.AddrInfo
+	// RetryAfter is how long until the draining node is expected to accept
+	// connections again. Zero means unknown.
+	RetryAfter time.Duration `json:",omitempty"`
+}
+
+var _ record.Record = (*RedirectRecord)(nil)
+
+// Domain implements record.Record.
+func (r *RedirectRecord) Domain() string { return RedirectDomain }
+
+// Codec implements record.Record.
+func (r *RedirectRecord) Codec() []byte { return RedirectCodec }
+
+// MarshalRecord implements record.Record.
+func (r *RedirectRecord) MarshalRecord() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// UnmarshalRecord implements record.Record.
+func (r *RedirectRecord) UnmarshalRecord(data []byte) error {
+	return json.Unmarshal(data, r)
+}
+
+// DrainableListener is a Listener that can be drained for rolling restarts,
+// without dropping its users abruptly.
+//
+// Listeners aren't required to implement this interface. Use Drain to drain
+// any listener.
+type DrainableListener interface {
+	Listener
+
+	// Drain stops accepting connections and waits until the connections
+	// accepted by the listener are closed, or the context is done, then
+	// closes the listener. Accept returns an error once draining starts.
+	//
+	// If redirect isn't nil, it holds a RedirectRecord sent to the peers
+	// connecting while the listener drains, instead of completing their
+	// handshake.
+	//
+	// Drain returns the context's error if connections were still open
+	// when it was done; they are left open for the caller to close.
+	Drain(ctx context.Context, redirect *record.Envelope) error
+}
+
+// Drain drains the listener if it implements DrainableListener. Otherwise, it
+// closes it right away, and the redirect isn't sent.
+func Drain(ctx context.Context, l Listener, redirect *record.Envelope) error {
+	if dl, ok := l.(DrainableListener); ok {
+		return dl.Drain(ctx, redirect)
+	}
+	return l.Close()
+}
+
+// ConsumeRedirect verifies a serialized envelope holding a redirect record, as
+// received when connecting to a draining node, and returns the record along
+// with the node that signed it. Records revoked according to the checker are
+// rejected; a nil checker disables revocation checks.
+func ConsumeRedirect(data []byte, c record.RevocationChecker) (*RedirectRecord, peer.ID, error) {
+	rec := new(RedirectRecord)
+	e, err := record.ConsumeCheckedTypedEnvelope(data, rec, c)
+	if err != nil {
+		return nil, "", err
+	}
+	signer, err := peer.IDFromPublicKey(e.PublicKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return rec, signer, nil
+}
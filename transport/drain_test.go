@@ -0,0 +1,108 @@
+package transport
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/record"
+)
+
+type closeListener struct {
+	Listener
+	closed bool
+}
+
+func (l *closeListener) Close() error {
+	l.closed = true
+	return nil
+}
+
+type drainListener struct {
+	closeListener
+	redirect *record.Envelope
+}
+
+func (l *drainListener) Drain(_ context.Context, redirect *record.Envelope) error {
+	l.redirect = redirect
+	return l.Close()
+}
+
+func TestDrain(t *testing.T) {
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirect, err := record.Seal(&RedirectRecord{RetryAfter: time.Minute}, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain := new(closeListener)
+	if err := Drain(context.Background(), plain, redirect); err != nil || !plain.closed {
+		t.Fatalf("expected the listener to be closed, got %v", err)
+	}
+
+	dl := new(drainListener)
+	if err := Drain(context.Background(), dl, redirect); err != nil || !dl.closed || dl.redirect != redirect {
+		t.Fatalf("expected the listener to be drained, got %v", err)
+	}
+
+	data, err := redirect.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, rec, err := record.ConsumeEnvelope(data, RedirectDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := rec.(*RedirectRecord); !ok || r.RetryAfter != time.Minute {
+		t.Fatalf("unexpected record %#v", rec)
+	}
+}
+
+func TestConsumeRedirect(t *testing.T) {
+	sk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seal := func(rec record.Record) ([]byte, *record.Envelope) {
+		e, err := record.Seal(rec, sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := e.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data, e
+	}
+
+	data, e := seal(&RedirectRecord{RetryAfter: time.Minute})
+	list := record.NewRevocationList()
+	rec, signer, err := ConsumeRedirect(data, list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.RetryAfter != time.Minute || !signer.MatchesPrivateKey(sk) {
+		t.Fatalf("unexpected record %#v from %s", rec, signer)
+	}
+
+	h, err := record.EnvelopeHash(e, RedirectDomain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev, _ := seal(&record.RevocationRecord{EnvelopeHash: h})
+	if err := list.Add(rev); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ConsumeRedirect(data, list); !errors.Is(err, record.ErrRevoked) {
+		t.Fatalf("expected ErrRevoked, got %v", err)
+	}
+	if _, _, err := ConsumeRedirect(data, nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,88 @@
+package transport
+
+import (
+	"context"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+)
+
+// PathQuality is an estimate of the quality of the network path of a
+// connection. Zero fields are unknown.
+type PathQuality struct {
+	// RTT is the smoothed round trip time.
+	RTT time.Duration
+	// Loss is the fraction of packets lost, between 0 and 1.
+	Loss float64
+	// Throughput is the estimated achievable throughput, in bytes per
+	// second.
+	Throughput int64
+
+	// Updated is the time of the estimate.
+	Updated time.Time
+}
+
+// EffectiveRTT returns the RTT inflated by the loss rate: the expected time
+// for a packet to make it across, counting retransmissions. It returns 0 if
+// the RTT is unknown.
+func (q PathQuality) EffectiveRTT() time.Duration {
+	if q.RTT <= 0 {
+		return 0
+	}
+	if q.Loss >= 1 {
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Duration(float64(q.RTT) / (1 - q.Loss))
+}
+
+// PathQualityConn is implemented by connections whose transport tracks the
+// quality of the path, e.g. from QUIC's congestion controller statistics.
+// Networks should expose it on their connections when the underlying
+// transport connection implements it.
+type PathQualityConn interface {
+	// PathQuality returns the current estimate. It must be cheap to call.
+	PathQuality() PathQuality
+}
+
+// ProbingConn is implemented by connections that can actively measure the
+// quality of their path.
+type ProbingConn interface {
+	PathQualityConn
+
+	// ProbePathQuality sends probes over the connection and returns the
+	// updated estimate.
+	ProbePathQuality(ctx context.Context) (PathQuality, error)
+}
+
+// GetPathQuality returns the path quality estimate of the connection, if it
+// implements PathQualityConn.
+func GetPathQuality(c interface{}) (PathQuality, bool) {
+	pc, ok := c.(PathQualityConn)
+	if !ok {
+		return PathQuality{}, false
+	}
+	return pc.PathQuality(), true
+}
+
+// BestConn returns the connection with the lowest effective RTT among the
+// connections to a multi-connected peer. Connections without a known RTT are
+// only picked if no connection has one, in which case the first connection is
+// returned. It returns nil if conns is empty.
+func BestConn(conns []network.Conn) network.Conn {
+	var best network.Conn
+	var bestRTT time.Duration
+	for _, c := range conns {
+		q, ok := GetPathQuality(c)
+		if !ok {
+			continue
+		}
+		rtt := q.EffectiveRTT()
+		if rtt > 0 && (best == nil || rtt < bestRTT) {
+			best, bestRTT = c, rtt
+		}
+	}
+	if best == nil && len(conns) > 0 {
+		return conns[0]
+	}
+	return best
+}
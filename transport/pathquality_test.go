@@ -0,0 +1,34 @@
+package transport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+)
+
+type qualityConn struct {
+	network.Conn
+	q PathQuality
+}
+
+func (c *qualityConn) PathQuality() PathQuality { return c.q }
+
+func TestBestConn(t *testing.T) {
+	fast := &qualityConn{q: PathQuality{RTT: 20 * time.Millisecond, Loss: 0.5}}
+	lossless := &qualityConn{q: PathQuality{RTT: 30 * time.Millisecond}}
+	unknown := &qualityConn{}
+
+	if got := BestConn([]network.Conn{unknown, fast, lossless}); got != lossless {
+		t.Fatal("expected the connection with the lowest effective RTT")
+	}
+	if got := BestConn([]network.Conn{unknown}); got != unknown {
+		t.Fatal("expected the first connection when no quality is known")
+	}
+	if BestConn(nil) != nil {
+		t.Fatal("expected nil without connections")
+	}
+	if rtt := fast.q.EffectiveRTT(); rtt != 40*time.Millisecond {
+		t.Fatalf("unexpected effective RTT %s", rtt)
+	}
+}
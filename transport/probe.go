@@ -0,0 +1,106 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+var (
+	// ErrAddrNotSupported is returned when a transport doesn't handle any
+	// protocol of an address.
+	ErrAddrNotSupported = errors.New("address not supported by transport")
+
+	// ErrPreflightNotSupported is returned by PreflightDial when the
+	// transport can't check reachability without a full dial.
+	ErrPreflightNotSupported = errors.New("transport doesn't support dial preflight checks")
+)
+
+// AddrError is returned by the address probing helpers, describing why an
+// address can't be used.
+type AddrError struct {
+	// Op is the probed operation: "listen" or "dial".
+	Op   string
+	Addr ma.Multiaddr
+	Err  error
+}
+
+func (e *AddrError) Error() string {
+	return fmt.Sprintf("cannot %s on %s: %s", e.Op, e.Addr, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *AddrError) Unwrap() error {
+	return e.Err
+}
+
+// AddrProber is an optional interface implemented by transports that can
+// check whether addresses are usable without listening or upgrading
+// connections, so that configuration validation and tooling can report
+// errors before runtime failures.
+type AddrProber interface {
+	// CanListen returns nil if the transport could listen on the address,
+	// e.g. after checking the address is well-formed, the interface exists
+	// and the port can be bound. It must not keep any resource open.
+	CanListen(laddr ma.Multiaddr) error
+
+	// PreflightDial checks the address could be dialed, e.g. by resolving
+	// it and opening then closing a raw connection, without the security
+	// and muxer upgrade.
+	PreflightDial(ctx context.Context, raddr ma.Multiaddr) error
+}
+
+// CanListen returns nil if the transport could listen on the address, asking
+// the transport if it implements AddrProber. Otherwise, it only checks that
+// the transport handles one of the protocols of the address. Errors are
+// returned as *AddrError.
+func CanListen(t Transport, laddr ma.Multiaddr) error {
+	if !handlesAddr(t, laddr) {
+		return &AddrError{Op: "listen", Addr: laddr, Err: ErrAddrNotSupported}
+	}
+	p, ok := t.(AddrProber)
+	if !ok {
+		return nil
+	}
+	if err := p.CanListen(laddr); err != nil {
+		if _, ok := err.(*AddrError); ok {
+			return err
+		}
+		return &AddrError{Op: "listen", Addr: laddr, Err: err}
+	}
+	return nil
+}
+
+// PreflightDial checks the address could be dialed by the transport, asking
+// the transport if it implements AddrProber, and returning
+// ErrPreflightNotSupported otherwise once CanDial accepted the address.
+// Errors are returned as *AddrError.
+func PreflightDial(ctx context.Context, t Transport, raddr ma.Multiaddr) error {
+	if !t.CanDial(raddr) {
+		return &AddrError{Op: "dial", Addr: raddr, Err: ErrAddrNotSupported}
+	}
+	p, ok := t.(AddrProber)
+	if !ok {
+		return &AddrError{Op: "dial", Addr: raddr, Err: ErrPreflightNotSupported}
+	}
+	if err := p.PreflightDial(ctx, raddr); err != nil {
+		if _, ok := err.(*AddrError); ok {
+			return err
+		}
+		return &AddrError{Op: "dial", Addr: raddr, Err: err}
+	}
+	return nil
+}
+
+func handlesAddr(t Transport, addr ma.Multiaddr) bool {
+	for _, code := range t.Protocols() {
+		for _, p := range addr.Protocols() {
+			if p.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+type tcpTransport struct {
+	Transport
+}
+
+func (tcpTransport) Protocols() []int { return []int{ma.P_TCP} }
+
+func (tcpTransport) CanDial(addr ma.Multiaddr) bool {
+	_, err := addr.ValueForProtocol(ma.P_TCP)
+	return err == nil
+}
+
+var errPortInUse = errors.New("port in use")
+
+type probingTransport struct {
+	tcpTransport
+}
+
+func (probingTransport) CanListen(laddr ma.Multiaddr) error {
+	if port, _ := laddr.ValueForProtocol(ma.P_TCP); port == "80" {
+		return errPortInUse
+	}
+	return nil
+}
+
+func (probingTransport) PreflightDial(context.Context, ma.Multiaddr) error {
+	return nil
+}
+
+func TestCanListen(t *testing.T) {
+	tcp := ma.StringCast("/ip4/127.0.0.1/tcp/4001")
+	udp := ma.StringCast("/ip4/127.0.0.1/udp/4001")
+
+	if err := CanListen(tcpTransport{}, tcp); err != nil {
+		t.Fatal(err)
+	}
+	var aerr *AddrError
+	if err := CanListen(tcpTransport{}, udp); !errors.As(err, &aerr) || aerr.Op != "listen" || !errors.Is(err, ErrAddrNotSupported) {
+		t.Fatalf("expected ErrAddrNotSupported, got %v", err)
+	}
+	if err := CanListen(probingTransport{}, ma.StringCast("/ip4/127.0.0.1/tcp/80")); !errors.Is(err, errPortInUse) {
+		t.Fatalf("expected the prober's error, got %v", err)
+	}
+}
+
+func TestPreflightDial(t *testing.T) {
+	ctx := context.Background()
+	tcp := ma.StringCast("/ip4/127.0.0.1/tcp/4001")
+
+	if err := PreflightDial(ctx, tcpTransport{}, tcp); !errors.Is(err, ErrPreflightNotSupported) {
+		t.Fatalf("expected ErrPreflightNotSupported, got %v", err)
+	}
+	if err := PreflightDial(ctx, probingTransport{}, ma.StringCast("/ip4/127.0.0.1/udp/1")); !errors.Is(err, ErrAddrNotSupported) {
+		t.Fatalf("expected ErrAddrNotSupported, got %v", err)
+	}
+	if err := PreflightDial(ctx, probingTransport{}, tcp); err != nil {
+		t.Fatal(err)
+	}
+}
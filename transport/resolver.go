@@ -0,0 +1,468 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// Multicodecs of the DNS multiaddr protocols.
+const (
+	pDNS     = 0x35
+	pDNS4    = 0x36
+	pDNS6    = 0x37
+	pDNSADDR = 0x38
+)
+
+// ErrResolverNotSupported is returned by SetResolver when the network doesn't
+// implement ResolverNetwork.
+var ErrResolverNotSupported = errors.New("network doesn't support custom DNS resolvers")
+
+// MaxDNSAddrDepth is the maximum number of nested /dnsaddr lookups performed
+// by ResolveMultiaddr.
+var MaxDNSAddrDepth = 4
+
+// Resolver resolves the host names of /dns, /dns4, /dns6 and /dnsaddr
+// multiaddrs. *net.Resolver implements it, and so do the DNS over TLS and DNS
+// over HTTPS resolvers of NewDoTResolver and NewDoHResolver; other backends
+// plug in by implementing it too.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// SystemResolver is the Resolver of the operating system.
+var SystemResolver Resolver = net.DefaultResolver
+
+// NewDoTResolver returns a Resolver sending its queries over DNS over TLS to
+// the server, e.g. "1.1.1.1:853", so lookups aren't leaked to the system
+// resolver or on-path observers. A nil config verifies the server's
+// certificate against the host part of the address.
+func NewDoTResolver(server string, config *tls.Config) Resolver {
+	if config == nil {
+		host, _, err := net.SplitHostPort(server)
+		if err != nil {
+			host = server
+		}
+		config = &tls.Config{ServerName: host}
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			// Stream connections make the resolver use the TCP framing
+			// DNS over TLS is defined with.
+			dialer := new(net.Dialer)
+			if deadline, ok := ctx.Deadline(); ok {
+				dialer.Deadline = deadline
+			}
+			return tls.DialWithDialer(dialer, "tcp", server, config)
+		},
+	}
+}
+
+// maxDNSMessageSize is the maximum size of a DNS message.
+const maxDNSMessageSize = 0xffff
+
+// NewDoHResolver returns a Resolver sending its queries over DNS over HTTPS
+// (RFC 8484) to the URL, e.g. "https://cloudflare-dns.com/dns-query". A nil
+// client uses http.DefaultClient.
+func NewDoHResolver(url string, client *http.Client) Resolver {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return &dohConn{ctx: ctx, url: url, client: client}, nil
+		},
+	}
+}
+
+// dohConn is the connection the resolver of NewDoHResolver exchanges DNS
+// messages over. Being a stream connection, messages are prefixed with their
+// length; each query written is posted to the DoH server, and its answer
+// read back.
+type dohConn struct {
+	ctx    context.Context
+	url    string
+	client *http.Client
+
+	deadline    time.Time
+	query, resp bytes.Buffer
+}
+
+var _ net.Conn = (*dohConn)(nil)
+
+func (c *dohConn) Write(b []byte) (int, error) {
+	return c.query.Write(b)
+}
+
+func (c *dohConn) Read(b []byte) (int, error) {
+	if c.resp.Len() == 0 {
+		if err := c.roundTrip(); err != nil {
+			return 0, err
+		}
+	}
+	return c.resp.Read(b)
+}
+
+func (c *dohConn) roundTrip() error {
+	q := c.query.Bytes()
+	if len(q) < 2 || len(q) < 2+(int(q[0])<<8|int(q[1])) {
+		return errors.New("incomplete DNS query")
+	}
+	msg := c.query.Next(2 + (int(q[0])<<8 | int(q[1])))[2:]
+
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("DoH server answered %s", resp.Status)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxDNSMessageSize+1))
+	if err != nil {
+		return err
+	}
+	if len(body) > maxDNSMessageSize {
+		return errors.New("DoH answer too large")
+	}
+	c.resp.Write([]byte{byte(len(body) >> 8), byte(len(body))})
+	c.resp.Write(body)
+	return nil
+}
+
+func (c *dohConn) Close() error                       { return nil }
+func (c *dohConn) LocalAddr() net.Addr                { return dohAddr("") }
+func (c *dohConn) RemoteAddr() net.Addr               { return dohAddr(c.url) }
+func (c *dohConn) SetDeadline(t time.Time) error      { c.deadline = t; return nil }
+func (c *dohConn) SetReadDeadline(t time.Time) error  { c.deadline = t; return nil }
+func (c *dohConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// dohAddr is the URL of a DoH server.
+type dohAddr string
+
+func (a dohAddr) Network() string { return "doh" }
+func (a dohAddr) String() string  { return string(a) }
+
+// MultiResolver dispatches lookups per domain: names under a domain of Domains
+// (the longest matching one) are resolved with its resolver, and the others
+// with Default, or SystemResolver if Default is nil. Domains are given
+// without a trailing dot, e.g. "example.com" matches "example.com" and
+// "a.example.com".
+type MultiResolver struct {
+	Default Resolver
+	Domains map[string]Resolver
+}
+
+var _ Resolver = (*MultiResolver)(nil)
+
+// ResolverFor returns the resolver of the name.
+func (r *MultiResolver) ResolverFor(name string) Resolver {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	for {
+		if res, ok := r.Domains[name]; ok {
+			return res
+		}
+		i := strings.IndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[i+1:]
+	}
+	if r.Default != nil {
+		return r.Default
+	}
+	return SystemResolver
+}
+
+// LookupIPAddr implements Resolver.
+func (r *MultiResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return r.ResolverFor(host).LookupIPAddr(ctx, host)
+}
+
+// LookupTXT implements Resolver.
+func (r *MultiResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return r.ResolverFor(name).LookupTXT(ctx, name)
+}
+
+// TTLResolver is implemented by Resolvers reporting the TTL of their answers,
+// the smallest TTL of the records, so caches don't keep them for longer.
+type TTLResolver interface {
+	Resolver
+
+	LookupIPAddrTTL(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error)
+	LookupTXTTTL(ctx context.Context, name string) ([]string, time.Duration, error)
+}
+
+// DefaultResolverCacheSize is the number of names cached per record type by
+// CachingResolvers created with a non-positive size.
+const DefaultResolverCacheSize = 1024
+
+type cachedLookup struct {
+	ips     []net.IPAddr
+	txt     []string
+	expires time.Time
+}
+
+// CachingResolver caches the successful lookups of a Resolver for a fixed
+// TTL, or the TTL of the answers if shorter and the Resolver implements
+// TTLResolver. It holds a bounded number of names per record type: once full,
+// expired answers are swept, then the answers expiring first are evicted.
+type CachingResolver struct {
+	r     Resolver
+	ttl   time.Duration
+	size  int
+	clock network.Clock
+
+	lk  sync.Mutex
+	ips map[string]cachedLookup
+	txt map[string]cachedLookup
+}
+
+var _ Resolver = (*CachingResolver)(nil)
+
+// NewCachingResolver wraps the resolver, caching its answers for up to ttl,
+// for up to DefaultResolverCacheSize names per record type.
+func NewCachingResolver(r Resolver, ttl time.Duration) *CachingResolver {
+	return NewCachingResolverWithSize(r, ttl, DefaultResolverCacheSize)
+}
+
+// NewCachingResolverWithSize wraps the resolver, caching its answers for up
+// to ttl, for up to size names per record type.
+func NewCachingResolverWithSize(r Resolver, ttl time.Duration, size int) *CachingResolver {
+	if size <= 0 {
+		size = DefaultResolverCacheSize
+	}
+	return &CachingResolver{
+		r:     r,
+		ttl:   ttl,
+		size:  size,
+		clock: network.RealClock,
+		ips:   make(map[string]cachedLookup),
+		txt:   make(map[string]cachedLookup),
+	}
+}
+
+// SetClock sets the clock used to expire cached answers.
+func (r *CachingResolver) SetClock(c network.Clock) {
+	r.clock = c
+}
+
+func (r *CachingResolver) get(cache map[string]cachedLookup, name string) (cachedLookup, bool) {
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	l, ok := cache[name]
+	if ok && !r.clock.Now().Before(l.expires) {
+		delete(cache, name)
+		return l, false
+	}
+	return l, ok
+}
+
+func (r *CachingResolver) put(cache map[string]cachedLookup, name string, l cachedLookup, ttl time.Duration) {
+	if ttl > r.ttl {
+		ttl = r.ttl
+	}
+	if ttl <= 0 {
+		return
+	}
+	r.lk.Lock()
+	defer r.lk.Unlock()
+	now := r.clock.Now()
+	if _, ok := cache[name]; !ok && len(cache) >= r.size {
+		r.evict(cache, now)
+	}
+	l.expires = now.Add(ttl)
+	cache[name] = l
+}
+
+// evict sweeps the expired answers of the cache, or evicts the one expiring
+// first if none expired.
+func (r *CachingResolver) evict(cache map[string]cachedLookup, now time.Time) {
+	var (
+		first   string
+		expires time.Time
+	)
+	for name, l := range cache {
+		if !now.Before(l.expires) {
+			delete(cache, name)
+			continue
+		}
+		if first == "" || l.expires.Before(expires) {
+			first, expires = name, l.expires
+		}
+	}
+	if len(cache) >= r.size {
+		delete(cache, first)
+	}
+}
+
+// LookupIPAddr implements Resolver.
+func (r *CachingResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	if l, ok := r.get(r.ips, host); ok {
+		return l.ips, nil
+	}
+	var (
+		ips []net.IPAddr
+		ttl = r.ttl
+		err error
+	)
+	if tr, ok := r.r.(TTLResolver); ok {
+		ips, ttl, err = tr.LookupIPAddrTTL(ctx, host)
+	} else {
+		ips, err = r.r.LookupIPAddr(ctx, host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.put(r.ips, host, cachedLookup{ips: ips}, ttl)
+	return ips, nil
+}
+
+// LookupTXT implements Resolver.
+func (r *CachingResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if l, ok := r.get(r.txt, name); ok {
+		return l.txt, nil
+	}
+	var (
+		txt []string
+		ttl = r.ttl
+		err error
+	)
+	if tr, ok := r.r.(TTLResolver); ok {
+		txt, ttl, err = tr.LookupTXTTTL(ctx, name)
+	} else {
+		txt, err = r.r.LookupTXT(ctx, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.put(r.txt, name, cachedLookup{txt: txt}, ttl)
+	return txt, nil
+}
+
+// ResolveMultiaddr resolves the DNS component the address starts with, if
+// any: /dns, /dns4 and /dns6 are replaced by the resolved /ip4 and /ip6
+// addresses, and /dnsaddr by the addresses listed in the "dnsaddr=" TXT
+// records of its _dnsaddr subdomain, recursively, keeping only those ending
+// with the rest of the address (usually /p2p/<peer>). Other addresses are
+// returned as-is.
+func ResolveMultiaddr(ctx context.Context, r Resolver, addr ma.Multiaddr) ([]ma.Multiaddr, error) {
+	return resolveMultiaddr(ctx, r, addr, 0)
+}
+
+func resolveMultiaddr(ctx context.Context, r Resolver, addr ma.Multiaddr, depth int) ([]ma.Multiaddr, error) {
+	first, rest := ma.SplitFirst(addr)
+	if first == nil {
+		return []ma.Multiaddr{addr}, nil
+	}
+	encapsulate := func(a ma.Multiaddr) ma.Multiaddr {
+		if rest == nil {
+			return a
+		}
+		return a.Encapsulate(rest)
+	}
+
+	code, host := first.Protocol().Code, first.Value()
+	switch code {
+	case pDNS, pDNS4, pDNS6:
+		ips, err := r.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var out []ma.Multiaddr
+		for _, ip := range ips {
+			var c *ma.Component
+			if ip4 := ip.IP.To4(); ip4 != nil && code != pDNS6 {
+				c, err = ma.NewComponent("ip4", ip4.String())
+			} else if ip4 == nil && code != pDNS4 {
+				c, err = ma.NewComponent("ip6", ip.IP.String())
+			} else {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, encapsulate(c))
+		}
+		return out, nil
+	case pDNSADDR:
+		if depth >= MaxDNSAddrDepth {
+			return nil, errors.New("too many nested dnsaddr lookups")
+		}
+		txt, err := r.LookupTXT(ctx, "_dnsaddr."+host)
+		if err != nil {
+			return nil, err
+		}
+		var out []ma.Multiaddr
+		for _, t := range txt {
+			if !strings.HasPrefix(t, "dnsaddr=") {
+				continue
+			}
+			a, err := ma.NewMultiaddr(t[len("dnsaddr="):])
+			if err != nil {
+				continue
+			}
+			resolved, err := resolveMultiaddr(ctx, r, a, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			for _, a := range resolved {
+				if rest == nil || bytes.HasSuffix(a.Bytes(), rest.Bytes()) {
+					out = append(out, a)
+				}
+			}
+		}
+		return out, nil
+	default:
+		return []ma.Multiaddr{addr}, nil
+	}
+}
+
+// ResolverNetwork is implemented by networks resolving DNS multiaddrs with a
+// configurable Resolver.
+type ResolverNetwork interface {
+	network.Network
+
+	// SetResolver sets the resolver of the network's DNS lookups. Nil
+	// means SystemResolver.
+	SetResolver(Resolver)
+}
+
+// SetResolver sets the network's resolver, or returns ErrResolverNotSupported
+// if the network doesn't implement ResolverNetwork.
+func SetResolver(n network.Network, r Resolver) error {
+	rn, ok := n.(ResolverNetwork)
+	if !ok {
+		return ErrResolverNotSupported
+	}
+	rn.SetResolver(r)
+	return nil
+}
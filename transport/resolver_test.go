@@ -0,0 +1,309 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/network"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// stringTranscoder encodes string protocol values as is.
+type stringTranscoder struct{}
+
+func (stringTranscoder) StringToBytes(s string) ([]byte, error) { return []byte(s), nil }
+func (stringTranscoder) BytesToString(b []byte) (string, error) { return string(b), nil }
+func (stringTranscoder) ValidateBytes([]byte) error             { return nil }
+
+func init() {
+	// Older multiaddr releases don't know the DNS protocols.
+	for _, p := range []struct {
+		name string
+		code int
+	}{{"dns", pDNS}, {"dns4", pDNS4}, {"dns6", pDNS6}, {"dnsaddr", pDNSADDR}} {
+		if ma.ProtocolWithCode(p.code).Code != 0 {
+			continue
+		}
+		if err := ma.AddProtocol(ma.Protocol{
+			Name:       p.name,
+			Code:       p.code,
+			VCode:      ma.CodeToVarint(p.code),
+			Size:       ma.LengthPrefixedVarSize,
+			Transcoder: stringTranscoder{},
+		}); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// countingResolver resolves every name to 127.0.0.1, counting lookups.
+type countingResolver struct {
+	lookups int
+}
+
+func (r *countingResolver) LookupIPAddr(context.Context, string) ([]net.IPAddr, error) {
+	r.lookups++
+	return []net.IPAddr{{IP: net.IPv4(127, 0, 0, 1)}}, nil
+}
+
+func (r *countingResolver) LookupTXT(context.Context, string) ([]string, error) {
+	r.lookups++
+	return nil, nil
+}
+
+func TestMultiResolver(t *testing.T) {
+	internal, def := new(countingResolver), new(countingResolver)
+	r := &MultiResolver{Default: def, Domains: map[string]Resolver{"corp.example": internal}}
+
+	for name, expected := range map[string]Resolver{
+		"corp.example":      internal,
+		"a.b.corp.example.": internal,
+		"corp.example.org":  def,
+		"libp2p.io":         def,
+		"notcorp.example":   def,
+	} {
+		if got := r.ResolverFor(name); got != expected {
+			t.Errorf("%s: unexpected resolver", name)
+		}
+	}
+}
+
+func TestCachingResolver(t *testing.T) {
+	ctx := context.Background()
+	backend := new(countingResolver)
+	r := NewCachingResolver(backend, time.Minute)
+	clock := network.NewMockClock(time.Now())
+	r.SetClock(clock)
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.LookupIPAddr(ctx, "libp2p.io"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if backend.lookups != 1 {
+		t.Fatalf("expected 1 lookup, got %d", backend.lookups)
+	}
+	clock.Advance(time.Minute)
+	r.LookupIPAddr(ctx, "libp2p.io")
+	if backend.lookups != 2 {
+		t.Fatalf("expected the cached answer to expire, got %d lookups", backend.lookups)
+	}
+}
+
+func TestResolveMultiaddrPassthrough(t *testing.T) {
+	addr := ma.StringCast("/ip4/1.2.3.4/tcp/4001")
+	out, err := ResolveMultiaddr(context.Background(), new(countingResolver), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || !out[0].Equal(addr) {
+		t.Fatalf("unexpected addresses %v", out)
+	}
+}
+
+// mapResolver answers from maps.
+type mapResolver struct {
+	ips map[string][]net.IPAddr
+	txt map[string][]string
+}
+
+func (r *mapResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	if ips, ok := r.ips[host]; ok {
+		return ips, nil
+	}
+	return nil, fmt.Errorf("no such host %s", host)
+}
+
+func (r *mapResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	return r.txt[name], nil
+}
+
+func resolve(t *testing.T, r Resolver, addr string) []ma.Multiaddr {
+	t.Helper()
+	out, err := ResolveMultiaddr(context.Background(), r, ma.StringCast(addr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// equalAddrs compares the addresses with the parsed expected ones, as their
+// string forms vary across multiaddr releases (e.g. /ipfs and /p2p).
+func equalAddrs(got []ma.Multiaddr, expected []string) bool {
+	if len(got) != len(expected) {
+		return false
+	}
+	for i, a := range got {
+		if !a.Equal(ma.StringCast(expected[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestResolveMultiaddrDNS(t *testing.T) {
+	r := &mapResolver{ips: map[string][]net.IPAddr{
+		"example.com": {{IP: net.IPv4(1, 2, 3, 4)}, {IP: net.ParseIP("2001:db8::1")}},
+	}}
+	for addr, expected := range map[string][]string{
+		"/dns4/example.com/tcp/4001": {"/ip4/1.2.3.4/tcp/4001"},
+		"/dns6/example.com/tcp/4001": {"/ip6/2001:db8::1/tcp/4001"},
+		"/dns/example.com/tcp/4001":  {"/ip4/1.2.3.4/tcp/4001", "/ip6/2001:db8::1/tcp/4001"},
+	} {
+		got := resolve(t, r, addr)
+		if !equalAddrs(got, expected) {
+			t.Errorf("%s: expected %v, got %v", addr, expected, got)
+		}
+	}
+}
+
+func TestResolveMultiaddrDNSAddr(t *testing.T) {
+	const (
+		peerA = "QmcgpsyWgH8Y8ajJz1Cu72KnS5uo2Aa2LpzU7kinSupNKC"
+		peerB = "QmNnooDu7bfjPFoTZYxMNLWUQJyrVwtbZg5gBMjTezGAJN"
+	)
+	r := &mapResolver{
+		ips: map[string][]net.IPAddr{"a.example.com": {{IP: net.IPv4(1, 2, 3, 4)}}},
+		txt: map[string][]string{
+			"_dnsaddr.bootstrap.example.com": {
+				"dnsaddr=/dnsaddr/nested.example.com/p2p/" + peerA,
+				"dnsaddr=/ip4/5.6.7.8/tcp/4001/p2p/" + peerB,
+				"not a dnsaddr record",
+			},
+			"_dnsaddr.nested.example.com": {
+				"dnsaddr=/dns4/a.example.com/tcp/4001/p2p/" + peerA,
+				"dnsaddr=/ip4/9.9.9.9/tcp/4001/p2p/" + peerB,
+			},
+			"_dnsaddr.loop.example.com": {"dnsaddr=/dnsaddr/loop.example.com"},
+		},
+	}
+
+	// Nested records are resolved, and only the addresses of the requested
+	// peer are kept.
+	got := resolve(t, r, "/dnsaddr/bootstrap.example.com/p2p/"+peerA)
+	if expected := []string{"/ip4/1.2.3.4/tcp/4001/p2p/" + peerA}; !equalAddrs(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	got = resolve(t, r, "/dnsaddr/bootstrap.example.com")
+	// The nested record still filters by its own suffix.
+	if len(got) != 2 {
+		t.Fatalf("expected 2 addresses without a suffix, got %v", got)
+	}
+
+	if _, err := ResolveMultiaddr(context.Background(), r, ma.StringCast("/dnsaddr/loop.example.com")); err == nil {
+		t.Fatal("expected recursive lookups to be bounded")
+	}
+}
+
+// ttlResolver reports a TTL of 10s.
+type ttlResolver struct {
+	countingResolver
+}
+
+func (r *ttlResolver) LookupIPAddrTTL(ctx context.Context, host string) ([]net.IPAddr, time.Duration, error) {
+	ips, err := r.LookupIPAddr(ctx, host)
+	return ips, 10 * time.Second, err
+}
+
+func (r *ttlResolver) LookupTXTTTL(ctx context.Context, name string) ([]string, time.Duration, error) {
+	txt, err := r.LookupTXT(ctx, name)
+	return txt, 10 * time.Second, err
+}
+
+func TestCachingResolverBounds(t *testing.T) {
+	ctx := context.Background()
+	clock := network.NewMockClock(time.Now())
+
+	// The answers' TTL applies when shorter.
+	backend := new(ttlResolver)
+	r := NewCachingResolver(backend, time.Minute)
+	r.SetClock(clock)
+	r.LookupIPAddr(ctx, "libp2p.io")
+	clock.Advance(10 * time.Second)
+	r.LookupIPAddr(ctx, "libp2p.io")
+	if backend.lookups != 2 {
+		t.Fatalf("expected the answer's TTL to be honored, got %d lookups", backend.lookups)
+	}
+
+	r = NewCachingResolverWithSize(new(countingResolver), time.Minute, 2)
+	r.SetClock(clock)
+	r.LookupIPAddr(ctx, "a")
+	clock.Advance(time.Second)
+	r.LookupIPAddr(ctx, "b")
+	clock.Advance(time.Second)
+	r.LookupIPAddr(ctx, "c")
+	if len(r.ips) != 2 {
+		t.Fatalf("expected the cache to be capped, got %d names", len(r.ips))
+	}
+	if _, ok := r.ips["a"]; ok {
+		t.Fatal("expected the answer expiring first to be evicted")
+	}
+
+	// Expired answers are swept first.
+	clock.Advance(time.Minute)
+	r.LookupIPAddr(ctx, "d")
+	if len(r.ips) != 1 {
+		t.Fatalf("expected the expired answers to be swept, got %d names", len(r.ips))
+	}
+}
+
+// dohHandler answers the A queries it's posted with 1.2.3.4, and the others
+// with no records.
+func dohHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/dns-message" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		q, err := ioutil.ReadAll(r.Body)
+		if err != nil || len(q) < 12 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		// Skip the question name, then its type and class.
+		end := 12
+		for end < len(q) && q[end] != 0 {
+			end += int(q[end]) + 1
+		}
+		end += 5
+		if end > len(q) {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		isA := q[end-4] == 0 && q[end-3] == 1
+
+		var resp []byte
+		resp = append(resp, q[0], q[1], 0x81, 0x80, 0, 1, 0, 0, 0, 0, 0, 0)
+		resp = append(resp, q[12:end]...)
+		if isA {
+			resp[7] = 1
+			// A pointer to the question name, type A, class IN, a
+			// 60s TTL and the address.
+			resp = append(resp, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 1, 2, 3, 4)
+		}
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(resp)
+	}
+}
+
+func TestDoHResolver(t *testing.T) {
+	srv := httptest.NewServer(dohHandler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	ips, err := NewDoHResolver(srv.URL, srv.Client()).LookupIPAddr(ctx, "libp2p.example.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ips) != 1 || !ips[0].IP.Equal(net.IPv4(1, 2, 3, 4)) {
+		t.Fatalf("unexpected addresses %v", ips)
+	}
+}
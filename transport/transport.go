@@ -24,6 +24,15 @@ var DialTimeout = 60 * time.Second
 // protocol selection as well as the handshake, if applicable.
 var AcceptTimeout = 60 * time.Second
 
+// AcceptBacklog is the default number of fully upgraded connections a
+// BoundedListener queues while waiting for Accept to be called.
+var AcceptBacklog = 16
+
+// MaxPendingUpgrades is the default number of inbound connections a
+// BoundedListener upgrades concurrently. Connections arriving while this
+// many upgrades are in flight are dropped before any handshake work is done.
+var MaxPendingUpgrades = 16
+
 // A CapableConn represents a connection that has offers the basic
 // capabilities required by libp2p: stream multiplexing, encryption and
 // peer authentication.
@@ -83,6 +92,27 @@ type Transport interface {
 	Proxy() bool
 }
 
+// AddrTranslator is an optional interface implemented by transports that
+// know how to turn their listen addresses into addresses worth advertising to
+// other peers, e.g. by expanding unspecified IPs (0.0.0.0, ::) to the
+// addresses of the local interfaces or by appending certificate hashes.
+type AddrTranslator interface {
+	// TranslateListenAddr returns the addresses to advertise for the given
+	// listen address. ifaceAddrs holds the addresses of the local network
+	// interfaces. Returning nil means the address shouldn't be advertised.
+	TranslateListenAddr(listen ma.Multiaddr, ifaceAddrs []ma.Multiaddr) []ma.Multiaddr
+}
+
+// TranslateListenAddr returns the addresses to advertise for the given listen
+// address, asking the transport if it implements AddrTranslator and returning
+// the listen address as-is otherwise.
+func TranslateListenAddr(t Transport, listen ma.Multiaddr, ifaceAddrs []ma.Multiaddr) []ma.Multiaddr {
+	if at, ok := t.(AddrTranslator); ok {
+		return at.TranslateListenAddr(listen, ifaceAddrs)
+	}
+	return []ma.Multiaddr{listen}
+}
+
 // Listener is an interface closely resembling the net.Listener interface. The
 // only real difference is that Accept() returns Conn's of the type in this
 // package, and also exposes a Multiaddr method as opposed to a regular Addr
@@ -94,6 +124,51 @@ type Listener interface {
 	Multiaddr() ma.Multiaddr
 }
 
+// AcceptQueueStats is a snapshot of the pre-handshake state of a listener.
+type AcceptQueueStats struct {
+	// Backlog is the maximum number of upgraded connections waiting for
+	// Accept.
+	Backlog int
+	// Queued is the number of upgraded connections currently waiting for
+	// Accept.
+	Queued int
+
+	// MaxPendingUpgrades is the maximum number of concurrent upgrades.
+	MaxPendingUpgrades int
+	// PendingUpgrades is the number of connections currently being upgraded.
+	PendingUpgrades int
+
+	// DroppedBacklog counts connections dropped because the accept backlog
+	// was full.
+	DroppedBacklog uint64
+	// DroppedUpgrades counts connections dropped because too many upgrades
+	// were already in flight.
+	DroppedUpgrades uint64
+}
+
+// BoundedListener is a Listener that lets the user bound the amount of work
+// performed on inbound connections before they're accepted.
+//
+// Listeners aren't required to implement this interface. Callers should use
+// a type assertion to check whether a listener supports it.
+type BoundedListener interface {
+	Listener
+
+	// SetAcceptBacklog sets the maximum number of upgraded connections
+	// waiting for Accept. Connections upgraded while the backlog is full are
+	// closed and counted as dropped.
+	SetAcceptBacklog(n int) error
+
+	// SetMaxPendingUpgrades sets the maximum number of inbound connections
+	// being upgraded concurrently. Raw connections arriving while the limit
+	// is reached are closed before the handshake and counted as dropped.
+	SetMaxPendingUpgrades(n int) error
+
+	// AcceptQueueStats returns a snapshot of the listener's queues and drop
+	// counters.
+	AcceptQueueStats() AcceptQueueStats
+}
+
 // Network is an inet.Network with methods for managing transports.
 type TransportNetwork interface {
 	network.Network
@@ -0,0 +1,225 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/libp2p/go-libp2p-core/mux"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/pnet"
+	"github.com/libp2p/go-libp2p-core/sec"
+
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+// Names of the standard upgrade stages.
+const (
+	StagePnet     = "pnet"
+	StageSecurity = "security"
+	StageMuxer    = "muxer"
+	StageRcmgr    = "rcmgr"
+)
+
+// ErrStageNotFound is returned when a pipeline doesn't contain the stage an
+// operation refers to.
+var ErrStageNotFound = errors.New("upgrade stage not found")
+
+// UpgradeState is a connection going through the upgrade pipeline. Each stage
+// reads the fields set by the previous stages and fills in its own.
+type UpgradeState struct {
+	Transport  Transport
+	Direction  network.Direction
+	LocalAddr  ma.Multiaddr
+	RemoteAddr ma.Multiaddr
+
+	// RemotePeer is the peer we expect on outbound connections. It's set by
+	// the security stage on inbound connections.
+	RemotePeer peer.ID
+
+	// Conn is the connection as wrapped by the stages so far (e.g. by the
+	// private network protector and the security transport).
+	Conn net.Conn
+
+	// Security is set by the security stage.
+	Security network.ConnSecurity
+	// Muxer is set by the muxer stage.
+	Muxer mux.MuxedConn
+	// Scope is set by the resource manager stage.
+	Scope network.ResourceScope
+}
+
+// UpgradeStage is a single step of the upgrade pipeline.
+type UpgradeStage interface {
+	// Name identifies the stage in the pipeline.
+	Name() string
+
+	// Upgrade performs the stage's work on the connection.
+	Upgrade(ctx context.Context, s *UpgradeState) error
+}
+
+type funcStage struct {
+	name string
+	f    func(context.Context, *UpgradeState) error
+}
+
+func (s *funcStage) Name() string { return s.name }
+
+func (s *funcStage) Upgrade(ctx context.Context, st *UpgradeState) error {
+	return s.f(ctx, st)
+}
+
+// NewUpgradeStage returns a stage calling f, e.g. to run an application
+// handshake once the connection is secured.
+func NewUpgradeStage(name string, f func(context.Context, *UpgradeState) error) UpgradeStage {
+	return &funcStage{name: name, f: f}
+}
+
+// PnetStage returns a stage protecting the connection with the private
+// network protector.
+func PnetStage(p pnet.Protector) UpgradeStage {
+	return NewUpgradeStage(StagePnet, func(_ context.Context, s *UpgradeState) error {
+		c, err := p.Protect(s.Conn)
+		if err != nil {
+			return err
+		}
+		s.Conn = c
+		return nil
+	})
+}
+
+// SecurityStage returns a stage securing the connection with the security
+// transport.
+func SecurityStage(t sec.SecureTransport) UpgradeStage {
+	return NewUpgradeStage(StageSecurity, func(ctx context.Context, s *UpgradeState) error {
+		var (
+			sc  sec.SecureConn
+			err error
+		)
+		if s.Direction == network.DirOutbound {
+			sc, err = t.SecureOutbound(ctx, s.Conn, s.RemotePeer)
+		} else {
+			sc, err = t.SecureInbound(ctx, s.Conn)
+		}
+		if err != nil {
+			return err
+		}
+		s.Conn = sc
+		s.Security = sc
+		s.RemotePeer = sc.RemotePeer()
+		return nil
+	})
+}
+
+// MuxerStage returns a stage setting up the stream multiplexer.
+func MuxerStage(m mux.Multiplexer) UpgradeStage {
+	return NewUpgradeStage(StageMuxer, func(_ context.Context, s *UpgradeState) error {
+		mc, err := m.NewConn(s.Conn, s.Direction == network.DirInbound)
+		if err != nil {
+			return err
+		}
+		s.Muxer = mc
+		return nil
+	})
+}
+
+// RcmgrStage returns a stage attaching the connection to the resource scope
+// returned by open.
+func RcmgrStage(open func(context.Context, *UpgradeState) (network.ResourceScope, error)) UpgradeStage {
+	return NewUpgradeStage(StageRcmgr, func(ctx context.Context, s *UpgradeState) error {
+		scope, err := open(ctx, s)
+		if err != nil {
+			return err
+		}
+		s.Scope = scope
+		return nil
+	})
+}
+
+// Pipeline is an ordered list of upgrade stages. The methods modifying a
+// pipeline return a new one, leaving the original untouched.
+type Pipeline []UpgradeStage
+
+// Run runs the stages in order, stopping at the first error.
+func (p Pipeline) Run(ctx context.Context, s *UpgradeState) error {
+	for _, stage := range p {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := stage.Upgrade(ctx, s); err != nil {
+			return fmt.Errorf("%s upgrade failed: %w", stage.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (p Pipeline) index(name string) int {
+	for i, s := range p {
+		if s.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p Pipeline) insert(i int, stage UpgradeStage) Pipeline {
+	out := make(Pipeline, 0, len(p)+1)
+	out = append(out, p[:i]...)
+	out = append(out, stage)
+	return append(out, p[i:]...)
+}
+
+// InsertBefore returns a pipeline with stage inserted before the named stage.
+func (p Pipeline) InsertBefore(name string, stage UpgradeStage) (Pipeline, error) {
+	i := p.index(name)
+	if i < 0 {
+		return nil, ErrStageNotFound
+	}
+	return p.insert(i, stage), nil
+}
+
+// InsertAfter returns a pipeline with stage inserted after the named stage.
+func (p Pipeline) InsertAfter(name string, stage UpgradeStage) (Pipeline, error) {
+	i := p.index(name)
+	if i < 0 {
+		return nil, ErrStageNotFound
+	}
+	return p.insert(i+1, stage), nil
+}
+
+// Replace returns a pipeline with the named stage replaced by stage.
+func (p Pipeline) Replace(name string, stage UpgradeStage) (Pipeline, error) {
+	i := p.index(name)
+	if i < 0 {
+		return nil, ErrStageNotFound
+	}
+	out := append(Pipeline(nil), p...)
+	out[i] = stage
+	return out, nil
+}
+
+// Remove returns a pipeline without the named stage.
+func (p Pipeline) Remove(name string) (Pipeline, error) {
+	i := p.index(name)
+	if i < 0 {
+		return nil, ErrStageNotFound
+	}
+	out := make(Pipeline, 0, len(p)-1)
+	out = append(out, p[:i]...)
+	return append(out, p[i+1:]...), nil
+}
+
+// Upgrader turns raw connections into CapableConns by running them through a
+// pipeline of stages, typically pnet, security, muxer and rcmgr, in that
+// order. Users can reorder the stages or extend the pipeline with their own.
+type Upgrader interface {
+	// Pipeline returns the stages connections go through.
+	Pipeline() Pipeline
+
+	// Upgrade runs the connection through the pipeline. The state must have
+	// at least Transport, Direction, the addresses and Conn set, as well as
+	// RemotePeer for outbound connections.
+	Upgrade(ctx context.Context, s *UpgradeState) (CapableConn, error)
+}
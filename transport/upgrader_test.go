@@ -0,0 +1,66 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func recordStage(name string, trace *[]string) UpgradeStage {
+	return NewUpgradeStage(name, func(context.Context, *UpgradeState) error {
+		*trace = append(*trace, name)
+		return nil
+	})
+}
+
+func TestPipeline(t *testing.T) {
+	var trace []string
+	p := Pipeline{
+		recordStage(StagePnet, &trace),
+		recordStage(StageSecurity, &trace),
+		recordStage(StageMuxer, &trace),
+	}
+
+	p2, err := p.InsertAfter(StageSecurity, recordStage("handshake", &trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err = p2.Remove(StagePnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err = p2.InsertBefore(StageSecurity, recordStage("first", &trace))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p2.Remove("unknown"); err != ErrStageNotFound {
+		t.Fatalf("expected ErrStageNotFound, got %v", err)
+	}
+
+	if err := p2.Run(context.Background(), new(UpgradeState)); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(trace, ","); got != "first,security,handshake,muxer" {
+		t.Fatalf("unexpected stage order: %s", got)
+	}
+	if len(p) != 3 || p[0].Name() != StagePnet {
+		t.Fatal("original pipeline was modified")
+	}
+}
+
+func TestPipelineError(t *testing.T) {
+	boom := errors.New("boom")
+	var trace []string
+	p := Pipeline{
+		NewUpgradeStage("failing", func(context.Context, *UpgradeState) error { return boom }),
+		recordStage(StageMuxer, &trace),
+	}
+	err := p.Run(context.Background(), new(UpgradeState))
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected stage error, got %v", err)
+	}
+	if len(trace) != 0 {
+		t.Fatal("pipeline didn't stop at the failing stage")
+	}
+}